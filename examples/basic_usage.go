@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -98,13 +99,15 @@ func main() {
 		},
 	}
 
-	result, err := engine.ProcessRequest(request)
-	if err != nil {
+	result := engine.Process(context.Background(), request)
+	if result.Error != nil {
 		logger.Error("Failed to process request", map[string]interface{}{
-			"error": err.Error(),
+			"code":  string(result.Error.Code),
+			"error": result.Error.Message,
 		})
 	} else {
-		fmt.Printf("Request processed: %+v\n", result)
+		encoded, _ := json.Marshal(result)
+		fmt.Printf("Request processed: %s\n", encoded)
 	}
 
 	// Example 4: State management
@@ -129,6 +132,11 @@ func main() {
 	openaiMetrics := openaiClient.GetMetrics()
 
 	fmt.Printf("Engine Metrics: %+v\n", engineMetrics)
+	// Every request type is measured without any middleware.
+	for requestType, m := range engineMetrics["by_type"].(map[string]core.TypeSnapshot) {
+		fmt.Printf("  %s: %d requests, %.0f%% errors, p99 %.3fs\n",
+			requestType, m.Requests, m.ErrorRate*100, m.Latency.Quantile(0.99))
+	}
 	fmt.Printf("Solana Metrics: %+v\n", solanaMetrics)
 	fmt.Printf("OpenAI Metrics: %+v\n", openaiMetrics)
 
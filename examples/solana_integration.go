@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -51,6 +52,14 @@ func main() {
 	fmt.Println("\n=== Example 5: WebSocket Subscriptions ===")
 	demonstrateWebSocketSubscriptions(client, logger)
 
+	// Example 6: Custom RPC Calls
+	fmt.Println("\n=== Example 6: Custom RPC Calls ===")
+	demonstrateCustomCall(client, logger)
+
+	// Example 7: Sponsored Transactions
+	fmt.Println("\n=== Example 7: Sponsored Transactions ===")
+	demonstrateSponsoredTransfer(client, logger)
+
 	fmt.Println("\nSolana integration examples completed!")
 }
 
@@ -97,6 +106,12 @@ func demonstrateTransactionHandling(client *solana.Client, logger *utils.Logger)
 
 	// Request airdrop for testing
 	signature, err := client.RequestAirdrop(ctx, sender.PublicKey(), 1000000000) // 1 SOL
+	if errors.Is(err, solana.ErrAirdropLimitReached) {
+		logger.Warn("Faucet rate limit reached, try again later", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		logger.Error("Failed to request airdrop", map[string]interface{}{
 			"error": err.Error(),
@@ -200,6 +215,24 @@ func demonstrateTokenOperations(client *solana.Client, logger *utils.Logger) {
 		return
 	}
 	fmt.Printf("Tokens minted: %s\n", signature)
+
+	// Mint to a brand-new wallet; its token account is created in the same
+	// transaction
+	recipient, err := solana.NewWallet()
+	if err != nil {
+		logger.Error("Failed to create recipient wallet", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	signature, err = client.MintTokens(ctx, mint, recipient.PublicKey(), 500, solana.EnsureRecipient())
+	if err != nil {
+		logger.Error("Failed to mint tokens to new wallet", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	fmt.Printf("Tokens minted to %s: %s\n", recipient.PublicKey(), signature)
 }
 
 func demonstrateWebSocketSubscriptions(client *solana.Client, logger *utils.Logger) {
@@ -226,8 +259,29 @@ func demonstrateWebSocketSubscriptions(client *solana.Client, logger *utils.Logg
 	}
 	fmt.Printf("Subscribed to program: %s\n", programSub)
 
-	// Wait for some notifications
-	time.Sleep(10 * time.Second)
+	// Read notifications for a while. Cancelling ctx would also end both
+	// subscriptions and close their channels.
+	wait := time.After(10 * time.Second)
+	for done := false; !done; {
+		select {
+		case n, ok := <-accountSub.C():
+			if !ok {
+				fmt.Printf("Account subscription ended: %v\n", accountSub.Err())
+				done = true
+				continue
+			}
+			fmt.Printf("Account changed at slot %d\n", n.Slot)
+		case n, ok := <-programSub.C():
+			if !ok {
+				fmt.Printf("Program subscription ended: %v\n", programSub.Err())
+				done = true
+				continue
+			}
+			fmt.Printf("Program account changed at slot %d\n", n.Slot)
+		case <-wait:
+			done = true
+		}
+	}
 
 	// Unsubscribe
 	if err := client.Unsubscribe(ctx, accountSub); err != nil {
@@ -241,4 +295,57 @@ func demonstrateWebSocketSubscriptions(client *solana.Client, logger *utils.Logg
 			"error": err.Error(),
 		})
 	}
-}
\ No newline at end of file
+}
+
+func demonstrateCustomCall(client *solana.Client, logger *utils.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Call reaches RPC methods without a typed wrapper. getGenesisHash has
+	// one (GetGenesisHash); it is used here to show the raw form.
+	var genesisHash string
+	if err := client.Call(ctx, "getGenesisHash", nil, &genesisHash); err != nil {
+		logger.Error("Failed to call getGenesisHash", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	fmt.Printf("Genesis hash: %s\n", genesisHash)
+}
+
+func demonstrateSponsoredTransfer(client *solana.Client, logger *utils.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// The relayer pays the fee; the user only needs lamports to move.
+	relayer, _ := client.CreateWallet()
+	user, _ := client.CreateWallet()
+	recipient, _ := solana.NewWallet()
+
+	for _, wallet := range []*solana.Wallet{relayer, user} {
+		signature, err := client.RequestAirdrop(ctx, wallet.PublicKey(), solana.LamportsPerSOL)
+		if err == nil {
+			err = client.ConfirmTransaction(ctx, signature, "confirmed")
+		}
+		if err != nil {
+			logger.Error("Failed to fund wallet", map[string]interface{}{
+				"wallet": wallet.PublicKey(),
+				"error":  err.Error(),
+			})
+			return
+		}
+	}
+
+	signature, err := solana.NewTransactionBuilder().
+		SetFeePayer(relayer.Key()).
+		AddInstruction(solana.TransferInstruction(user.Key(), recipient.Key(), solana.LamportsPerSOL/10)).
+		AddSigner(relayer, user).
+		Send(ctx, client)
+	if err != nil {
+		logger.Error("Failed to send sponsored transfer", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	fmt.Printf("Sponsored transfer sent: %s (fee paid by %s)\n", signature, relayer.PublicKey())
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// background tracks the goroutines the engine starts, so Shutdown can
+// cancel them through one root context and wait for them to exit.
+type background struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func newBackground() *background {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &background{ctx: ctx, cancel: cancel}
+}
+
+// spawn runs fn in a tracked goroutine with the root context. It reports
+// false, without running fn, once the engine has stopped its goroutines.
+func (b *background) spawn(fn func(ctx context.Context)) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return false
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		fn(b.ctx)
+	}()
+	return true
+}
+
+// stop cancels the root context and waits until every tracked goroutine
+// has returned or ctx is done.
+func (b *background) stop(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("core: background goroutines still running: %w", ctx.Err())
+	}
+}
+
+// Go runs fn in a goroutine tied to the engine's lifetime, e.g. a poller or
+// a subscription reader started by a handler. fn's context is cancelled by
+// Shutdown, which then waits for fn to return, up to its own deadline. Go
+// returns ErrEngineClosed once Shutdown has begun.
+func (e *Engine) Go(fn func(ctx context.Context)) error {
+	if e.closed.Load() || !e.bg.spawn(fn) {
+		return ErrEngineClosed
+	}
+	return nil
+}
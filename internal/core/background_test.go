@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestShutdownLeavesNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 20; i++ {
+		engine, err := NewEngine(&utils.Config{})
+		if err != nil {
+			t.Fatalf("NewEngine: %v", err)
+		}
+		block := make(chan struct{})
+		engine.RegisterHandler("slow", func(ctx context.Context, req *Request) (interface{}, error) {
+			close(block)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		if err := engine.Go(func(ctx context.Context) {
+			ticker := time.NewTicker(time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}); err != nil {
+			t.Fatalf("Go: %v", err)
+		}
+		results, err := engine.Submit(context.Background(), &Request{ID: "r", Type: "slow"})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		<-block
+
+		// The drain times out on the stuck request, which the final
+		// cancellation then stops.
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		engine.Shutdown(ctx)
+		cancel()
+		if result := <-results; result.Status != StatusFailed {
+			t.Fatalf("stuck request status = %v, want failed", result.Status)
+		}
+		if err := engine.Go(func(context.Context) {}); !errors.Is(err, ErrEngineClosed) {
+			t.Fatalf("Go after Shutdown = %v, want ErrEngineClosed", err)
+		}
+	}
+
+	// Goroutines from other tests may still be winding down.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("goroutines = %d after shutdowns, was %d", n, before)
+	}
+}
@@ -0,0 +1,109 @@
+package coretest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/labs-alone/alone-main/internal/openai"
+)
+
+// ErrNoReply is returned by FakeCompleter when no reply has been queued.
+var ErrNoReply = errors.New("coretest: no reply queued")
+
+// FakeCompleter is an openai.Completer that answers from a queue of scripted
+// replies and records the requests it receives.
+type FakeCompleter struct {
+	mu       sync.Mutex
+	replies  []fakeReply
+	requests []*openai.ChatCompletionRequest
+}
+
+type fakeReply struct {
+	content string
+	err     error
+}
+
+// Reply queues an assistant message with content.
+func (f *FakeCompleter) Reply(content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replies = append(f.replies, fakeReply{content: content})
+}
+
+// Fail queues err as the next completion's result.
+func (f *FakeCompleter) Fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replies = append(f.replies, fakeReply{err: err})
+}
+
+// Requests returns the requests received so far, oldest first.
+func (f *FakeCompleter) Requests() []*openai.ChatCompletionRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*openai.ChatCompletionRequest(nil), f.requests...)
+}
+
+// next records req and pops the next reply.
+func (f *FakeCompleter) next(ctx context.Context, req *openai.ChatCompletionRequest) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+	if len(f.replies) == 0 {
+		return "", ErrNoReply
+	}
+	reply := f.replies[0]
+	f.replies = f.replies[1:]
+	return reply.content, reply.err
+}
+
+// CreateChatCompletion implements openai.Completer.
+func (f *FakeCompleter) CreateChatCompletion(ctx context.Context, req *openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	content, err := f.next(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatMessage{Role: openai.RoleAssistant, Content: content},
+			FinishReason: "stop",
+		}},
+	}, nil
+}
+
+// CreateChatCompletionStream implements openai.Completer. The reply is
+// delivered as a single chunk.
+func (f *FakeCompleter) CreateChatCompletionStream(ctx context.Context, req *openai.ChatCompletionRequest) (openai.ChatStream, error) {
+	content, err := f.next(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeStream{chunks: []*openai.ChatCompletionStreamResponse{{
+		Choices: []openai.ChatCompletionStreamChoice{{
+			Delta:        openai.ChatCompletionStreamDelta{Role: openai.RoleAssistant, Content: content},
+			FinishReason: "stop",
+		}},
+	}}}, nil
+}
+
+type fakeStream struct {
+	chunks []*openai.ChatCompletionStreamResponse
+}
+
+func (s *fakeStream) Recv() (*openai.ChatCompletionStreamResponse, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *fakeStream) Close() error {
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package coretest runs a core.Engine in memory for handler tests. The
+// engine is wired to a scripted chat model, an in-memory Solana node, and a
+// manual clock, so tests need no network and no real time. It lives apart
+// from core so production builds do not include the fakes.
+package coretest
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/core"
+	"github.com/labs-alone/alone-main/internal/solana"
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// DefaultStart is the time a Harness clock starts at.
+var DefaultStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SubmitTimeout bounds how long Submit waits, in real time, for a queued
+// request before failing the test.
+const SubmitTimeout = 5 * time.Second
+
+// fakeSolanaEndpoint is never dialed; FakeSolana serves every request.
+const fakeSolanaEndpoint = "http://solana.coretest"
+
+// Harness is an engine wired to fakes. Register handlers on Engine, script
+// LLM and Solana, then drive requests with Process or Submit.
+type Harness struct {
+	Engine *core.Engine
+	// LLM is the engine's Completer.
+	LLM *FakeCompleter
+	// Solana is a client backed by SolanaNode. Handlers under test should
+	// capture it rather than building their own.
+	Solana     *solana.Client
+	SolanaNode *FakeSolana
+	Clock      *Clock
+
+	t      testing.TB
+	events *core.Subscription
+	nextID atomic.Uint64
+}
+
+// New returns a harness whose engine is shut down when the test ends. opts
+// are applied after the harness's own options, so they may override them.
+func New(t testing.TB, opts ...core.EngineOption) *Harness {
+	t.Helper()
+	h := &Harness{
+		LLM:        &FakeCompleter{},
+		SolanaNode: NewFakeSolana(),
+		Clock:      NewClock(DefaultStart),
+		t:          t,
+	}
+
+	logger := utils.NewLogger(utils.WithLevel(utils.ERROR))
+	config := &utils.Config{
+		Engine: utils.EngineConfig{Name: t.Name()},
+		Solana: &utils.SolanaConfig{Endpoint: fakeSolanaEndpoint},
+	}
+	var err error
+	h.Solana, err = solana.NewClient(config.Solana, solana.WithLogger(logger), solana.WithRoundTripper(h.SolanaNode))
+	if err != nil {
+		t.Fatalf("coretest: solana client: %v", err)
+	}
+	engineOpts := append([]core.EngineOption{
+		core.WithLogger(logger),
+		core.WithCompleter(h.LLM),
+		core.WithSolanaClient(h.Solana),
+		core.WithClock(h.Clock),
+	}, opts...)
+	h.Engine, err = core.NewEngine(config, engineOpts...)
+	if err != nil {
+		t.Fatalf("coretest: engine: %v", err)
+	}
+	// Publishing is synchronous, so every event is buffered by the time
+	// the call that published it returns.
+	h.events, err = h.Engine.Events().Subscribe(core.TopicAll, 1024)
+	if err != nil {
+		t.Fatalf("coretest: subscribe: %v", err)
+	}
+	t.Cleanup(func() {
+		h.Engine.Shutdown(context.Background())
+		h.Solana.Close()
+	})
+	return h
+}
+
+// Process runs req to completion on the calling goroutine.
+func (h *Harness) Process(req *core.Request) *core.Result {
+	return h.Engine.Process(context.Background(), req)
+}
+
+// Submit queues req like a production caller would and waits for its
+// Result. The request is given an ID if it has none.
+func (h *Harness) Submit(req *core.Request) *core.Result {
+	h.t.Helper()
+	if req != nil && req.ID == "" {
+		req.ID = fmt.Sprintf("coretest-%d", h.nextID.Add(1))
+	}
+	ch, err := h.Engine.Submit(context.Background(), req)
+	if err != nil {
+		h.t.Fatalf("coretest: submit: %v", err)
+	}
+	select {
+	case res := <-ch:
+		return res
+	case <-time.After(SubmitTimeout):
+		h.t.Fatalf("coretest: request %s did not finish within %s", req.ID, SubmitTimeout)
+		return nil
+	}
+}
+
+// Events returns the events published since the previous call, oldest
+// first.
+func (h *Harness) Events() []core.Event {
+	var events []core.Event
+	for {
+		select {
+		case event := <-h.events.C():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+// EventTopics returns the topics of Events, which is often all a test
+// needs to compare.
+func (h *Harness) EventTopics() []string {
+	events := h.Events()
+	topics := make([]string, len(events))
+	for i, event := range events {
+		topics[i] = event.Topic
+	}
+	return topics
+}
+
+// State returns a copy of the engine state.
+func (h *Harness) State() map[string]interface{} {
+	return h.Engine.GetState()
+}
+
+// Metrics returns the engine's metrics snapshot.
+func (h *Harness) Metrics() map[string]interface{} {
+	return h.Engine.GetMetrics()
+}
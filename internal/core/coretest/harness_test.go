@@ -0,0 +1,87 @@
+package coretest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/core"
+	"github.com/labs-alone/alone-main/internal/openai"
+)
+
+func TestHarness(t *testing.T) {
+	h := New(t)
+	h.SolanaNode.HandleResult("getBalance", WithContext(1, 2_000_000_000))
+	h.LLM.Reply("You hold 2 SOL.")
+
+	h.Engine.RegisterHandler("summarize", func(ctx context.Context, req *core.Request) (interface{}, error) {
+		address := req.Payload["address"].(string)
+		lamports, err := h.Solana.GetBalance(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := h.Engine.Completer().CreateChatCompletion(ctx, &openai.ChatCompletionRequest{
+			Messages: []openai.ChatMessage{{Role: openai.RoleUser, Content: fmt.Sprintf("%d lamports", lamports)}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		h.Clock.Advance(250 * time.Millisecond)
+		h.Engine.UpdateState(address, lamports)
+		return resp.Choices[0].Message.Content, nil
+	})
+	h.Events()
+
+	const address = "11111111111111111111111111111111"
+	res := h.Submit(&core.Request{Type: "summarize", Payload: map[string]interface{}{"address": address}})
+	if res.Status != core.StatusSuccess || res.Data != "You hold 2 SOL." {
+		t.Fatalf("result = %+v", res)
+	}
+	if res.Duration != 250*time.Millisecond || !res.StartedAt.Equal(DefaultStart) {
+		t.Fatalf("timing = %v from %v", res.Duration, res.StartedAt)
+	}
+	if got := h.LLM.Requests(); len(got) != 1 || got[0].Messages[0].Content != "2000000000 lamports" {
+		t.Fatalf("LLM requests = %+v", got)
+	}
+	if h.SolanaNode.Calls("getBalance") != 1 {
+		t.Fatalf("getBalance calls = %d", h.SolanaNode.Calls("getBalance"))
+	}
+	if got := h.State()[address]; got != uint64(2_000_000_000) {
+		t.Fatalf("state = %v", got)
+	}
+	want := []string{core.TopicRequestReceived, core.TopicStateChanged, core.TopicRequestCompleted}
+	if got := h.EventTopics(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+
+	// The queue is empty, so the next call fails without a reply.
+	res = h.Process(&core.Request{Type: "summarize", Payload: map[string]interface{}{"address": address}})
+	if !errors.Is(res.Err, ErrNoReply) {
+		t.Fatalf("result without a queued reply = %+v", res)
+	}
+}
+
+func TestHarnessClockTimeout(t *testing.T) {
+	h := New(t)
+	h.Engine.RegisterHandler("wait", func(ctx context.Context, req *core.Request) (interface{}, error) {
+		select {
+		case <-h.Clock.After(time.Minute):
+			return nil, context.DeadlineExceeded
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	done := make(chan *core.Result, 1)
+	go func() { done <- h.Process(&core.Request{Type: "wait"}) }()
+	for h.Clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	h.Clock.Advance(time.Minute)
+	if res := <-done; res.Error == nil || res.Error.Code != core.CodeTimeout {
+		t.Fatalf("result = %+v", res)
+	}
+}
@@ -0,0 +1,116 @@
+package coretest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/labs-alone/alone-main/internal/solana"
+)
+
+// SolanaHandler answers one JSON-RPC method. Returning a *solana.RPCError
+// sends it as the JSON-RPC error; any other error fails the HTTP request.
+type SolanaHandler func(params json.RawMessage) (interface{}, error)
+
+// FakeSolana is an in-memory Solana JSON-RPC node. It is an
+// http.RoundTripper, so a client built with solana.WithRoundTripper talks to
+// it without opening sockets. Methods without a handler fail with the
+// node's "method not found" error.
+type FakeSolana struct {
+	mu       sync.Mutex
+	handlers map[string]SolanaHandler
+	calls    map[string]int
+}
+
+// NewFakeSolana returns a node with no handlers.
+func NewFakeSolana() *FakeSolana {
+	return &FakeSolana{
+		handlers: make(map[string]SolanaHandler),
+		calls:    make(map[string]int),
+	}
+}
+
+// Handle sets the handler for method, replacing any existing one.
+func (f *FakeSolana) Handle(method string, handler SolanaHandler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[method] = handler
+}
+
+// HandleResult makes method always return result.
+func (f *FakeSolana) HandleResult(method string, result interface{}) {
+	f.Handle(method, func(json.RawMessage) (interface{}, error) {
+		return result, nil
+	})
+}
+
+// Calls returns how many times method has been called.
+func (f *FakeSolana) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// WithContext wraps value in the {context, value} envelope used by methods
+// such as getBalance and getAccountInfo.
+func WithContext(slot uint64, value interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"context": map[string]interface{}{"slot": slot},
+		"value":   value,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (f *FakeSolana) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Body != nil {
+		defer r.Body.Close()
+	}
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+	var req struct {
+		ID     uint64          `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("coretest: decode request: %w", err)
+	}
+
+	f.mu.Lock()
+	f.calls[req.Method]++
+	handler, ok := f.handlers[req.Method]
+	f.mu.Unlock()
+
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if !ok {
+		resp["error"] = &solana.RPCError{Code: -32601, Message: "Method not found"}
+	} else {
+		result, err := handler(req.Params)
+		var rpcErr *solana.RPCError
+		switch {
+		case errors.As(err, &rpcErr):
+			resp["error"] = rpcErr
+		case err != nil:
+			return nil, err
+		default:
+			resp["result"] = result
+		}
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("coretest: encode response: %w", err)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    r,
+	}, nil
+}
@@ -0,0 +1,584 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/openai"
+	"github.com/labs-alone/alone-main/internal/solana"
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+var (
+	// ErrEngineClosed is returned when the engine has been shut down.
+	ErrEngineClosed = errors.New("core: engine is shut down")
+	// ErrUnknownRequestType is returned when no handler matches a request
+	// and no default handler is set.
+	ErrUnknownRequestType = errors.New("core: unknown request type")
+	// ErrInvalidRequest is returned for nil or malformed requests.
+	ErrInvalidRequest = errors.New("core: invalid request")
+	// ErrDuplicateRequest is returned, wrapped with ErrInvalidRequest, when
+	// a request is submitted with the ID of one queued or running.
+	ErrDuplicateRequest = errors.New("core: duplicate request ID")
+)
+
+// Engine dispatches requests to handlers and holds shared state.
+type Engine struct {
+	config  *utils.Config
+	logger  *utils.Logger
+	bus     *EventBus
+	metrics *engineMetrics
+	retries *utils.RetryBudget
+	llm     openai.Completer
+	solana  *solana.Client
+	clock   Clock
+	store   StateStore
+	loader  *stateLoader
+	state   *stateMap
+	bg      *background
+
+	// solanaErr and llmErr are why NewEngine could not build the client
+	// of a configured dependency; its preflight check reports them.
+	solanaErr error
+	llmErr    error
+
+	// warnNoDeadline and fallbackDeadline are set by WarnOnNoDeadline.
+	warnNoDeadline   bool
+	fallbackDeadline time.Duration
+
+	mu        sync.RWMutex
+	handlers  map[string]Handler
+	fallback  Handler
+	pipelines map[string]*registeredPipeline
+	hooks     []shutdownHook
+	preflight []preflightCheck
+	// pipelineTypes lists the keys of pipelines in registration order.
+	pipelineTypes []string
+
+	inFlight     *inFlightLimiter
+	queue        *requestQueue
+	workers      int
+	limiter      *concurrencyLimiter // nil for a fixed pool
+	startWorkers sync.Once
+	workersWG    sync.WaitGroup
+
+	closed    atomic.Bool
+	startedAt time.Time
+
+	requestsTotal  atomic.Uint64
+	requestsFailed atomic.Uint64
+}
+
+// RequestEvent is the payload of request lifecycle events.
+type RequestEvent struct {
+	RequestID string
+	Type      string
+	Duration  time.Duration
+	Err       error
+}
+
+// StateEvent is the payload of TopicStateChanged events. Value is the copy
+// the engine stored, so changes the caller of UpdateState makes afterwards
+// do not show in it. It is shared with the state: do not modify it.
+type StateEvent struct {
+	Key   string
+	Value interface{}
+}
+
+// EngineOption configures an Engine.
+type EngineOption func(*Engine)
+
+// WithLogger makes the engine log through logger, prefixed with "Engine".
+// Pass the same logger to solana.WithLogger and openai.WithLogger
+// so every component shares one level and output. Without it the engine
+// derives its logger from utils.DefaultLogger.
+func WithLogger(logger *utils.Logger) EngineOption {
+	return func(e *Engine) {
+		if logger != nil {
+			e.logger = logger.Named("Engine")
+		}
+	}
+}
+
+// WithRetryBudget attaches the retry budget shared with the Solana and
+// OpenAI clients. Handlers draw from it through RetryBudget, and its
+// utilization is reported with the engine's metrics.
+func WithRetryBudget(budget *utils.RetryBudget) EngineOption {
+	return func(e *Engine) {
+		e.retries = budget
+	}
+}
+
+// WithCompleter sets the chat model provider handlers reach through
+// Completer. Pass openai.NewCompleter for OpenAI, or any other
+// implementation of openai.Completer. The seam uses the openai package's
+// request and response types rather than a copy in core: they are the chat
+// format other providers accept too, and a copy would have to be converted
+// field by field, tools and streaming included, on every call.
+func WithCompleter(completer openai.Completer) EngineOption {
+	return func(e *Engine) {
+		e.llm = completer
+	}
+}
+
+// WithSolanaClient sets the Solana client handlers share through Solana.
+// Without it NewEngine builds one from the config's Solana section, if
+// any.
+func WithSolanaClient(client *solana.Client) EngineOption {
+	return func(e *Engine) {
+		e.solana = client
+	}
+}
+
+// WithEventBus makes the engine publish its events on bus instead of a bus
+// of its own. Subscribe to bus before calling NewEngine to receive
+// TopicEngineStarted, which NewEngine publishes before returning. The
+// engine closes bus on Shutdown.
+func WithEventBus(bus *EventBus) EngineOption {
+	return func(e *Engine) {
+		if bus != nil {
+			e.bus = bus
+		}
+	}
+}
+
+// Clock tells the engine the time. Request timing in Results, event
+// timestamps, and metrics are read from it.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// WithClock makes the engine read the time from clock instead of the system
+// clock, so tests can control request timing. Context deadlines still run
+// on the system clock.
+func WithClock(clock Clock) EngineOption {
+	return func(e *Engine) {
+		if clock != nil {
+			e.clock = clock
+		}
+	}
+}
+
+// WarnOnNoDeadline makes the engine log a warning for every request whose
+// context carries no deadline, naming the request ID and type. A timeout
+// configured for the request's type counts as a deadline. A positive
+// fallback is then applied as the handler's timeout, starting once the
+// request holds an in-flight slot; zero only warns. Off by default.
+func WarnOnNoDeadline(fallback time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.warnNoDeadline = true
+		e.fallbackDeadline = fallback
+	}
+}
+
+// NewEngine creates an engine from config. Unless WithSolanaClient or
+// WithCompleter supplies them, it builds the Solana client and the OpenAI
+// completer from the config's sections, and it registers a preflight check
+// probing each; a client that cannot be built fails its check. With
+// Engine.Preflight.OnStart set it runs the checks and returns their
+// *PreflightError if any fails.
+func NewEngine(config *utils.Config, opts ...EngineOption) (*Engine, error) {
+	if config == nil {
+		return nil, errors.New("core: nil config")
+	}
+
+	workers := config.Engine.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	queueSize := config.Engine.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	e := &Engine{
+		config:    config,
+		logger:    utils.DefaultLogger().Named("Engine"),
+		bus:       NewEventBus(),
+		handlers:  make(map[string]Handler),
+		pipelines: make(map[string]*registeredPipeline),
+		state:     newStateMap(),
+		bg:        newBackground(),
+		queue:     newRequestQueue(queueSize),
+		workers:   workers,
+		clock:     systemClock{},
+	}
+	if config.Engine.AdaptiveConcurrency.Enabled {
+		limiter, err := newConcurrencyLimiter(config.Engine.AdaptiveConcurrency, workers)
+		if err != nil {
+			return nil, err
+		}
+		e.limiter = limiter
+		e.workers = limiter.max
+	}
+	inFlight, err := newInFlightLimiter(config.Engine.MaxInFlight, config.Engine.InFlightPolicy)
+	if err != nil {
+		return nil, err
+	}
+	e.inFlight = inFlight
+	if config.Engine.LoadShedding.Enabled {
+		shedder, err := newLoadShedder(config.Engine.LoadShedding, queueSize)
+		if err != nil {
+			return nil, err
+		}
+		e.queue.shedder = shedder
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.startedAt = e.clock.Now()
+	e.bus.now = e.clock.Now
+	e.metrics = newEngineMetrics(config.Engine.MetricLabels, config.Engine.LatencyBuckets, config.Engine.MetricsWindow, e.clock.Now)
+	if err := e.loadState(); err != nil {
+		return nil, err
+	}
+	e.buildClients()
+	e.registerBuiltinPreflightChecks()
+	if config.Engine.Preflight.OnStart {
+		if err := e.Preflight(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	e.RegisterShutdownHook("engine.workers", ShutdownOrderDrainWorkers, e.drainWorkers)
+
+	e.bus.Publish(TopicEngineStarted, nil)
+	return e, nil
+}
+
+// Events returns the engine's event bus. Handlers may publish custom topics
+// on it alongside the built-in lifecycle topics.
+func (e *Engine) Events() *EventBus {
+	return e.bus
+}
+
+// RegisterHandler registers handler for requests of requestType, replacing
+// any existing handler.
+func (e *Engine) RegisterHandler(requestType string, handler Handler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.handlers[requestType] = handler
+}
+
+// SetDefaultHandler sets the handler of requests whose type has no handler
+// registered, e.g. to route them generically or reply that they are
+// unsupported. It receives the full request and runs after the pipeline
+// registered for the request's type, if any, like a typed handler. Passing
+// nil removes it, so unknown types fail with ErrUnknownRequestType again.
+func (e *Engine) SetDefaultHandler(handler Handler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fallback = handler
+}
+
+// ProcessRequest handles req with a background context.
+func (e *Engine) ProcessRequest(req *Request) (interface{}, error) {
+	return e.ProcessRequestContext(context.Background(), req)
+}
+
+// ProcessRequestContext dispatches req to the handler registered for its
+// type. It returns Process's Data and Err; both are set for a partial result.
+func (e *Engine) ProcessRequestContext(ctx context.Context, req *Request) (interface{}, error) {
+	res := e.Process(ctx, req)
+	return res.Data, res.Err
+}
+
+// Process dispatches req to the handler registered for its type and reports
+// the outcome as a Result. It never returns nil; failures, including a
+// closed engine or a nil request, are reported in the Result.
+func (e *Engine) Process(ctx context.Context, req *Request) *Result {
+	if e.closed.Load() {
+		return e.failedResult(req, ErrEngineClosed)
+	}
+	if req == nil {
+		return e.failedResult(nil, fmt.Errorf("%w: nil request", ErrInvalidRequest))
+	}
+	return e.process(ctx, req, 0)
+}
+
+// process runs req through its handler, recording metrics and publishing
+// lifecycle events. The handler's context is cancelled once the timeout of
+// req's type, if any, passes. Queued requests reach it after the engine has
+// closed, so it does not check for shutdown. At the MaxInFlight cap it
+// waits for a slot or fails the request with ErrOverloaded, as configured.
+// queued is how long req waited in the queue, zero if it was not queued.
+func (e *Engine) process(ctx context.Context, req *Request, queued time.Duration) *Result {
+	timing := Timing{Queued: queued}
+	admitted := e.clock.Now()
+	release, err := e.inFlight.acquire(ctx)
+	if err != nil {
+		res := e.failedResult(req, fmt.Errorf("request %s (%s): %w", req.ID, req.Type, err))
+		res.Timing = timing
+		res.Timing.InFlightWait = res.CompletedAt.Sub(admitted)
+		e.logCompletion(res)
+		return res
+	}
+	defer release()
+	timing.InFlightWait = e.clock.Now().Sub(admitted)
+
+	e.requestsTotal.Add(1)
+	e.bus.Publish(TopicRequestReceived, RequestEvent{RequestID: req.ID, Type: req.Type})
+
+	res := newResult(req, e.clock.Now())
+	ctx, usage := utils.WithUsageRecorder(ctx)
+	ctx, cancel, checkTimeout := e.withRequestTimeout(ctx, req)
+	ctx, cancelFallback := e.withFallbackDeadline(ctx, req)
+	data, err := e.dispatch(ctx, req)
+	err = checkTimeout(err)
+	cancelFallback()
+	cancel()
+	if err != nil {
+		err = fmt.Errorf("request %s (%s): %w", req.ID, req.Type, err)
+	}
+	res.complete(e.clock.Now(), data, err)
+	res.Usage = usage.Usage()
+	res.Timing = timing
+	e.logCompletion(res)
+	e.metrics.observe(req, res.Duration, err)
+	event := RequestEvent{
+		RequestID: req.ID,
+		Type:      req.Type,
+		Duration:  res.Duration,
+		Err:       err,
+	}
+
+	if err != nil {
+		e.requestsFailed.Add(1)
+		e.bus.Publish(TopicRequestFailed, event)
+		return res
+	}
+	e.bus.Publish(TopicRequestCompleted, event)
+	return res
+}
+
+// logCompletion logs where the time of a finished request went: at DEBUG,
+// or at WARN when it timed out, since that is when the breakdown is
+// needed.
+func (e *Engine) logCompletion(res *Result) {
+	level := utils.DEBUG
+	if res.Error != nil && res.Error.Code == CodeTimeout {
+		level = utils.WARN
+	}
+	if !e.logger.Enabled(level) {
+		return
+	}
+	fields := map[string]interface{}{
+		"request_id":     res.RequestID,
+		"type":           res.Type,
+		"status":         string(res.Status),
+		"queued":         res.Timing.Queued.String(),
+		"in_flight_wait": res.Timing.InFlightWait.String(),
+		"handler":        res.Duration.String(),
+		"openai":         res.Usage.OpenAITime.String(),
+		"openai_calls":   res.Usage.OpenAIRequests,
+		"rpc":            res.Usage.RPCTime.String(),
+		"rpc_calls":      res.Usage.RPCCalls,
+	}
+	if level == utils.WARN {
+		e.logger.Warn("Request timed out", fields)
+		return
+	}
+	e.logger.Debug("Request completed", fields)
+}
+
+func (e *Engine) dispatch(ctx context.Context, req *Request) (interface{}, error) {
+	e.mu.RLock()
+	handler, ok := e.handlers[req.Type]
+	if !ok {
+		handler = e.fallback
+	}
+	pipeline := e.pipelines[req.Type]
+	e.mu.RUnlock()
+
+	if handler == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRequestType, req.Type)
+	}
+	if pipeline != nil {
+		var (
+			value interface{}
+			done  bool
+			err   error
+		)
+		ctx, req, value, done, err = pipeline.run(ctx, req)
+		if err != nil || done {
+			return value, err
+		}
+	}
+	return handler(ctx, req)
+}
+
+// UpdateState sets key in the engine state. The engine keeps a deep copy
+// of value, so changing value afterwards does not change the state. It is
+// safe to call concurrently with GetState.
+func (e *Engine) UpdateState(key string, value interface{}) error {
+	if e.closed.Load() {
+		return ErrEngineClosed
+	}
+
+	stored := e.state.set(key, value)
+
+	e.bus.Publish(TopicStateChanged, StateEvent{Key: key, Value: stored})
+	return nil
+}
+
+// GetState returns a deep copy of the engine state: nested maps and slices
+// are copied too, so callers may modify the result freely.
+func (e *Engine) GetState() map[string]interface{} {
+	return e.state.snapshot()
+}
+
+// GetMetrics returns a snapshot of engine counters. "by_type" reports the
+// count, error rate, and latency of every request type and is always
+// recorded; "series" breaks the same figures down by the configured metric
+// labels instead. Both count timeouts apart from other errors. "stages"
+// reports the latency of each pipeline stage. "shed" counts the
+// submissions rejected with ErrOverloaded per priority, and "shedding"
+// tells whether they are being rejected now. "processing" counts the
+// requests being processed, synchronously or by a worker, against
+// "max_in_flight" (zero when uncapped), and "in_flight_rejections" those
+// turned away at the cap.
+// "window" counts requests and failures over the last
+// Engine.MetricsWindow only, as told by the engine's clock.
+func (e *Engine) GetMetrics() map[string]interface{} {
+	queued, running := e.queue.depth()
+	return map[string]interface{}{
+		"requests_total":       e.requestsTotal.Load(),
+		"requests_failed":      e.requestsFailed.Load(),
+		"uptime_seconds":       e.clock.Now().Sub(e.startedAt).Seconds(),
+		"by_type":              e.metrics.typeSnapshots(),
+		"series":               e.metrics.snapshot(),
+		"stages":               e.stageSnapshots(),
+		"window":               e.metrics.window.Snapshot(),
+		"retry_budget":         e.retries.GetMetrics(),
+		"queue_depth":          queued,
+		"in_flight":            running,
+		"concurrency":          e.concurrencyLimit(),
+		"processing":           e.inFlight.current.Load(),
+		"max_in_flight":        e.inFlight.max(),
+		"in_flight_rejections": e.inFlight.rejected.Load(),
+		"shed":                 e.queue.shedder.snapshot(),
+		"shedding":             e.queue.shedding(),
+	}
+}
+
+// ResetMetrics zeroes the engine's request counters, series, and stage
+// latencies, e.g. between tests. The shared retry budget is left alone.
+func (e *Engine) ResetMetrics() {
+	e.requestsTotal.Store(0)
+	e.requestsFailed.Store(0)
+	e.metrics.reset()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, rp := range e.pipelines {
+		for _, h := range rp.latency {
+			h.Reset()
+		}
+	}
+}
+
+// RetryBudget returns the engine's shared retry budget. It is nil, which
+// allows every retry, unless WithRetryBudget was given.
+func (e *Engine) RetryBudget() *utils.RetryBudget {
+	return e.retries
+}
+
+// Completer returns the chat model provider set with WithCompleter or built
+// from the config's OpenAI section, or nil if there is neither. Handlers
+// should request completions through it rather than holding an
+// openai.Client, so the provider can be swapped without changing them. When
+// OpenAI is disabled in config it returns a provider whose calls fail with
+// utils.ErrDisabled, and when its client could not be built, one whose
+// calls fail with the reason.
+func (e *Engine) Completer() openai.Completer {
+	return e.llm
+}
+
+// Solana returns the Solana client set with WithSolanaClient or built from
+// the config's Solana section, or nil if there is neither or it could not
+// be built.
+func (e *Engine) Solana() *solana.Client {
+	return e.solana
+}
+
+// buildClients builds the clients of the dependencies in the config that no
+// option supplied. A client NewEngine builds is closed on Shutdown. With
+// OpenAI disabled and no completer supplied, Completer fails every call
+// with utils.ErrDisabled.
+func (e *Engine) buildClients() {
+	if e.solana == nil && e.config.Solana != nil {
+		e.solana, e.solanaErr = solana.NewClient(e.config.Solana, solana.WithLogger(e.logger))
+		if client := e.solana; client != nil {
+			e.RegisterShutdownHook("solana.client", ShutdownOrderCloseClients, func(context.Context) error {
+				return client.Close()
+			})
+		}
+	}
+	cfg := e.config.OpenAI
+	if e.llm != nil || cfg == nil {
+		return
+	}
+	if !cfg.IsEnabled() {
+		e.llm = unavailableCompleter{fmt.Errorf("openai: %w", utils.ErrDisabled)}
+		return
+	}
+	client, err := openai.NewClient(&openai.ClientConfig{
+		APIKey:  cfg.APIKey,
+		BaseURL: cfg.BaseURL,
+		Model:   cfg.Model,
+		Timeout: cfg.Timeout,
+	}, openai.WithLogger(e.logger))
+	if err != nil {
+		e.llmErr = err
+		e.llm = unavailableCompleter{err}
+		return
+	}
+	e.llm = openai.NewCompleter(client)
+}
+
+// unavailableCompleter stands in for a chat model provider that cannot be
+// used, failing every call with err.
+type unavailableCompleter struct {
+	err error
+}
+
+func (c unavailableCompleter) CreateChatCompletion(context.Context, *openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+	return nil, c.err
+}
+
+func (c unavailableCompleter) CreateChatCompletionStream(context.Context, *openai.ChatCompletionRequest) (openai.ChatStream, error) {
+	return nil, c.err
+}
+
+// Shutdown stops the engine. Subsequent requests fail with ErrEngineClosed.
+// Registered shutdown hooks then run in order within ctx's deadline; if any
+// fail or time out Shutdown returns a *ShutdownError naming them. Last, the
+// engine's background goroutines, its workers and those started with Go,
+// are cancelled, along with any request still running, and Shutdown waits
+// for them to exit until ctx is done.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	if !e.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	e.bus.Publish(TopicEngineShutdown, nil)
+	err := e.runShutdownHooks(ctx)
+	// The workers exit once the queue is closed, even if the drain hook
+	// never ran because ctx expired first.
+	e.queue.close()
+	if bgErr := e.bg.stop(ctx); err == nil {
+		err = bgErr
+	}
+	e.bus.Close()
+	e.logger.Info("Engine shut down", map[string]interface{}{
+		"requests_total": e.requestsTotal.Load(),
+	})
+	return err
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestWarnOnNoDeadline(t *testing.T) {
+	var buf bytes.Buffer
+	logger := utils.NewLogger(utils.WithOutput(&buf))
+	engine, err := NewEngine(&utils.Config{}, WithLogger(logger), WarnOnNoDeadline(time.Minute))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	var hadDeadline bool
+	engine.RegisterHandler("ai", func(ctx context.Context, req *Request) (interface{}, error) {
+		_, hadDeadline = ctx.Deadline()
+		return nil, nil
+	})
+
+	if _, err := engine.ProcessRequest(&Request{ID: "r1", Type: "ai"}); err != nil {
+		t.Fatalf("ProcessRequest: %v", err)
+	}
+	if !hadDeadline {
+		t.Fatal("fallback deadline was not applied")
+	}
+	if out := buf.String(); !strings.Contains(out, "no deadline") || !strings.Contains(out, "request_id=r1") {
+		t.Fatalf("log = %q, want a deadline warning for r1", out)
+	}
+
+	buf.Reset()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := engine.ProcessRequestContext(ctx, &Request{ID: "r2", Type: "ai"}); err != nil {
+		t.Fatalf("ProcessRequestContext: %v", err)
+	}
+	if strings.Contains(buf.String(), "no deadline") {
+		t.Fatalf("warned about a request with a deadline: %q", buf.String())
+	}
+
+	// A type with its own timeout has a deadline and gets no fallback.
+	buf.Reset()
+	config := &utils.Config{Engine: utils.EngineConfig{RequestTimeouts: map[string]time.Duration{"timed": 5 * time.Second}}}
+	engine, err = NewEngine(config, WithLogger(logger), WarnOnNoDeadline(time.Minute))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	var left time.Duration
+	engine.RegisterHandler("timed", func(ctx context.Context, req *Request) (interface{}, error) {
+		deadline, _ := ctx.Deadline()
+		left = time.Until(deadline)
+		return nil, nil
+	})
+	if _, err := engine.ProcessRequest(&Request{ID: "r3", Type: "timed"}); err != nil {
+		t.Fatalf("ProcessRequest: %v", err)
+	}
+	if strings.Contains(buf.String(), "no deadline") || left > 5*time.Second {
+		t.Fatalf("type timeout: %v left, log %q; want the 5s timeout and no warning", left, buf.String())
+	}
+}
+
+func TestDisabledOpenAI(t *testing.T) {
+	disabled := false
+	config := &utils.Config{OpenAI: &utils.OpenAIConfig{APIKey: "key", Enabled: &disabled}}
+	engine, err := NewEngine(config)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+
+	if _, err := engine.Completer().CreateChatCompletion(context.Background(), nil); !errors.Is(err, utils.ErrDisabled) {
+		t.Fatalf("CreateChatCompletion error = %v, want ErrDisabled", err)
+	}
+	if _, err := engine.Completer().CreateChatCompletionStream(context.Background(), nil); !errors.Is(err, utils.ErrDisabled) {
+		t.Fatalf("CreateChatCompletionStream error = %v, want ErrDisabled", err)
+	}
+
+	// A completer supplied as an option is kept: disabling OpenAI only
+	// stops NewEngine from building one.
+	supplied := unavailableCompleter{errors.New("supplied")}
+	engine, err = NewEngine(config, WithCompleter(supplied))
+	if err != nil {
+		t.Fatalf("NewEngine with a completer: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+	if got := engine.Completer(); got != supplied {
+		t.Fatalf("Completer = %v, want the one passed to WithCompleter", got)
+	}
+}
+
+func TestDefaultHandler(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+	engine.RegisterHandler("known", func(ctx context.Context, req *Request) (interface{}, error) {
+		return "typed", nil
+	})
+	engine.RegisterPipeline("legacy", NewPipeline(Stage{Name: "tag", Run: func(ctx context.Context, req *Request) (context.Context, *Request, error) {
+		req.Payload = map[string]interface{}{"tagged": true}
+		return ctx, req, nil
+	}}))
+
+	if _, err := engine.ProcessRequest(&Request{ID: "r1", Type: "legacy"}); !errors.Is(err, ErrUnknownRequestType) {
+		t.Fatalf("without a default handler = %v, want ErrUnknownRequestType", err)
+	}
+
+	var sawTag bool
+	engine.SetDefaultHandler(func(ctx context.Context, req *Request) (interface{}, error) {
+		sawTag = req.Payload["tagged"] == true
+		return "unsupported: " + req.Type, nil
+	})
+	if v, err := engine.ProcessRequest(&Request{ID: "r2", Type: "known"}); err != nil || v != "typed" {
+		t.Fatalf("typed request = %v, %v", v, err)
+	}
+	if v, err := engine.ProcessRequest(&Request{ID: "r3", Type: "legacy"}); err != nil || v != "unsupported: legacy" || !sawTag {
+		t.Fatalf("default-handled request = %v, %v (pipeline ran: %v)", v, err, sawTag)
+	}
+
+	engine.SetDefaultHandler(nil)
+	if _, err := engine.ProcessRequest(&Request{ID: "r4", Type: "legacy"}); !errors.Is(err, ErrUnknownRequestType) {
+		t.Fatalf("after removing the default handler = %v, want ErrUnknownRequestType", err)
+	}
+}
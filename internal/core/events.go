@@ -0,0 +1,171 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Lifecycle topics published by the engine.
+const (
+	// TopicEngineStarted is published by NewEngine, so only subscribers
+	// of a bus passed with WithEventBus receive it.
+	TopicEngineStarted    = "engine.started"
+	TopicEngineShutdown   = "engine.shutdown"
+	TopicRequestReceived  = "request.received"
+	TopicRequestCompleted = "request.completed"
+	TopicRequestFailed    = "request.failed"
+	TopicStateChanged     = "state.changed"
+
+	// TopicAll subscribes to every topic.
+	TopicAll = "*"
+)
+
+// DefaultSubscriberBuffer is the per-subscriber buffer used when Subscribe is
+// given a non-positive size.
+const DefaultSubscriberBuffer = 64
+
+// ErrBusClosed is returned when publishing to or subscribing on a closed bus.
+var ErrBusClosed = errors.New("core: event bus closed")
+
+// Event is a message delivered to subscribers.
+type Event struct {
+	Topic string
+	Time  time.Time
+	Data  interface{}
+}
+
+// EventBus is an in-process, topic-based publish/subscribe bus. Each
+// subscriber has its own buffer; when it is full the event is dropped for
+// that subscriber so a slow consumer never blocks publishers.
+type EventBus struct {
+	mu     sync.RWMutex
+	subs   map[string]map[uint64]*Subscription
+	nextID uint64
+	closed bool
+	now    func() time.Time
+}
+
+// Subscription is a single subscriber's view of a topic.
+type Subscription struct {
+	id      uint64
+	topic   string
+	ch      chan Event
+	bus     *EventBus
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// NewEventBus creates an empty bus.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[string]map[uint64]*Subscription), now: time.Now}
+}
+
+// Subscribe registers a subscriber for topic, or for every topic when topic
+// is TopicAll.
+func (b *EventBus) Subscribe(topic string, buffer int) (*Subscription, error) {
+	if buffer <= 0 {
+		buffer = DefaultSubscriberBuffer
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+
+	b.nextID++
+	sub := &Subscription{
+		id:    b.nextID,
+		topic: topic,
+		ch:    make(chan Event, buffer),
+		bus:   b,
+	}
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[uint64]*Subscription)
+	}
+	b.subs[topic][sub.id] = sub
+	return sub, nil
+}
+
+// Publish delivers data to every subscriber of topic and of TopicAll. It
+// never blocks and returns the number of subscribers that received it.
+func (b *EventBus) Publish(topic string, data interface{}) (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return 0, ErrBusClosed
+	}
+
+	event := Event{Topic: topic, Time: b.now(), Data: data}
+	delivered := 0
+	for _, set := range [2]map[uint64]*Subscription{b.subs[topic], b.subs[TopicAll]} {
+		for _, sub := range set {
+			select {
+			case sub.ch <- event:
+				delivered++
+			default:
+				sub.dropped.Add(1)
+			}
+		}
+	}
+	return delivered, nil
+}
+
+// Close closes every subscription channel. Further Publish and Subscribe
+// calls return ErrBusClosed.
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, set := range b.subs {
+		for _, sub := range set {
+			sub.closeChannel()
+		}
+	}
+	b.subs = nil
+}
+
+// C returns the channel on which events are delivered. It is closed when the
+// subscription is cancelled or the bus is closed.
+func (s *Subscription) C() <-chan Event {
+	return s.ch
+}
+
+// Topic returns the subscribed topic.
+func (s *Subscription) Topic() string {
+	return s.topic
+}
+
+// Dropped returns the number of events discarded because the buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe removes the subscription and closes its channel. It is safe to
+// call more than once.
+func (s *Subscription) Unsubscribe() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if set, ok := s.bus.subs[s.topic]; ok {
+		delete(set, s.id)
+		if len(set) == 0 {
+			delete(s.bus.subs, s.topic)
+		}
+	}
+	s.closeChannel()
+}
+
+func (s *Subscription) closeChannel() {
+	s.once.Do(func() {
+		close(s.ch)
+	})
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestEventBusFanOut(t *testing.T) {
+	bus := NewEventBus()
+	defer bus.Close()
+
+	a, _ := bus.Subscribe("custom", 4)
+	b, _ := bus.Subscribe("custom", 4)
+	all, _ := bus.Subscribe(TopicAll, 4)
+
+	n, err := bus.Publish("custom", 42)
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("delivered = %d, want 3", n)
+	}
+	for _, sub := range []*Subscription{a, b, all} {
+		ev := <-sub.C()
+		if ev.Topic != "custom" || ev.Data != 42 {
+			t.Errorf("event = %+v", ev)
+		}
+	}
+}
+
+func TestEventBusSlowSubscriberDoesNotBlock(t *testing.T) {
+	bus := NewEventBus()
+	defer bus.Close()
+
+	slow, _ := bus.Subscribe("t", 1)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			bus.Publish("t", i)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publisher blocked on slow subscriber")
+	}
+	if slow.Dropped() != 9 {
+		t.Fatalf("dropped = %d, want 9", slow.Dropped())
+	}
+}
+
+func TestEventBusUnsubscribeAndClose(t *testing.T) {
+	bus := NewEventBus()
+	sub, _ := bus.Subscribe("t", 1)
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+
+	if _, ok := <-sub.C(); ok {
+		t.Fatal("channel not closed after Unsubscribe")
+	}
+	if n, _ := bus.Publish("t", nil); n != 0 {
+		t.Fatalf("delivered = %d after unsubscribe", n)
+	}
+
+	other, _ := bus.Subscribe("t", 1)
+	bus.Close()
+	if _, ok := <-other.C(); ok {
+		t.Fatal("channel not closed after Close")
+	}
+	if _, err := bus.Publish("t", nil); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("Publish after Close = %v, want ErrBusClosed", err)
+	}
+	if _, err := bus.Subscribe("t", 1); !errors.Is(err, ErrBusClosed) {
+		t.Fatalf("Subscribe after Close = %v, want ErrBusClosed", err)
+	}
+}
+
+func TestEngineStartedEvent(t *testing.T) {
+	bus := NewEventBus()
+	started, _ := bus.Subscribe(TopicEngineStarted, 1)
+
+	engine, err := NewEngine(&utils.Config{}, WithEventBus(bus))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	if engine.Events() != bus {
+		t.Fatal("engine does not publish on the bus it was given")
+	}
+	select {
+	case ev := <-started.C():
+		if ev.Topic != TopicEngineStarted {
+			t.Fatalf("event = %+v", ev)
+		}
+	default:
+		t.Fatal("TopicEngineStarted was not delivered")
+	}
+}
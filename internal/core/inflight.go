@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// In-flight policies for utils.EngineConfig.InFlightPolicy.
+const (
+	InFlightBlock  = "block"
+	InFlightReject = "reject"
+)
+
+// inFlightLimiter counts the requests being processed and, with a cap,
+// holds back or rejects those beyond it. Unlike the worker pool it also
+// bounds requests passed to Process directly.
+type inFlightLimiter struct {
+	slots  chan struct{} // nil without a cap
+	reject bool
+
+	current  atomic.Int64
+	rejected atomic.Uint64
+}
+
+func newInFlightLimiter(max int, policy string) (*inFlightLimiter, error) {
+	l := &inFlightLimiter{}
+	switch policy {
+	case "", InFlightBlock:
+	case InFlightReject:
+		l.reject = true
+	default:
+		return nil, fmt.Errorf("core: unknown in_flight_policy %q", policy)
+	}
+	if max > 0 {
+		l.slots = make(chan struct{}, max)
+	}
+	return l, nil
+}
+
+// acquire takes a slot for one request. At the cap it fails with
+// ErrOverloaded when rejecting, or waits until a slot frees up or ctx is
+// done. Call release once the request completes.
+func (l *inFlightLimiter) acquire(ctx context.Context) (release func(), err error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			if l.reject {
+				l.rejected.Add(1)
+				return nil, fmt.Errorf("%w: %d requests in flight", ErrOverloaded, cap(l.slots))
+			}
+			select {
+			case l.slots <- struct{}{}:
+			case <-ctx.Done():
+				return nil, context.Cause(ctx)
+			}
+		}
+	}
+	l.current.Add(1)
+	return func() {
+		l.current.Add(-1)
+		if l.slots != nil {
+			<-l.slots
+		}
+	}, nil
+}
+
+// max returns the cap, or zero without one.
+func (l *inFlightLimiter) max() int {
+	return cap(l.slots)
+}
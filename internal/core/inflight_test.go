@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	for _, policy := range []string{InFlightReject, InFlightBlock} {
+		t.Run(policy, func(t *testing.T) {
+			engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{MaxInFlight: 1, InFlightPolicy: policy}})
+			if err != nil {
+				t.Fatalf("NewEngine: %v", err)
+			}
+			defer engine.Shutdown(context.Background())
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			engine.RegisterHandler("slow", func(ctx context.Context, req *Request) (interface{}, error) {
+				close(started)
+				<-release
+				return "slow", nil
+			})
+			engine.RegisterHandler("fast", func(ctx context.Context, req *Request) (interface{}, error) {
+				return "fast", nil
+			})
+
+			slow := make(chan *Result, 1)
+			go func() { slow <- engine.Process(context.Background(), &Request{ID: "slow", Type: "slow"}) }()
+			<-started
+
+			// A submitted request counts against the same cap.
+			submitted, err := engine.Submit(context.Background(), &Request{ID: "queued", Type: "fast"})
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			res := engine.Process(ctx, &Request{ID: "over", Type: "fast"})
+			want := CodeTimeout
+			if policy == InFlightReject {
+				want = CodeOverloaded
+			}
+			if res.Status != StatusFailed || res.Error.Code != want {
+				t.Fatalf("request over the cap = %+v, want %s", res.Error, want)
+			}
+
+			metrics := engine.GetMetrics()
+			if metrics["processing"] != int64(1) || metrics["max_in_flight"] != 1 {
+				t.Fatalf("processing = %v, max_in_flight = %v", metrics["processing"], metrics["max_in_flight"])
+			}
+
+			rejections := uint64(0)
+			if policy == InFlightReject {
+				// The submission is turned away while the slow request runs.
+				if res := <-submitted; res.Error == nil || res.Error.Code != CodeOverloaded {
+					t.Fatalf("submission = %+v, want CodeOverloaded", res)
+				}
+				rejections = 2
+			}
+			close(release)
+			if res := <-slow; res.Data != "slow" {
+				t.Fatalf("slow result = %+v", res)
+			}
+			if policy == InFlightBlock {
+				if res := <-submitted; res.Data != "fast" {
+					t.Fatalf("blocked submission = %+v, want it to run once the slot freed", res)
+				}
+			}
+			if got := engine.GetMetrics()["in_flight_rejections"]; got != rejections {
+				t.Fatalf("in_flight_rejections = %v, want %d", got, rejections)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// Adaptive concurrency defaults applied when
+// utils.AdaptiveConcurrencyConfig leaves them unset.
+const (
+	DefaultMinWorkers   = 1
+	DefaultMaxWorkers   = 64
+	DefaultMaxErrorRate = 0.1
+)
+
+const (
+	// limitBackoff is the factor the limit is cut by when requests degrade.
+	limitBackoff = 0.75
+	// limitMinSamples is the fewest completions the limit is judged on.
+	limitMinSamples = 10
+)
+
+// concurrencyLimiter bounds the requests processed at once with an AIMD
+// limit. The limit is judged after each window of completions, at least as
+// many as the limit itself: it grows by one if the window was healthy and
+// the pool was full at some point, and is cut by limitBackoff if the
+// average latency passed the target or the error rate passed the maximum.
+// Workers take a slot before waiting for a job, so only slots whose job
+// has started count toward a full pool; idle workers do not.
+//
+// A nil limiter admits everything, so the fixed-size pool needs no checks.
+type concurrencyLimiter struct {
+	min, max      int
+	targetLatency time.Duration
+	maxErrorRate  float64
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    int
+	inFlight int // slots taken, including by workers waiting for a job
+	running  int // slots whose job has started
+
+	// The current window.
+	samples   int
+	failures  int
+	latency   time.Duration
+	saturated bool
+}
+
+func newConcurrencyLimiter(cfg utils.AdaptiveConcurrencyConfig, initial int) (*concurrencyLimiter, error) {
+	l := &concurrencyLimiter{
+		min:           cfg.MinWorkers,
+		max:           cfg.MaxWorkers,
+		targetLatency: cfg.TargetLatency,
+		maxErrorRate:  cfg.MaxErrorRate,
+	}
+	if l.min <= 0 {
+		l.min = DefaultMinWorkers
+	}
+	if l.max <= 0 {
+		l.max = DefaultMaxWorkers
+	}
+	if l.maxErrorRate <= 0 {
+		l.maxErrorRate = DefaultMaxErrorRate
+	}
+	if l.min > l.max {
+		return nil, fmt.Errorf("core: adaptive concurrency min_workers %d exceeds max_workers %d", l.min, l.max)
+	}
+	l.limit = initial
+	if l.limit < l.min {
+		l.limit = l.min
+	}
+	if l.limit > l.max {
+		l.limit = l.max
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l, nil
+}
+
+// acquire blocks until fewer requests than the limit are in flight and
+// takes a slot.
+func (l *concurrencyLimiter) acquire() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight >= l.limit {
+		l.cond.Wait()
+	}
+	l.inFlight++
+}
+
+// start marks the job of a taken slot as started.
+func (l *concurrencyLimiter) start() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running++
+	if l.running >= l.limit {
+		l.saturated = true
+	}
+}
+
+// release gives back a slot without a completed request.
+func (l *concurrencyLimiter) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.cond.Signal()
+}
+
+// done gives back a started slot and records the completed request.
+func (l *concurrencyLimiter) done(latency time.Duration, failed bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.running--
+
+	l.samples++
+	l.latency += latency
+	if failed {
+		l.failures++
+	}
+	if l.samples >= l.limit && l.samples >= limitMinSamples {
+		l.adjust()
+	}
+	// The limit may have grown by one besides the freed slot.
+	l.cond.Broadcast()
+}
+
+// adjust applies the current window to the limit and starts a new one.
+func (l *concurrencyLimiter) adjust() {
+	degraded := float64(l.failures)/float64(l.samples) > l.maxErrorRate ||
+		l.targetLatency > 0 && l.latency/time.Duration(l.samples) > l.targetLatency
+	switch {
+	case degraded:
+		l.limit = int(float64(l.limit) * limitBackoff)
+		if l.limit < l.min {
+			l.limit = l.min
+		}
+	case l.saturated && l.limit < l.max:
+		l.limit++
+	}
+	l.samples, l.failures, l.latency = 0, 0, 0
+	l.saturated = l.running >= l.limit
+}
+
+// current returns the limit.
+func (l *concurrencyLimiter) current() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// concurrencyLimit returns the number of requests the engine processes at
+// once: the adaptive limit, or the fixed worker count.
+func (e *Engine) concurrencyLimit() int {
+	if e.limiter == nil {
+		return e.workers
+	}
+	return e.limiter.current()
+}
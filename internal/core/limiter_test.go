@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	l, err := newConcurrencyLimiter(utils.AdaptiveConcurrencyConfig{
+		MinWorkers:    2,
+		MaxWorkers:    12,
+		TargetLatency: 100 * time.Millisecond,
+		MaxErrorRate:  0.2,
+	}, 1)
+	if err != nil {
+		t.Fatalf("newConcurrencyLimiter: %v", err)
+	}
+	if got := l.current(); got != 2 {
+		t.Fatalf("initial limit = %d, want clamped to 2", got)
+	}
+
+	// window runs one judged window of completions with the pool full.
+	window := func(latency time.Duration, failures int) int {
+		n := l.current()
+		if n < limitMinSamples {
+			n = limitMinSamples
+		}
+		for i := 0; i < n; i++ {
+			for l.inFlight < l.limit {
+				l.acquire()
+				l.start()
+			}
+			l.done(latency, i < failures)
+		}
+		// Give back the slots still held without recording them.
+		l.inFlight, l.running = 0, 0
+		return l.current()
+	}
+	for want := 3; want <= 12; want++ {
+		if got := window(10*time.Millisecond, 1); got != want {
+			t.Fatalf("healthy window: limit = %d, want %d", got, want)
+		}
+	}
+	if got := window(10*time.Millisecond, 0); got != 12 {
+		t.Fatalf("limit = %d, want capped at 12", got)
+	}
+	if got := window(10*time.Millisecond, 3); got != 9 {
+		t.Fatalf("after errors: limit = %d, want 9", got)
+	}
+	if got := window(time.Second, 0); got != 6 {
+		t.Fatalf("after slow window: limit = %d, want 6", got)
+	}
+	for i := 0; i < 5; i++ {
+		window(time.Second, 0)
+	}
+	if got := l.current(); got != 2 {
+		t.Fatalf("limit = %d, want floored at 2", got)
+	}
+
+	// Without a full pool there is no evidence more workers would help.
+	for i := 0; i < limitMinSamples; i++ {
+		l.acquire()
+		l.start()
+		l.done(time.Millisecond, false)
+	}
+	// Nor with the other slots held by workers still waiting for a job.
+	for i := 0; i < limitMinSamples; i++ {
+		l.acquire()
+		l.acquire()
+		l.start()
+		l.done(time.Millisecond, false)
+		l.release()
+	}
+	if got := l.current(); got != 2 {
+		t.Fatalf("unsaturated window: limit = %d, want 2", got)
+	}
+
+	if _, err := newConcurrencyLimiter(utils.AdaptiveConcurrencyConfig{MinWorkers: 5, MaxWorkers: 4}, 4); err == nil {
+		t.Fatal("min above max was accepted")
+	}
+}
+
+func TestAdaptiveConcurrencyEngine(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{
+		Workers:             2,
+		AdaptiveConcurrency: utils.AdaptiveConcurrencyConfig{Enabled: true, MaxWorkers: 8},
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+
+	release := make(chan struct{})
+	running := make(chan struct{}, 16)
+	engine.RegisterHandler("work", func(ctx context.Context, req *Request) (interface{}, error) {
+		running <- struct{}{}
+		<-release
+		return nil, nil
+	})
+	submit := func(n int) []<-chan *Result {
+		var results []<-chan *Result
+		for i := 0; i < n; i++ {
+			res, err := engine.Submit(context.Background(), &Request{ID: fmt.Sprint(time.Now().UnixNano(), i), Type: "work"})
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			results = append(results, res)
+		}
+		return results
+	}
+
+	results := submit(4)
+	<-running
+	<-running
+	select {
+	case <-running:
+		t.Fatal("more requests running than the starting limit")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if got := engine.GetMetrics()["concurrency"]; got != 2 {
+		t.Fatalf("concurrency = %v, want 2", got)
+	}
+	close(release)
+	for _, res := range append(results, submit(limitMinSamples)...) {
+		if r := <-res; r.Err != nil {
+			t.Fatalf("result: %v", r.Err)
+		}
+	}
+	if got := engine.GetMetrics()["concurrency"].(int); got <= 2 {
+		t.Fatalf("concurrency = %d, want grown past 2 under a healthy full pool", got)
+	}
+
+	fixed, _ := NewEngine(&utils.Config{Engine: utils.EngineConfig{Workers: 3}})
+	defer fixed.Shutdown(context.Background())
+	if got := fixed.GetMetrics()["concurrency"]; got != 3 {
+		t.Fatalf("fixed concurrency = %v, want 3", got)
+	}
+}
+
+func TestAdaptiveConcurrencyIdle(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{
+		Workers:             2,
+		AdaptiveConcurrency: utils.AdaptiveConcurrencyConfig{Enabled: true, MaxWorkers: 8},
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+	engine.RegisterHandler("work", func(ctx context.Context, req *Request) (interface{}, error) {
+		return nil, nil
+	})
+
+	// One request at a time leaves the queue empty and the idle workers
+	// waiting, which is no sign that more workers would help.
+	for i := 0; i < 5*limitMinSamples; i++ {
+		res, err := engine.Submit(context.Background(), &Request{ID: fmt.Sprint("idle-", i), Type: "work"})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		if r := <-res; r.Err != nil {
+			t.Fatalf("result: %v", r.Err)
+		}
+	}
+	if got := engine.GetMetrics()["concurrency"]; got != 2 {
+		t.Fatalf("concurrency = %v, want 2 while the queue stays empty", got)
+	}
+}
@@ -0,0 +1,254 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// LabelType is the built-in metric label holding Request.Type.
+const LabelType = "type"
+
+// Metric label values used when a label is absent or cardinality is capped.
+const (
+	DefaultLabelValue  = "default"
+	OverflowLabelValue = "overflow"
+)
+
+// DefaultMaxMetricSeries caps the number of distinct labeled series.
+const DefaultMaxMetricSeries = 1000
+
+// engineMetrics aggregates request counts and latency per whitelisted label
+// set, and per request type regardless of the whitelist.
+type engineMetrics struct {
+	labels    []string
+	maxSeries int
+	buckets   []float64
+	window    *utils.SlidingWindow
+
+	mu     sync.Mutex
+	series map[string]*labeledSeries
+	byType map[string]*labeledSeries
+}
+
+type labeledSeries struct {
+	labels   map[string]string
+	requests atomic.Uint64
+	errors   atomic.Uint64
+	timeouts atomic.Uint64
+	latency  *utils.Histogram
+}
+
+// TypeSnapshot is a point-in-time view of the requests of one type.
+// Timeouts counts the errors with CodeTimeout, whether the type's timeout
+// or the caller's deadline expired; they are included in Errors.
+type TypeSnapshot struct {
+	Requests    uint64                  `json:"requests"`
+	Errors      uint64                  `json:"errors"`
+	ErrorRate   float64                 `json:"error_rate"`
+	Timeouts    uint64                  `json:"timeouts"`
+	TimeoutRate float64                 `json:"timeout_rate"`
+	Latency     utils.HistogramSnapshot `json:"latency"`
+}
+
+// SeriesSnapshot is a point-in-time view of one labeled series.
+type SeriesSnapshot struct {
+	Labels   map[string]string       `json:"labels"`
+	Requests uint64                  `json:"requests"`
+	Errors   uint64                  `json:"errors"`
+	Timeouts uint64                  `json:"timeouts"`
+	Latency  utils.HistogramSnapshot `json:"latency"`
+}
+
+func newEngineMetrics(labels []string, buckets []float64, window time.Duration, now func() time.Time) *engineMetrics {
+	if len(labels) == 0 {
+		labels = []string{LabelType}
+	}
+	sorted := append([]string(nil), labels...)
+	sort.Strings(sorted)
+	return &engineMetrics{
+		labels:    sorted,
+		maxSeries: DefaultMaxMetricSeries,
+		buckets:   buckets,
+		window:    utils.NewSlidingWindow(window, 0, now),
+		series:    make(map[string]*labeledSeries),
+		byType:    make(map[string]*labeledSeries),
+	}
+}
+
+// labelsFor selects the whitelisted labels of req. Labels outside the
+// whitelist are ignored; missing ones take DefaultLabelValue.
+func (m *engineMetrics) labelsFor(req *Request) map[string]string {
+	out := make(map[string]string, len(m.labels))
+	for _, name := range m.labels {
+		value := req.Labels[name]
+		if name == LabelType {
+			value = req.Type
+		}
+		if value == "" {
+			value = DefaultLabelValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+func (m *engineMetrics) seriesFor(labels map[string]string) *labeledSeries {
+	key := seriesKey(m.labels, labels)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.series[key]; ok {
+		return s
+	}
+	if len(m.series) >= m.maxSeries {
+		overflow := make(map[string]string, len(m.labels))
+		for _, name := range m.labels {
+			overflow[name] = OverflowLabelValue
+		}
+		labels = overflow
+		key = seriesKey(m.labels, labels)
+		if s, ok := m.series[key]; ok {
+			return s
+		}
+	}
+	s := &labeledSeries{labels: labels, latency: utils.NewHistogram(m.buckets)}
+	m.series[key] = s
+	return s
+}
+
+// typeSeries returns the series of requestType. Past maxSeries types, new
+// ones share the OverflowLabelValue series.
+func (m *engineMetrics) typeSeries(requestType string) *labeledSeries {
+	if requestType == "" {
+		requestType = DefaultLabelValue
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.byType[requestType]; ok {
+		return s
+	}
+	if len(m.byType) >= m.maxSeries {
+		requestType = OverflowLabelValue
+		if s, ok := m.byType[requestType]; ok {
+			return s
+		}
+	}
+	s := &labeledSeries{labels: map[string]string{LabelType: requestType}, latency: utils.NewHistogram(m.buckets)}
+	m.byType[requestType] = s
+	return s
+}
+
+func (m *engineMetrics) observe(req *Request, d time.Duration, err error) {
+	timedOut := err != nil && errorCode(err) == CodeTimeout
+	for _, s := range []*labeledSeries{m.seriesFor(m.labelsFor(req)), m.typeSeries(req.Type)} {
+		s.requests.Add(1)
+		if err != nil {
+			s.errors.Add(1)
+		}
+		if timedOut {
+			s.timeouts.Add(1)
+		}
+		s.latency.Observe(d)
+	}
+	m.window.Record(err != nil)
+}
+
+func (m *engineMetrics) reset() {
+	m.window.Reset()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.series = make(map[string]*labeledSeries)
+	m.byType = make(map[string]*labeledSeries)
+}
+
+// typeSnapshots returns the per-type series keyed by request type.
+func (m *engineMetrics) typeSnapshots() map[string]TypeSnapshot {
+	m.mu.Lock()
+	byType := make(map[string]*labeledSeries, len(m.byType))
+	for requestType, s := range m.byType {
+		byType[requestType] = s
+	}
+	m.mu.Unlock()
+
+	out := make(map[string]TypeSnapshot, len(byType))
+	for requestType, s := range byType {
+		snap := TypeSnapshot{
+			Requests: s.requests.Load(),
+			Errors:   s.errors.Load(),
+			Timeouts: s.timeouts.Load(),
+			Latency:  s.latency.Snapshot(),
+		}
+		if snap.Requests > 0 {
+			snap.ErrorRate = float64(snap.Errors) / float64(snap.Requests)
+			snap.TimeoutRate = float64(snap.Timeouts) / float64(snap.Requests)
+		}
+		out[requestType] = snap
+	}
+	return out
+}
+
+func (m *engineMetrics) snapshot() []SeriesSnapshot {
+	m.mu.Lock()
+	series := make([]*labeledSeries, 0, len(m.series))
+	for _, s := range m.series {
+		series = append(series, s)
+	}
+	m.mu.Unlock()
+
+	out := make([]SeriesSnapshot, 0, len(series))
+	for _, s := range series {
+		out = append(out, SeriesSnapshot{
+			Labels:   s.labels,
+			Requests: s.requests.Load(),
+			Errors:   s.errors.Load(),
+			Timeouts: s.timeouts.Load(),
+			Latency:  s.latency.Snapshot(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return seriesKey(m.labels, out[i].Labels) < seriesKey(m.labels, out[j].Labels)
+	})
+	return out
+}
+
+func seriesKey(names []string, labels map[string]string) string {
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(labels[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// CollectPrometheus implements utils.PrometheusCollector.
+func (e *Engine) CollectPrometheus(w *utils.PrometheusWriter) {
+	for _, s := range e.metrics.snapshot() {
+		w.Counter("engine_requests_total", "Requests processed by the engine.", float64(s.Requests), s.Labels)
+		w.Counter("engine_request_errors_total", "Requests that failed.", float64(s.Errors), s.Labels)
+		w.Counter("engine_request_timeouts_total", "Requests that failed by timing out.", float64(s.Timeouts), s.Labels)
+		w.Histogram("engine_request_latency_seconds", "Request processing latency.", s.Latency, s.Labels)
+	}
+	for _, s := range e.stageSnapshots() {
+		w.Histogram("engine_stage_latency_seconds", "Pipeline stage latency.", s.Latency,
+			map[string]string{"type": s.Type, "stage": s.Stage})
+	}
+	w.Gauge("engine_concurrency_limit", "Requests the engine processes at once.", float64(e.concurrencyLimit()), nil)
+	w.Gauge("engine_in_flight_requests", "Requests being processed.", float64(e.inFlight.current.Load()), nil)
+	w.Counter("engine_in_flight_rejections_total", "Requests rejected at the in-flight cap.", float64(e.inFlight.rejected.Load()), nil)
+	shed := e.queue.shedder.snapshot()
+	for _, priority := range priorities {
+		w.Counter("engine_requests_shed_total", "Submissions rejected by load shedding.", float64(shed[priority]), map[string]string{"priority": string(priority)})
+	}
+	e.retries.CollectPrometheus(w)
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestEngineMetricsByLabel(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{
+		MetricLabels: []string{"type", "tenant"},
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	engine.RegisterHandler("echo", func(ctx context.Context, req *Request) (interface{}, error) {
+		return req.Payload, nil
+	})
+
+	reqs := []*Request{
+		{ID: "1", Type: "echo", Labels: map[string]string{"tenant": "acme", "user": "ignored"}},
+		{ID: "2", Type: "echo", Labels: map[string]string{"tenant": "acme"}},
+		{ID: "3", Type: "echo"},
+	}
+	for _, req := range reqs {
+		if _, err := engine.ProcessRequest(req); err != nil {
+			t.Fatalf("ProcessRequest: %v", err)
+		}
+	}
+
+	series := engine.GetMetrics()["series"].([]SeriesSnapshot)
+	counts := map[string]uint64{}
+	for _, s := range series {
+		if _, ok := s.Labels["user"]; ok {
+			t.Fatal("non-whitelisted label recorded")
+		}
+		counts[s.Labels["tenant"]] = s.Requests
+	}
+	if counts["acme"] != 2 || counts[DefaultLabelValue] != 1 {
+		t.Fatalf("per-tenant counts = %v", counts)
+	}
+}
+
+func TestEngineMetricsOverflow(t *testing.T) {
+	m := newEngineMetrics([]string{"tenant"}, nil, 0, nil)
+	m.maxSeries = 2
+	for _, tenant := range []string{"a", "b", "c", "d"} {
+		m.observe(&Request{Labels: map[string]string{"tenant": tenant}}, 0, nil)
+	}
+	snap := m.snapshot()
+	if len(snap) != 3 {
+		t.Fatalf("series = %d, want 2 plus overflow", len(snap))
+	}
+	for _, s := range snap {
+		if s.Labels["tenant"] == OverflowLabelValue && s.Requests != 2 {
+			t.Fatalf("overflow requests = %d, want 2", s.Requests)
+		}
+	}
+}
+
+func TestEngineWindowAndReset(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1700000000, 0)}
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{MetricsWindow: 10 * time.Second}}, WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	engine.RegisterHandler("echo", func(ctx context.Context, req *Request) (interface{}, error) {
+		return nil, nil
+	})
+	engine.ProcessRequest(&Request{Type: "echo"})
+	engine.ProcessRequest(&Request{Type: "missing"})
+
+	window := engine.GetMetrics()["window"].(utils.WindowSnapshot)
+	if window.Events != 2 || window.Errors != 1 || window.ErrorRate != 0.5 || window.Window != 10*time.Second {
+		t.Fatalf("window = %+v", window)
+	}
+	clock.advance(5 * time.Second)
+	engine.ProcessRequest(&Request{Type: "echo"})
+	clock.advance(6 * time.Second)
+	if window := engine.GetMetrics()["window"].(utils.WindowSnapshot); window.Events != 1 || window.Errors != 0 {
+		t.Fatalf("window once the first requests aged out = %+v", window)
+	}
+
+	engine.ResetMetrics()
+	m := engine.GetMetrics()
+	if m["requests_total"] != uint64(0) || len(m["series"].([]SeriesSnapshot)) != 0 {
+		t.Fatalf("metrics after reset = %v", m)
+	}
+	if window := m["window"].(utils.WindowSnapshot); window.Events != 0 {
+		t.Fatalf("window after reset = %+v", window)
+	}
+}
+
+func TestEngineMetricsByType(t *testing.T) {
+	// Per-type metrics are recorded even when "type" is not a metric label.
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{MetricLabels: []string{"tenant"}}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	engine.RegisterHandler("echo", func(ctx context.Context, req *Request) (interface{}, error) {
+		return req.Payload, nil
+	})
+	engine.RegisterHandler("fail", func(ctx context.Context, req *Request) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	for _, typ := range []string{"echo", "echo", "fail", "missing"} {
+		engine.ProcessRequest(&Request{ID: typ, Type: typ})
+	}
+
+	byType := engine.GetMetrics()["by_type"].(map[string]TypeSnapshot)
+	if s := byType["echo"]; s.Requests != 2 || s.Errors != 0 || s.Latency.Count != 2 {
+		t.Fatalf("echo = %+v", s)
+	}
+	if s := byType["fail"]; s.Requests != 1 || s.ErrorRate != 1 {
+		t.Fatalf("fail = %+v", s)
+	}
+	if s := byType["missing"]; s.Errors != 1 {
+		t.Fatalf("unknown type = %+v", s)
+	}
+
+	engine.ResetMetrics()
+	if n := len(engine.GetMetrics()["by_type"].(map[string]TypeSnapshot)); n != 0 {
+		t.Fatalf("types after reset = %d", n)
+	}
+}
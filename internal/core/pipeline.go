@@ -0,0 +1,168 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// StageFunc is one step of a Pipeline. It returns the context and request
+// passed to the next stage, so it may enrich the payload or attach values
+// to the context. The context passed on keeps the request's deadline; one
+// with a later deadline or none is bounded by it. Returning Stop(value)
+// ends the pipeline early with value as the request's result; any other
+// error aborts the request.
+type StageFunc func(ctx context.Context, req *Request) (context.Context, *Request, error)
+
+// Stage is a named pipeline step. The name identifies the stage in errors
+// and metrics.
+type Stage struct {
+	Name string
+	Run  StageFunc
+}
+
+// Pipeline is an ordered list of stages run before a request's handler.
+type Pipeline struct {
+	stages []Stage
+}
+
+// NewPipeline creates a pipeline running stages in order.
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: append([]Stage(nil), stages...)}
+}
+
+// StageError reports the pipeline stage that failed a request.
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("core: stage %s: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// stopError carries the result of a stage that short-circuits its pipeline.
+type stopError struct {
+	value interface{}
+}
+
+func (e *stopError) Error() string {
+	return "core: pipeline stopped"
+}
+
+// Stop returns an error that, returned from a stage, ends the pipeline
+// successfully with value as the request's result. The handler and later
+// stages do not run.
+func Stop(value interface{}) error {
+	return &stopError{value: value}
+}
+
+// registeredPipeline is a pipeline with latency histograms for its stages,
+// timed on the engine's clock.
+type registeredPipeline struct {
+	*Pipeline
+	clock   Clock
+	latency []*utils.Histogram
+}
+
+// RegisterPipeline runs p before the handler of requests of requestType,
+// replacing any existing pipeline for that type.
+func (e *Engine) RegisterPipeline(requestType string, p *Pipeline) error {
+	if p == nil {
+		return fmt.Errorf("%w: nil pipeline", ErrInvalidRequest)
+	}
+	for _, s := range p.stages {
+		if s.Name == "" || s.Run == nil {
+			return fmt.Errorf("%w: pipeline stages need a name and a function", ErrInvalidRequest)
+		}
+	}
+
+	rp := &registeredPipeline{Pipeline: p, clock: e.clock, latency: make([]*utils.Histogram, len(p.stages))}
+	for i := range rp.latency {
+		rp.latency[i] = utils.NewHistogram(e.config.Engine.LatencyBuckets)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.pipelines[requestType]; !ok {
+		e.pipelineTypes = append(e.pipelineTypes, requestType)
+	}
+	e.pipelines[requestType] = rp
+	return nil
+}
+
+// run passes req through the stages. done is true when a stage stopped the
+// pipeline, in which case value is the request's result.
+func (rp *registeredPipeline) run(ctx context.Context, req *Request) (_ context.Context, _ *Request, value interface{}, done bool, err error) {
+	for i, s := range rp.stages {
+		start := rp.clock.Now()
+		nextCtx, nextReq, err := s.Run(ctx, req)
+		rp.latency[i].Observe(rp.clock.Now().Sub(start))
+
+		var stop *stopError
+		if errors.As(err, &stop) {
+			return ctx, req, stop.value, true, nil
+		}
+		if err != nil {
+			return ctx, req, nil, false, &StageError{Stage: s.Name, Err: err}
+		}
+		if nextCtx != nil {
+			ctx = keepDeadline(nextCtx, ctx)
+		}
+		if nextReq != nil {
+			req = nextReq
+		}
+	}
+	return ctx, req, nil, false, nil
+}
+
+// keepDeadline returns next, the context a stage passed on, bounded by the
+// deadline of prev, the one it was given. A stage that builds its context
+// afresh, or with a longer timeout, would otherwise give later stages and
+// the handler more time than the request has. The bound also ends when
+// prev is cancelled.
+func keepDeadline(next, prev context.Context) context.Context {
+	deadline, ok := prev.Deadline()
+	if !ok {
+		return next
+	}
+	if d, ok := next.Deadline(); ok && !d.After(deadline) {
+		return next
+	}
+	bounded, cancel := context.WithDeadline(next, deadline)
+	context.AfterFunc(prev, cancel)
+	return bounded
+}
+
+// StageSnapshot is the latency of one pipeline stage. Snapshots list the
+// pipelines in the order they were first registered, each with its stages
+// in order.
+type StageSnapshot struct {
+	Type    string                  `json:"type"`
+	Stage   string                  `json:"stage"`
+	Latency utils.HistogramSnapshot `json:"latency"`
+}
+
+func (e *Engine) stageSnapshots() []StageSnapshot {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var out []StageSnapshot
+	for _, requestType := range e.pipelineTypes {
+		rp := e.pipelines[requestType]
+		for i, s := range rp.stages {
+			out = append(out, StageSnapshot{
+				Type:    requestType,
+				Stage:   s.Name,
+				Latency: rp.latency[i].Snapshot(),
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestPipeline(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	engine.RegisterHandler("custom", func(ctx context.Context, req *Request) (interface{}, error) {
+		return req.Payload["greeting"], nil
+	})
+
+	errInvalid := errors.New("missing name")
+	err = engine.RegisterPipeline("custom", NewPipeline(
+		Stage{Name: "validate", Run: func(ctx context.Context, req *Request) (context.Context, *Request, error) {
+			if req.Payload["name"] == nil {
+				return nil, nil, errInvalid
+			}
+			return ctx, req, nil
+		}},
+		Stage{Name: "cache", Run: func(ctx context.Context, req *Request) (context.Context, *Request, error) {
+			if req.Payload["name"] == "cached" {
+				return nil, nil, Stop("from cache")
+			}
+			return ctx, req, nil
+		}},
+		Stage{Name: "enrich", Run: func(ctx context.Context, req *Request) (context.Context, *Request, error) {
+			enriched := *req
+			enriched.Payload = map[string]interface{}{"greeting": "hello " + req.Payload["name"].(string)}
+			return ctx, &enriched, nil
+		}},
+	))
+	if err != nil {
+		t.Fatalf("RegisterPipeline: %v", err)
+	}
+
+	result, err := engine.ProcessRequest(&Request{Type: "custom", Payload: map[string]interface{}{"name": "ana"}})
+	if err != nil || result != "hello ana" {
+		t.Fatalf("ProcessRequest = %v, %v", result, err)
+	}
+	result, err = engine.ProcessRequest(&Request{Type: "custom", Payload: map[string]interface{}{"name": "cached"}})
+	if err != nil || result != "from cache" {
+		t.Fatalf("short-circuited ProcessRequest = %v, %v", result, err)
+	}
+
+	_, err = engine.ProcessRequest(&Request{Type: "custom"})
+	var stageErr *StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != "validate" || !errors.Is(err, errInvalid) {
+		t.Fatalf("ProcessRequest = %v, want validate stage error", err)
+	}
+
+	stages := engine.GetMetrics()["stages"].([]StageSnapshot)
+	if len(stages) != 3 || stages[0].Stage != "validate" || stages[0].Latency.Count != 3 || stages[2].Latency.Count != 1 {
+		t.Fatalf("stage metrics = %+v", stages)
+	}
+}
+
+func TestPipelineKeepsRequestDeadline(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	type key struct{}
+	var remaining time.Duration
+	engine.RegisterHandler("budget", func(ctx context.Context, req *Request) (interface{}, error) {
+		remaining = utils.RemainingBudget(ctx)
+		return ctx.Value(key{}), nil
+	})
+	engine.RegisterPipeline("budget", NewPipeline(
+		Stage{Name: "fresh", Run: func(ctx context.Context, req *Request) (context.Context, *Request, error) {
+			// Builds its context afresh, dropping the request's deadline.
+			return context.WithValue(context.Background(), key{}, "kept"), req, nil
+		}},
+	))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	value, err := engine.ProcessRequestContext(ctx, &Request{Type: "budget"})
+	if err != nil || value != "kept" {
+		t.Fatalf("ProcessRequestContext = %v, %v", value, err)
+	}
+	if remaining <= 0 || remaining > time.Minute {
+		t.Fatalf("handler budget = %v, want the request's remaining minute", remaining)
+	}
+
+	if _, err := engine.ProcessRequest(&Request{Type: "budget"}); err != nil || remaining != utils.UnlimitedBudget {
+		t.Fatalf("without a deadline: budget = %v, err = %v", remaining, err)
+	}
+}
+
+// manualClock is a Clock that only moves when a test advances it.
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestPipelineStageLatencyUsesEngineClock(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1700000000, 0)}
+	engine, err := NewEngine(&utils.Config{}, WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	engine.RegisterHandler("custom", func(ctx context.Context, req *Request) (interface{}, error) {
+		return "ok", nil
+	})
+	engine.RegisterPipeline("custom", NewPipeline(
+		Stage{Name: "slow", Run: func(ctx context.Context, req *Request) (context.Context, *Request, error) {
+			clock.advance(2 * time.Second)
+			return ctx, req, nil
+		}},
+	))
+
+	if _, err := engine.ProcessRequest(&Request{Type: "custom"}); err != nil {
+		t.Fatalf("ProcessRequest: %v", err)
+	}
+	stages := engine.GetMetrics()["stages"].([]StageSnapshot)
+	if len(stages) != 1 || stages[0].Latency.Sum != 2 {
+		t.Fatalf("stage metrics = %+v, want 2s on the engine clock", stages)
+	}
+}
+
+func TestStageSnapshotsKeepRegistrationOrder(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	pass := func(ctx context.Context, req *Request) (context.Context, *Request, error) { return ctx, req, nil }
+	for _, requestType := range []string{"zeta", "alpha", "mid"} {
+		engine.RegisterPipeline(requestType, NewPipeline(Stage{Name: "second", Run: pass}, Stage{Name: "first", Run: pass}))
+	}
+	// Replacing a pipeline keeps its place.
+	engine.RegisterPipeline("zeta", NewPipeline(Stage{Name: "only", Run: pass}))
+
+	var got []string
+	for _, s := range engine.GetMetrics()["stages"].([]StageSnapshot) {
+		got = append(got, s.Type+"/"+s.Stage)
+	}
+	want := []string{"zeta/only", "alpha/second", "alpha/first", "mid/second", "mid/first"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("stages = %v, want %v", got, want)
+	}
+}
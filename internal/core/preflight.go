@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/openai"
+)
+
+// DefaultPreflightTimeout bounds a preflight check registered without a
+// timeout of its own.
+const DefaultPreflightTimeout = 5 * time.Second
+
+// Names of the preflight checks NewEngine registers from the config.
+const (
+	PreflightSolana     = "solana"
+	PreflightOpenAI     = "openai"
+	PreflightStateStore = "state_store"
+)
+
+// preflightStateKey is the key the state store check loads. It is never
+// saved, so a reachable store answers ErrStateNotFound.
+const preflightStateKey = "core/preflight"
+
+// PreflightCheck verifies that a dependency is usable. Client methods such
+// as solana.Client.GetHealth fit directly; for OpenAI, wrap ListModels.
+type PreflightCheck func(ctx context.Context) error
+
+type preflightCheck struct {
+	name    string
+	timeout time.Duration
+	fn      PreflightCheck
+}
+
+// PreflightError aggregates the checks that failed or timed out during
+// Preflight.
+type PreflightError struct {
+	Checks []*HookError
+}
+
+func (e *PreflightError) Error() string {
+	parts := make([]string, len(e.Checks))
+	for i, c := range e.Checks {
+		parts[i] = c.Error()
+	}
+	return "core: preflight failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the check errors, so errors.Is matches any of them.
+func (e *PreflightError) Unwrap() []error {
+	errs := make([]error, len(e.Checks))
+	for i, c := range e.Checks {
+		errs[i] = c
+	}
+	return errs
+}
+
+// RegisterPreflightCheck adds a check run by Preflight. A non-positive
+// timeout uses DefaultPreflightTimeout. NewEngine registers checks for the
+// Solana node, the OpenAI API, and the state store in its config; register
+// one for every other dependency handlers rely on.
+func (e *Engine) RegisterPreflightCheck(name string, timeout time.Duration, fn PreflightCheck) error {
+	if name == "" || fn == nil {
+		return fmt.Errorf("%w: preflight check needs a name and a function", ErrInvalidRequest)
+	}
+	if timeout <= 0 {
+		timeout = DefaultPreflightTimeout
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.preflight = append(e.preflight, preflightCheck{name: name, timeout: timeout, fn: fn})
+	return nil
+}
+
+// Preflight runs the registered checks concurrently, each under its own
+// timeout, and returns a *PreflightError naming every check that failed.
+// Call it after wiring the engine and before accepting traffic, so
+// misconfiguration and outages surface at boot rather than on the first
+// request, or set utils.PreflightConfig.OnStart to have NewEngine run the
+// built-in checks. State from WithStateLoader has been loaded by then.
+func (e *Engine) Preflight(ctx context.Context) error {
+	e.mu.RLock()
+	checks := append([]preflightCheck(nil), e.preflight...)
+	e.mu.RUnlock()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c preflightCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := e.clock.Now()
+			errs[i] = runHook(checkCtx, ShutdownHook(c.fn))
+			fields := map[string]interface{}{
+				"check":    c.name,
+				"duration": e.clock.Now().Sub(start).String(),
+			}
+			if errs[i] != nil {
+				fields["error"] = errs[i].Error()
+				e.logger.Error("Preflight check failed", fields)
+				return
+			}
+			e.logger.Debug("Preflight check passed", fields)
+		}(i, c)
+	}
+	wg.Wait()
+
+	var failed []*HookError
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, &HookError{Name: checks[i].name, Err: err})
+		}
+	}
+	if len(failed) > 0 {
+		return &PreflightError{Checks: failed}
+	}
+	return nil
+}
+
+// modelLister is implemented by completers that can list their models, such
+// as the one openai.NewCompleter returns.
+type modelLister interface {
+	ListModels(ctx context.Context) ([]openai.Model, error)
+}
+
+// registerBuiltinPreflightChecks registers a check for each dependency in
+// the config, probing the engine's own clients: getHealth on the Solana
+// client, a models list through the completer when OpenAI is enabled and
+// the completer can list them, and a read from the state store set by
+// WithQueuePersistence. A client NewEngine failed to build fails its check
+// with the reason.
+func (e *Engine) registerBuiltinPreflightChecks() {
+	timeout := e.config.Engine.Preflight.Timeout
+	if client, err := e.solana, e.solanaErr; client != nil || err != nil {
+		e.RegisterPreflightCheck(PreflightSolana, timeout, func(ctx context.Context) error {
+			if err != nil {
+				return fmt.Errorf("build solana client: %w", err)
+			}
+			return client.GetHealth(ctx)
+		})
+	}
+	if e.config.OpenAI.IsEnabled() {
+		err := e.llmErr
+		lister, ok := e.llm.(modelLister)
+		if ok || err != nil {
+			e.RegisterPreflightCheck(PreflightOpenAI, timeout, func(ctx context.Context) error {
+				if err != nil {
+					return fmt.Errorf("build openai client: %w", err)
+				}
+				_, err := lister.ListModels(ctx)
+				return err
+			})
+		}
+	}
+	if store := e.store; store != nil {
+		e.RegisterPreflightCheck(PreflightStateStore, timeout, func(ctx context.Context) error {
+			if _, err := store.Load(ctx, preflightStateKey); err != nil && !errors.Is(err, ErrStateNotFound) {
+				return err
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/openai"
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestPreflight(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	if err := engine.Preflight(context.Background()); err != nil {
+		t.Fatalf("Preflight with no checks: %v", err)
+	}
+
+	errDown := errors.New("connection refused")
+	engine.RegisterPreflightCheck("solana", 0, func(ctx context.Context) error { return nil })
+	engine.RegisterPreflightCheck("openai", 0, func(ctx context.Context) error { return errDown })
+	engine.RegisterPreflightCheck("state", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	err = engine.Preflight(context.Background())
+	if time.Since(start) > time.Second {
+		t.Fatal("Preflight did not honour the per-check timeout")
+	}
+	var pe *PreflightError
+	if !errors.As(err, &pe) || len(pe.Checks) != 2 {
+		t.Fatalf("Preflight = %v, want two failed checks", err)
+	}
+	if !errors.Is(err, errDown) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Preflight = %v, want the openai error and a timeout", err)
+	}
+	if pe.Checks[0].Name != "openai" || pe.Checks[1].Name != "state" {
+		t.Fatalf("failed checks = %v, want openai then state", pe)
+	}
+}
+
+// unreachableStore is a StateStore whose backend is down.
+type unreachableStore struct{ MemoryStateStore }
+
+func (*unreachableStore) Load(ctx context.Context, key string) ([]byte, error) {
+	return nil, errors.New("dial tcp: connection refused")
+}
+
+func TestBuiltinPreflightChecks(t *testing.T) {
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+	}))
+	defer rpc.Close()
+	var modelsDown atomic.Bool
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || modelsDown.Load() {
+			http.Error(w, `{"error":{"message":"invalid api key"}}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"gpt-4o-mini"}]}`))
+	}))
+	defer llm.Close()
+
+	config := &utils.Config{
+		Solana: &utils.SolanaConfig{Endpoint: rpc.URL},
+		OpenAI: &utils.OpenAIConfig{APIKey: "k", BaseURL: llm.URL},
+		Engine: utils.EngineConfig{Preflight: utils.PreflightConfig{OnStart: true, Timeout: time.Second}},
+	}
+	engine, err := NewEngine(config, WithQueuePersistence(NewMemoryStateStore()))
+	if err != nil {
+		t.Fatalf("NewEngine with healthy dependencies: %v", err)
+	}
+	engine.Shutdown(context.Background())
+
+	modelsDown.Store(true)
+	_, err = NewEngine(config, WithQueuePersistence(&unreachableStore{}))
+	var pe *PreflightError
+	if !errors.As(err, &pe) || len(pe.Checks) != 2 {
+		t.Fatalf("NewEngine = %v, want the openai and state store checks failed", err)
+	}
+	if pe.Checks[0].Name != PreflightOpenAI || pe.Checks[1].Name != PreflightStateStore {
+		t.Fatalf("failed checks = %v", pe)
+	}
+
+	// Without OnStart the checks are only registered.
+	config.Engine.Preflight.OnStart = false
+	engine, err = NewEngine(config)
+	if err != nil {
+		t.Fatalf("NewEngine without OnStart: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+	if err := engine.Preflight(context.Background()); !errors.As(err, &pe) || len(pe.Checks) != 1 || pe.Checks[0].Name != PreflightOpenAI {
+		t.Fatalf("Preflight = %v, want the openai check failed", err)
+	}
+
+	// Clients that cannot be built fail their checks rather than passing.
+	broken, err := NewEngine(&utils.Config{
+		Solana: &utils.SolanaConfig{Endpoint: "://no-scheme"},
+		OpenAI: &utils.OpenAIConfig{BaseURL: llm.URL},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine with broken clients: %v", err)
+	}
+	defer broken.Shutdown(context.Background())
+	if err := broken.Preflight(context.Background()); !errors.As(err, &pe) || len(pe.Checks) != 2 || !errors.Is(err, openai.ErrInvalidConfig) {
+		t.Fatalf("Preflight = %v, want both client builds reported", err)
+	}
+	if _, err := broken.Completer().CreateChatCompletion(context.Background(), nil); !errors.Is(err, openai.ErrInvalidConfig) {
+		t.Fatalf("completer of an unbuilt client = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,288 @@
+package core
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Async processing defaults applied when EngineConfig leaves them unset.
+const (
+	DefaultWorkers   = 4
+	DefaultQueueSize = 256
+)
+
+var (
+	// ErrQueueFull is returned by Submit when the request queue is at
+	// capacity.
+	ErrQueueFull = errors.New("core: request queue is full")
+	// ErrRequestCancelled is the Result error of a request cancelled with
+	// CancelRequest.
+	ErrRequestCancelled = errors.New("core: request cancelled")
+)
+
+type job struct {
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	req      *Request
+	priority Priority
+	queuedAt time.Time
+	result   chan *Result
+}
+
+// requestQueue is a bounded FIFO of submitted requests that also tracks the
+// requests currently running, so a request can be cancelled by ID in either
+// state without racing the worker that picks it up.
+type requestQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	items   *list.List
+	queued  map[string]*list.Element
+	running map[string]*job
+	max     int
+	closed  bool
+	shedder *loadShedder // nil unless load shedding is enabled
+}
+
+func newRequestQueue(max int) *requestQueue {
+	q := &requestQueue{
+		items:   list.New(),
+		queued:  make(map[string]*list.Element),
+		running: make(map[string]*job),
+		max:     max,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *requestQueue) push(j *job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrEngineClosed
+	}
+	id := j.req.ID
+	if _, ok := q.queued[id]; ok {
+		return fmt.Errorf("%w: %w %q", ErrInvalidRequest, ErrDuplicateRequest, id)
+	}
+	if _, ok := q.running[id]; ok {
+		return fmt.Errorf("%w: %w %q", ErrInvalidRequest, ErrDuplicateRequest, id)
+	}
+	if q.shedder != nil && !q.shedder.admit(q.items.Len(), j.priority) {
+		return fmt.Errorf("%w: %d requests queued, shedding %s priority", ErrOverloaded, q.items.Len(), j.priority)
+	}
+	if q.items.Len() >= q.max {
+		return ErrQueueFull
+	}
+	q.queued[id] = q.items.PushBack(j)
+	q.cond.Signal()
+	return nil
+}
+
+// pop blocks until a job is available and marks it running. It returns nil
+// once the queue is closed and empty.
+func (q *requestQueue) pop() *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for q.items.Len() == 0 {
+		if q.closed {
+			return nil
+		}
+		q.cond.Wait()
+	}
+	j := q.items.Remove(q.items.Front()).(*job)
+	delete(q.queued, j.req.ID)
+	q.shedder.observe(q.items.Len())
+	j.ctx, j.cancel = context.WithCancelCause(j.ctx)
+	q.running[j.req.ID] = j
+	return j
+}
+
+func (q *requestQueue) finish(j *job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.running, j.req.ID)
+	j.cancel(nil)
+}
+
+// cancel removes a queued job and returns it, or cancels a running one.
+func (q *requestQueue) cancel(id string) (removed *job, cancelled bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if el, ok := q.queued[id]; ok {
+		delete(q.queued, id)
+		removed = q.items.Remove(el).(*job)
+		q.shedder.observe(q.items.Len())
+		return removed, true
+	}
+	if j, ok := q.running[id]; ok {
+		j.cancel(ErrRequestCancelled)
+		return nil, true
+	}
+	return nil, false
+}
+
+// takePending removes and returns the queued jobs, and cancels the running
+// ones with cause and returns them too.
+func (q *requestQueue) takePending(cause error) (queued, running []*job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for el := q.items.Front(); el != nil; el = el.Next() {
+		queued = append(queued, el.Value.(*job))
+	}
+	q.items.Init()
+	q.queued = make(map[string]*list.Element)
+	q.shedder.observe(0)
+	for _, j := range q.running {
+		j.cancel(cause)
+		running = append(running, j)
+	}
+	return queued, running
+}
+
+func (q *requestQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// shedding reports whether the queue is shedding requests.
+func (q *requestQueue) shedding() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.shedder != nil && q.shedder.shedding
+}
+
+func (q *requestQueue) depth() (queued, running int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len(), len(q.running)
+}
+
+// Submit queues req for asynchronous processing and returns a channel that
+// receives its Result. The request must have an ID unique among queued and
+// running requests. ctx applies to the request's processing, including the
+// time it spends queued. With load shedding enabled, a request whose
+// Priority is being shed fails with ErrOverloaded.
+func (e *Engine) Submit(ctx context.Context, req *Request) (<-chan *Result, error) {
+	if e.closed.Load() {
+		return nil, ErrEngineClosed
+	}
+	if req == nil || req.ID == "" {
+		return nil, fmt.Errorf("%w: submitted requests need an ID", ErrInvalidRequest)
+	}
+	priority, err := requestPriority(req)
+	if err != nil {
+		return nil, err
+	}
+
+	e.startWorkers.Do(func() {
+		for i := 0; i < e.workers; i++ {
+			e.workersWG.Add(1)
+			if !e.bg.spawn(e.worker) {
+				e.workersWG.Done()
+			}
+		}
+	})
+
+	j := &job{ctx: ctx, req: req, priority: priority, queuedAt: e.clock.Now(), result: make(chan *Result, 1)}
+	if err := e.queue.push(j); err != nil {
+		return nil, err
+	}
+	return j.result, nil
+}
+
+// CancelRequest cancels a submitted request. A queued request is removed
+// and never runs; a running request has its context cancelled. Either way
+// its Result carries ErrRequestCancelled. It reports whether a request with
+// that ID was found.
+func (e *Engine) CancelRequest(requestID string) bool {
+	removed, cancelled := e.queue.cancel(requestID)
+	if removed != nil {
+		removed.result <- e.failedResult(removed.req, ErrRequestCancelled)
+		e.logger.Debug("Cancelled queued request", map[string]interface{}{
+			"request_id": requestID,
+		})
+	}
+	return cancelled
+}
+
+// worker processes queued requests until the queue is closed. A request
+// still running when ctx, the engine's root context, is cancelled has its
+// context cancelled with ErrEngineClosed.
+func (e *Engine) worker(ctx context.Context) {
+	defer e.workersWG.Done()
+	for {
+		e.limiter.acquire()
+		j := e.queue.pop()
+		if j == nil {
+			e.limiter.release()
+			return
+		}
+		e.limiter.start()
+		stop := context.AfterFunc(ctx, func() { j.cancel(ErrEngineClosed) })
+		result := e.run(j)
+		stop()
+		e.limiter.done(result.Duration, result.Status == StatusFailed && result.Error.Code != CodeCancelled)
+		j.result <- result
+	}
+}
+
+func (e *Engine) run(j *job) *Result {
+	defer e.queue.finish(j)
+
+	queued := e.clock.Now().Sub(j.queuedAt)
+	if err := j.ctx.Err(); err != nil {
+		// The request ran out of time in the queue, which its timing
+		// should show.
+		result := e.failedResult(j.req, err)
+		result.Timing.Queued = queued
+		e.logCompletion(result)
+		return result
+	}
+	result := e.process(j.ctx, j.req, queued)
+	if errors.Is(context.Cause(j.ctx), ErrRequestCancelled) && result.Err != nil {
+		result.complete(result.CompletedAt, nil, fmt.Errorf("%w: %w", ErrRequestCancelled, result.Error.Err))
+	}
+	return result
+}
+
+// drainWorkers stops the queue and waits for the workers to finish the
+// requests already queued. With WithQueuePersistence, the requests left
+// when the deadline nears are persisted instead; if ctx ends before that,
+// they are persisted within DefaultPersistReserve.
+func (e *Engine) drainWorkers(ctx context.Context) error {
+	e.queue.close()
+	drainCtx, cancel := e.drainDeadline(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		e.workersWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-drainCtx.Done():
+		if e.store == nil {
+			return ctx.Err()
+		}
+		if ctx.Err() == nil {
+			return e.persistQueue(ctx)
+		}
+		// Shutdown's context ended first; the requests are still worth
+		// saving, within a bound of their own.
+		persistCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultPersistReserve)
+		defer cancel()
+		return errors.Join(ctx.Err(), e.persistQueue(persistCtx))
+	}
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestCancelRequest(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{Workers: 1}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+
+	started := make(chan struct{})
+	engine.RegisterHandler("slow", func(ctx context.Context, req *Request) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	engine.RegisterHandler("fast", func(ctx context.Context, req *Request) (interface{}, error) {
+		return "done", nil
+	})
+
+	running, err := engine.Submit(context.Background(), &Request{ID: "a", Type: "slow"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+	queued, err := engine.Submit(context.Background(), &Request{ID: "b", Type: "fast"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if engine.CancelRequest("unknown") {
+		t.Fatal("CancelRequest reported an unknown ID as cancelled")
+	}
+	if !engine.CancelRequest("b") {
+		t.Fatal("queued request was not cancelled")
+	}
+	if res := <-queued; !errors.Is(res.Err, ErrRequestCancelled) {
+		t.Fatalf("queued result = %v, want ErrRequestCancelled", res.Err)
+	}
+	if !engine.CancelRequest("a") {
+		t.Fatal("running request was not cancelled")
+	}
+	select {
+	case res := <-running:
+		if !errors.Is(res.Err, ErrRequestCancelled) {
+			t.Fatalf("running result = %v, want ErrRequestCancelled", res.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("running request did not stop after cancellation")
+	}
+
+	again, err := engine.Submit(context.Background(), &Request{ID: "a", Type: "fast"})
+	if err != nil {
+		t.Fatalf("Submit after cancellation: %v", err)
+	}
+	if res := <-again; res.Err != nil || res.Value != "done" {
+		t.Fatalf("result = %+v", res)
+	}
+}
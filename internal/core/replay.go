@@ -0,0 +1,219 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// QueueStateKey is the StateStore key under which the engine persists its
+// pending requests.
+const QueueStateKey = "engine.queue"
+
+// DefaultPersistReserve is how much of the shutdown deadline the engine
+// keeps back for persisting its queue. At most half the remaining time is
+// reserved.
+const DefaultPersistReserve = 2 * time.Second
+
+// queueStateVersion is the format version of persisted queues.
+const queueStateVersion = 1
+
+// ErrRequestPersisted is the Result error of a queued request that was
+// persisted at shutdown instead of processed. It is wrapped together with
+// ErrEngineClosed.
+var ErrRequestPersisted = errors.New("core: request persisted for replay")
+
+// WithQueuePersistence makes Shutdown save the submitted requests it could
+// not finish to store, and ReplayPersistedRequests submit them again on the
+// next start.
+//
+// Delivery is at least once. The requests still running when the shutdown
+// deadline nears are saved along with the queued ones and have their
+// contexts cancelled, but a handler may already have done its work, so a
+// replayed request can run twice. Handlers of such requests must be
+// idempotent, keyed on Request.ID; Request.Replayed tells them a request is
+// a replay. Requests are saved as JSON, so numbers in Payload come back as
+// float64, and a request's original context, with its deadline and values,
+// is not kept.
+func WithQueuePersistence(store StateStore) EngineOption {
+	return func(e *Engine) {
+		e.store = store
+	}
+}
+
+type persistedQueue struct {
+	Version  int                `json:"version"`
+	Requests []persistedRequest `json:"requests"`
+}
+
+type persistedRequest struct {
+	ID       string                 `json:"id"`
+	Type     string                 `json:"type"`
+	Payload  map[string]interface{} `json:"payload,omitempty"`
+	Labels   map[string]string      `json:"labels,omitempty"`
+	Priority Priority               `json:"priority,omitempty"`
+}
+
+// drainDeadline returns the context the workers are drained within: ctx
+// itself, or with persistence one that ends early enough to save what is
+// left.
+func (e *Engine) drainDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if e.store == nil || !ok {
+		return ctx, func() {}
+	}
+	reserve := DefaultPersistReserve
+	if half := time.Until(deadline) / 2; half < reserve {
+		reserve = half
+	}
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
+}
+
+// persistQueue saves the queued and running requests to the state store,
+// after any saved by an earlier shutdown that were not yet replayed. Queued
+// requests are removed and fail with ErrRequestPersisted; running ones are
+// cancelled.
+func (e *Engine) persistQueue(ctx context.Context) error {
+	queued, running := e.queue.takePending(ErrRequestPersisted)
+	if len(queued) == 0 && len(running) == 0 {
+		return nil
+	}
+
+	saved, err := e.loadQueue(ctx)
+	if err == nil {
+		for _, j := range append(running, queued...) {
+			saved = append(saved, j.req)
+		}
+		err = e.saveQueue(ctx, saved)
+	}
+
+	cause := fmt.Errorf("%w: %w", ErrEngineClosed, ErrRequestPersisted)
+	if err != nil {
+		cause = fmt.Errorf("%w: persist queue: %w", ErrEngineClosed, err)
+	}
+	for _, j := range queued {
+		j.result <- e.failedResult(j.req, cause)
+	}
+	if err != nil {
+		return fmt.Errorf("persist %d queued and %d running requests: %w", len(queued), len(running), err)
+	}
+	e.logger.Info("Persisted pending requests for replay", map[string]interface{}{
+		"queued":  len(queued),
+		"running": len(running),
+		"total":   len(saved),
+	})
+	return nil
+}
+
+// ReplayPersistedRequests submits the requests persisted by a previous
+// Shutdown, in their original order, and removes them from the state store.
+// Call it once the handlers are registered. Requests whose ID is already
+// queued or running are skipped. Requests Submit rejects as invalid are
+// dropped and reported together in the returned error, wrapping
+// ErrInvalidRequest. ctx applies to the replayed requests as with Submit;
+// their outcomes are reported through events and metrics. If the queue
+// fills, the requests not yet submitted stay persisted and the error is
+// returned. Without WithQueuePersistence it does nothing.
+func (e *Engine) ReplayPersistedRequests(ctx context.Context) (int, error) {
+	if e.store == nil {
+		return 0, nil
+	}
+	reqs, err := e.loadQueue(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	var invalid []error
+	for i, req := range reqs {
+		req.Replayed = true
+		_, err := e.Submit(ctx, req)
+		switch {
+		case err == nil:
+			replayed++
+		case errors.Is(err, ErrDuplicateRequest):
+			e.logger.Warn("Skipped replay of duplicate request", map[string]interface{}{
+				"request_id": req.ID,
+				"type":       req.Type,
+			})
+		case errors.Is(err, ErrInvalidRequest):
+			e.logger.Error("Dropped invalid persisted request", map[string]interface{}{
+				"request_id": req.ID,
+				"type":       req.Type,
+				"error":      err.Error(),
+			})
+			invalid = append(invalid, fmt.Errorf("request %q: %w", req.ID, err))
+		default:
+			if saveErr := e.saveQueue(ctx, reqs[i:]); saveErr != nil {
+				err = fmt.Errorf("%w (keeping %d unreplayed requests: %w)", err, len(reqs)-i, saveErr)
+			}
+			return replayed, fmt.Errorf("replay request %s: %w", req.ID, err)
+		}
+	}
+	if len(reqs) > 0 {
+		if err := e.store.Delete(ctx, QueueStateKey); err != nil {
+			return replayed, fmt.Errorf("delete persisted queue: %w", err)
+		}
+		e.logger.Info("Replayed persisted requests", map[string]interface{}{
+			"replayed": replayed,
+			"skipped":  len(reqs) - replayed,
+		})
+	}
+	if len(invalid) > 0 {
+		return replayed, fmt.Errorf("replay dropped %d invalid requests: %w", len(invalid), errors.Join(invalid...))
+	}
+	return replayed, nil
+}
+
+// loadQueue returns the persisted requests, or none if nothing is saved.
+func (e *Engine) loadQueue(ctx context.Context) ([]*Request, error) {
+	data, err := e.store.Load(ctx, QueueStateKey)
+	if errors.Is(err, ErrStateNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load persisted queue: %w", err)
+	}
+	var state persistedQueue
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("decode persisted queue: %w", err)
+	}
+	if state.Version > queueStateVersion {
+		return nil, fmt.Errorf("persisted queue version %d is newer than supported version %d", state.Version, queueStateVersion)
+	}
+	reqs := make([]*Request, len(state.Requests))
+	for i, r := range state.Requests {
+		reqs[i] = &Request{ID: r.ID, Type: r.Type, Payload: r.Payload, Labels: r.Labels, Priority: r.Priority}
+	}
+	return reqs, nil
+}
+
+// saveQueue persists reqs, dropping repeated IDs so a request saved by two
+// shutdowns replays once.
+func (e *Engine) saveQueue(ctx context.Context, reqs []*Request) error {
+	state := persistedQueue{Version: queueStateVersion}
+	seen := make(map[string]bool, len(reqs))
+	for _, req := range reqs {
+		if seen[req.ID] {
+			continue
+		}
+		seen[req.ID] = true
+		state.Requests = append(state.Requests, persistedRequest{
+			ID:       req.ID,
+			Type:     req.Type,
+			Payload:  req.Payload,
+			Labels:   req.Labels,
+			Priority: req.Priority,
+		})
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode queue: %w", err)
+	}
+	if err := e.store.Save(ctx, QueueStateKey, data); err != nil {
+		return fmt.Errorf("save queue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestQueuePersistedAcrossRestart(t *testing.T) {
+	store := NewMemoryStateStore()
+	config := &utils.Config{Engine: utils.EngineConfig{Workers: 1}}
+
+	first, err := NewEngine(config, WithQueuePersistence(store))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	started := make(chan struct{})
+	first.RegisterHandler("work", func(ctx context.Context, req *Request) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if _, err := first.Submit(context.Background(), &Request{ID: "a", Type: "work"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+	queued, err := first.Submit(context.Background(), &Request{ID: "b", Type: "work", Payload: map[string]interface{}{"n": 1}})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := first.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if res := <-queued; !errors.Is(res.Err, ErrRequestPersisted) || !errors.Is(res.Err, ErrEngineClosed) {
+		t.Fatalf("queued result = %v, want ErrRequestPersisted", res.Err)
+	}
+
+	second, err := NewEngine(config, WithQueuePersistence(store))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer second.Shutdown(context.Background())
+	var (
+		mu  sync.Mutex
+		ran []*Request
+		wg  sync.WaitGroup
+	)
+	wg.Add(2)
+	second.RegisterHandler("work", func(ctx context.Context, req *Request) (interface{}, error) {
+		mu.Lock()
+		ran = append(ran, req)
+		mu.Unlock()
+		wg.Done()
+		return nil, nil
+	})
+	n, err := second.ReplayPersistedRequests(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("ReplayPersistedRequests = %d, %v; want 2, nil", n, err)
+	}
+	wg.Wait()
+	if ran[0].ID != "a" || ran[1].ID != "b" || !ran[1].Replayed || ran[1].Payload["n"] != float64(1) {
+		t.Fatalf("replayed %+v, %+v", ran[0], ran[1])
+	}
+	if _, err := store.Load(context.Background(), QueueStateKey); !errors.Is(err, ErrStateNotFound) {
+		t.Fatalf("persisted queue after replay: %v", err)
+	}
+	if n, err := second.ReplayPersistedRequests(context.Background()); n != 0 || err != nil {
+		t.Fatalf("second replay = %d, %v", n, err)
+	}
+}
+
+func TestQueuePersistedWhenShutdownIsCancelled(t *testing.T) {
+	store := NewMemoryStateStore()
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{Workers: 1}}, WithQueuePersistence(store))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	started := make(chan struct{})
+	engine.RegisterHandler("work", func(ctx context.Context, req *Request) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if _, err := engine.Submit(context.Background(), &Request{ID: "a", Type: "work"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+	queued, err := engine.Submit(context.Background(), &Request{ID: "b", Type: "work"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	// Without a deadline there is no reserve; cancelling must not lose
+	// the requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if err := engine.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Shutdown = %v, want context.Canceled", err)
+	}
+	select {
+	case res := <-queued:
+		if !errors.Is(res.Err, ErrRequestPersisted) {
+			t.Fatalf("queued result = %v, want ErrRequestPersisted", res.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued request was never persisted")
+	}
+	if reqs, err := engine.loadQueue(context.Background()); err != nil || len(reqs) != 2 {
+		t.Fatalf("persisted %d requests, %v; want 2", len(reqs), err)
+	}
+}
+
+func TestReplayReportsInvalidRequests(t *testing.T) {
+	store := NewMemoryStateStore()
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{Workers: 1}}, WithQueuePersistence(store))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+	started, hold := make(chan struct{}), make(chan struct{})
+	engine.RegisterHandler("work", func(ctx context.Context, req *Request) (interface{}, error) {
+		if req.ID == "x" {
+			close(started)
+			<-hold
+		}
+		return nil, nil
+	})
+	defer close(hold)
+	if _, err := engine.Submit(context.Background(), &Request{ID: "x", Type: "work"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+
+	if err := engine.saveQueue(context.Background(), []*Request{
+		{ID: "x", Type: "work"},
+		{ID: "y", Type: "work", Priority: "urgent"},
+		{ID: "z", Type: "work"},
+	}); err != nil {
+		t.Fatalf("saveQueue: %v", err)
+	}
+	// The duplicate is skipped quietly; the invalid one is reported.
+	n, err := engine.ReplayPersistedRequests(context.Background())
+	if n != 1 || !errors.Is(err, ErrInvalidRequest) || errors.Is(err, ErrDuplicateRequest) {
+		t.Fatalf("ReplayPersistedRequests = %d, %v; want 1 and the invalid request", n, err)
+	}
+}
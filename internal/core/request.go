@@ -0,0 +1,25 @@
+package core
+
+import "context"
+
+// Request is a unit of work dispatched to a handler by type.
+type Request struct {
+	ID      string
+	Type    string
+	Payload map[string]interface{}
+	// Labels segment metrics, e.g. by tenant. Only labels listed in
+	// EngineConfig.MetricLabels are recorded.
+	Labels map[string]string
+	// Priority decides which requests are shed first when the engine is
+	// overloaded. Empty means PriorityNormal.
+	Priority Priority
+	// Replayed is set on requests resubmitted by ReplayPersistedRequests,
+	// which may already have run once before a restart.
+	Replayed bool
+}
+
+// Handler processes a request of a registered type. ctx carries the
+// request's deadline, which is its whole time budget: pass ctx to every
+// downstream call, such as OpenAI and then Solana, so they share it, and
+// see utils.RemainingBudget for what is left.
+type Handler func(ctx context.Context, req *Request) (interface{}, error)
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// ErrPartialResult marks a handler error that comes with usable data, e.g.
+// when some of several sources failed. A handler returning data together
+// with an error wrapping ErrPartialResult yields a StatusPartial Result that
+// keeps the data.
+var ErrPartialResult = errors.New("core: partial result")
+
+// Status is the outcome of a request.
+type Status string
+
+// Request outcomes.
+const (
+	StatusSuccess Status = "success"
+	StatusFailed  Status = "failed"
+	StatusPartial Status = "partial"
+)
+
+// ErrorCode classifies why a request failed.
+type ErrorCode string
+
+// Error codes reported in RequestError.
+const (
+	CodeInvalidRequest     ErrorCode = "invalid_request"
+	CodeUnknownRequestType ErrorCode = "unknown_request_type"
+	CodeEngineClosed       ErrorCode = "engine_closed"
+	CodeCancelled          ErrorCode = "cancelled"
+	CodeTimeout            ErrorCode = "timeout"
+	CodeDisabled           ErrorCode = "disabled"
+	CodeOverloaded         ErrorCode = "overloaded"
+	CodeStageFailed        ErrorCode = "stage_failed"
+	CodePartial            ErrorCode = "partial"
+	CodeHandlerFailed      ErrorCode = "handler_failed"
+)
+
+// RequestError is the typed error of a Result. It wraps the underlying
+// error, so errors.Is and errors.As see through it.
+type RequestError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// Stage names the pipeline stage that failed the request, if any.
+	Stage string `json:"stage,omitempty"`
+	Err   error  `json:"-"`
+}
+
+func newRequestError(err error) *RequestError {
+	e := &RequestError{Code: errorCode(err), Message: err.Error(), Err: err}
+	var stageErr *StageError
+	if errors.As(err, &stageErr) {
+		e.Stage = stageErr.Stage
+	}
+	return e
+}
+
+func (e *RequestError) Error() string {
+	return e.Message
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// errorCode maps err to the most specific code. Cancellation and timeouts
+// take precedence over the stage or handler that observed them.
+func errorCode(err error) ErrorCode {
+	var stageErr *StageError
+	switch {
+	case errors.Is(err, ErrRequestTimeout):
+		return CodeTimeout
+	case errors.Is(err, ErrRequestCancelled), errors.Is(err, context.Canceled):
+		return CodeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, ErrEngineClosed):
+		return CodeEngineClosed
+	case errors.Is(err, ErrOverloaded):
+		return CodeOverloaded
+	case errors.Is(err, ErrInvalidRequest):
+		return CodeInvalidRequest
+	case errors.Is(err, ErrUnknownRequestType):
+		return CodeUnknownRequestType
+	case errors.Is(err, utils.ErrDisabled):
+		return CodeDisabled
+	case errors.As(err, &stageErr):
+		return CodeStageFailed
+	case errors.Is(err, ErrPartialResult):
+		return CodePartial
+	default:
+		return CodeHandlerFailed
+	}
+}
+
+// Result is the outcome of a request, as returned by Process and delivered
+// by Submit. It marshals to JSON for logging or returning over an API;
+// Duration and Timing are encoded in nanoseconds.
+type Result struct {
+	RequestID   string        `json:"request_id"`
+	Type        string        `json:"type"`
+	Status      Status        `json:"status"`
+	Data        interface{}   `json:"data,omitempty"`
+	Error       *RequestError `json:"error,omitempty"`
+	StartedAt   time.Time     `json:"started_at"`
+	CompletedAt time.Time     `json:"completed_at"`
+	Duration    time.Duration `json:"duration_ns"`
+	// Usage is what the request used of OpenAI and Solana through clients
+	// called with its context. It is zero for requests that used neither
+	// or failed before reaching their handler.
+	Usage utils.RequestUsage `json:"usage"`
+	// Timing is how long the request waited before its handler ran.
+	// Duration is how long the handler took, and Usage how much of that
+	// went to OpenAI and Solana RPC calls.
+	Timing Timing `json:"timing"`
+
+	// Value is Data.
+	//
+	// Deprecated: Use Data.
+	Value interface{} `json:"-"`
+	// Err is Error as an error interface, nil on success.
+	//
+	// Deprecated: Use Error and Status.
+	Err error `json:"-"`
+}
+
+// Timing records where a request waited before it was processed.
+type Timing struct {
+	// Queued is how long a submitted request waited in the queue for a
+	// worker. It is zero for requests passed to Process.
+	Queued time.Duration `json:"queued_ns"`
+	// InFlightWait is how long the request waited for a MaxInFlight slot.
+	InFlightWait time.Duration `json:"in_flight_wait_ns"`
+}
+
+// newResult returns a Result for req started at start. Call complete to
+// fill in the outcome.
+func newResult(req *Request, start time.Time) *Result {
+	r := &Result{StartedAt: start}
+	if req != nil {
+		r.RequestID, r.Type = req.ID, req.Type
+	}
+	return r
+}
+
+// complete records the outcome at end. Data is kept only on success or when
+// err marks a partial result.
+func (r *Result) complete(end time.Time, data interface{}, err error) *Result {
+	r.CompletedAt = end
+	r.Duration = r.CompletedAt.Sub(r.StartedAt)
+	switch {
+	case err == nil:
+		r.Status = StatusSuccess
+	case data != nil && errors.Is(err, ErrPartialResult):
+		r.Status = StatusPartial
+	default:
+		r.Status = StatusFailed
+		data = nil
+	}
+	r.Data, r.Value = data, data
+	r.Error, r.Err = nil, nil
+	if err != nil {
+		r.Error = newRequestError(err)
+		r.Err = r.Error
+	}
+	return r
+}
+
+// failedResult is a Result for a request that failed before it was
+// processed.
+func (e *Engine) failedResult(req *Request, err error) *Result {
+	now := e.clock.Now()
+	return newResult(req, now).complete(now, nil, err)
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/openai"
+	"github.com/labs-alone/alone-main/internal/solana"
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestProcessResult(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+	engine.RegisterHandler("echo", func(ctx context.Context, req *Request) (interface{}, error) {
+		return req.Payload["msg"], nil
+	})
+	engine.RegisterHandler("fanout", func(ctx context.Context, req *Request) (interface{}, error) {
+		return []string{"a"}, fmt.Errorf("%w: 1 of 2 sources failed", ErrPartialResult)
+	})
+
+	res := engine.Process(context.Background(), &Request{ID: "r1", Type: "echo", Payload: map[string]interface{}{"msg": "hi"}})
+	if res.Status != StatusSuccess || res.Data != "hi" || res.Error != nil || res.Err != nil {
+		t.Fatalf("success result = %+v", res)
+	}
+	if res.StartedAt.IsZero() || res.CompletedAt.Before(res.StartedAt) || res.Duration != res.CompletedAt.Sub(res.StartedAt) {
+		t.Fatalf("timing = %v, %v, %v", res.StartedAt, res.CompletedAt, res.Duration)
+	}
+
+	res = engine.Process(context.Background(), &Request{ID: "r2", Type: "missing"})
+	if res.Status != StatusFailed || res.Error == nil || res.Error.Code != CodeUnknownRequestType {
+		t.Fatalf("failed result = %+v", res)
+	}
+	if !errors.Is(res.Err, ErrUnknownRequestType) {
+		t.Fatalf("Err = %v, want ErrUnknownRequestType", res.Err)
+	}
+
+	res = engine.Process(context.Background(), &Request{ID: "r3", Type: "fanout"})
+	if res.Status != StatusPartial || res.Data == nil || res.Error.Code != CodePartial {
+		t.Fatalf("partial result = %+v", res)
+	}
+	data, err := engine.ProcessRequest(&Request{ID: "r4", Type: "fanout"})
+	if data == nil || !errors.Is(err, ErrPartialResult) {
+		t.Fatalf("ProcessRequest partial = %v, %v", data, err)
+	}
+
+	encoded, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded map[string]interface{}
+	json.Unmarshal(encoded, &decoded)
+	if decoded["status"] != "partial" || decoded["request_id"] != "r3" {
+		t.Fatalf("encoded = %s", encoded)
+	}
+	if e, _ := decoded["error"].(map[string]interface{}); e["code"] != "partial" || e["message"] != res.Error.Message {
+		t.Fatalf("encoded error = %s", encoded)
+	}
+
+	engine.Shutdown(context.Background())
+	if res := engine.Process(context.Background(), &Request{Type: "echo"}); res.Error == nil || res.Error.Code != CodeEngineClosed {
+		t.Fatalf("result after shutdown = %+v", res)
+	}
+}
+
+func TestResultUsage(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"gpt-4o-mini-2024-07-18","choices":[{"message":{"role":"assistant","content":"ok"}}],` +
+			`"usage":{"prompt_tokens":1000,"completion_tokens":500,"total_tokens":1500}}`))
+	}))
+	defer llm.Close()
+	rpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":5}}`))
+	}))
+	defer rpc.Close()
+
+	completer, err := openai.NewClient(&openai.ClientConfig{APIKey: "k", BaseURL: llm.URL})
+	if err != nil {
+		t.Fatalf("openai.NewClient: %v", err)
+	}
+	chain, err := solana.NewClient(&utils.SolanaConfig{Endpoint: rpc.URL})
+	if err != nil {
+		t.Fatalf("solana.NewClient: %v", err)
+	}
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+	engine.RegisterHandler("both", func(ctx context.Context, req *Request) (interface{}, error) {
+		for i := 0; i < 2; i++ {
+			if _, err := chain.GetBalance(ctx, "11111111111111111111111111111111"); err != nil {
+				return nil, err
+			}
+		}
+		return completer.CreateChatCompletion(ctx, &openai.ChatCompletionRequest{
+			Messages: []openai.ChatMessage{{Role: openai.RoleUser, Content: "hi"}},
+		})
+	})
+	engine.RegisterHandler("none", func(ctx context.Context, req *Request) (interface{}, error) {
+		return "done", nil
+	})
+
+	res := engine.Process(context.Background(), &Request{ID: "r1", Type: "both"})
+	if res.Err != nil {
+		t.Fatalf("Process: %v", res.Err)
+	}
+	usage := res.Usage
+	// gpt-4o-mini: $0.15 and $0.60 per million prompt and completion tokens.
+	if usage.OpenAIRequests != 1 || usage.PromptTokens != 1000 || usage.CompletionTokens != 500 ||
+		usage.RPCCalls != 2 || math.Abs(usage.EstimatedCostUSD-0.00045) > 1e-12 {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if usage.OpenAITime <= 0 || usage.RPCTime <= 0 || usage.OpenAITime+usage.RPCTime > res.Duration {
+		t.Fatalf("call times %v and %v in a request of %v", usage.OpenAITime, usage.RPCTime, res.Duration)
+	}
+
+	res = engine.Process(context.Background(), &Request{ID: "r2", Type: "none"})
+	if res.Usage != (utils.RequestUsage{}) {
+		t.Fatalf("usage of a request calling no service = %+v", res.Usage)
+	}
+	data, _ := json.Marshal(res)
+	if !strings.Contains(string(data), `"usage":{"openai_requests":0,`) {
+		t.Fatalf("JSON = %s, want zero usage fields", data)
+	}
+}
+
+func TestResultTiming(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{Workers: 1}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+	engine.RegisterHandler("sleep", func(ctx context.Context, req *Request) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		return nil, nil
+	})
+
+	first, err := engine.Submit(context.Background(), &Request{ID: "first", Type: "sleep"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	second, err := engine.Submit(context.Background(), &Request{ID: "second", Type: "sleep"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	expired, err := engine.Submit(ctx, &Request{ID: "expired", Type: "sleep"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-first
+	// The only worker was busy with the first request.
+	if res := <-second; res.Timing.Queued < 15*time.Millisecond || res.Duration < 20*time.Millisecond {
+		t.Fatalf("queued request timing = %+v over %v", res.Timing, res.Duration)
+	}
+	// A request that timed out while queued still reports the wait.
+	if res := <-expired; res.Error == nil || res.Error.Code != CodeTimeout || res.Timing.Queued < 5*time.Millisecond {
+		t.Fatalf("expired request = %+v, timing %+v", res.Error, res.Timing)
+	}
+	if res := engine.Process(context.Background(), &Request{ID: "direct", Type: "sleep"}); res.Timing.Queued != 0 {
+		t.Fatalf("unqueued request timing = %+v", res.Timing)
+	}
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// ErrOverloaded is returned by Submit for a request shed because the queue
+// is backed up, see utils.LoadSheddingConfig, and is the Result error of a
+// request rejected at the EngineConfig.MaxInFlight cap.
+var ErrOverloaded = errors.New("core: engine overloaded")
+
+// Priority ranks a request for load shedding.
+type Priority string
+
+// Request priorities, lowest first.
+const (
+	PriorityLow      Priority = "low"
+	PriorityNormal   Priority = "normal"
+	PriorityHigh     Priority = "high"
+	PriorityCritical Priority = "critical"
+)
+
+// priorities lists every priority, lowest first.
+var priorities = []Priority{PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical}
+
+// ParsePriority returns the priority named s. Empty means PriorityNormal.
+func ParsePriority(s string) (Priority, error) {
+	if s == "" {
+		return PriorityNormal, nil
+	}
+	for _, p := range priorities {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown priority %q", s)
+}
+
+// Load shedding defaults, as fractions of the queue size, applied when
+// utils.LoadSheddingConfig leaves the water marks unset.
+const (
+	DefaultShedHighWater = 0.8
+	DefaultShedLowWater  = 0.5
+)
+
+// DefaultShedPriorities are shed when utils.LoadSheddingConfig lists none.
+var DefaultShedPriorities = []Priority{PriorityLow, PriorityNormal}
+
+// loadShedder rejects the submissions of some priorities while the queue is
+// backed up. Shedding starts once the queue holds highWater requests and
+// stops once it is down to lowWater, so it does not flap around one depth.
+// Its state is guarded by the requestQueue's mutex.
+type loadShedder struct {
+	highWater, lowWater int
+	shed                map[Priority]bool
+	shedding            bool
+
+	counts map[Priority]*atomic.Uint64
+}
+
+func newLoadShedder(cfg utils.LoadSheddingConfig, queueSize int) (*loadShedder, error) {
+	s := &loadShedder{
+		highWater: cfg.HighWater,
+		lowWater:  cfg.LowWater,
+		shed:      make(map[Priority]bool),
+		counts:    make(map[Priority]*atomic.Uint64, len(priorities)),
+	}
+	if s.highWater <= 0 {
+		s.highWater = max(1, int(float64(queueSize)*DefaultShedHighWater))
+	}
+	if s.lowWater <= 0 {
+		s.lowWater = int(float64(queueSize) * DefaultShedLowWater)
+	}
+	if s.lowWater >= s.highWater {
+		return nil, fmt.Errorf("core: load shedding low_water %d must be below high_water %d", s.lowWater, s.highWater)
+	}
+	if s.highWater > queueSize {
+		return nil, fmt.Errorf("core: load shedding high_water %d exceeds queue_size %d", s.highWater, queueSize)
+	}
+
+	shed := DefaultShedPriorities
+	if len(cfg.ShedPriorities) > 0 {
+		shed = nil
+		for _, name := range cfg.ShedPriorities {
+			p, err := ParsePriority(name)
+			if err != nil {
+				return nil, fmt.Errorf("core: load shedding: %w", err)
+			}
+			shed = append(shed, p)
+		}
+	}
+	for _, p := range shed {
+		s.shed[p] = true
+	}
+	for _, p := range priorities {
+		s.counts[p] = new(atomic.Uint64)
+	}
+	return s, nil
+}
+
+// observe updates the shedding state for a queue now holding depth
+// requests. The queue calls it whenever its depth changes, so shedding
+// stops as the queue drains, not at the next submission.
+func (s *loadShedder) observe(depth int) {
+	if s == nil {
+		return
+	}
+	switch {
+	case depth >= s.highWater:
+		s.shedding = true
+	case depth <= s.lowWater:
+		s.shedding = false
+	}
+}
+
+// admit reports whether a request of priority p may join a queue of depth
+// requests, updating the shedding state and counting the rejection if not.
+func (s *loadShedder) admit(depth int, p Priority) bool {
+	s.observe(depth)
+	if !s.shedding || !s.shed[p] {
+		return true
+	}
+	s.counts[p].Add(1)
+	return false
+}
+
+// snapshot returns the requests shed per priority. A nil shedder reports
+// none.
+func (s *loadShedder) snapshot() map[Priority]uint64 {
+	out := make(map[Priority]uint64, len(priorities))
+	for _, p := range priorities {
+		var n uint64
+		if s != nil {
+			n = s.counts[p].Load()
+		}
+		out[p] = n
+	}
+	return out
+}
+
+// requestPriority returns the priority of req, checking it is known.
+func requestPriority(req *Request) (Priority, error) {
+	p, err := ParsePriority(string(req.Priority))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	return p, nil
+}
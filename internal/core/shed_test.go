@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestLoadShedding(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{
+		Workers:      1,
+		QueueSize:    10,
+		LoadShedding: utils.LoadSheddingConfig{Enabled: true, HighWater: 4, LowWater: 2},
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	defer engine.Shutdown(context.Background())
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	engine.RegisterHandler("work", func(ctx context.Context, req *Request) (interface{}, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil, nil
+	})
+	submit := func(id string, p Priority) (<-chan *Result, error) {
+		return engine.Submit(context.Background(), &Request{ID: id, Type: "work", Priority: p})
+	}
+
+	if _, err := submit("running", PriorityLow); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+	var queued []<-chan *Result
+	for _, id := range []string{"q1", "q2", "q3", "q4"} {
+		res, err := submit(id, "")
+		if err != nil {
+			t.Fatalf("Submit %s below the high-water mark: %v", id, err)
+		}
+		queued = append(queued, res)
+	}
+
+	for _, p := range []Priority{PriorityLow, PriorityNormal, ""} {
+		if _, err := submit("shed-"+string(p), p); !errors.Is(err, ErrOverloaded) {
+			t.Fatalf("Submit %q priority at the high-water mark = %v, want ErrOverloaded", p, err)
+		}
+	}
+	for _, p := range []Priority{PriorityHigh, PriorityCritical} {
+		if _, err := submit("kept-"+string(p), p); err != nil {
+			t.Fatalf("Submit %s priority while shedding: %v", p, err)
+		}
+	}
+	if _, err := submit("bogus", "urgent"); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("Submit with an unknown priority = %v, want ErrInvalidRequest", err)
+	}
+
+	// Let the running request and q1 to q4 finish, leaving at most the
+	// high and critical requests queued: at the low-water mark.
+	for i := 0; i < 5; i++ {
+		release <- struct{}{}
+	}
+	<-queued[3]
+	// Draining alone ends shedding; it does not wait for a submission.
+	if engine.GetMetrics()["shedding"] != false {
+		t.Fatal("still shedding after draining to the low-water mark")
+	}
+	if _, err := submit("after", PriorityLow); err != nil {
+		t.Fatalf("Submit after draining to the low-water mark: %v", err)
+	}
+	close(release)
+
+	metrics := engine.GetMetrics()
+	shed := metrics["shed"].(map[Priority]uint64)
+	if shed[PriorityLow] != 1 || shed[PriorityNormal] != 2 || shed[PriorityHigh] != 0 {
+		t.Fatalf("shed = %v", shed)
+	}
+	if metrics["shedding"] != false {
+		t.Fatal("still shedding after the queue drained")
+	}
+}
+
+func TestLoadSheddingConfig(t *testing.T) {
+	for _, cfg := range []utils.LoadSheddingConfig{
+		{Enabled: true, HighWater: 4, LowWater: 4},
+		{Enabled: true, HighWater: 20},
+		{Enabled: true, ShedPriorities: []string{"urgent"}},
+	} {
+		if _, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{QueueSize: 10, LoadShedding: cfg}}); err == nil {
+			t.Errorf("NewEngine accepted %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Suggested shutdown hook orders. Hooks run in ascending order; hooks with
+// the same order run in registration order.
+const (
+	ShutdownOrderStopAccepting      = 100
+	ShutdownOrderDrainWorkers       = 200
+	ShutdownOrderCloseSubscriptions = 300
+	ShutdownOrderCloseClients       = 400
+)
+
+// ShutdownHook is a cleanup step run by Engine.Shutdown.
+type ShutdownHook func(ctx context.Context) error
+
+type shutdownHook struct {
+	name  string
+	order int
+	fn    ShutdownHook
+}
+
+// HookError is the failure of a single shutdown hook.
+type HookError struct {
+	Name string
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// ShutdownError aggregates the hooks that failed or timed out during
+// Shutdown. A hook that did not finish before the shutdown context was done
+// reports the context's error.
+type ShutdownError struct {
+	Hooks []*HookError
+}
+
+func (e *ShutdownError) Error() string {
+	parts := make([]string, len(e.Hooks))
+	for i, h := range e.Hooks {
+		parts[i] = h.Error()
+	}
+	return "core: shutdown hooks failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the hook errors, so errors.Is matches any of them.
+func (e *ShutdownError) Unwrap() []error {
+	errs := make([]error, len(e.Hooks))
+	for i, h := range e.Hooks {
+		errs[i] = h
+	}
+	return errs
+}
+
+// RegisterShutdownHook registers fn to run during Shutdown at the given
+// order, after the engine has stopped accepting requests. Use the
+// ShutdownOrder* constants to slot components into the usual sequence.
+func (e *Engine) RegisterShutdownHook(name string, order int, fn ShutdownHook) error {
+	if name == "" || fn == nil {
+		return fmt.Errorf("%w: shutdown hook needs a name and a function", ErrInvalidRequest)
+	}
+	if e.closed.Load() {
+		return ErrEngineClosed
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks = append(e.hooks, shutdownHook{name: name, order: order, fn: fn})
+	return nil
+}
+
+// runShutdownHooks runs the registered hooks in order. Once ctx is done the
+// remaining hooks are not started and are reported as timed out.
+func (e *Engine) runShutdownHooks(ctx context.Context) error {
+	e.mu.Lock()
+	hooks := append([]shutdownHook(nil), e.hooks...)
+	e.mu.Unlock()
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].order < hooks[j].order })
+
+	var failed []*HookError
+	for _, h := range hooks {
+		start := e.clock.Now()
+		err := runHook(ctx, h.fn)
+		fields := map[string]interface{}{
+			"hook":     h.name,
+			"order":    h.order,
+			"duration": e.clock.Now().Sub(start).String(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+			e.logger.Error("Shutdown hook failed", fields)
+			failed = append(failed, &HookError{Name: h.name, Err: err})
+			continue
+		}
+		e.logger.Info("Shutdown hook completed", fields)
+	}
+
+	if len(failed) > 0 {
+		return &ShutdownError{Hooks: failed}
+	}
+	return nil
+}
+
+// runHook runs fn, returning early with ctx's error if ctx is done first.
+// A hook that ignores its context keeps running in the background.
+func runHook(ctx context.Context, fn ShutdownHook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
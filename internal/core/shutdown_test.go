@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestShutdownHooksRunInOrder(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	var ran []string
+	hook := func(name string, err error) ShutdownHook {
+		return func(context.Context) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+	errClose := errors.New("close failed")
+	engine.RegisterShutdownHook("clients", ShutdownOrderCloseClients, hook("clients", nil))
+	engine.RegisterShutdownHook("subscriptions", ShutdownOrderCloseSubscriptions, hook("subscriptions", errClose))
+	engine.RegisterShutdownHook("workers", ShutdownOrderDrainWorkers, hook("workers", nil))
+	engine.RegisterShutdownHook("slow", ShutdownOrderCloseClients+1, func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = engine.Shutdown(ctx)
+
+	want := []string{"workers", "subscriptions", "clients"}
+	if len(ran) != len(want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Fatalf("ran %v, want %v", ran, want)
+		}
+	}
+
+	var shutdownErr *ShutdownError
+	if !errors.As(err, &shutdownErr) || len(shutdownErr.Hooks) != 2 {
+		t.Fatalf("Shutdown = %v, want two failed hooks", err)
+	}
+	if !errors.Is(err, errClose) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want hook and deadline errors", err)
+	}
+	if shutdownErr.Hooks[1].Name != "slow" {
+		t.Fatalf("timed out hook = %q, want slow", shutdownErr.Hooks[1].Name)
+	}
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStateNotFound is returned by StateStore.Load for a key that has not
+// been saved.
+var ErrStateNotFound = errors.New("core: state not found")
+
+// StateStore persists engine state across restarts, e.g. in a file, a
+// database, or a key-value service. Implementations must be safe for
+// concurrent use.
+type StateStore interface {
+	// Save stores data under key, replacing any previous value.
+	Save(ctx context.Context, key string, data []byte) error
+	// Load returns the data saved under key, or an error wrapping
+	// ErrStateNotFound.
+	Load(ctx context.Context, key string) ([]byte, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryStateStore is a StateStore held in memory. It survives an engine
+// restart within one process, which makes it useful in tests.
+type MemoryStateStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+// NewMemoryStateStore creates an empty MemoryStateStore.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{data: make(map[string][]byte)}
+}
+
+// Save implements StateStore.
+func (s *MemoryStateStore) Save(ctx context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = append([]byte(nil), data...)
+	return nil
+}
+
+// Load implements StateStore.
+func (s *MemoryStateStore) Load(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.data[key]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Delete implements StateStore.
+func (s *MemoryStateStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+// DefaultStateLoadTimeout bounds a StateLoader given no timeout of its own.
+const DefaultStateLoadTimeout = 10 * time.Second
+
+// ErrStateLoadFailed wraps the error of a StateLoader that failed or timed
+// out.
+var ErrStateLoadFailed = errors.New("core: loading initial state failed")
+
+// StateLoader fetches the initial engine state, e.g. from a database or a
+// configuration service.
+type StateLoader func(ctx context.Context) (map[string]interface{}, error)
+
+// StateLoadFailure chooses what NewEngine does when its StateLoader fails.
+type StateLoadFailure int
+
+const (
+	// StateLoadFatal makes NewEngine fail with ErrStateLoadFailed.
+	StateLoadFatal StateLoadFailure = iota
+	// StateLoadStartEmpty logs the failure and starts with empty state.
+	StateLoadStartEmpty
+)
+
+type stateLoader struct {
+	load      StateLoader
+	timeout   time.Duration
+	onFailure StateLoadFailure
+}
+
+// WithStateLoader makes NewEngine seed the engine state from loader. The
+// loader runs once, inside NewEngine and under timeout (DefaultStateLoadTimeout
+// if not positive); a loader that ignores its context is abandoned when the
+// timeout passes. onFailure decides whether an error, timeout, or panic
+// fails NewEngine or leaves the state empty.
+//
+// The state is loaded before NewEngine returns and so before Preflight can
+// run: the loader cannot rely on the preflight checks having passed and
+// must handle its source being unavailable. Loaded keys do not publish
+// TopicStateChanged; the state is complete once TopicEngineStarted is
+// published.
+func WithStateLoader(loader StateLoader, timeout time.Duration, onFailure StateLoadFailure) EngineOption {
+	return func(e *Engine) {
+		if timeout <= 0 {
+			timeout = DefaultStateLoadTimeout
+		}
+		e.loader = &stateLoader{load: loader, timeout: timeout, onFailure: onFailure}
+	}
+}
+
+// loadState runs the state loader, if any, and merges what it returns into
+// the engine state.
+func (e *Engine) loadState() error {
+	if e.loader == nil || e.loader.load == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.loader.timeout)
+	defer cancel()
+
+	start := e.clock.Now()
+	var state map[string]interface{}
+	err := runHook(ctx, func(ctx context.Context) error {
+		var err error
+		state, err = e.loader.load(ctx)
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrStateLoadFailed, err)
+		if e.loader.onFailure == StateLoadFatal {
+			return err
+		}
+		e.logger.Error("Loading initial state failed, starting empty", map[string]interface{}{
+			"duration": e.clock.Now().Sub(start).String(),
+			"error":    err.Error(),
+		})
+		return nil
+	}
+
+	e.state.merge(state)
+	e.logger.Info("Loaded initial state", map[string]interface{}{
+		"keys":     len(state),
+		"duration": e.clock.Now().Sub(start).String(),
+	})
+	return nil
+}
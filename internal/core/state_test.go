@@ -0,0 +1,63 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestStateLoader(t *testing.T) {
+	config := &utils.Config{}
+	engine, err := NewEngine(config, WithStateLoader(func(ctx context.Context) (map[string]interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("loader ran without a deadline")
+		}
+		return map[string]interface{}{"mode": "warm", "count": 3}, nil
+	}, 0, StateLoadFatal))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	if state := engine.GetState(); state["mode"] != "warm" || state["count"] != 3 {
+		t.Fatalf("state = %v", state)
+	}
+
+	// The load is timed on the engine's clock.
+	var buf bytes.Buffer
+	clock := &manualClock{now: time.Unix(1700000000, 0)}
+	_, err = NewEngine(config, WithClock(clock), WithLogger(utils.NewLogger(utils.WithOutput(&buf))),
+		WithStateLoader(func(ctx context.Context) (map[string]interface{}, error) {
+			clock.advance(3 * time.Second)
+			return nil, nil
+		}, 0, StateLoadFatal))
+	if err != nil || !strings.Contains(buf.String(), "3s") {
+		t.Fatalf("NewEngine = %v, logged %q; want a 3s load", err, buf.String())
+	}
+
+	failing := func(ctx context.Context) (map[string]interface{}, error) {
+		return nil, errors.New("config service unavailable")
+	}
+	if _, err := NewEngine(config, WithStateLoader(failing, 0, StateLoadFatal)); !errors.Is(err, ErrStateLoadFailed) {
+		t.Fatalf("fatal loader failure: err = %v, want ErrStateLoadFailed", err)
+	}
+	engine, err = NewEngine(config, WithStateLoader(failing, 0, StateLoadStartEmpty))
+	if err != nil || len(engine.GetState()) != 0 {
+		t.Fatalf("NewEngine = %v, state %v; want empty state", err, engine.GetState())
+	}
+
+	// A loader that ignores its context is abandoned at the timeout.
+	block := make(chan struct{})
+	defer close(block)
+	start := time.Now()
+	_, err = NewEngine(config, WithStateLoader(func(ctx context.Context) (map[string]interface{}, error) {
+		<-block
+		return nil, nil
+	}, 20*time.Millisecond, StateLoadFatal))
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Fatalf("hung loader: err = %v after %v", err, time.Since(start))
+	}
+}
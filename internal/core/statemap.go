@@ -0,0 +1,120 @@
+package core
+
+import (
+	"reflect"
+	"sync"
+)
+
+// stateMap is the engine's key-value state. Values are deep-copied going
+// in and coming out, so neither the caller of UpdateState nor the reader
+// of GetState shares mutable maps or slices with it.
+type stateMap struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+func newStateMap() *stateMap {
+	return &stateMap{values: make(map[string]interface{})}
+}
+
+// set stores a copy of value under key and returns the copy.
+func (s *stateMap) set(key string, value interface{}) interface{} {
+	value = deepCopy(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return value
+}
+
+// merge stores a copy of every entry of values.
+func (s *stateMap) merge(values map[string]interface{}) {
+	copied := deepCopy(values).(map[string]interface{})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range copied {
+		s.values[k] = v
+	}
+}
+
+// snapshot returns a deep copy of the state.
+func (s *stateMap) snapshot() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return deepCopy(s.values).(map[string]interface{})
+}
+
+// deepCopy copies maps, slices, and arrays at any depth, such as decoded
+// JSON. Other values, including structs and pointers, are copied as they
+// are: a struct is copied by value, but maps it holds and pointer targets
+// are shared.
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = deepCopy(e)
+		}
+		return out
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = deepCopy(e)
+		}
+		return out
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return deepCopyValue(rv).Interface()
+	default:
+		return v
+	}
+}
+
+func deepCopyValue(rv reflect.Value) reflect.Value {
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv
+		}
+		out := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return out
+	case reflect.Slice:
+		if rv.IsNil() {
+			return rv
+		}
+		out := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out.Index(i).Set(deepCopyValue(rv.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(rv.Type()).Elem()
+		for i := 0; i < rv.Len(); i++ {
+			out.Index(i).Set(deepCopyValue(rv.Index(i)))
+		}
+		return out
+	case reflect.Interface:
+		if rv.IsNil() {
+			return rv
+		}
+		out := reflect.New(rv.Type()).Elem()
+		out.Set(deepCopyValue(rv.Elem()))
+		return out
+	default:
+		return rv
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestGetStateReturnsDeepCopy(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	changes, _ := engine.Events().Subscribe(TopicStateChanged, 1)
+	value := map[string]interface{}{
+		"agents": map[string]interface{}{"a": []interface{}{"x"}},
+		"tags":   map[string][]string{"env": {"prod"}},
+	}
+	if err := engine.UpdateState("config", value); err != nil {
+		t.Fatalf("UpdateState: %v", err)
+	}
+	// Changing the stored value afterwards does not reach the state.
+	value["agents"].(map[string]interface{})["b"] = 1
+	published := (<-changes.C()).Data.(StateEvent).Value.(map[string]interface{})
+	if _, ok := published["agents"].(map[string]interface{})["b"]; ok {
+		t.Fatal("the state change event shares the caller's value")
+	}
+
+	state := engine.GetState()
+	config := state["config"].(map[string]interface{})
+	config["agents"].(map[string]interface{})["a"].([]interface{})[0] = "changed"
+	config["tags"].(map[string][]string)["env"][0] = "changed"
+
+	again := engine.GetState()["config"].(map[string]interface{})
+	agents := again["agents"].(map[string]interface{})
+	if _, ok := agents["b"]; ok || agents["a"].([]interface{})[0] != "x" {
+		t.Fatalf("agents = %v, state shares maps with callers", agents)
+	}
+	if env := again["tags"].(map[string][]string)["env"][0]; env != "prod" {
+		t.Fatalf("tags env = %q, state shares slices with callers", env)
+	}
+}
+
+func TestStateConcurrentAccess(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				engine.UpdateState(fmt.Sprint("key", i%10), map[string]interface{}{"writer": w, "n": []interface{}{i}})
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				for _, v := range engine.GetState() {
+					v.(map[string]interface{})["n"] = nil
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(engine.GetState()); n != 10 {
+		t.Fatalf("state has %d keys, want 10", n)
+	}
+	for k, v := range engine.GetState() {
+		if v.(map[string]interface{})["n"] == nil {
+			t.Fatalf("state[%s] was modified through a GetState copy", k)
+		}
+	}
+}
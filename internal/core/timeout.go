@@ -0,0 +1,60 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRequestTimeout is the Result error of a request that ran past the
+// timeout configured for its type. Its Result has CodeTimeout.
+var ErrRequestTimeout = errors.New("core: request timed out")
+
+// requestTimeout returns the timeout of requestType from
+// EngineConfig.RequestTimeouts, falling back to RequestTimeout. Zero means
+// none.
+func (e *Engine) requestTimeout(requestType string) time.Duration {
+	if timeout, ok := e.config.Engine.RequestTimeouts[requestType]; ok {
+		return timeout
+	}
+	return e.config.Engine.RequestTimeout
+}
+
+// withRequestTimeout bounds ctx by the timeout of req's type. The returned
+// check wraps a handler error in ErrRequestTimeout if that timeout, rather
+// than the caller's deadline, cancelled ctx. A handler that ignores ctx
+// and succeeds anyway keeps its result.
+func (e *Engine) withRequestTimeout(ctx context.Context, req *Request) (context.Context, context.CancelFunc, func(error) error) {
+	timeout := e.requestTimeout(req.Type)
+	if timeout <= 0 {
+		return ctx, func() {}, func(err error) error { return err }
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, ErrRequestTimeout)
+	check := func(err error) error {
+		if err == nil || !errors.Is(context.Cause(ctx), ErrRequestTimeout) {
+			return err
+		}
+		return fmt.Errorf("%w after %v: %w", ErrRequestTimeout, timeout, err)
+	}
+	return ctx, cancel, check
+}
+
+// withFallbackDeadline applies WarnOnNoDeadline to ctx, which already
+// carries the timeout of req's type if it has one.
+func (e *Engine) withFallbackDeadline(ctx context.Context, req *Request) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || !e.warnNoDeadline {
+		return ctx, func() {}
+	}
+	fields := map[string]interface{}{
+		"request_id": req.ID,
+		"type":       req.Type,
+	}
+	cancel := func() {}
+	if e.fallbackDeadline > 0 {
+		fields["fallback"] = e.fallbackDeadline.String()
+		ctx, cancel = context.WithTimeout(ctx, e.fallbackDeadline)
+	}
+	e.logger.Warn("Request context has no deadline", fields)
+	return ctx, cancel
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestRequestTimeoutsPerType(t *testing.T) {
+	engine, err := NewEngine(&utils.Config{Engine: utils.EngineConfig{
+		RequestTimeout:  time.Hour,
+		RequestTimeouts: map[string]time.Duration{"slow": 20 * time.Millisecond, "exempt": 0},
+	}})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	deadlines := make(chan bool, 1)
+	wait := func(ctx context.Context, req *Request) (interface{}, error) {
+		_, ok := ctx.Deadline()
+		deadlines <- ok
+		if req.Payload["block"] == nil {
+			return "done", nil
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	for _, requestType := range []string{"slow", "exempt", "other"} {
+		engine.RegisterHandler(requestType, wait)
+	}
+
+	res := engine.Process(context.Background(), &Request{ID: "1", Type: "slow", Payload: map[string]interface{}{"block": true}})
+	<-deadlines
+	if !errors.Is(res.Err, ErrRequestTimeout) || res.Error.Code != CodeTimeout {
+		t.Fatalf("Result = %+v, want a CodeTimeout ErrRequestTimeout", res.Error)
+	}
+	if res := engine.Process(context.Background(), &Request{ID: "2", Type: "slow"}); res.Status != StatusSuccess {
+		t.Fatalf("fast request = %+v", res.Error)
+	}
+	<-deadlines
+
+	// The caller's own deadline is a timeout, but not the type's.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	res = engine.Process(ctx, &Request{ID: "3", Type: "other", Payload: map[string]interface{}{"block": true}})
+	<-deadlines
+	if errors.Is(res.Err, ErrRequestTimeout) || res.Error.Code != CodeTimeout {
+		t.Fatalf("Result after the caller's deadline = %+v", res.Error)
+	}
+
+	engine.Process(context.Background(), &Request{ID: "4", Type: "exempt"})
+	if <-deadlines {
+		t.Fatal("exempt request got a deadline")
+	}
+
+	slow := engine.GetMetrics()["by_type"].(map[string]TypeSnapshot)["slow"]
+	if slow.Requests != 2 || slow.Errors != 1 || slow.Timeouts != 1 || slow.TimeoutRate != 0.5 {
+		t.Fatalf("slow metrics = %+v", slow)
+	}
+}
@@ -0,0 +1,244 @@
+// Package integration connects the OpenAI and Solana clients. It is kept
+// apart from both so that neither client depends on the other.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/labs-alone/alone-main/internal/openai"
+	"github.com/labs-alone/alone-main/internal/solana"
+)
+
+// Tool names registered by RegisterSolanaTools.
+const (
+	ToolGetBalance     = "get_balance"
+	ToolGetAccountInfo = "get_account_info"
+	ToolSendSOL        = "send_sol"
+)
+
+// ErrInvalidArguments is returned when a tool call's arguments fail
+// validation.
+var ErrInvalidArguments = errors.New("integration: invalid tool arguments")
+
+// SolanaToolsConfig selects the Solana tools to expose.
+type SolanaToolsConfig struct {
+	// SendFrom is the registered wallet send_sol transfers from. send_sol
+	// is only registered when it is set, so a model cannot move funds
+	// unless the caller opts in.
+	SendFrom string
+	// MaxSendLamports caps a single send_sol transfer. Zero means no cap.
+	MaxSendLamports uint64
+}
+
+// BalanceResult is the result of get_balance.
+type BalanceResult struct {
+	Address  string  `json:"address"`
+	Lamports uint64  `json:"lamports"`
+	SOL      float64 `json:"sol"`
+}
+
+// AccountInfoResult is the result of get_account_info.
+type AccountInfoResult struct {
+	Address    string `json:"address"`
+	Exists     bool   `json:"exists"`
+	Lamports   uint64 `json:"lamports"`
+	Owner      string `json:"owner,omitempty"`
+	Executable bool   `json:"executable"`
+	DataLength int    `json:"data_length"`
+}
+
+// SendResult is the result of send_sol.
+type SendResult struct {
+	Signature string `json:"signature"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Lamports  uint64 `json:"lamports"`
+}
+
+// NewSolanaToolRegistry creates a tool registry holding the Solana tools.
+func NewSolanaToolRegistry(client *solana.Client, config SolanaToolsConfig) (*openai.ToolRegistry, error) {
+	registry := openai.NewToolRegistry()
+	if err := RegisterSolanaTools(registry, client, config); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// RegisterSolanaTools adds get_balance, get_account_info, and, when
+// config.SendFrom is set, send_sol to registry. Their handlers call client.
+func RegisterSolanaTools(registry *openai.ToolRegistry, client *solana.Client, config SolanaToolsConfig) error {
+	if client == nil {
+		return errors.New("integration: nil solana client")
+	}
+
+	tools := []struct {
+		def     openai.FunctionDefinition
+		handler openai.ToolHandler
+	}{
+		{
+			def: openai.FunctionDefinition{
+				Name:        ToolGetBalance,
+				Description: "Get the SOL balance of a Solana address.",
+				Parameters:  addressSchema("The base58 address to look up."),
+			},
+			handler: getBalance(client),
+		},
+		{
+			def: openai.FunctionDefinition{
+				Name:        ToolGetAccountInfo,
+				Description: "Get the owner program, balance, and data size of a Solana account.",
+				Parameters:  addressSchema("The base58 account address."),
+			},
+			handler: getAccountInfo(client),
+		},
+	}
+	if config.SendFrom != "" {
+		if err := solana.ValidateAddress(config.SendFrom); err != nil {
+			return err
+		}
+		tools = append(tools, struct {
+			def     openai.FunctionDefinition
+			handler openai.ToolHandler
+		}{
+			def: openai.FunctionDefinition{
+				Name:        ToolSendSOL,
+				Description: "Send lamports (1 SOL = 1,000,000,000 lamports) to a Solana address.",
+				Parameters: json.RawMessage(`{
+					"type": "object",
+					"properties": {
+						"to": {"type": "string", "description": "The base58 recipient address."},
+						"lamports": {"type": "integer", "minimum": 1, "description": "The amount to send in lamports."}
+					},
+					"required": ["to", "lamports"],
+					"additionalProperties": false
+				}`),
+			},
+			handler: sendSOL(client, config),
+		})
+	}
+
+	for _, t := range tools {
+		if err := registry.Register(t.def, t.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addressSchema(description string) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"type": "object",
+		"properties": {"address": {"type": "string", "description": %q}},
+		"required": ["address"],
+		"additionalProperties": false
+	}`, description))
+}
+
+type addressArgs struct {
+	Address string `json:"address"`
+}
+
+func (a *addressArgs) validate() error {
+	if a.Address == "" {
+		return fmt.Errorf("%w: address is required", ErrInvalidArguments)
+	}
+	if err := solana.ValidateAddress(a.Address); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidArguments, err)
+	}
+	return nil
+}
+
+func getBalance(client *solana.Client) openai.ToolHandler {
+	return func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var args addressArgs
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if err := args.validate(); err != nil {
+			return nil, err
+		}
+		lamports, err := client.GetBalance(ctx, args.Address)
+		if err != nil {
+			return nil, err
+		}
+		return &BalanceResult{
+			Address:  args.Address,
+			Lamports: lamports,
+			SOL:      float64(lamports) / solana.LamportsPerSOL,
+		}, nil
+	}
+}
+
+func getAccountInfo(client *solana.Client) openai.ToolHandler {
+	return func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var args addressArgs
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if err := args.validate(); err != nil {
+			return nil, err
+		}
+		info, err := client.GetAccountInfo(ctx, args.Address)
+		if errors.Is(err, solana.ErrAccountNotFound) {
+			return &AccountInfoResult{Address: args.Address}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &AccountInfoResult{
+			Address:    args.Address,
+			Exists:     true,
+			Lamports:   info.Lamports,
+			Owner:      info.Owner,
+			Executable: info.Executable,
+			DataLength: len(info.Data),
+		}, nil
+	}
+}
+
+func sendSOL(client *solana.Client, config SolanaToolsConfig) openai.ToolHandler {
+	return func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var args struct {
+			To       string `json:"to"`
+			Lamports uint64 `json:"lamports"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if err := solana.ValidateAddress(args.To); err != nil {
+			return nil, fmt.Errorf("%w: to: %w", ErrInvalidArguments, err)
+		}
+		if args.Lamports == 0 {
+			return nil, fmt.Errorf("%w: lamports must be positive", ErrInvalidArguments)
+		}
+		if config.MaxSendLamports > 0 && args.Lamports > config.MaxSendLamports {
+			return nil, fmt.Errorf("%w: %d lamports exceeds the %d lamport limit",
+				ErrInvalidArguments, args.Lamports, config.MaxSendLamports)
+		}
+
+		signature, err := client.SendTransaction(ctx, config.SendFrom, args.To, args.Lamports)
+		if err != nil {
+			return nil, err
+		}
+		return &SendResult{
+			Signature: signature,
+			From:      config.SendFrom,
+			To:        args.To,
+			Lamports:  args.Lamports,
+		}, nil
+	}
+}
+
+// decodeArgs decodes a tool's arguments, rejecting unknown fields.
+func decodeArgs(raw json.RawMessage, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+	return nil
+}
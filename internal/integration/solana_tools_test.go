@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labs-alone/alone-main/internal/openai"
+	"github.com/labs-alone/alone-main/internal/solana"
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+const testAddress = "11111111111111111111111111111111"
+
+func TestSolanaTools(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":2500000000}}`))
+	}))
+	defer srv.Close()
+
+	client, err := solana.NewClient(&utils.SolanaConfig{Endpoint: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	registry, err := NewSolanaToolRegistry(client, SolanaToolsConfig{})
+	if err != nil {
+		t.Fatalf("NewSolanaToolRegistry: %v", err)
+	}
+
+	tools := registry.Tools()
+	if len(tools) != 2 || tools[0].Function.Name != ToolGetAccountInfo || tools[1].Function.Name != ToolGetBalance {
+		t.Fatalf("tools = %+v, want only the read-only tools", tools)
+	}
+	for _, tool := range tools {
+		if !json.Valid(tool.Function.Parameters) {
+			t.Fatalf("%s schema is not valid JSON", tool.Function.Name)
+		}
+	}
+
+	msg, err := registry.Call(context.Background(), openai.ToolCall{
+		ID:       "call_1",
+		Function: openai.FunctionCall{Name: ToolGetBalance, Arguments: `{"address":"` + testAddress + `"}`},
+	})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	var balance BalanceResult
+	if err := json.Unmarshal([]byte(msg.Content), &balance); err != nil {
+		t.Fatalf("decode result %q: %v", msg.Content, err)
+	}
+	if msg.ToolCallID != "call_1" || balance.Lamports != 2500000000 || balance.SOL != 2.5 {
+		t.Fatalf("message = %+v", msg)
+	}
+
+	for _, args := range []string{`{}`, `{"address":"not-base58!"}`, `{"address":"` + testAddress + `","extra":1}`} {
+		_, err := registry.Call(context.Background(), openai.ToolCall{
+			Function: openai.FunctionCall{Name: ToolGetBalance, Arguments: args},
+		})
+		if !errors.Is(err, ErrInvalidArguments) {
+			t.Errorf("Call(%s) = %v, want ErrInvalidArguments", args, err)
+		}
+	}
+}
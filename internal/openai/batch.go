@@ -0,0 +1,71 @@
+package openai
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultChatBatchConcurrency is how many completions
+// CreateChatCompletionsBatch runs at once when not told otherwise.
+const DefaultChatBatchConcurrency = 4
+
+// CreateChatCompletionsBatch sends every request in reqs, at most
+// concurrency at a time, and returns responses and errors aligned with
+// reqs: for each index exactly one of the two is set. Each request goes
+// through CreateChatCompletion, so the client's limits, policy, retries,
+// RetryBudget, and fallback models apply to it as to a single call, and a
+// utils.UsageRecorder in ctx accounts for every completion. A failed
+// request does not stop the others; once ctx is done, requests not yet
+// started fail with its cause and those in flight are aborted. concurrency
+// of zero or less uses DefaultChatBatchConcurrency. SumUsage totals the
+// token usage of the batch.
+func (c *Client) CreateChatCompletionsBatch(ctx context.Context, reqs []*ChatCompletionRequest, concurrency int) ([]*ChatCompletionResponse, []error) {
+	resps := make([]*ChatCompletionResponse, len(reqs))
+	errs := make([]error, len(reqs))
+	if concurrency <= 0 {
+		concurrency = DefaultChatBatchConcurrency
+	}
+	if concurrency > len(reqs) {
+		concurrency = len(reqs)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		next atomic.Int64
+	)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(reqs) {
+					return
+				}
+				if ctx.Err() != nil {
+					errs[i] = context.Cause(ctx)
+					continue
+				}
+				resps[i], errs[i] = c.CreateChatCompletion(ctx, reqs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return resps, errs
+}
+
+// SumUsage totals the token usage of responses, skipping nil entries such
+// as the failed requests of a batch.
+func SumUsage(responses []*ChatCompletionResponse) Usage {
+	var total Usage
+	for _, resp := range responses {
+		if resp == nil {
+			continue
+		}
+		total.PromptTokens += resp.Usage.PromptTokens
+		total.CompletionTokens += resp.Usage.CompletionTokens
+		total.TotalTokens += resp.Usage.TotalTokens
+	}
+	return total
+}
@@ -0,0 +1,80 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateChatCompletionsBatch(t *testing.T) {
+	var running, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		var req ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Messages[0].Content
+		if prompt == "fail" {
+			http.Error(w, `{"error":{"message":"bad prompt"}}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(ChatCompletionResponse{
+			Choices: []ChatCompletionChoice{{Message: ChatMessage{Role: RoleAssistant, Content: prompt}}},
+			Usage:   Usage{PromptTokens: 2, CompletionTokens: 1, TotalTokens: 3},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	reqs := make([]*ChatCompletionRequest, 20)
+	for i := range reqs {
+		prompt := strconv.Itoa(i)
+		if i == 7 {
+			prompt = "fail"
+		}
+		reqs[i] = &ChatCompletionRequest{Messages: []ChatMessage{{Role: RoleUser, Content: prompt}}}
+	}
+	resps, errs := client.CreateChatCompletionsBatch(context.Background(), reqs, 3)
+	for i := range reqs {
+		if i == 7 {
+			if resps[i] != nil || errs[i] == nil {
+				t.Fatalf("failing request = %+v, %v", resps[i], errs[i])
+			}
+			continue
+		}
+		if errs[i] != nil || resps[i].Choices[0].Message.Content != strconv.Itoa(i) {
+			t.Fatalf("request %d = %+v, %v", i, resps[i], errs[i])
+		}
+	}
+	if p := peak.Load(); p > 3 {
+		t.Fatalf("%d requests ran at once, want at most 3", p)
+	}
+	if usage := SumUsage(resps); usage != (Usage{PromptTokens: 38, CompletionTokens: 19, TotalTokens: 57}) {
+		t.Fatalf("batch usage = %+v", usage)
+	}
+
+	// A cancelled batch fails every request it had not finished.
+	ctx, cancel := context.WithCancelCause(context.Background())
+	stop := errors.New("stopped")
+	cancel(stop)
+	_, errs = client.CreateChatCompletionsBatch(ctx, reqs, 0)
+	for i, err := range errs {
+		if !errors.Is(err, stop) {
+			t.Fatalf("request %d after cancellation: %v", i, err)
+		}
+	}
+}
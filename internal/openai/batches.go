@@ -0,0 +1,457 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// Batch API settings.
+const (
+	// DefaultBatchPollInterval is how often RetrieveBatchResults checks a
+	// batch's status when ClientConfig.BatchPollInterval is unset.
+	DefaultBatchPollInterval = 30 * time.Second
+	// BatchCompletionWindow is the time the API is given to run a batch,
+	// the only window it offers.
+	BatchCompletionWindow = "24h"
+	// BatchPriceFactor scales list prices for batched requests, which are
+	// billed at half price, in the cost reported to a utils.UsageRecorder.
+	BatchPriceFactor = 0.5
+)
+
+// ErrBatchFailed is returned when a batch, or a request in one, did not
+// complete because the batch failed, expired, or was cancelled.
+var ErrBatchFailed = errors.New("openai: batch did not complete")
+
+// BatchStatus is the state of a batch.
+type BatchStatus string
+
+// Batch states. Completed, failed, expired, and cancelled are final.
+const (
+	BatchValidating BatchStatus = "validating"
+	BatchInProgress BatchStatus = "in_progress"
+	BatchFinalizing BatchStatus = "finalizing"
+	BatchCompleted  BatchStatus = "completed"
+	BatchFailed     BatchStatus = "failed"
+	BatchExpired    BatchStatus = "expired"
+	BatchCancelling BatchStatus = "cancelling"
+	BatchCancelled  BatchStatus = "cancelled"
+)
+
+// Done reports whether s is final, so the batch will not change further.
+func (s BatchStatus) Done() bool {
+	switch s {
+	case BatchCompleted, BatchFailed, BatchExpired, BatchCancelled:
+		return true
+	}
+	return false
+}
+
+// BatchRequest is one request of a batch. Exactly one of Chat and
+// Embedding is set, and every request of a batch must set the same one.
+type BatchRequest struct {
+	// CustomID identifies the request in the results and must be unique
+	// within the batch. Empty uses "request-N", N being its index.
+	CustomID  string
+	Chat      *ChatCompletionRequest
+	Embedding *EmbeddingRequest
+}
+
+// BatchRequestCounts tallies the requests of a batch by outcome.
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// BatchError is a problem with a batch as a whole, such as an input line
+// the API could not parse.
+type BatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// BatchErrors lists the problems that failed a batch.
+type BatchErrors struct {
+	Data []BatchError `json:"data"`
+}
+
+// Batch is the state of a batch job.
+type Batch struct {
+	ID            string             `json:"id"`
+	Endpoint      string             `json:"endpoint"`
+	Status        BatchStatus        `json:"status"`
+	InputFileID   string             `json:"input_file_id"`
+	OutputFileID  string             `json:"output_file_id,omitempty"`
+	ErrorFileID   string             `json:"error_file_id,omitempty"`
+	CreatedAt     int64              `json:"created_at"`
+	CompletedAt   int64              `json:"completed_at,omitempty"`
+	RequestCounts BatchRequestCounts `json:"request_counts"`
+	Errors        *BatchErrors       `json:"errors,omitempty"`
+}
+
+// BatchResult is the outcome of one request of a batch. On success Chat or
+// Embedding is set, matching the request.
+type BatchResult struct {
+	CustomID  string
+	Chat      *ChatCompletionResponse
+	Embedding *EmbeddingResponse
+	// Err is why the request failed: an *APIError for a request the API
+	// rejected, or ErrBatchFailed for one that never ran.
+	Err error
+}
+
+// batchInputLine is one line of a batch input file.
+type batchInputLine struct {
+	CustomID string      `json:"custom_id"`
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Body     interface{} `json:"body"`
+}
+
+// batchOutputLine is one line of a batch output or error file.
+type batchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *apiErrorObject `json:"error"`
+}
+
+// fileUpload is a multipart request body, sent as is by doRequest.
+type fileUpload struct {
+	contentType string
+	data        []byte
+}
+
+// CreateBatch submits requests to run within BatchCompletionWindow at the
+// Batch API's reduced price and returns the batch ID. Chat requests get
+// the client's default model, Limits, and Policy as with
+// CreateChatCompletion, but not its FallbackModels. The requests are
+// uploaded as a file, which is deleted again if the batch cannot be
+// created.
+func (c *Client) CreateBatch(ctx context.Context, requests []BatchRequest) (string, error) {
+	endpoint, input, err := c.batchInput(ctx, requests)
+	if err != nil {
+		return "", err
+	}
+	fileID, err := c.uploadFile(ctx, "batch", "batch.jsonl", input)
+	if err != nil {
+		return "", fmt.Errorf("openai: upload batch input: %w", err)
+	}
+
+	var batch Batch
+	body := map[string]string{
+		"input_file_id":     fileID,
+		"endpoint":          endpoint,
+		"completion_window": BatchCompletionWindow,
+	}
+	// A retry after a lost response could create, and bill, a second batch.
+	if err := c.doRequestOnce(ctx, http.MethodPost, "/batches", body, &batch); err != nil {
+		c.deleteFiles(ctx, fileID)
+		return "", err
+	}
+	c.logger.Info("Batch created", map[string]interface{}{
+		"batch":    batch.ID,
+		"endpoint": endpoint,
+		"requests": len(requests),
+	})
+	return batch.ID, nil
+}
+
+// batchInput encodes requests as a batch input file and returns it with
+// the endpoint they are for.
+func (c *Client) batchInput(ctx context.Context, requests []BatchRequest) (string, []byte, error) {
+	if len(requests) == 0 {
+		return "", nil, fmt.Errorf("%w: batch requires at least one request", ErrInvalidRequest)
+	}
+	var (
+		endpoint string
+		buf      bytes.Buffer
+		enc      = json.NewEncoder(&buf)
+		seen     = make(map[string]bool, len(requests))
+	)
+	for i, r := range requests {
+		id := r.CustomID
+		if id == "" {
+			id = fmt.Sprintf("request-%d", i)
+		}
+		if seen[id] {
+			return "", nil, fmt.Errorf("%w: batch custom ID %q is used twice", ErrInvalidRequest, id)
+		}
+		seen[id] = true
+
+		line := batchInputLine{CustomID: id, Method: http.MethodPost}
+		switch {
+		case r.Chat != nil && r.Embedding == nil:
+			if err := r.Chat.validate(); err != nil {
+				return "", nil, fmt.Errorf("batch request %s: %w", id, err)
+			}
+			body := *r.Chat
+			body.Stream = false
+			body.StreamOptions = nil
+			if body.Model == "" {
+				body.Model = c.config.Model
+			}
+			if err := c.config.Limits.apply(&body); err != nil {
+				return "", nil, fmt.Errorf("batch request %s: %w", id, err)
+			}
+			if err := c.applyPolicy(ctx, &body); err != nil {
+				return "", nil, fmt.Errorf("batch request %s: %w", id, err)
+			}
+			line.URL, line.Body = "/v1/chat/completions", &body
+		case r.Embedding != nil && r.Chat == nil:
+			if n := len(r.Embedding.Input); n == 0 || n > MaxEmbeddingInputs {
+				return "", nil, fmt.Errorf("%w: batch request %s has %d embedding inputs, want 1 to %d", ErrInvalidRequest, id, n, MaxEmbeddingInputs)
+			}
+			body := *r.Embedding
+			if body.Model == "" {
+				body.Model = DefaultEmbeddingModel
+			}
+			line.URL, line.Body = "/v1/embeddings", &body
+		default:
+			return "", nil, fmt.Errorf("%w: batch request %s must set exactly one of Chat and Embedding", ErrInvalidRequest, id)
+		}
+		if endpoint == "" {
+			endpoint = line.URL
+		} else if line.URL != endpoint {
+			return "", nil, fmt.Errorf("%w: batch request %s is for %s, but the batch is for %s", ErrInvalidRequest, id, line.URL, endpoint)
+		}
+		if err := enc.Encode(line); err != nil {
+			return "", nil, fmt.Errorf("openai: marshal batch request %s: %w", id, err)
+		}
+	}
+	return endpoint, buf.Bytes(), nil
+}
+
+// GetBatch returns the current state of a batch.
+func (c *Client) GetBatch(ctx context.Context, batchID string) (*Batch, error) {
+	if batchID == "" {
+		return nil, fmt.Errorf("%w: batch ID is required", ErrInvalidRequest)
+	}
+	var batch Batch
+	if err := c.doRequest(ctx, http.MethodGet, "/batches/"+url.PathEscape(batchID), nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// RetrieveBatchResults waits for a batch to finish, checking its status
+// every ClientConfig.BatchPollInterval until ctx is done, and returns one
+// result per request in the order they were submitted. Requests fail one
+// by one: a request the API rejected, or one left unrun when the batch
+// expired or was cancelled, has its Err set while the others succeed. An
+// error is returned only when no results can be read, such as for a batch
+// that failed validation. Token usage and its discounted cost are
+// reported like those of single calls. The batch's files are left in
+// place, so results can be retrieved again; delete them with
+// DeleteBatchFiles once they are no longer needed.
+func (c *Client) RetrieveBatchResults(ctx context.Context, batchID string) ([]BatchResult, error) {
+	batch, err := c.waitBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.OutputFileID == "" && batch.ErrorFileID == "" {
+		detail := "no results"
+		if batch.Errors != nil && len(batch.Errors.Data) > 0 {
+			e := batch.Errors.Data[0]
+			detail = fmt.Sprintf("%s (%s)", e.Message, e.Code)
+			if e.Line > 0 {
+				detail = fmt.Sprintf("line %d: %s", e.Line, detail)
+			}
+		}
+		return nil, fmt.Errorf("%w: batch %s %s: %s", ErrBatchFailed, batchID, batch.Status, detail)
+	}
+
+	input, err := c.downloadFile(ctx, batch.InputFileID)
+	if err != nil {
+		return nil, fmt.Errorf("openai: download input of batch %s: %w", batchID, err)
+	}
+	results := make([]BatchResult, 0, batch.RequestCounts.Total)
+	index := make(map[string]int)
+	for _, raw := range jsonLines(input) {
+		var line batchInputLine
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return nil, fmt.Errorf("openai: decode input of batch %s: %w", batchID, err)
+		}
+		index[line.CustomID] = len(results)
+		results = append(results, BatchResult{CustomID: line.CustomID})
+	}
+
+	done := make([]bool, len(results))
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		data, err := c.downloadFile(ctx, fileID)
+		if err != nil {
+			return nil, fmt.Errorf("openai: download results of batch %s: %w", batchID, err)
+		}
+		for _, raw := range jsonLines(data) {
+			var line batchOutputLine
+			if err := json.Unmarshal(raw, &line); err != nil {
+				return nil, fmt.Errorf("openai: decode results of batch %s: %w", batchID, err)
+			}
+			i, ok := index[line.CustomID]
+			if !ok {
+				continue
+			}
+			results[i] = c.batchResult(ctx, batch.Endpoint, &line)
+			done[i] = true
+		}
+	}
+	for i := range results {
+		if !done[i] {
+			results[i].Err = fmt.Errorf("%w: request %s did not run before batch %s was %s", ErrBatchFailed, results[i].CustomID, batchID, batch.Status)
+		}
+	}
+	return results, nil
+}
+
+// DeleteBatchFiles deletes the input, output, and error files of a batch,
+// which otherwise stay in the organization's file storage. Its results can
+// no longer be retrieved afterwards. Every file is attempted; the errors of
+// those that could not be deleted are returned together.
+func (c *Client) DeleteBatchFiles(ctx context.Context, batchID string) error {
+	batch, err := c.GetBatch(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, id := range []string{batch.InputFileID, batch.OutputFileID, batch.ErrorFileID} {
+		if id == "" {
+			continue
+		}
+		if err := c.doRequest(ctx, http.MethodDelete, "/files/"+url.PathEscape(id), nil, nil); err != nil {
+			errs = append(errs, fmt.Errorf("openai: delete file %s of batch %s: %w", id, batchID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// waitBatch polls a batch until its status is final.
+func (c *Client) waitBatch(ctx context.Context, batchID string) (*Batch, error) {
+	ticker := time.NewTicker(c.config.BatchPollInterval)
+	defer ticker.Stop()
+	for {
+		batch, err := c.GetBatch(ctx, batchID)
+		if err != nil {
+			return nil, err
+		}
+		if batch.Status.Done() {
+			return batch, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("openai: wait for batch %s (%s): %w", batchID, batch.Status, context.Cause(ctx))
+		case <-ticker.C:
+		}
+	}
+}
+
+// batchResult decodes one line of a batch's output or error file.
+func (c *Client) batchResult(ctx context.Context, endpoint string, line *batchOutputLine) BatchResult {
+	result := BatchResult{CustomID: line.CustomID}
+	path := strings.TrimPrefix(endpoint, "/v1")
+	switch {
+	case line.Error != nil:
+		result.Err = fmt.Errorf("%w: request %s: %s (%s)", ErrBatchFailed, line.CustomID, line.Error.Message, strings.Trim(string(line.Error.Code), `"`))
+	case line.Response == nil:
+		result.Err = fmt.Errorf("%w: request %s has no response", ErrBatchFailed, line.CustomID)
+	case line.Response.StatusCode < 200 || line.Response.StatusCode > 299:
+		result.Err = newAPIError(http.MethodPost, path, line.Response.StatusCode, line.Response.Body)
+	case path == "/embeddings":
+		var resp EmbeddingResponse
+		if err := json.Unmarshal(line.Response.Body, &resp); err != nil {
+			result.Err = fmt.Errorf("openai: decode batch request %s: %w", line.CustomID, err)
+			break
+		}
+		c.recordBatchUsage(ctx, resp.Model, resp.Usage)
+		result.Embedding = &resp
+	default:
+		var resp ChatCompletionResponse
+		if err := json.Unmarshal(line.Response.Body, &resp); err != nil {
+			result.Err = fmt.Errorf("openai: decode batch request %s: %w", line.CustomID, err)
+			break
+		}
+		c.recordBatchUsage(ctx, resp.Model, resp.Usage)
+		result.Chat = &resp
+	}
+	return result
+}
+
+// recordBatchUsage is recordUsage at the discounted batch price.
+func (c *Client) recordBatchUsage(ctx context.Context, model string, u Usage) {
+	c.metrics.addUsage(u)
+	price, _ := c.price(model)
+	utils.UsageRecorderFrom(ctx).AddTokens(uint64(u.PromptTokens), uint64(u.CompletionTokens), price.Cost(u)*BatchPriceFactor)
+}
+
+// uploadFile uploads data as a file for purpose and returns its ID.
+func (c *Client) uploadFile(ctx context.Context, purpose, name string, data []byte) (string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	// Writes to a bytes.Buffer do not fail.
+	w.WriteField("purpose", purpose)
+	part, _ := w.CreateFormFile("file", name)
+	part.Write(data)
+	w.Close()
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	upload := &fileUpload{contentType: w.FormDataContentType(), data: buf.Bytes()}
+	// Not retried, so a lost response cannot leave a second copy behind.
+	if err := c.doRequestOnce(ctx, http.MethodPost, "/files", upload, &file); err != nil {
+		return "", err
+	}
+	return file.ID, nil
+}
+
+// downloadFile returns the content of a file.
+func (c *Client) downloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	var data []byte
+	err := c.doRequest(ctx, http.MethodGet, "/files/"+url.PathEscape(fileID)+"/content", nil, &data)
+	return data, err
+}
+
+// deleteFiles deletes the named files, skipping empty IDs. A file that
+// cannot be deleted is logged and left behind; it does not fail the call
+// that created it. Deletion goes ahead even when ctx is cancelled.
+func (c *Client) deleteFiles(ctx context.Context, fileIDs ...string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, id := range fileIDs {
+		if id == "" {
+			continue
+		}
+		if err := c.doRequest(ctx, http.MethodDelete, "/files/"+url.PathEscape(id), nil, nil); err != nil {
+			c.logger.Warn("Failed to delete file", map[string]interface{}{
+				"file":  id,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// jsonLines splits a JSON Lines file into its non-blank lines.
+func jsonLines(data []byte) [][]byte {
+	var lines [][]byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,228 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// fakeBatchAPI runs uploaded batches of chat completions, answering each
+// with its prompt, failing the prompt "reject" with a 400, and, while
+// expire is set, leaving the prompt "late" unrun.
+type fakeBatchAPI struct {
+	mu      sync.Mutex
+	files   map[string][]byte
+	batches map[string]*Batch
+	polls   int
+	expire  bool
+}
+
+func (f *fakeBatchAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/files":
+		if r.FormValue("purpose") != "batch" {
+			http.Error(w, "purpose", http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		id := fmt.Sprintf("file-%d", len(f.files))
+		f.files[id] = data
+		json.NewEncoder(w).Encode(map[string]string{"id": id})
+	case r.Method == http.MethodPost && r.URL.Path == "/batches":
+		var body struct {
+			InputFileID string `json:"input_file_id"`
+			Endpoint    string `json:"endpoint"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		batch := &Batch{ID: "batch-1", Endpoint: body.Endpoint, Status: BatchValidating, InputFileID: body.InputFileID}
+		f.batches[batch.ID] = batch
+		json.NewEncoder(w).Encode(batch)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/batches/"):
+		batch, ok := f.batches[strings.TrimPrefix(r.URL.Path, "/batches/")]
+		if !ok {
+			http.Error(w, "no batch", http.StatusNotFound)
+			return
+		}
+		if f.polls++; f.polls == 2 {
+			f.run(batch)
+		}
+		json.NewEncoder(w).Encode(batch)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/content"):
+		data, ok := f.files[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/files/"), "/content")]
+		if !ok {
+			http.Error(w, "no file", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/files/"):
+		delete(f.files, strings.TrimPrefix(r.URL.Path, "/files/"))
+	default:
+		http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+	}
+}
+
+func (f *fakeBatchAPI) run(batch *Batch) {
+	var output []string
+	var errs strings.Builder
+	for _, raw := range jsonLines(f.files[batch.InputFileID]) {
+		var line struct {
+			CustomID string                `json:"custom_id"`
+			Body     ChatCompletionRequest `json:"body"`
+		}
+		json.Unmarshal(raw, &line)
+		prompt := line.Body.Messages[0].Content
+		batch.RequestCounts.Total++
+		switch {
+		case prompt == "reject":
+			fmt.Fprintf(&errs, `{"custom_id":%q,"response":{"status_code":400,"body":{"error":{"message":"bad prompt","type":"invalid_request_error"}}}}`+"\n", line.CustomID)
+			batch.RequestCounts.Failed++
+		case prompt == "late" && f.expire:
+		default:
+			resp, _ := json.Marshal(ChatCompletionResponse{
+				Model:   line.Body.Model,
+				Choices: []ChatCompletionChoice{{Message: ChatMessage{Role: RoleAssistant, Content: prompt}}},
+				Usage:   Usage{PromptTokens: 4, CompletionTokens: 2, TotalTokens: 6},
+			})
+			// The API writes results in no particular order.
+			output = append([]string{fmt.Sprintf(`{"custom_id":%q,"response":{"status_code":200,"body":%s}}`, line.CustomID, resp)}, output...)
+			batch.RequestCounts.Completed++
+		}
+	}
+	batch.Status = BatchCompleted
+	if f.expire {
+		batch.Status = BatchExpired
+	}
+	batch.OutputFileID, batch.ErrorFileID = "file-output", "file-errors"
+	f.files["file-output"] = []byte(strings.Join(output, "\n"))
+	f.files["file-errors"] = []byte(errs.String())
+}
+
+func TestBatchLifecycle(t *testing.T) {
+	api := &fakeBatchAPI{files: make(map[string][]byte), batches: make(map[string]*Batch), expire: true}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL, BatchPollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	prompts := []string{"first", "reject", "late", "last"}
+	var requests []BatchRequest
+	for _, p := range prompts {
+		requests = append(requests, BatchRequest{Chat: &ChatCompletionRequest{Messages: []ChatMessage{{Role: RoleUser, Content: p}}}})
+	}
+	id, err := client.CreateBatch(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	if batch, err := client.GetBatch(context.Background(), id); err != nil || batch.Status != BatchValidating || batch.Endpoint != "/v1/chat/completions" {
+		t.Fatalf("GetBatch = %+v, %v", batch, err)
+	}
+
+	results, err := client.RetrieveBatchResults(context.Background(), id)
+	if err != nil {
+		t.Fatalf("RetrieveBatchResults: %v", err)
+	}
+	if len(results) != len(prompts) {
+		t.Fatalf("%d results for %d requests", len(results), len(prompts))
+	}
+	for i, r := range results {
+		if want := fmt.Sprintf("request-%d", i); r.CustomID != want {
+			t.Fatalf("result %d is for %s, want %s", i, r.CustomID, want)
+		}
+	}
+	if r := results[0]; r.Err != nil || r.Chat.Choices[0].Message.Content != "first" || r.Chat.Model != DefaultModel {
+		t.Fatalf("first result = %+v", r)
+	}
+	if r := results[1]; !errors.Is(r.Err, ErrInvalidRequest) || r.Chat != nil {
+		t.Fatalf("rejected result = %+v, want ErrInvalidRequest", r)
+	}
+	if r := results[2]; !errors.Is(r.Err, ErrBatchFailed) {
+		t.Fatalf("unrun result = %+v, want ErrBatchFailed", r)
+	}
+	if r := results[3]; r.Err != nil || r.Chat.Choices[0].Message.Content != "last" {
+		t.Fatalf("last result = %+v", r)
+	}
+	if n := client.GetMetrics()["prompt_tokens"]; n != uint64(8) {
+		t.Fatalf("prompt_tokens = %v, want 8", n)
+	}
+	// Retrieving leaves the files; deleting them is a separate call.
+	if again, err := client.RetrieveBatchResults(context.Background(), id); err != nil || len(again) != len(results) {
+		t.Fatalf("second RetrieveBatchResults = %d results, %v", len(again), err)
+	}
+	if err := client.DeleteBatchFiles(context.Background(), id); err != nil {
+		t.Fatalf("DeleteBatchFiles: %v", err)
+	}
+	if len(api.files) != 0 {
+		t.Fatalf("files left behind: %d", len(api.files))
+	}
+	endpoints := client.GetMetrics()["latency_by_endpoint"].(map[string]utils.HistogramSnapshot)
+	if _, ok := endpoints["/batches/{id}"]; !ok || len(endpoints) != 5 {
+		t.Fatalf("latency endpoints = %v, want batch and file IDs collapsed", endpoints)
+	}
+
+	if _, err := client.CreateBatch(context.Background(), []BatchRequest{
+		{Chat: testChatRequest()},
+		{Embedding: &EmbeddingRequest{Input: []string{"x"}}},
+	}); err == nil {
+		t.Fatal("CreateBatch accepted a batch mixing endpoints")
+	}
+}
+
+func TestCreateBatchDoesNotRetry(t *testing.T) {
+	api := &fakeBatchAPI{files: make(map[string][]byte), batches: make(map[string]*Batch)}
+	var uploads, creates atomic.Int32
+	var failUploads atomic.Bool
+	failUploads.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/files":
+			if uploads.Add(1); failUploads.Load() {
+				http.Error(w, "upstream timeout", http.StatusBadGateway)
+				return
+			}
+		case r.Method == http.MethodPost && r.URL.Path == "/batches":
+			creates.Add(1)
+			http.Error(w, "upstream timeout", http.StatusBadGateway)
+			return
+		}
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL, MaxRetries: 3})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	requests := []BatchRequest{{Chat: testChatRequest()}}
+	if _, err := client.CreateBatch(context.Background(), requests); !errors.Is(err, ErrServerError) || uploads.Load() != 1 {
+		t.Fatalf("CreateBatch = %v after %d uploads, want one failed upload", err, uploads.Load())
+	}
+
+	failUploads.Store(false)
+	if _, err := client.CreateBatch(context.Background(), requests); !errors.Is(err, ErrServerError) || creates.Load() != 1 {
+		t.Fatalf("CreateBatch = %v after %d creations, want one failed creation", err, creates.Load())
+	}
+	if len(api.files) != 0 {
+		t.Fatalf("files left behind: %d", len(api.files))
+	}
+}
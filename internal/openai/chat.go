@@ -0,0 +1,148 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// ChatCompletionRequest is a request to the chat completions endpoint.
+type ChatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Temperature float32       `json:"temperature,omitempty"`
+	TopP        float32       `json:"top_p,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
+	User        string        `json:"user,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+	// StreamOptions applies to streamed requests only. Nil asks for usage,
+	// so streamed calls are accounted like unstreamed ones.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	Tools         []Tool         `json:"tools,omitempty"`
+	// ToolChoice is "none", "auto", "required", or an object naming a
+	// function. Nil leaves the API default.
+	ToolChoice interface{} `json:"tool_choice,omitempty"`
+	// Seed asks for best-effort deterministic sampling: the same seed,
+	// model, and inputs should yield the same output while the response's
+	// SystemFingerprint is unchanged. It must be non-negative; nil leaves
+	// sampling random.
+	Seed *int64 `json:"seed,omitempty"`
+	// LogitBias adjusts the likelihood of tokens, keyed by token ID in
+	// decimal. Values from -100 to 100 are added to the model's logits:
+	// -100 effectively bans a token and 100 all but forces it, while small
+	// values such as ±1 nudge it. Token IDs depend on the model's
+	// tokenizer, e.g. o200k_base for gpt-4o models and cl100k_base for
+	// gpt-4 and gpt-3.5-turbo; look them up with the tiktoken library or
+	// OpenAI's online tokenizer. Note that a word is often several tokens
+	// and that a leading space yields a different token.
+	LogitBias map[string]float64 `json:"logit_bias,omitempty"`
+}
+
+// Bounds of ChatCompletionRequest.LogitBias values and the most Stop
+// sequences the API accepts.
+const (
+	MinLogitBias     = -100
+	MaxLogitBias     = 100
+	MaxStopSequences = 4
+)
+
+// StreamOptions configures a streamed chat completion.
+type StreamOptions struct {
+	// IncludeUsage asks for a final chunk, with no choices, carrying the
+	// token usage of the whole request.
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// validate checks the fields shared by streamed and unstreamed requests.
+func (r *ChatCompletionRequest) validate() error {
+	if r == nil || len(r.Messages) == 0 {
+		return fmt.Errorf("%w: chat completion requires at least one message", ErrInvalidRequest)
+	}
+	if r.Seed != nil && *r.Seed < 0 {
+		return fmt.Errorf("%w: seed must be non-negative, got %d", ErrInvalidRequest, *r.Seed)
+	}
+	if len(r.Stop) > MaxStopSequences {
+		return fmt.Errorf("%w: at most %d stop sequences are allowed, got %d", ErrInvalidRequest, MaxStopSequences, len(r.Stop))
+	}
+	for token, bias := range r.LogitBias {
+		if id, err := strconv.ParseUint(token, 10, 32); err != nil || strconv.FormatUint(id, 10) != token {
+			return fmt.Errorf("%w: logit bias key %q is not a token ID", ErrInvalidRequest, token)
+		}
+		if math.IsNaN(bias) || bias < MinLogitBias || bias > MaxLogitBias {
+			return fmt.Errorf("%w: logit bias of token %s must be between %d and %d, got %v", ErrInvalidRequest, token, MinLogitBias, MaxLogitBias, bias)
+		}
+	}
+	return nil
+}
+
+// Usage reports token consumption for a request.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionChoice is one generated completion.
+type ChatCompletionChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// ChatCompletionResponse is the response from the chat completions endpoint.
+type ChatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   Usage                  `json:"usage"`
+	// SystemFingerprint identifies the backend configuration that served
+	// the request. Seeded outputs are only comparable while it is the same.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+}
+
+// CreateChatCompletion sends a chat completion request. The client's default
+// model is used when req.Model is empty, and the client's Limits and Policy
+// are applied before sending.
+func (c *Client) CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	body := *req
+	body.Stream = false
+	body.StreamOptions = nil
+	if body.Model == "" {
+		body.Model = c.config.Model
+	}
+	if err := c.config.Limits.apply(&body); err != nil {
+		return nil, err
+	}
+
+	var resp ChatCompletionResponse
+	_, err := c.withFallback(ctx, body.Model, func(model string) error {
+		attempt := body
+		attempt.Model = model
+		if err := c.applyPolicy(ctx, &attempt); err != nil {
+			return err
+		}
+		resp = ChatCompletionResponse{}
+		err := c.doRequest(ctx, http.MethodPost, "/chat/completions", &attempt, &resp)
+		body.Model = attempt.Model
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Model == "" {
+		resp.Model = body.Model
+	}
+	c.recordUsage(utils.UsageRecorderFrom(ctx), resp.Model, resp.Usage)
+	return &resp, nil
+}
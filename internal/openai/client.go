@@ -0,0 +1,394 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// Client defaults applied when the config leaves them unset.
+const (
+	DefaultBaseURL = "https://api.openai.com/v1"
+	DefaultModel   = "gpt-4o-mini"
+	DefaultTimeout = 60 * time.Second
+	// DefaultRetryBackoff is the delay before the first retry; it doubles
+	// on each subsequent attempt.
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// DefaultLogBodyLimit is the number of bytes of a body LogBodies
+	// writes before truncating it.
+	DefaultLogBodyLimit = 4096
+)
+
+// ErrInvalidConfig is returned when the client configuration is unusable.
+var ErrInvalidConfig = errors.New("openai: invalid config")
+
+// ClientConfig configures an OpenAI client.
+type ClientConfig struct {
+	APIKey  string
+	BaseURL string
+	// Model is used for requests that do not set one.
+	Model string
+	// FallbackModels are tried in order when a chat completion fails with
+	// a server error (5xx), such as 503 when a model is overloaded, after
+	// the request's own retries. Client errors (4xx), including rate
+	// limits, never fall back. The response's Model names the model that
+	// served it.
+	FallbackModels []string
+	// Timeout bounds a single API call when the caller's context carries no
+	// deadline of its own.
+	Timeout time.Duration
+	// Timeouts override Timeout for individual operations. A call is
+	// bounded by the caller's context deadline if it has one, otherwise by
+	// its operation's timeout if set, otherwise by Timeout.
+	Timeouts OperationTimeouts
+	// LatencyBuckets are the HTTP latency histogram upper bounds in seconds.
+	// Empty uses utils.DefaultLatencyBuckets.
+	LatencyBuckets []float64
+	// MetricsWindow is the span the "window" metrics count calls and
+	// errors over. Zero uses utils.DefaultMetricsWindow.
+	MetricsWindow time.Duration
+	// EmbeddingConcurrency bounds the API calls CreateEmbedding runs at once
+	// when it splits a large batch. Zero uses DefaultEmbeddingConcurrency.
+	EmbeddingConcurrency int
+	// BatchPollInterval is how often RetrieveBatchResults checks whether a
+	// batch has finished. Zero uses DefaultBatchPollInterval.
+	BatchPollInterval time.Duration
+	// Organization, when set, is sent as the OpenAI-Organization header so
+	// usage is billed to that organization.
+	Organization string
+	// Project, when set, is sent as the OpenAI-Project header so usage is
+	// attributed to that project.
+	Project string
+	// UserAgent, when set, is sent as the User-Agent of every request,
+	// overriding any User-Agent in Headers.
+	UserAgent string
+	// Headers are added to every request. Authorization, Content-Type,
+	// OpenAI-Organization, and OpenAI-Project are set by the client and
+	// cannot be overridden here.
+	Headers map[string]string
+	// Limits, when set, caps prompt size and response tokens of chat
+	// completions. Nil disables the checks.
+	Limits *RequestLimits
+	// Policy, when set, inspects every chat completion before it is sent
+	// and may change or reject it; see RequestPolicy and PolicyRules.
+	Policy RequestPolicy
+	// MaxRetries is how many times a request failing with a rate limit,
+	// server error, or network error is retried. Zero disables retries.
+	// Only requests that cannot take effect twice are retried: reads,
+	// deletes, and chat completions and embeddings, which compute a
+	// response without storing anything. Streaming requests, file uploads,
+	// and batch creation are never retried, nor is a request whose context
+	// deadline would pass during the backoff.
+	MaxRetries int
+	// RetryBudget, when set, must grant every retry. Share one budget with
+	// the other components so an outage stops retries everywhere at once.
+	RetryBudget *utils.RetryBudget
+	// LogBodies logs every request and response with its headers and body
+	// while the client's logger has DEBUG enabled. The Authorization header, the API
+	// key, and the fields named by utils.DefaultRedactedKeys and
+	// RedactFields are redacted. Streamed response bodies are not logged.
+	LogBodies bool
+	// LogBodyLimit truncates logged bodies to this many bytes. Zero uses
+	// DefaultLogBodyLimit; negative logs whole bodies.
+	LogBodyLimit int
+	// RedactFields names further headers and JSON fields whose values are
+	// redacted from logged requests and responses.
+	RedactFields []string
+	// Pricing sets the price of models, overriding DefaultPricing, for
+	// the cost estimates reported to a utils.UsageRecorder.
+	Pricing map[string]ModelPrice
+	// Recorder, when set, records every request and its response or, in
+	// utils.ReplayMode, answers requests from its recordings without
+	// contacting the API; see utils.Recorder for how requests are matched.
+	// Streamed completions are delivered once the whole stream is read.
+	Recorder *utils.Recorder
+}
+
+// OperationTimeouts bound the calls of one kind of operation. Zero fields
+// fall back to ClientConfig.Timeout. Streamed chat completions are bounded
+// only by the caller's context, since a timeout would cut off the stream.
+type OperationTimeouts struct {
+	// Chat bounds CreateChatCompletion calls.
+	Chat time.Duration
+	// Embeddings bounds each call CreateEmbedding makes.
+	Embeddings time.Duration
+	// Models bounds ListModels calls.
+	Models time.Duration
+}
+
+// reservedHeaders are set by the client itself and rejected in
+// ClientConfig.Headers.
+var reservedHeaders = []string{"Authorization", "Content-Type", "OpenAI-Organization", "OpenAI-Project"}
+
+// Client is an OpenAI API client.
+type Client struct {
+	config     ClientConfig
+	httpClient *http.Client
+	transport  *utils.Transport
+	logger     *utils.Logger
+	metrics    *clientMetrics
+	redactor   *utils.Redactor
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithLogger makes the client log through logger. Entries are prefixed with
+// the logger's prefix followed by "OpenAI". Without it the client derives its
+// logger from utils.DefaultLogger.
+func WithLogger(logger *utils.Logger) ClientOption {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger.Named("OpenAI")
+		}
+	}
+}
+
+// NewClient creates an OpenAI client.
+func NewClient(config *ClientConfig, opts ...ClientOption) (*Client, error) {
+	if config == nil {
+		return nil, fmt.Errorf("%w: nil config", ErrInvalidConfig)
+	}
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("%w: API key is required", ErrInvalidConfig)
+	}
+
+	if err := utils.ValidateHeaders(config.Headers, reservedHeaders...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if config.Organization != "" && strings.TrimSpace(config.Organization) == "" {
+		return nil, fmt.Errorf("%w: organization is blank", ErrInvalidConfig)
+	}
+	if config.Project != "" && strings.TrimSpace(config.Project) == "" {
+		return nil, fmt.Errorf("%w: project is blank", ErrInvalidConfig)
+	}
+
+	cfg := *config
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Model == "" {
+		cfg.Model = DefaultModel
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.EmbeddingConcurrency <= 0 {
+		cfg.EmbeddingConcurrency = DefaultEmbeddingConcurrency
+	}
+	if cfg.BatchPollInterval <= 0 {
+		cfg.BatchPollInterval = DefaultBatchPollInterval
+	}
+	if cfg.LogBodyLimit == 0 {
+		cfg.LogBodyLimit = DefaultLogBodyLimit
+	}
+
+	// Clients share one pooled transport. http.DefaultTransport keeps only
+	// two idle connections per host, so concurrent callers would otherwise
+	// open and discard sockets on every burst.
+	transport := utils.SharedTransport(utils.TransportConfig{})
+	var rt http.RoundTripper = transport
+	if cfg.Recorder != nil {
+		rt = cfg.Recorder.Wrap(transport)
+	}
+	c := &Client{
+		config:     cfg,
+		httpClient: &http.Client{Transport: rt},
+		transport:  transport,
+		logger:     utils.DefaultLogger().Named("OpenAI"),
+		metrics:    newClientMetrics(cfg.LatencyBuckets, cfg.MetricsWindow),
+		redactor:   utils.NewRedactor(cfg.RedactFields, cfg.APIKey),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// doRequest sends a JSON request to path and decodes the JSON response into
+// out. body and out may be nil. A *fileUpload body is sent as is, and a
+// *[]byte out receives the response body undecoded.
+func (c *Client) doRequest(ctx context.Context, method, path string, body, out interface{}) error {
+	if !idempotent(method, path) {
+		return c.doRequestOnce(ctx, method, path, body, out)
+	}
+	backoff := DefaultRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.doRequestOnce(ctx, method, path, body, out)
+		if err == nil {
+			return nil
+		}
+		// A backoff the request's deadline cannot cover would only fail later.
+		if attempt >= c.config.MaxRetries || !retryable(ctx, err) || utils.RemainingBudget(ctx) <= backoff {
+			return utils.WrapAttempts(err, attempt+1)
+		}
+		if budgetErr := c.config.RetryBudget.Acquire(); budgetErr != nil {
+			return utils.WrapAttempts(fmt.Errorf("%w: %w", budgetErr, err), attempt+1)
+		}
+
+		c.logger.Warn("Retrying request", map[string]interface{}{
+			"path":    path,
+			"attempt": attempt + 1,
+			"error":   err.Error(),
+		})
+		select {
+		case <-ctx.Done():
+			return utils.WrapAttempts(err, attempt+1)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// doRequestOnce is doRequest without retries, for calls that must not be
+// repeated because a failed attempt may still have taken effect.
+func (c *Client) doRequestOnce(ctx context.Context, method, path string, body, out interface{}) error {
+	start := time.Now()
+	err := c.send(ctx, method, path, body, out)
+	elapsed := time.Since(start)
+	c.metrics.observe(endpointLabel(path), elapsed, err)
+	utils.UsageRecorderFrom(ctx).AddOpenAIRequest(elapsed)
+	return err
+}
+
+// idempotent reports whether a request to path can be repeated without
+// taking effect twice. POST requests are only when they compute a response
+// and store nothing.
+func idempotent(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return path == "/chat/completions" || path == "/embeddings"
+	}
+	return false
+}
+
+// retryable reports whether err is worth retrying: rate limits, server
+// errors, and network errors while ctx is still live.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// timeoutFor returns the timeout of calls to path.
+func (c *Client) timeoutFor(path string) time.Duration {
+	var timeout time.Duration
+	switch path {
+	case "/chat/completions":
+		timeout = c.config.Timeouts.Chat
+	case "/embeddings":
+		timeout = c.config.Timeouts.Embeddings
+	case "/models":
+		timeout = c.config.Timeouts.Models
+	}
+	if timeout <= 0 {
+		return c.config.Timeout
+	}
+	return timeout
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body, out interface{}) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeoutFor(path))
+		defer cancel()
+	}
+
+	resp, err := c.open(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var reader io.Reader = resp.Body
+	if c.logsWire() {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("openai: read %s response: %w", path, err)
+		}
+		c.logResponse(method, path, resp, data)
+		reader = bytes.NewReader(data)
+	}
+	if out == nil {
+		return nil
+	}
+	if raw, ok := out.(*[]byte); ok {
+		if *raw, err = io.ReadAll(reader); err != nil {
+			return fmt.Errorf("openai: read %s response: %w", path, err)
+		}
+		return nil
+	}
+	if err := json.NewDecoder(reader).Decode(out); err != nil {
+		return fmt.Errorf("openai: decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// open sends the request and returns the response once a 2xx status has
+// been received. The caller must close the body.
+func (c *Client) open(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	var data []byte
+	contentType := "application/json"
+	if upload, ok := body.(*fileUpload); ok {
+		data, contentType = upload.data, upload.contentType
+		reader = bytes.NewReader(data)
+	} else if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("openai: marshal %s request: %w", path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("openai: create %s request: %w", path, err)
+	}
+	utils.ApplyHeaders(req.Header, c.config.Headers, c.config.UserAgent)
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	if c.config.Organization != "" {
+		req.Header.Set("OpenAI-Organization", c.config.Organization)
+	}
+	if c.config.Project != "" {
+		req.Header.Set("OpenAI-Project", c.config.Project)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.logsWire() {
+		c.logRequest(req, data)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai: %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		if c.logsWire() {
+			c.logResponse(method, path, resp, data)
+		}
+		return nil, newAPIError(method, path, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return resp, nil
+}
@@ -0,0 +1,258 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestRetriesStopWhenBudgetExhausted(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{
+		APIKey:      "test",
+		BaseURL:     srv.URL,
+		MaxRetries:  5,
+		RetryBudget: utils.NewRetryBudget(0, 1),
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	_, err = client.CreateChatCompletion(context.Background(), testChatRequest())
+	if !errors.Is(err, utils.ErrRetryBudgetExhausted) {
+		t.Fatalf("CreateChatCompletion = %v, want ErrRetryBudgetExhausted", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("server called %d times, want 2", n)
+	}
+}
+
+func TestOnlyIdempotentRequestsRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL, MaxRetries: 1})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	for _, tc := range []struct {
+		method, path string
+		calls        int32
+	}{
+		{http.MethodGet, "/models", 2},
+		{http.MethodPost, "/embeddings", 2},
+		{http.MethodPost, "/files", 1},
+		{http.MethodPost, "/batches/batch-1/cancel", 1},
+	} {
+		calls.Store(0)
+		if err := client.doRequest(context.Background(), tc.method, tc.path, nil, nil); err == nil {
+			t.Fatalf("%s %s succeeded", tc.method, tc.path)
+		}
+		if n := calls.Load(); n != tc.calls {
+			t.Fatalf("%s %s sent %d times, want %d", tc.method, tc.path, n, tc.calls)
+		}
+	}
+}
+
+func TestOrganizationAndProjectHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"choices":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL, Organization: "org-1", Project: "proj-1"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), testChatRequest()); err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+	if got.Get("OpenAI-Organization") != "org-1" || got.Get("OpenAI-Project") != "proj-1" {
+		t.Fatalf("headers = %v", got)
+	}
+
+	if _, err := NewClient(&ClientConfig{APIKey: "test", Project: "  "}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewClient with blank project = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestSeedAndSystemFingerprint(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"x"}}],"system_fingerprint":"fp_1"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	req := testChatRequest()
+	seed := int64(0)
+	req.Seed = &seed
+	resp, err := client.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+	if body["seed"] != float64(0) || resp.SystemFingerprint != "fp_1" {
+		t.Fatalf("seed sent = %v, fingerprint = %q", body["seed"], resp.SystemFingerprint)
+	}
+
+	seed = -1
+	if _, err := client.CreateChatCompletion(context.Background(), req); err == nil {
+		t.Fatal("negative seed was accepted")
+	}
+}
+
+func TestLogitBias(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"x"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	req := testChatRequest()
+	req.LogitBias = map[string]float64{"50256": -100, "1734": 2.5}
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+	if bias, _ := body["logit_bias"].(map[string]interface{}); bias["50256"] != float64(-100) || bias["1734"] != 2.5 {
+		t.Fatalf("logit_bias sent = %v", body["logit_bias"])
+	}
+
+	for _, bias := range []map[string]float64{{"50256": -101}, {"50256": 100.5}, {"token": 1}, {"-1": 1}, {"007": 1}} {
+		req.LogitBias = bias
+		if _, err := client.CreateChatCompletion(context.Background(), req); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("logit bias %v: err = %v, want ErrInvalidRequest", bias, err)
+		}
+	}
+	req.LogitBias = nil
+	req.Stop = []string{"a", "b", "c", "d", "e"}
+	if _, err := client.CreateChatCompletion(context.Background(), req); err == nil {
+		t.Error("five stop sequences were accepted")
+	}
+}
+
+func TestFallbackModels(t *testing.T) {
+	var models []string
+	status := map[string]int{"primary": http.StatusServiceUnavailable, "backup": http.StatusOK}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		models = append(models, body.Model)
+		if code := status[body.Model]; code != http.StatusOK {
+			http.Error(w, "unavailable", code)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"x"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{
+		APIKey:         "test",
+		BaseURL:        srv.URL,
+		Model:          "primary",
+		FallbackModels: []string{"primary", "backup"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err := client.CreateChatCompletion(context.Background(), testChatRequest())
+	if err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+	if resp.Model != "backup" || len(models) != 2 || models[0] != "primary" {
+		t.Fatalf("served by %q after trying %v", resp.Model, models)
+	}
+	if n := client.GetMetrics()["model_fallbacks"]; n != uint64(1) {
+		t.Fatalf("model_fallbacks = %v, want 1", n)
+	}
+
+	// Client errors are the request's fault and are not retried elsewhere.
+	models = nil
+	status["primary"] = http.StatusBadRequest
+	if _, err := client.CreateChatCompletion(context.Background(), testChatRequest()); err == nil {
+		t.Fatal("CreateChatCompletion succeeded on a 400")
+	}
+	if len(models) != 1 {
+		t.Fatalf("tried %v after a 400, want only the primary", models)
+	}
+}
+
+func TestOperationTimeouts(t *testing.T) {
+	deadlines := make(chan time.Duration, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[],"data":[{"index":0,"embedding":[0.1]}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{
+		APIKey:   "test",
+		BaseURL:  srv.URL,
+		Timeout:  time.Minute,
+		Timeouts: OperationTimeouts{Embeddings: 5 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	// The server cannot see the request deadline; the transport reports it.
+	client.httpClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		deadline, _ := r.Context().Deadline()
+		deadlines <- time.Until(deadline).Round(time.Second)
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	if _, err := client.CreateEmbedding(context.Background(), &EmbeddingRequest{Input: []string{"a"}}); err != nil {
+		t.Fatalf("CreateEmbedding: %v", err)
+	}
+	if d := <-deadlines; d != 5*time.Second {
+		t.Fatalf("embedding timeout = %v, want 5s", d)
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), testChatRequest()); err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+	if d := <-deadlines; d != time.Minute {
+		t.Fatalf("chat timeout = %v, want the global 1m", d)
+	}
+
+	// The caller's deadline wins over both.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	if _, err := client.CreateEmbedding(ctx, &EmbeddingRequest{Input: []string{"a"}}); err != nil {
+		t.Fatalf("CreateEmbedding: %v", err)
+	}
+	if d := <-deadlines; d != time.Hour {
+		t.Fatalf("embedding timeout with caller deadline = %v, want 1h", d)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,60 @@
+package openai
+
+import "context"
+
+// Completer is the minimal interface a chat model provider must satisfy.
+// Code that only needs completions, such as Conversation and engine
+// handlers, depends on it rather than on Client, so a local model, another
+// vendor, or a test fake can stand in for OpenAI.
+//
+// A provider must:
+//   - return a response with at least one choice from CreateChatCompletion,
+//     or an error;
+//   - honour ctx cancellation in both methods;
+//   - fill in a default model when the request leaves Model empty.
+//
+// Requests and responses use this package's types; fields a provider does
+// not support may be ignored.
+type Completer interface {
+	CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error)
+	CreateChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (ChatStream, error)
+}
+
+// ChatStream is a streamed completion as seen through a Completer. Recv
+// returns chunks until io.EOF or another error; Close releases the stream
+// and must always be called.
+type ChatStream interface {
+	Recv() (*ChatCompletionStreamResponse, error)
+	Close() error
+}
+
+// NewCompleter returns client as a Completer. It is the default provider;
+// OpenAI-specific methods such as CreateEmbedding stay on Client.
+func NewCompleter(client *Client) Completer {
+	return clientCompleter{client}
+}
+
+// clientCompleter adapts Client, whose stream method returns the concrete
+// *ChatCompletionStream.
+type clientCompleter struct {
+	client *Client
+}
+
+// ListModels lists the client's models, so a health check can reach the
+// API through the Completer.
+func (c clientCompleter) ListModels(ctx context.Context) ([]Model, error) {
+	return c.client.ListModels(ctx)
+}
+
+func (c clientCompleter) CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	return c.client.CreateChatCompletion(ctx, req)
+}
+
+func (c clientCompleter) CreateChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (ChatStream, error) {
+	stream, err := c.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		// Avoid returning a non-nil interface holding a nil pointer.
+		return nil, err
+	}
+	return stream, nil
+}
@@ -0,0 +1,67 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// fakeCompleter replies with a fixed message, or fails when err is set.
+type fakeCompleter struct {
+	reply string
+	err   error
+}
+
+func (f *fakeCompleter) CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ChatCompletionResponse{Choices: []ChatCompletionChoice{{Message: ChatMessage{Role: RoleAssistant, Content: f.reply}}}}, nil
+}
+
+func (f *fakeCompleter) CreateChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (ChatStream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestConversationWithFakeCompleter(t *testing.T) {
+	conv := NewConversation("", ConversationSettings{})
+	fake := &fakeCompleter{err: errors.New("provider down")}
+	if _, err := conv.Send(context.Background(), fake, "hi"); err == nil {
+		t.Fatal("Send succeeded with a failing provider")
+	}
+	if n := len(conv.Messages()); n != 0 {
+		t.Fatalf("history has %d messages after a failed send, want 0", n)
+	}
+
+	fake.err = nil
+	fake.reply = "hello"
+	reply, err := conv.Send(context.Background(), fake, "hi")
+	if err != nil || reply.Content != "hello" {
+		t.Fatalf("Send = %+v, %v", reply, err)
+	}
+}
+
+func TestNewCompleterStream(t *testing.T) {
+	completer := NewCompleter(newStreamServer(t, "a", "b"))
+	stream, err := completer.CreateChatCompletionStream(context.Background(), testChatRequest())
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream: %v", err)
+	}
+	defer stream.Close()
+
+	var content string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		content += chunk.Choices[0].Delta.Content
+	}
+	if content != "ab" {
+		t.Fatalf("content = %q, want %q", content, "ab")
+	}
+}
@@ -0,0 +1,174 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// conversationVersion is the current persisted conversation format.
+const conversationVersion = 1
+
+// ConversationSettings are the model parameters sent with every turn of a
+// Conversation. Zero values leave the API or client defaults in place.
+type ConversationSettings struct {
+	Model       string   `json:"model,omitempty"`
+	Temperature float32  `json:"temperature,omitempty"`
+	TopP        float32  `json:"top_p,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int64   `json:"seed,omitempty"`
+}
+
+// Conversation is a chat session: a system prompt, model settings, and the
+// message history. It is safe for concurrent use and round-trips through
+// JSON, so a session can be saved and resumed later.
+type Conversation struct {
+	mu       sync.Mutex
+	system   string
+	settings ConversationSettings
+	messages []ChatMessage
+	// generation changes whenever existing history is replaced rather
+	// than appended to.
+	generation uint64
+}
+
+// NewConversation starts a conversation. systemPrompt may be empty.
+func NewConversation(systemPrompt string, settings ConversationSettings) *Conversation {
+	return &Conversation{system: systemPrompt, settings: settings}
+}
+
+// SystemPrompt returns the conversation's system prompt.
+func (c *Conversation) SystemPrompt() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.system
+}
+
+// Settings returns the conversation's model settings.
+func (c *Conversation) Settings() ConversationSettings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.settings
+}
+
+// Messages returns a copy of the history, excluding the system prompt.
+func (c *Conversation) Messages() []ChatMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ChatMessage(nil), c.messages...)
+}
+
+// Append adds messages to the history.
+func (c *Conversation) Append(messages ...ChatMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, messages...)
+}
+
+// Request builds a chat completion request from the system prompt, the
+// history, and the settings.
+func (c *Conversation) Request() *ChatCompletionRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	messages := make([]ChatMessage, 0, len(c.messages)+1)
+	if c.system != "" {
+		messages = append(messages, ChatMessage{Role: RoleSystem, Content: c.system})
+	}
+	messages = append(messages, c.messages...)
+	req := &ChatCompletionRequest{
+		Model:       c.settings.Model,
+		Messages:    messages,
+		MaxTokens:   c.settings.MaxTokens,
+		Temperature: c.settings.Temperature,
+		TopP:        c.settings.TopP,
+		Stop:        append([]string(nil), c.settings.Stop...),
+	}
+	if c.settings.Seed != nil {
+		seed := *c.settings.Seed
+		req.Seed = &seed
+	}
+	return req
+}
+
+// Send adds a user message, requests a completion from completer, and
+// records the reply. On error the history is left as it was before the call.
+func (c *Conversation) Send(ctx context.Context, completer Completer, content string) (*ChatMessage, error) {
+	c.mu.Lock()
+	index := len(c.messages)
+	c.messages = append(c.messages, ChatMessage{Role: RoleUser, Content: content})
+	c.mu.Unlock()
+
+	resp, err := completer.CreateChatCompletion(ctx, c.Request())
+	if err == nil && len(resp.Choices) == 0 {
+		err = errors.New("openai: completion returned no choices")
+	}
+	if err != nil {
+		c.mu.Lock()
+		c.messages = append(c.messages[:index], c.messages[index+1:]...)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	reply := resp.Choices[0].Message
+	c.Append(reply)
+	return &reply, nil
+}
+
+type conversationJSON struct {
+	Version      int                  `json:"version"`
+	SystemPrompt string               `json:"system_prompt,omitempty"`
+	Settings     ConversationSettings `json:"settings"`
+	Messages     []ChatMessage        `json:"messages"`
+}
+
+// MarshalJSON encodes the conversation with a format version.
+func (c *Conversation) MarshalJSON() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return json.Marshal(conversationJSON{
+		Version:      conversationVersion,
+		SystemPrompt: c.system,
+		Settings:     c.settings,
+		Messages:     c.messages,
+	})
+}
+
+// UnmarshalJSON restores a conversation encoded by MarshalJSON. Unknown
+// fields are ignored, so additions that keep the format version still load;
+// a newer format version is rejected.
+func (c *Conversation) UnmarshalJSON(data []byte) error {
+	var v conversationJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("openai: decode conversation: %w", err)
+	}
+	if v.Version > conversationVersion {
+		return fmt.Errorf("openai: conversation format version %d is newer than supported version %d", v.Version, conversationVersion)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.system = v.SystemPrompt
+	c.settings = v.Settings
+	c.messages = v.Messages
+	c.generation++
+	return nil
+}
+
+// Save writes the conversation to w as JSON.
+func (c *Conversation) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(c)
+}
+
+// LoadConversation reads a conversation written by Save.
+func LoadConversation(r io.Reader) (*Conversation, error) {
+	c := &Conversation{}
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
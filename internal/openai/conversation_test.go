@@ -0,0 +1,68 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConversationRoundTrip(t *testing.T) {
+	conv := NewConversation("You are terse.", ConversationSettings{Model: "m", Temperature: 0.2, MaxTokens: 64})
+	conv.Append(
+		ChatMessage{Role: RoleUser, Content: "balance?"},
+		ChatMessage{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "c1", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_balance", Arguments: `{"address":"x"}`}}}},
+		ChatMessage{Role: RoleTool, ToolCallID: "c1", Content: `{"sol":1}`},
+	)
+
+	var buf bytes.Buffer
+	if err := conv.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadConversation(&buf)
+	if err != nil {
+		t.Fatalf("LoadConversation: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Request(), conv.Request()) {
+		t.Fatalf("loaded request = %+v, want %+v", loaded.Request(), conv.Request())
+	}
+
+	future := `{"version":1,"system_prompt":"s","settings":{"model":"m"},"messages":[],"tags":["new"]}`
+	if _, err := LoadConversation(strings.NewReader(future)); err != nil {
+		t.Fatalf("unknown fields were not ignored: %v", err)
+	}
+	if _, err := LoadConversation(strings.NewReader(`{"version":99}`)); err == nil {
+		t.Fatal("newer format version was accepted")
+	}
+}
+
+func TestConversationSummarize(t *testing.T) {
+	conv := NewConversation("You are terse.", ConversationSettings{Model: "m"})
+	for i := 0; i < 20; i++ {
+		conv.Append(
+			ChatMessage{Role: RoleUser, Content: strings.Repeat("question ", 40)},
+			ChatMessage{Role: RoleAssistant, Content: strings.Repeat("answer ", 40)},
+		)
+	}
+	opts := SummarizeOptions{MaxTokens: 500}
+	fake := &fakeCompleter{reply: "The user asked many questions."}
+
+	done, err := conv.Summarize(context.Background(), fake, opts)
+	if err != nil || !done {
+		t.Fatalf("Summarize = %v, %v; want true, nil", done, err)
+	}
+	req := conv.Request()
+	if got := EstimateTokens(req.Messages...); got > opts.MaxTokens {
+		t.Fatalf("history is %d tokens after summarizing, budget %d", got, opts.MaxTokens)
+	}
+	msgs := conv.Messages()
+	if len(msgs) != DefaultSummaryKeep+1 || msgs[0].Role != RoleSystem || !strings.Contains(msgs[0].Content, fake.reply) {
+		t.Fatalf("history after summarizing = %+v", msgs)
+	}
+
+	done, err = conv.Summarize(context.Background(), fake, opts)
+	if err != nil || done {
+		t.Fatalf("Summarize under budget = %v, %v; want false, nil", done, err)
+	}
+}
@@ -0,0 +1,41 @@
+package openai
+
+import (
+	"net/http"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// logsWire reports whether requests and responses are logged, as set by
+// ClientConfig.LogBodies.
+func (c *Client) logsWire() bool {
+	return c.config.LogBodies && c.logger.Enabled(utils.DEBUG)
+}
+
+// logRequest logs req, whose body is body, with credentials redacted.
+func (c *Client) logRequest(req *http.Request, body []byte) {
+	fields := map[string]interface{}{
+		"method":  req.Method,
+		"url":     c.redactor.String(req.URL.String()),
+		"headers": c.redactor.Headers(req.Header),
+	}
+	if body != nil {
+		fields["body"] = c.redactor.Body(body, c.config.LogBodyLimit)
+	}
+	c.logger.Debug("HTTP request", fields)
+}
+
+// logResponse logs resp to the request method and path with credentials
+// redacted. A nil body, as for a stream, is left out.
+func (c *Client) logResponse(method, path string, resp *http.Response, body []byte) {
+	fields := map[string]interface{}{
+		"method":  method,
+		"path":    path,
+		"status":  resp.StatusCode,
+		"headers": c.redactor.Headers(resp.Header),
+	}
+	if body != nil {
+		fields["body"] = c.redactor.Body(body, c.config.LogBodyLimit)
+	}
+	c.logger.Debug("HTTP response", fields)
+}
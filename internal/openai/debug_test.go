@@ -0,0 +1,59 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestLogBodiesRedactsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"` + strings.Repeat("x", 100) + `"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var out bytes.Buffer
+	client, err := NewClient(&ClientConfig{
+		APIKey:       "sk-very-secret",
+		BaseURL:      srv.URL,
+		Headers:      map[string]string{"X-Api-Key": "gateway-key", "X-Session": "s1"},
+		LogBodies:    true,
+		LogBodyLimit: 64,
+		RedactFields: []string{"user"},
+	}, WithLogger(utils.NewLogger(utils.WithOutput(&out), utils.WithLevel(utils.DEBUG))))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	req := testChatRequest()
+	req.User = "alice@example.com"
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+
+	logged := out.String()
+	for _, want := range []string{"HTTP request", "HTTP response", "X-Session:s1", "bytes truncated", utils.Redacted} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log lacks %q:\n%s", want, logged)
+		}
+	}
+	for _, secret := range []string{"sk-very-secret", "gateway-key", "alice@example.com"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("log leaks %q:\n%s", secret, logged)
+		}
+	}
+
+	// Without DEBUG nothing is logged.
+	out.Reset()
+	client.logger.SetLevel(utils.INFO)
+	if _, err := client.CreateChatCompletion(context.Background(), testChatRequest()); err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("logged at INFO:\n%s", out.String())
+	}
+}
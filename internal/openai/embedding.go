@@ -0,0 +1,158 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// Embedding defaults and the per-request limits CreateEmbedding splits
+// batches to stay under.
+const (
+	DefaultEmbeddingModel       = "text-embedding-3-small"
+	DefaultEmbeddingConcurrency = 4
+	MaxEmbeddingInputs          = 2048
+	MaxEmbeddingBatchTokens     = 300000
+)
+
+// EmbeddingRequest is a request to the embeddings endpoint.
+type EmbeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+	User       string   `json:"user,omitempty"`
+}
+
+// Embedding is the vector for one input.
+type Embedding struct {
+	Index     int       `json:"index"`
+	Object    string    `json:"object"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// EmbeddingResponse is the response from the embeddings endpoint. Data is
+// ordered like the request's Input.
+type EmbeddingResponse struct {
+	Object string      `json:"object"`
+	Model  string      `json:"model"`
+	Data   []Embedding `json:"data"`
+	Usage  Usage       `json:"usage"`
+}
+
+// CreateEmbedding embeds req.Input. Inputs beyond a single API call's
+// limits are split into several calls, run at most
+// ClientConfig.EmbeddingConcurrency at a time, and joined back in input
+// order with their token usage summed. If any call fails the others are
+// cancelled and the first error is returned.
+func (c *Client) CreateEmbedding(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if req == nil || len(req.Input) == 0 {
+		return nil, fmt.Errorf("%w: embedding requires at least one input", ErrInvalidRequest)
+	}
+	model := req.Model
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+
+	batches := splitEmbeddingInput(req.Input, MaxEmbeddingInputs, MaxEmbeddingBatchTokens)
+	resp := &EmbeddingResponse{
+		Object: "list",
+		Model:  model,
+		Data:   make([]Embedding, len(req.Input)),
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, c.config.EmbeddingConcurrency)
+	)
+	for _, b := range batches {
+		wg.Add(1)
+		go func(b embeddingBatch) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			body := *req
+			body.Model = model
+			body.Input = req.Input[b.start:b.end]
+			var part EmbeddingResponse
+			err := c.doRequest(ctx, http.MethodPost, "/embeddings", &body, &part)
+			if err == nil && len(part.Data) != len(body.Input) {
+				err = fmt.Errorf("openai: embeddings returned %d vectors for %d inputs", len(part.Data), len(body.Input))
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			for _, e := range part.Data {
+				if e.Index < 0 || e.Index >= len(body.Input) {
+					continue
+				}
+				e.Index += b.start
+				resp.Data[e.Index] = e
+			}
+			resp.Model = part.Model
+			resp.Usage.PromptTokens += part.Usage.PromptTokens
+			resp.Usage.TotalTokens += part.Usage.TotalTokens
+		}(b)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.recordUsage(utils.UsageRecorderFrom(ctx), resp.Model, resp.Usage)
+	return resp, nil
+}
+
+// embeddingBatch is the input range [start, end) sent in one API call.
+type embeddingBatch struct {
+	start, end int
+}
+
+// splitEmbeddingInput groups consecutive inputs into batches of at most
+// maxInputs entries and an estimated maxTokens tokens. An input larger than
+// maxTokens on its own gets a batch to itself.
+func splitEmbeddingInput(input []string, maxInputs, maxTokens int) []embeddingBatch {
+	var (
+		batches []embeddingBatch
+		start   int
+		tokens  int
+	)
+	for i, s := range input {
+		n := estimateTokens(s)
+		if i > start && (i-start >= maxInputs || tokens+n > maxTokens) {
+			batches = append(batches, embeddingBatch{start, i})
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	return append(batches, embeddingBatch{start, len(input)})
+}
+
+// estimateTokens approximates the token count of s at three bytes per
+// token, rounding up. Typical English averages closer to four, so the
+// estimate errs high and keeps batches under the limit.
+func estimateTokens(s string) int {
+	return (len(s) + 2) / 3
+}
@@ -0,0 +1,69 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSplitEmbeddingInput(t *testing.T) {
+	input := []string{"aaa", "aaa", "aaa", strings.Repeat("a", 30), "aaa"}
+	got := splitEmbeddingInput(input, 2, 5)
+	want := []embeddingBatch{{0, 2}, {2, 3}, {3, 4}, {4, 5}}
+	if len(got) != len(want) {
+		t.Fatalf("batches = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("batches = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCreateEmbeddingChunksInOrder(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		resp := EmbeddingResponse{Model: req.Model, Usage: Usage{PromptTokens: len(req.Input), TotalTokens: len(req.Input)}}
+		// Answer out of order to check results are placed by index.
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			v, _ := strconv.Atoi(req.Input[i])
+			resp.Data = append(resp.Data, Embedding{Index: i, Embedding: []float32{float32(v)}})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL, EmbeddingConcurrency: 2})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	input := make([]string, MaxEmbeddingInputs*2+5)
+	for i := range input {
+		input[i] = strconv.Itoa(i)
+	}
+	resp, err := client.CreateEmbedding(context.Background(), &EmbeddingRequest{Input: input})
+	if err != nil {
+		t.Fatalf("CreateEmbedding: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("API calls = %d, want 3", n)
+	}
+	for i, e := range resp.Data {
+		if e.Index != i || e.Embedding[0] != float32(i) {
+			t.Fatalf("embedding %d = %+v", i, e)
+		}
+	}
+	if resp.Usage.TotalTokens != len(input) {
+		t.Fatalf("total tokens = %d, want %d", resp.Usage.TotalTokens, len(input))
+	}
+}
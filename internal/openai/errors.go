@@ -0,0 +1,116 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// API error categories. An *APIError matches the one it falls in with
+// errors.Is, e.g. errors.Is(err, ErrRateLimited).
+var (
+	// ErrRateLimited is a 429 response: too many requests or tokens, or an
+	// exhausted quota, told apart by APIError.Code ("insufficient_quota").
+	ErrRateLimited = errors.New("openai: rate limited")
+	// ErrInvalidRequest is a 4xx response to a malformed request or one
+	// naming an unknown model; APIError.Param names the offending
+	// parameter, if any. Requests the client rejects before sending them
+	// wrap it too.
+	ErrInvalidRequest = errors.New("openai: invalid request")
+	// ErrAuthentication is a 401 or 403 response: the API key is missing,
+	// invalid, or lacks access to the organization, project, or model.
+	ErrAuthentication = errors.New("openai: authentication failed")
+	// ErrServerError is a 5xx response, or a server error reported in a
+	// stream.
+	ErrServerError = errors.New("openai: server error")
+)
+
+// APIError is an error response from the API. Its fields are parsed from
+// the response's error object when it has one; Body keeps the response as
+// received, for logging.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int // zero for an error reported mid-stream
+
+	Code    string
+	Type    string
+	Message string
+	Param   string
+
+	Body []byte
+}
+
+func newAPIError(method, path string, statusCode int, body []byte) *APIError {
+	e := &APIError{Method: method, Path: path, StatusCode: statusCode, Body: body}
+	var envelope struct {
+		Error *apiErrorObject `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error != nil {
+		envelope.Error.fill(e)
+	}
+	return e
+}
+
+// apiErrorObject is the error object of an API response.
+type apiErrorObject struct {
+	Code    json.RawMessage `json:"code"`
+	Type    string          `json:"type"`
+	Message string          `json:"message"`
+	Param   *string         `json:"param"`
+}
+
+func (o *apiErrorObject) fill(e *APIError) {
+	e.Type, e.Message = o.Type, o.Message
+	if o.Param != nil {
+		e.Param = *o.Param
+	}
+	// The code is usually a string, but null and numbers occur too.
+	if err := json.Unmarshal(o.Code, &e.Code); err != nil && string(o.Code) != "null" {
+		e.Code = string(o.Code)
+	}
+}
+
+func (e *APIError) Error() string {
+	detail := e.Message
+	if detail == "" {
+		detail = string(e.Body)
+	}
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("openai: stream error (%s): %s", e.Type, detail)
+	}
+	return fmt.Sprintf("openai: %s %s: status %d: %s", e.Method, e.Path, e.StatusCode, detail)
+}
+
+// Is reports whether target is the category of e.
+func (e *APIError) Is(target error) bool {
+	return target != nil && target == e.category()
+}
+
+// category returns the category sentinel of e, going by the status code
+// and, without a telling one, the error type. It is nil when neither fits.
+func (e *APIError) category() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		return ErrAuthentication
+	case e.StatusCode >= 500:
+		return ErrServerError
+	case e.StatusCode >= 400:
+		return ErrInvalidRequest
+	}
+	switch {
+	case strings.Contains(e.Type, "rate_limit"), e.Type == "insufficient_quota":
+		return ErrRateLimited
+	case strings.Contains(e.Type, "authentication"), strings.Contains(e.Type, "permission"):
+		return ErrAuthentication
+	case strings.Contains(e.Type, "server_error"), e.Type == "api_error":
+		return ErrServerError
+	case strings.Contains(e.Type, "invalid_request"):
+		return ErrInvalidRequest
+	}
+	return nil
+}
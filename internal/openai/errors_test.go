@@ -0,0 +1,93 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	var status int
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	tests := []struct {
+		status   int
+		body     string
+		category error
+		want     APIError
+	}{
+		{
+			status:   http.StatusTooManyRequests,
+			body:     `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","param":null,"code":"insufficient_quota"}}`,
+			category: ErrRateLimited,
+			want:     APIError{Code: "insufficient_quota", Type: "insufficient_quota", Message: "You exceeded your current quota"},
+		},
+		{
+			status:   http.StatusUnauthorized,
+			body:     `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			category: ErrAuthentication,
+			want:     APIError{Code: "invalid_api_key", Type: "invalid_request_error", Message: "Incorrect API key provided"},
+		},
+		{
+			status:   http.StatusBadRequest,
+			body:     `{"error":{"message":"Invalid value for 'temperature'","type":"invalid_request_error","param":"temperature","code":null}}`,
+			category: ErrInvalidRequest,
+			want:     APIError{Type: "invalid_request_error", Message: "Invalid value for 'temperature'", Param: "temperature"},
+		},
+		{
+			status:   http.StatusBadGateway,
+			body:     `<html>bad gateway</html>`,
+			category: ErrServerError,
+		},
+	}
+	categories := []error{ErrRateLimited, ErrAuthentication, ErrInvalidRequest, ErrServerError}
+	for _, tt := range tests {
+		status, body = tt.status, tt.body
+		_, err := client.CreateChatCompletion(context.Background(), testChatRequest())
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("status %d: err = %v, want an *APIError", tt.status, err)
+		}
+		if apiErr.StatusCode != tt.status || apiErr.Code != tt.want.Code || apiErr.Type != tt.want.Type ||
+			apiErr.Message != tt.want.Message || apiErr.Param != tt.want.Param || string(apiErr.Body) != tt.body {
+			t.Errorf("status %d: parsed %+v", tt.status, apiErr)
+		}
+		for _, category := range categories {
+			if errors.Is(err, category) != (category == tt.category) {
+				t.Errorf("status %d: errors.Is(err, %v) = %v", tt.status, category, !(category == tt.category))
+			}
+		}
+	}
+}
+
+func TestStreamAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "data: {\"error\":{\"message\":\"The server had an error\",\"type\":\"server_error\"}}\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	client, _ := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatRequest())
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream: %v", err)
+	}
+	defer stream.Close()
+	_, err = stream.Recv()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "The server had an error" || !errors.Is(err, ErrServerError) {
+		t.Fatalf("Recv = %v, want a server APIError", err)
+	}
+}
@@ -0,0 +1,42 @@
+package openai
+
+import (
+	"context"
+	"errors"
+)
+
+// withFallback calls fn with primary and then with each of the configured
+// FallbackModels while fn fails with a server error, and returns the model
+// of the last call.
+func (c *Client) withFallback(ctx context.Context, primary string, fn func(model string) error) (string, error) {
+	models := []string{primary}
+	for _, model := range c.config.FallbackModels {
+		if model != "" && model != primary {
+			models = append(models, model)
+		}
+	}
+
+	var err error
+	for i, model := range models {
+		if err = fn(model); err == nil || i == len(models)-1 || !fallbackable(ctx, err) {
+			return model, err
+		}
+		c.metrics.fallbacks.Add(1)
+		c.logger.Warn("Falling back to next model", map[string]interface{}{
+			"model":    model,
+			"fallback": models[i+1],
+			"error":    err.Error(),
+		})
+	}
+	return primary, err
+}
+
+// fallbackable reports whether err means the model could not serve the
+// request right now: a 5xx status while ctx is still live.
+func fallbackable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 500
+}
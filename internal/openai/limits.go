@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Defaults for RequestLimits fields left at zero.
+const (
+	DefaultMaxPromptBytes    = 1 << 20
+	DefaultMaxResponseTokens = 16384
+)
+
+// ErrPromptTooLarge is returned when a request's messages exceed
+// RequestLimits.MaxPromptBytes and cannot be truncated to fit.
+var ErrPromptTooLarge = errors.New("openai: prompt exceeds size limit")
+
+// RequestLimits guards spend on chat completions. Limits are checked before
+// a request is sent.
+type RequestLimits struct {
+	// MaxPromptBytes caps the combined size of all messages: content,
+	// names, and tool call arguments. Zero uses DefaultMaxPromptBytes.
+	MaxPromptBytes int
+	// MaxResponseTokens caps max_tokens. Requests that leave MaxTokens
+	// unset or ask for more are sent with this value. Zero uses
+	// DefaultMaxResponseTokens.
+	MaxResponseTokens int
+	// TruncatePrompt drops the oldest messages, keeping system messages
+	// and the final message, until the prompt fits. Without it an
+	// oversized prompt is rejected with ErrPromptTooLarge.
+	TruncatePrompt bool
+}
+
+// apply enforces the limits on body, which the caller owns.
+func (l *RequestLimits) apply(body *ChatCompletionRequest) error {
+	if l == nil {
+		return nil
+	}
+
+	maxTokens := l.MaxResponseTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxResponseTokens
+	}
+	if body.MaxTokens <= 0 || body.MaxTokens > maxTokens {
+		body.MaxTokens = maxTokens
+	}
+
+	maxBytes := l.MaxPromptBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxPromptBytes
+	}
+	size := promptBytes(body.Messages)
+	if size <= maxBytes {
+		return nil
+	}
+	if !l.TruncatePrompt {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrPromptTooLarge, size, maxBytes)
+	}
+
+	messages := append([]ChatMessage(nil), body.Messages...)
+	last := len(messages) - 1
+	for i := 0; i < last && size > maxBytes; {
+		if messages[i].Role == RoleSystem {
+			i++
+			continue
+		}
+		size -= messageBytes(messages[i])
+		messages = append(messages[:i], messages[i+1:]...)
+		last--
+		// Tool results cannot outlive the assistant message that
+		// requested them.
+		for i < last && messages[i].Role == RoleTool {
+			size -= messageBytes(messages[i])
+			messages = append(messages[:i], messages[i+1:]...)
+			last--
+		}
+	}
+	if size > maxBytes {
+		return fmt.Errorf("%w: %d bytes after truncation, limit %d", ErrPromptTooLarge, size, maxBytes)
+	}
+	body.Messages = messages
+	return nil
+}
+
+func promptBytes(messages []ChatMessage) int {
+	n := 0
+	for _, m := range messages {
+		n += messageBytes(m)
+	}
+	return n
+}
+
+func messageBytes(m ChatMessage) int {
+	n := len(m.Content) + len(m.Name)
+	for _, call := range m.ToolCalls {
+		n += len(call.Function.Name) + len(call.Function.Arguments)
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package openai
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRequestLimits(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	newRequest := func() *ChatCompletionRequest {
+		return &ChatCompletionRequest{
+			MaxTokens: 5000,
+			Messages: []ChatMessage{
+				{Role: RoleSystem, Content: "sys"},
+				{Role: RoleUser, Content: long},
+				{Role: RoleAssistant, ToolCalls: []ToolCall{{Function: FunctionCall{Name: "f", Arguments: "{}"}}}},
+				{Role: RoleTool, Content: long},
+				{Role: RoleUser, Content: "latest"},
+			},
+		}
+	}
+
+	reject := &RequestLimits{MaxPromptBytes: 50, MaxResponseTokens: 1000}
+	if err := reject.apply(newRequest()); !errors.Is(err, ErrPromptTooLarge) {
+		t.Fatalf("apply = %v, want ErrPromptTooLarge", err)
+	}
+
+	truncate := &RequestLimits{MaxPromptBytes: 50, MaxResponseTokens: 1000, TruncatePrompt: true}
+	req := newRequest()
+	if err := truncate.apply(req); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if req.MaxTokens != 1000 {
+		t.Fatalf("MaxTokens = %d, want 1000", req.MaxTokens)
+	}
+	if len(req.Messages) != 2 || req.Messages[0].Role != RoleSystem || req.Messages[1].Content != "latest" {
+		t.Fatalf("messages = %+v, want system and latest only", req.Messages)
+	}
+
+	var disabled *RequestLimits
+	req = newRequest()
+	if err := disabled.apply(req); err != nil || req.MaxTokens != 5000 || len(req.Messages) != 5 {
+		t.Fatalf("nil limits changed the request: %v %+v", err, req)
+	}
+}
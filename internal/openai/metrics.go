@@ -0,0 +1,151 @@
+package openai
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// clientMetrics records API call counts, token usage, and per-endpoint
+// latency.
+type clientMetrics struct {
+	requests         atomic.Uint64
+	errors           atomic.Uint64
+	promptTokens     atomic.Uint64
+	completionTokens atomic.Uint64
+	fallbacks        atomic.Uint64
+	policyRejections atomic.Uint64
+	// partialCompletions counts streams cancelled after content arrived.
+	partialCompletions atomic.Uint64
+
+	buckets []float64
+	latency *utils.Histogram
+	window  *utils.SlidingWindow
+
+	mu         sync.Mutex
+	byEndpoint map[string]*utils.Histogram
+}
+
+func newClientMetrics(buckets []float64, window time.Duration) *clientMetrics {
+	return &clientMetrics{
+		buckets:    buckets,
+		latency:    utils.NewHistogram(buckets),
+		window:     utils.NewSlidingWindow(window, 0, nil),
+		byEndpoint: make(map[string]*utils.Histogram),
+	}
+}
+
+func (m *clientMetrics) observe(endpoint string, d time.Duration, err error) {
+	m.requests.Add(1)
+	if err != nil {
+		m.errors.Add(1)
+	}
+	m.latency.Observe(d)
+	m.window.Record(err != nil)
+
+	m.mu.Lock()
+	h, ok := m.byEndpoint[endpoint]
+	if !ok {
+		h = utils.NewHistogram(m.buckets)
+		m.byEndpoint[endpoint] = h
+	}
+	m.mu.Unlock()
+	h.Observe(d)
+}
+
+// endpointLabel returns the endpoint path is a call to, with object IDs
+// replaced so that batches and files do not each get a histogram.
+func endpointLabel(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) > 2 && (parts[1] == "batches" || parts[1] == "files") {
+		parts[2] = "{id}"
+	}
+	return strings.Join(parts, "/")
+}
+
+func (m *clientMetrics) addUsage(u Usage) {
+	m.promptTokens.Add(uint64(u.PromptTokens))
+	m.completionTokens.Add(uint64(u.CompletionTokens))
+}
+
+func (m *clientMetrics) endpointSnapshots() map[string]utils.HistogramSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]utils.HistogramSnapshot, len(m.byEndpoint))
+	for endpoint, h := range m.byEndpoint {
+		out[endpoint] = h.Snapshot()
+	}
+	return out
+}
+
+func (m *clientMetrics) reset() {
+	m.requests.Store(0)
+	m.errors.Store(0)
+	m.promptTokens.Store(0)
+	m.completionTokens.Store(0)
+	m.fallbacks.Store(0)
+	m.policyRejections.Store(0)
+	m.partialCompletions.Store(0)
+	m.latency.Reset()
+	m.window.Reset()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byEndpoint = make(map[string]*utils.Histogram)
+}
+
+// GetMetrics returns API counters, token usage, and latency summaries.
+// "window" counts calls and errors over the last MetricsWindow only.
+// "model_fallbacks" counts chat completions moved to a FallbackModels entry.
+// "policy_rejections" counts chat completions rejected by the Policy.
+// "partial_completions" counts streamed completions cancelled after some
+// content arrived, which ChatCompletionStream.Response still returns.
+func (c *Client) GetMetrics() map[string]interface{} {
+	return map[string]interface{}{
+		"requests_total":      c.metrics.requests.Load(),
+		"errors_total":        c.metrics.errors.Load(),
+		"prompt_tokens":       c.metrics.promptTokens.Load(),
+		"completion_tokens":   c.metrics.completionTokens.Load(),
+		"latency":             c.metrics.latency.Snapshot(),
+		"latency_by_endpoint": c.metrics.endpointSnapshots(),
+		"window":              c.metrics.window.Snapshot(),
+		"open_connections":    c.transport.OpenConnections(),
+		"model_fallbacks":     c.metrics.fallbacks.Load(),
+		"policy_rejections":   c.metrics.policyRejections.Load(),
+		"partial_completions": c.metrics.partialCompletions.Load(),
+	}
+}
+
+// ResetMetrics zeroes all counters and histograms, e.g. between tests.
+func (c *Client) ResetMetrics() {
+	c.metrics.reset()
+}
+
+// CollectPrometheus implements utils.PrometheusCollector.
+func (c *Client) CollectPrometheus(w *utils.PrometheusWriter) {
+	w.Counter("openai_requests_total", "Total OpenAI API calls.", float64(c.metrics.requests.Load()), nil)
+	w.Counter("openai_errors_total", "Failed OpenAI API calls.", float64(c.metrics.errors.Load()), nil)
+	w.Counter("openai_tokens_total", "OpenAI tokens consumed.", float64(c.metrics.promptTokens.Load()),
+		map[string]string{"kind": "prompt"})
+	w.Counter("openai_tokens_total", "OpenAI tokens consumed.", float64(c.metrics.completionTokens.Load()),
+		map[string]string{"kind": "completion"})
+	w.Gauge("openai_open_connections", "Open connections in the shared API transport pool.", float64(c.transport.OpenConnections()), nil)
+	w.Counter("openai_model_fallbacks_total", "Chat completions retried on a fallback model.", float64(c.metrics.fallbacks.Load()), nil)
+	w.Counter("openai_partial_completions_total", "Streamed completions cancelled after content arrived.", float64(c.metrics.partialCompletions.Load()), nil)
+	w.Counter("openai_policy_rejections_total", "Chat completions rejected by the request policy.", float64(c.metrics.policyRejections.Load()), nil)
+
+	snaps := c.metrics.endpointSnapshots()
+	endpoints := make([]string, 0, len(snaps))
+	for endpoint := range snaps {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		w.Histogram("openai_request_latency_seconds", "OpenAI API call latency.", snaps[endpoint],
+			map[string]string{"endpoint": endpoint})
+	}
+}
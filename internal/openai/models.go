@@ -0,0 +1,26 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+)
+
+// Model describes a model available to the API key.
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// ListModels returns the models available to the API key. It is a cheap
+// way to check that the API is reachable and the key is accepted.
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	var resp struct {
+		Data []Model `json:"data"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, "/models", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
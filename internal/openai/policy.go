@@ -0,0 +1,89 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPolicyViolation is returned when ClientConfig.Policy rejects a request.
+// It wraps the policy's own error.
+var ErrPolicyViolation = errors.New("openai: request rejected by policy")
+
+// RequestPolicy governs the chat completions a client sends. It runs on
+// every attempt, after the default model, Limits, and any fallback model
+// have been applied, and sees the request as it will be sent. It may change
+// req, e.g. to clamp parameters, or return an error to reject it. ctx is the
+// caller's, so a policy can tell callers apart by context values.
+type RequestPolicy func(ctx context.Context, req *ChatCompletionRequest) error
+
+// PolicyRules is a RequestPolicy for the common rules; pass its Apply
+// method as ClientConfig.Policy. A model entry also matches the dated
+// snapshots of that model, so "gpt-4o" matches "gpt-4o-2024-08-06" but not
+// "gpt-4o-mini".
+type PolicyRules struct {
+	// AllowedModels, when not empty, are the only models requests may use.
+	AllowedModels []string
+	// DeniedModels are models requests may not use.
+	DeniedModels []string
+	// MaxTemperature, when positive, clamps Temperature. An unset
+	// temperature uses the API default of 1.
+	MaxTemperature float32
+	// MaxTokens, when positive, caps MaxTokens. Requests that leave it
+	// unset are sent with this value.
+	MaxTokens int
+}
+
+// Apply enforces r on req. It implements RequestPolicy.
+func (r PolicyRules) Apply(ctx context.Context, req *ChatCompletionRequest) error {
+	if len(r.AllowedModels) > 0 && !matchesModel(r.AllowedModels, req.Model) {
+		return fmt.Errorf("model %q is not allowed", req.Model)
+	}
+	if matchesModel(r.DeniedModels, req.Model) {
+		return fmt.Errorf("model %q is denied", req.Model)
+	}
+	if r.MaxTemperature > 0 {
+		// Zero is omitted from the request, which means 1 to the API.
+		if req.Temperature > r.MaxTemperature || req.Temperature == 0 && r.MaxTemperature < 1 {
+			req.Temperature = r.MaxTemperature
+		}
+	}
+	if r.MaxTokens > 0 && (req.MaxTokens <= 0 || req.MaxTokens > r.MaxTokens) {
+		req.MaxTokens = r.MaxTokens
+	}
+	return nil
+}
+
+// matchesModel reports whether model is one of names or a dated snapshot of
+// one.
+func matchesModel(names []string, model string) bool {
+	for _, name := range names {
+		if model == name {
+			return true
+		}
+		if rest := strings.TrimPrefix(model, name+"-"); rest != model && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPolicy runs the configured policy on req, which the caller owns.
+func (c *Client) applyPolicy(ctx context.Context, req *ChatCompletionRequest) error {
+	if c.config.Policy == nil {
+		return nil
+	}
+	if err := c.config.Policy(ctx, req); err != nil {
+		c.metrics.policyRejections.Add(1)
+		c.logger.Warn("Request rejected by policy", map[string]interface{}{
+			"model": req.Model,
+			"error": err.Error(),
+		})
+		if errors.Is(err, ErrPolicyViolation) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrPolicyViolation, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestPolicy(t *testing.T) {
+	var sent []ChatCompletionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body)
+		if body.Model == "primary" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"x"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	type trust struct{}
+	rules := PolicyRules{
+		AllowedModels:  []string{"gpt-4o-mini", "primary", "gpt-4o"},
+		DeniedModels:   []string{"gpt-4o"},
+		MaxTemperature: 0.5,
+		MaxTokens:      100,
+	}
+	client, err := NewClient(&ClientConfig{
+		APIKey:         "test",
+		BaseURL:        srv.URL,
+		Model:          "gpt-4o-mini",
+		FallbackModels: []string{"gpt-4o-2024-08-06"},
+		Limits:         &RequestLimits{MaxResponseTokens: 1000},
+		Policy: func(ctx context.Context, req *ChatCompletionRequest) error {
+			if ctx.Value(trust{}) != nil {
+				return nil
+			}
+			return rules.Apply(ctx, req)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	req := testChatRequest()
+	req.Temperature = 1.5
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+	// The policy sees the default model and the Limits' max tokens.
+	if got := sent[0]; got.Model != "gpt-4o-mini" || got.Temperature != 0.5 || got.MaxTokens != 100 {
+		t.Fatalf("sent model %q, temperature %v, max tokens %d", got.Model, got.Temperature, got.MaxTokens)
+	}
+	if req.Temperature != 1.5 {
+		t.Fatal("policy changed the caller's request")
+	}
+
+	for _, model := range []string{"gpt-4o", "gpt-4o-2024-08-06", "gpt-4-turbo"} {
+		req := testChatRequest()
+		req.Model = model
+		if _, err := client.CreateChatCompletion(context.Background(), req); !errors.Is(err, ErrPolicyViolation) {
+			t.Errorf("model %s: err = %v, want ErrPolicyViolation", model, err)
+		}
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d requests, want rejected ones kept back", len(sent))
+	}
+
+	// Fallback models are governed too.
+	sent = nil
+	req = testChatRequest()
+	req.Model = "primary"
+	if _, err := client.CreateChatCompletion(context.Background(), req); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("denied fallback: err = %v, want ErrPolicyViolation", err)
+	}
+	if len(sent) != 1 || sent[0].Model != "primary" {
+		t.Fatalf("sent %+v, want only the primary", sent)
+	}
+
+	trusted := context.WithValue(context.Background(), trust{}, true)
+	if _, err := client.CreateChatCompletion(trusted, &ChatCompletionRequest{Model: "gpt-4o", Messages: req.Messages}); err != nil {
+		t.Fatalf("trusted caller: %v", err)
+	}
+	if n := client.GetMetrics()["policy_rejections"]; n != uint64(4) {
+		t.Fatalf("policy_rejections = %v, want 4", n)
+	}
+}
@@ -0,0 +1,61 @@
+package openai
+
+import (
+	"strings"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	Prompt     float64 `yaml:"prompt"`
+	Completion float64 `yaml:"completion"`
+}
+
+// Cost returns the estimated cost of u in USD.
+func (p ModelPrice) Cost(u Usage) float64 {
+	return (float64(u.PromptTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion) / 1e6
+}
+
+// DefaultPricing holds list prices of common models, used to estimate the
+// cost of requests. Prices change; set ClientConfig.Pricing to override or
+// extend them. A model without a price is reported at zero cost.
+var DefaultPricing = map[string]ModelPrice{
+	"gpt-4o":                 {Prompt: 2.50, Completion: 10.00},
+	"gpt-4o-mini":            {Prompt: 0.15, Completion: 0.60},
+	"gpt-4-turbo":            {Prompt: 10.00, Completion: 30.00},
+	"gpt-4":                  {Prompt: 30.00, Completion: 60.00},
+	"gpt-3.5-turbo":          {Prompt: 0.50, Completion: 1.50},
+	"text-embedding-3-small": {Prompt: 0.02},
+	"text-embedding-3-large": {Prompt: 0.13},
+	"text-embedding-ada-002": {Prompt: 0.10},
+}
+
+// price returns the price of model from ClientConfig.Pricing, then
+// DefaultPricing. Dated snapshots such as "gpt-4o-2024-08-06" take the
+// price of the longest model name they start with.
+func (c *Client) price(model string) (ModelPrice, bool) {
+	for _, table := range []map[string]ModelPrice{c.config.Pricing, DefaultPricing} {
+		if p, ok := table[model]; ok {
+			return p, true
+		}
+		best := ""
+		for name := range table {
+			if len(name) > len(best) && strings.HasPrefix(model, name+"-") {
+				best = name
+			}
+		}
+		if best != "" {
+			return table[best], true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// recordUsage adds u to the client's token metrics and, with its estimated
+// cost, to the request usage recorder, if any.
+func (c *Client) recordUsage(recorder *utils.UsageRecorder, model string, u Usage) {
+	c.metrics.addUsage(u)
+	price, _ := c.price(model)
+	recorder.AddTokens(uint64(u.PromptTokens), uint64(u.CompletionTokens), price.Cost(u))
+}
@@ -0,0 +1,313 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaViolation is one way a value breaks a JSON schema. Path locates the
+// offending value, e.g. "$.recipients[2].amount".
+type SchemaViolation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ToolArgumentsError reports the arguments of a call to Tool that do not
+// match its schema. It wraps ErrInvalidToolArguments.
+type ToolArgumentsError struct {
+	Tool       string
+	Violations []SchemaViolation
+}
+
+func (e *ToolArgumentsError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Path + ": " + v.Message
+	}
+	return fmt.Sprintf("%v for %s: %s", ErrInvalidToolArguments, e.Tool, strings.Join(msgs, "; "))
+}
+
+func (e *ToolArgumentsError) Unwrap() error {
+	return ErrInvalidToolArguments
+}
+
+// ValidateJSONSchema checks the JSON document value against schema and
+// returns every violation found, or nil if it conforms. It supports the
+// keywords tool definitions commonly use: type, enum, const, properties,
+// required, additionalProperties, items, minItems, maxItems, minLength,
+// maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, allOf, anyOf, and oneOf. Other keywords, including
+// $ref, are ignored. An empty schema accepts anything. A pattern that is not
+// a valid regular expression fails every string it applies to.
+func ValidateJSONSchema(schema, value json.RawMessage) ([]SchemaViolation, error) {
+	if len(bytes.TrimSpace(schema)) == 0 {
+		return nil, nil
+	}
+	var s map[string]interface{}
+	if err := decodeJSONNumbers(schema, &s); err != nil {
+		return nil, fmt.Errorf("openai: decode schema: %w", err)
+	}
+	var v interface{}
+	if err := decodeJSONNumbers(value, &v); err != nil {
+		return []SchemaViolation{{Path: "$", Message: "not valid JSON: " + err.Error()}}, nil
+	}
+	var violations []SchemaViolation
+	validateSchema(s, v, "$", &violations)
+	return violations, nil
+}
+
+func decodeJSONNumbers(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+func validateSchema(schema map[string]interface{}, v interface{}, path string, out *[]SchemaViolation) {
+	fail := func(format string, args ...interface{}) {
+		*out = append(*out, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, v) {
+		fail("expected %s, got %s", describeType(t), jsonType(v))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if jsonEqual(allowed, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of %s", encodeJSON(enum))
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, v) {
+		fail("must be %s", encodeJSON(c))
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		validateObject(schema, v, path, out)
+	case []interface{}:
+		if n, ok := schemaInt(schema, "minItems"); ok && len(v) < n {
+			fail("must have at least %d items", n)
+		}
+		if n, ok := schemaInt(schema, "maxItems"); ok && len(v) > n {
+			fail("must have at most %d items", n)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i), out)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n, ok := schemaInt(schema, "minLength"); ok && length < n {
+			fail("must be at least %d characters", n)
+		}
+		if n, ok := schemaInt(schema, "maxLength"); ok && length > n {
+			fail("must be at most %d characters", n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err != nil {
+				fail("cannot be checked: schema pattern %q is invalid: %v", pattern, err)
+			} else if !re.MatchString(v) {
+				fail("must match %q", pattern)
+			}
+		}
+	case json.Number:
+		validateNumber(schema, v, fail)
+	}
+
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		subs, ok := schema[key].([]interface{})
+		if !ok {
+			continue
+		}
+		matched := 0
+		var first []SchemaViolation
+		for _, sub := range subs {
+			subSchema, ok := sub.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var subOut []SchemaViolation
+			validateSchema(subSchema, v, path, &subOut)
+			if len(subOut) == 0 {
+				matched++
+			} else if first == nil {
+				first = subOut
+			}
+			if key == "allOf" {
+				*out = append(*out, subOut...)
+			}
+		}
+		switch {
+		case key == "anyOf" && matched == 0:
+			fail("matches none of the allowed schemas")
+			*out = append(*out, first...)
+		case key == "oneOf" && matched != 1:
+			fail("must match exactly one of the allowed schemas, matches %d", matched)
+		}
+	}
+}
+
+func validateObject(schema, obj map[string]interface{}, path string, out *[]SchemaViolation) {
+	props, _ := schema["properties"].(map[string]interface{})
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := obj[name]; !present {
+					*out = append(*out, SchemaViolation{Path: path + "." + name, Message: "is required"})
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fieldPath := path + "." + name
+		if prop, ok := props[name].(map[string]interface{}); ok {
+			validateSchema(prop, obj[name], fieldPath, out)
+			continue
+		}
+		if _, declared := props[name]; declared {
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				*out = append(*out, SchemaViolation{Path: fieldPath, Message: "is not allowed"})
+			}
+		case map[string]interface{}:
+			validateSchema(extra, obj[name], fieldPath, out)
+		}
+	}
+}
+
+func validateNumber(schema map[string]interface{}, n json.Number, fail func(string, ...interface{})) {
+	value, ok := new(big.Float).SetString(n.String())
+	if !ok {
+		return
+	}
+	bound := func(key string) (*big.Float, bool) {
+		b, ok := schema[key].(json.Number)
+		if !ok {
+			return nil, false
+		}
+		return new(big.Float).SetString(b.String())
+	}
+	if b, ok := bound("minimum"); ok && value.Cmp(b) < 0 {
+		fail("must be at least %s", b.Text('g', -1))
+	}
+	if b, ok := bound("maximum"); ok && value.Cmp(b) > 0 {
+		fail("must be at most %s", b.Text('g', -1))
+	}
+	if b, ok := bound("exclusiveMinimum"); ok && value.Cmp(b) <= 0 {
+		fail("must be greater than %s", b.Text('g', -1))
+	}
+	if b, ok := bound("exclusiveMaximum"); ok && value.Cmp(b) >= 0 {
+		fail("must be less than %s", b.Text('g', -1))
+	}
+}
+
+func schemaInt(schema map[string]interface{}, key string) (int, bool) {
+	n, ok := schema[key].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	i, err := n.Int64()
+	return int(i), err == nil
+}
+
+// matchesType reports whether v has the schema type t, a name or a list of
+// names.
+func matchesType(t interface{}, v interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		actual := jsonType(v)
+		if t == "number" && actual == "integer" {
+			return true
+		}
+		return t == actual
+	case []interface{}:
+		for _, name := range t {
+			if matchesType(name, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func describeType(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// jsonType returns the schema type name of a decoded value. A number
+// without a fractional part is an integer.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, ok := new(big.Float).SetString(v.String()); ok && f.IsInt() {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func jsonEqual(a, b interface{}) bool {
+	if na, ok := a.(json.Number); ok {
+		nb, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fa, okA := new(big.Float).SetString(na.String())
+		fb, okB := new(big.Float).SetString(nb.String())
+		return okA && okB && fa.Cmp(fb) == 0
+	}
+	return encodeJSON(a) == encodeJSON(b)
+}
+
+func encodeJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
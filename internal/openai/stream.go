@@ -0,0 +1,315 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+var (
+	// ErrStreamAborted is returned by CreateChatCompletionStreamFunc when
+	// the callback returns an error. The callback's error is also wrapped.
+	ErrStreamAborted = errors.New("openai: stream aborted by callback")
+	// ErrStreamCancelled is returned by Recv when the stream's context is
+	// cancelled or its deadline passes mid-stream. The context's error is
+	// also wrapped, and the content received so far is kept in Response.
+	ErrStreamCancelled = errors.New("openai: stream cancelled")
+)
+
+// ChatCompletionStreamDelta is the incremental content of a streamed choice.
+type ChatCompletionStreamDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// ChatCompletionStreamChoice is one choice within a streamed chunk.
+type ChatCompletionStreamChoice struct {
+	Index        int                       `json:"index"`
+	Delta        ChatCompletionStreamDelta `json:"delta"`
+	FinishReason string                    `json:"finish_reason,omitempty"`
+}
+
+// ChatCompletionStreamResponse is a single streamed chunk.
+type ChatCompletionStreamResponse struct {
+	ID      string                       `json:"id"`
+	Object  string                       `json:"object"`
+	Created int64                        `json:"created"`
+	Model   string                       `json:"model"`
+	Choices []ChatCompletionStreamChoice `json:"choices"`
+	Usage   *Usage                       `json:"usage,omitempty"`
+	// SystemFingerprint is as in ChatCompletionResponse.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+}
+
+// ChatCompletionStream reads server-sent chunks of a streamed completion.
+type ChatCompletionStream struct {
+	client *Client
+	resp   *http.Response
+	reader *bufio.Reader
+	ctx    context.Context
+	cancel context.CancelFunc
+	start  time.Time
+	model  string
+	// recorder is the usage recorder of the context the stream was
+	// opened with.
+	recorder *utils.UsageRecorder
+
+	once  sync.Once
+	err   error
+	usage *Usage
+	// partial accumulates the chunks received, as Response returns them.
+	partial ChatCompletionResponse
+	choices []streamedChoice
+}
+
+// streamedChoice accumulates one choice of a stream.
+type streamedChoice struct {
+	role         string
+	content      []byte
+	finishReason string
+}
+
+// CreateChatCompletionStream starts a streamed chat completion. The caller
+// must call Recv until it returns io.EOF or another error, and must Close the
+// stream. Unless req.StreamOptions says otherwise the stream ends with a
+// usage chunk, which is added to the client's token metrics and available
+// from Usage.
+func (c *Client) CreateChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionStream, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	body := *req
+	body.Stream = true
+	if body.StreamOptions == nil {
+		body.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+	if body.Model == "" {
+		body.Model = c.config.Model
+	}
+	if err := c.config.Limits.apply(&body); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	recorder := utils.UsageRecorderFrom(ctx)
+	var resp *http.Response
+	_, err := c.withFallback(ctx, body.Model, func(model string) error {
+		attempt := body
+		attempt.Model = model
+		if err := c.applyPolicy(ctx, &attempt); err != nil {
+			return err
+		}
+		var err error
+		opened := time.Now()
+		resp, err = c.open(ctx, http.MethodPost, "/chat/completions", &attempt)
+		// Only the wait for the response headers counts; the stream is
+		// read at the caller's pace.
+		recorder.AddOpenAIRequest(time.Since(opened))
+		if err == nil && c.logsWire() {
+			c.logResponse(http.MethodPost, "/chat/completions", resp, nil)
+		}
+		body.Model = attempt.Model
+		return err
+	})
+	if err != nil {
+		cancel()
+		c.metrics.observe("/chat/completions", time.Since(start), err)
+		return nil, err
+	}
+
+	return &ChatCompletionStream{
+		client:   c,
+		resp:     resp,
+		reader:   bufio.NewReader(resp.Body),
+		ctx:      ctx,
+		cancel:   cancel,
+		start:    start,
+		model:    body.Model,
+		recorder: recorder,
+	}, nil
+}
+
+// Recv returns the next chunk, or io.EOF once the stream is complete. If
+// the stream's context ends first it returns ErrStreamCancelled, and
+// Response holds the partial completion.
+func (s *ChatCompletionStream) Recv() (*ChatCompletionStreamResponse, error) {
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			switch {
+			case s.ctx.Err() != nil:
+				err = fmt.Errorf("%w: %w", ErrStreamCancelled, context.Cause(s.ctx))
+				if s.received() {
+					s.client.metrics.partialCompletions.Add(1)
+				}
+			case errors.Is(err, io.EOF):
+				err = io.ErrUnexpectedEOF
+			}
+			s.finish(err)
+			return nil, err
+		}
+
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if string(data) == "[DONE]" {
+			s.finish(nil)
+			return nil, io.EOF
+		}
+
+		var envelope struct {
+			ChatCompletionStreamResponse
+			Error *apiErrorObject `json:"error"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			err = fmt.Errorf("openai: decode stream chunk: %w", err)
+			s.finish(err)
+			return nil, err
+		}
+		if envelope.Error != nil {
+			apiErr := &APIError{Body: data}
+			envelope.Error.fill(apiErr)
+			s.finish(apiErr)
+			return nil, apiErr
+		}
+		if envelope.Usage != nil {
+			s.usage = envelope.Usage
+			model := envelope.Model
+			if model == "" {
+				model = s.model
+			}
+			s.client.recordUsage(s.recorder, model, *envelope.Usage)
+		}
+		s.accumulate(&envelope.ChatCompletionStreamResponse)
+		return &envelope.ChatCompletionStreamResponse, nil
+	}
+}
+
+// accumulate adds chunk to the completion assembled by Response.
+func (s *ChatCompletionStream) accumulate(chunk *ChatCompletionStreamResponse) {
+	s.partial.ID, s.partial.Created, s.partial.Model = chunk.ID, chunk.Created, chunk.Model
+	if chunk.SystemFingerprint != "" {
+		s.partial.SystemFingerprint = chunk.SystemFingerprint
+	}
+	if chunk.Usage != nil {
+		s.partial.Usage = *chunk.Usage
+	}
+	for _, choice := range chunk.Choices {
+		if choice.Index < 0 {
+			continue
+		}
+		for len(s.choices) <= choice.Index {
+			s.choices = append(s.choices, streamedChoice{role: RoleAssistant})
+		}
+		c := &s.choices[choice.Index]
+		if choice.Delta.Role != "" {
+			c.role = choice.Delta.Role
+		}
+		if choice.FinishReason != "" {
+			c.finishReason = choice.FinishReason
+		}
+		c.content = append(c.content, choice.Delta.Content...)
+	}
+}
+
+// received reports whether any content has arrived.
+func (s *ChatCompletionStream) received() bool {
+	for i := range s.choices {
+		if len(s.choices[i].content) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Response returns the completion assembled from the chunks received so
+// far: the whole completion once Recv has returned io.EOF, and the partial
+// one, without a finish reason, if the stream was cancelled or failed
+// first. It always holds at least the first choice. Response is not safe
+// to call concurrently with Recv.
+func (s *ChatCompletionStream) Response() *ChatCompletionResponse {
+	resp := s.partial
+	resp.Object = "chat.completion"
+	resp.Choices = []ChatCompletionChoice{{Message: ChatMessage{Role: RoleAssistant}}}
+	if len(s.choices) > 0 {
+		resp.Choices = make([]ChatCompletionChoice, len(s.choices))
+		for i := range s.choices {
+			c := &s.choices[i]
+			resp.Choices[i] = ChatCompletionChoice{
+				Index:        i,
+				Message:      ChatMessage{Role: c.role, Content: string(c.content)},
+				FinishReason: c.finishReason,
+			}
+		}
+	}
+	return &resp
+}
+
+// Usage returns the token usage reported by the stream, or nil if none has
+// arrived. The usage chunk is the last before the end of the stream, so it
+// is complete once Recv has returned io.EOF. Usage is not safe to call
+// concurrently with Recv.
+func (s *ChatCompletionStream) Usage() *Usage {
+	return s.usage
+}
+
+// Close releases the underlying connection.
+func (s *ChatCompletionStream) Close() error {
+	s.finish(nil)
+	return nil
+}
+
+// finish records the stream's latency once and releases the connection.
+func (s *ChatCompletionStream) finish(err error) {
+	s.once.Do(func() {
+		s.err = err
+		s.client.metrics.observe("/chat/completions", time.Since(s.start), err)
+		s.cancel()
+		s.resp.Body.Close()
+	})
+}
+
+// CreateChatCompletionStreamFunc streams a chat completion, invoking onDelta
+// with each piece of generated content of the first choice, and returns the
+// assembled response. If onDelta returns an error the stream is closed and
+// the response assembled so far is returned with an error wrapping both
+// ErrStreamAborted and the callback's error. If ctx ends mid-stream the
+// partial response is returned with ErrStreamCancelled.
+func (c *Client) CreateChatCompletionStreamFunc(ctx context.Context, req *ChatCompletionRequest, onDelta func(content string) error) (*ChatCompletionResponse, error) {
+	stream, err := c.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.Response(), nil
+		}
+		if err != nil {
+			return stream.Response(), err
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Index != 0 || choice.Delta.Content == "" {
+				continue
+			}
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return stream.Response(), fmt.Errorf("%w: %w", ErrStreamAborted, err)
+			}
+		}
+	}
+}
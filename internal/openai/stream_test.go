@@ -0,0 +1,153 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newStreamServer(t *testing.T, deltas ...string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		for _, d := range deltas {
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", d)
+		}
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func testChatRequest() *ChatCompletionRequest {
+	return &ChatCompletionRequest{Messages: []ChatMessage{{Role: RoleUser, Content: "hi"}}}
+}
+
+func TestCreateChatCompletionStreamFunc(t *testing.T) {
+	client := newStreamServer(t, "Hel", "lo", "!")
+
+	var got []string
+	resp, err := client.CreateChatCompletionStreamFunc(context.Background(), testChatRequest(), func(content string) error {
+		got = append(got, content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStreamFunc: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("callback invoked %d times, want 3", len(got))
+	}
+	if resp.Choices[0].Message.Content != "Hello!" || resp.Choices[0].FinishReason != "stop" {
+		t.Fatalf("assembled = %+v", resp.Choices[0])
+	}
+}
+
+func TestCreateChatCompletionStreamFuncAbort(t *testing.T) {
+	client := newStreamServer(t, "a", "b", "c")
+	stop := errors.New("enough")
+
+	resp, err := client.CreateChatCompletionStreamFunc(context.Background(), testChatRequest(), func(content string) error {
+		if content == "b" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, ErrStreamAborted) || !errors.Is(err, stop) {
+		t.Fatalf("err = %v, want ErrStreamAborted wrapping callback error", err)
+	}
+	if resp.Choices[0].Message.Content != "ab" {
+		t.Fatalf("partial content = %q, want %q", resp.Choices[0].Message.Content, "ab")
+	}
+}
+
+func TestStreamUsage(t *testing.T) {
+	var body ChatCompletionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"model\":\"m\",\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":2,\"total_tokens\":9}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatRequest())
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream: %v", err)
+	}
+	defer stream.Close()
+	for {
+		if _, err := stream.Recv(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+	}
+
+	if body.StreamOptions == nil || !body.StreamOptions.IncludeUsage {
+		t.Fatalf("stream_options = %+v, want include_usage", body.StreamOptions)
+	}
+	if u := stream.Usage(); u == nil || u.PromptTokens != 7 || u.CompletionTokens != 2 || u.TotalTokens != 9 {
+		t.Fatalf("Usage = %+v", u)
+	}
+	m := client.GetMetrics()
+	if m["prompt_tokens"] != uint64(7) || m["completion_tokens"] != uint64(2) {
+		t.Fatalf("token metrics = %v, %v", m["prompt_tokens"], m["completion_tokens"])
+	}
+}
+
+func TestStreamCancelledKeepsPartialResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, d := range []string{"Hel", "lo"} {
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", d)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	client, err := NewClient(&ClientConfig{APIKey: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.CreateChatCompletionStream(ctx, testChatRequest())
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream: %v", err)
+	}
+	defer stream.Close()
+	for i := 0; i < 2; i++ {
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+	}
+	cancel()
+	if _, err := stream.Recv(); !errors.Is(err, ErrStreamCancelled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Recv after cancel = %v, want ErrStreamCancelled", err)
+	}
+	resp := stream.Response()
+	if resp.ID != "c1" || resp.Choices[0].Message.Content != "Hello" || resp.Choices[0].FinishReason != "" {
+		t.Fatalf("partial response = %+v", resp)
+	}
+	if n := client.GetMetrics()["partial_completions"]; n != uint64(1) {
+		t.Fatalf("partial_completions = %v, want 1", n)
+	}
+}
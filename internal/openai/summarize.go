@@ -0,0 +1,146 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultSummaryPrompt instructs the model how to summarize older turns
+// when SummarizeOptions.Prompt is empty.
+const DefaultSummaryPrompt = "Summarize the conversation below for your own future reference. " +
+	"Keep every fact, decision, open question, and user preference needed to continue it; " +
+	"omit pleasantries. Reply with the summary only."
+
+// DefaultSummaryKeep is how many recent messages Summarize keeps verbatim
+// when SummarizeOptions.Turns is zero.
+const DefaultSummaryKeep = 4
+
+// summaryPrefix marks the system message holding a summary.
+const summaryPrefix = "Summary of the earlier conversation:\n"
+
+// ErrConversationChanged is returned by Summarize when the history was
+// summarized or replaced by another caller while the summary was being
+// generated. The history is left as the other caller made it.
+var ErrConversationChanged = errors.New("openai: conversation changed during summarization")
+
+// SummarizeOptions configures Conversation.Summarize.
+type SummarizeOptions struct {
+	// MaxTokens is the estimated size, in tokens, of the system prompt and
+	// history above which Summarize acts. It must be positive.
+	MaxTokens int
+	// Turns is how many of the oldest messages are replaced by the
+	// summary. Zero summarizes all but the DefaultSummaryKeep most recent.
+	Turns int
+	// Prompt is the instruction sent with the turns to summarize. Empty
+	// uses DefaultSummaryPrompt.
+	Prompt string
+	// Model overrides the conversation's model for the summary, e.g. to
+	// use a cheaper one.
+	Model string
+}
+
+// EstimateTokens approximates the tokens messages take up in a request,
+// at about four characters per token plus a small per-message overhead.
+// It is meant for budgeting, not billing.
+func EstimateTokens(messages ...ChatMessage) int {
+	tokens := 0
+	for _, m := range messages {
+		chars := len(m.Role) + len(m.ToolCallID) + messageBytes(m)
+		tokens += 4 + (chars+3)/4
+	}
+	return tokens
+}
+
+// Summarize keeps a long conversation within opts.MaxTokens. When the
+// estimated size of the system prompt and history exceeds it, the oldest
+// opts.Turns messages are sent to completer to be summarized and replaced
+// by a single system message holding the summary; an earlier summary is
+// folded into the new one. It reports whether the history was summarized.
+//
+// The cut never separates tool results from the assistant message that
+// requested them, so it may cover a few more messages than opts.Turns.
+// Messages appended while the summary is generated are kept. On error the
+// history is unchanged.
+func (c *Conversation) Summarize(ctx context.Context, completer Completer, opts SummarizeOptions) (bool, error) {
+	if opts.MaxTokens <= 0 {
+		return false, errors.New("openai: summarize requires a positive MaxTokens")
+	}
+	if opts.Prompt == "" {
+		opts.Prompt = DefaultSummaryPrompt
+	}
+
+	c.mu.Lock()
+	size := EstimateTokens(c.messages...)
+	if c.system != "" {
+		size += EstimateTokens(ChatMessage{Role: RoleSystem, Content: c.system})
+	}
+	cut := opts.Turns
+	if cut <= 0 {
+		cut = len(c.messages) - DefaultSummaryKeep
+	}
+	if cut > len(c.messages) {
+		cut = len(c.messages)
+	}
+	for cut > 0 && cut < len(c.messages) && c.messages[cut].Role == RoleTool {
+		cut++
+	}
+	if size <= opts.MaxTokens || cut < 2 {
+		c.mu.Unlock()
+		return false, nil
+	}
+	turns := append([]ChatMessage(nil), c.messages[:cut]...)
+	generation := c.generation
+	model := c.settings.Model
+	c.mu.Unlock()
+
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	resp, err := completer.CreateChatCompletion(ctx, &ChatCompletionRequest{
+		Model: model,
+		Messages: []ChatMessage{
+			{Role: RoleSystem, Content: opts.Prompt},
+			{Role: RoleUser, Content: transcript(turns)},
+		},
+	})
+	if err == nil && len(resp.Choices) == 0 {
+		err = errors.New("openai: completion returned no choices")
+	}
+	if err != nil {
+		return false, fmt.Errorf("openai: summarize %d messages: %w", len(turns), err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return false, ErrConversationChanged
+	}
+	summary := ChatMessage{Role: RoleSystem, Content: summaryPrefix + strings.TrimSpace(resp.Choices[0].Message.Content)}
+	c.messages = append([]ChatMessage{summary}, c.messages[cut:]...)
+	c.generation++
+	return true, nil
+}
+
+// transcript renders messages as plain text for the summarizer.
+func transcript(messages []ChatMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		content := strings.TrimPrefix(m.Content, summaryPrefix)
+		switch {
+		case m.Role == RoleSystem:
+			fmt.Fprintf(&b, "[earlier summary] %s\n", content)
+		case len(m.ToolCalls) > 0:
+			for _, call := range m.ToolCalls {
+				fmt.Fprintf(&b, "%s called %s(%s)\n", m.Role, call.Function.Name, call.Function.Arguments)
+			}
+			if content != "" {
+				fmt.Fprintf(&b, "%s: %s\n", m.Role, content)
+			}
+		default:
+			fmt.Fprintf(&b, "%s: %s\n", m.Role, content)
+		}
+	}
+	return b.String()
+}
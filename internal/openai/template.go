@@ -0,0 +1,148 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+var (
+	// ErrTemplateNotFound is returned when rendering an unregistered template.
+	ErrTemplateNotFound = errors.New("openai: prompt template not found")
+	// ErrTemplateExists is returned when registering a duplicate name.
+	ErrTemplateExists = errors.New("openai: prompt template already registered")
+	// ErrInvalidTemplateFunc is returned when registering a helper that
+	// text/template cannot call.
+	ErrInvalidTemplateFunc = errors.New("openai: invalid template function")
+)
+
+// MessageTemplate is the source of one templated chat message.
+type MessageTemplate struct {
+	Role    string
+	Content string
+}
+
+// PromptTemplate renders a sequence of chat messages from text/template
+// sources. Referencing a key missing from the data map is an error.
+type PromptTemplate struct {
+	name     string
+	roles    []string
+	contents []*template.Template
+}
+
+// NewPromptTemplate parses messages into a named template using funcs as
+// helper functions. funcs may be nil.
+func NewPromptTemplate(name string, funcs template.FuncMap, messages ...MessageTemplate) (*PromptTemplate, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("prompt template %q: no messages", name)
+	}
+
+	p := &PromptTemplate{name: name}
+	for i, m := range messages {
+		if m.Role == "" {
+			return nil, fmt.Errorf("prompt template %q: message %d has no role", name, i)
+		}
+		tmpl, err := template.New(fmt.Sprintf("%s[%d]", name, i)).
+			Funcs(funcs).
+			Option("missingkey=error").
+			Parse(m.Content)
+		if err != nil {
+			return nil, fmt.Errorf("prompt template %q: %w", name, err)
+		}
+		p.roles = append(p.roles, m.Role)
+		p.contents = append(p.contents, tmpl)
+	}
+	return p, nil
+}
+
+// Name returns the template name.
+func (p *PromptTemplate) Name() string {
+	return p.name
+}
+
+// Render executes the template against data.
+func (p *PromptTemplate) Render(data map[string]interface{}) ([]ChatMessage, error) {
+	messages := make([]ChatMessage, 0, len(p.contents))
+	for i, tmpl := range p.contents {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("render prompt template %q: %w", p.name, err)
+		}
+		messages = append(messages, ChatMessage{Role: p.roles[i], Content: b.String()})
+	}
+	return messages, nil
+}
+
+// TemplateRegistry holds prompt templates by name, sharing a set of helper
+// functions. It is safe for concurrent use.
+type TemplateRegistry struct {
+	mu        sync.RWMutex
+	funcs     template.FuncMap
+	templates map[string]*PromptTemplate
+}
+
+// NewTemplateRegistry creates an empty registry.
+func NewTemplateRegistry() *TemplateRegistry {
+	return &TemplateRegistry{
+		funcs:     template.FuncMap{},
+		templates: make(map[string]*PromptTemplate),
+	}
+}
+
+// RegisterFunc adds a helper function available to templates registered
+// afterwards. fn must be a function returning one value, or a value and an
+// error, as text/template requires; anything else is rejected with
+// ErrInvalidTemplateFunc.
+func (r *TemplateRegistry) RegisterFunc(name string, fn interface{}) error {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return fmt.Errorf("%w: %q is a %T, not a function", ErrInvalidTemplateFunc, name, fn)
+	}
+	switch {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == reflect.TypeOf((*error)(nil)).Elem():
+	default:
+		return fmt.Errorf("%w: %q must return one value, or a value and an error", ErrInvalidTemplateFunc, name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.funcs[name] = fn
+	return nil
+}
+
+// Register parses messages and stores the template under name.
+func (r *TemplateRegistry) Register(name string, messages ...MessageTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.templates[name]; ok {
+		return fmt.Errorf("%w: %q", ErrTemplateExists, name)
+	}
+	p, err := NewPromptTemplate(name, r.funcs, messages...)
+	if err != nil {
+		return err
+	}
+	r.templates[name] = p
+	return nil
+}
+
+// Get returns the template registered under name.
+func (r *TemplateRegistry) Get(name string) (*PromptTemplate, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.templates[name]
+	return p, ok
+}
+
+// Render renders the template registered under name against data.
+func (r *TemplateRegistry) Render(name string, data map[string]interface{}) ([]ChatMessage, error) {
+	p, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrTemplateNotFound, name)
+	}
+	return p.Render(data)
+}
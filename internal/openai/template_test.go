@@ -0,0 +1,71 @@
+package openai
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTemplateRegistryRender(t *testing.T) {
+	r := NewTemplateRegistry()
+	if err := r.RegisterFunc("upper", strings.ToUpper); err != nil {
+		t.Fatalf("RegisterFunc: %v", err)
+	}
+	err := r.Register("greet",
+		MessageTemplate{Role: RoleSystem, Content: "You are {{.persona}}."},
+		MessageTemplate{Role: RoleUser, Content: "Say hi to {{upper .name}}"},
+	)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	messages, err := r.Render("greet", map[string]interface{}{"persona": "friendly", "name": "ada"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := []ChatMessage{
+		{Role: RoleSystem, Content: "You are friendly."},
+		{Role: RoleUser, Content: "Say hi to ADA"},
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(messages), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(messages[i], want[i]) {
+			t.Errorf("message %d = %+v, want %+v", i, messages[i], want[i])
+		}
+	}
+}
+
+func TestTemplateRegistryErrors(t *testing.T) {
+	r := NewTemplateRegistry()
+	if err := r.Register("bad", MessageTemplate{Role: RoleUser, Content: "{{.x"}); err == nil {
+		t.Fatal("Register accepted a malformed template")
+	}
+	if err := r.Register("p", MessageTemplate{Role: RoleUser, Content: "{{.missing}}"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := r.Register("p", MessageTemplate{Role: RoleUser, Content: ""}); !errors.Is(err, ErrTemplateExists) {
+		t.Fatalf("duplicate Register = %v, want ErrTemplateExists", err)
+	}
+	if _, err := r.Render("p", map[string]interface{}{}); err == nil {
+		t.Fatal("Render succeeded with a missing key")
+	}
+	if _, err := r.Render("nope", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("Render unknown = %v, want ErrTemplateNotFound", err)
+	}
+
+	for _, fn := range []interface{}{"not a func", nil, func() {}, func() (int, int) { return 0, 0 }} {
+		if err := r.RegisterFunc("bad", fn); !errors.Is(err, ErrInvalidTemplateFunc) {
+			t.Fatalf("RegisterFunc(%T) = %v, want ErrInvalidTemplateFunc", fn, err)
+		}
+	}
+	if err := r.RegisterFunc("parse", strconv.Atoi); err != nil {
+		t.Fatalf("RegisterFunc of a (value, error) function: %v", err)
+	}
+	if err := r.Register("after", MessageTemplate{Role: RoleUser, Content: "{{parse .n}}"}); err != nil {
+		t.Fatalf("Register after a rejected function: %v", err)
+	}
+}
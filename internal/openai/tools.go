@@ -0,0 +1,257 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ToolTypeFunction is the only tool type the API supports.
+const ToolTypeFunction = "function"
+
+var (
+	// ErrToolNotFound is returned when a tool call names an unregistered
+	// tool.
+	ErrToolNotFound = errors.New("openai: tool not found")
+	// ErrToolExists is returned when registering a tool name twice.
+	ErrToolExists = errors.New("openai: tool already registered")
+	// ErrInvalidToolArguments is returned when a tool call's arguments do
+	// not match the tool's Parameters schema.
+	ErrInvalidToolArguments = errors.New("openai: invalid tool arguments")
+	// ErrToolLoopLimit is returned by RunToolLoop when the model still asks
+	// for tools after the last allowed round.
+	ErrToolLoopLimit = errors.New("openai: tool loop round limit reached")
+)
+
+// DefaultMaxToolRounds is how many completions RunToolLoop requests when
+// ToolLoopConfig.MaxRounds is zero.
+const DefaultMaxToolRounds = 10
+
+// FunctionDefinition describes a function the model may call. Parameters
+// is a JSON schema for the arguments object.
+type FunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// Tool is a tool offered to the model.
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionCall is the function and JSON-encoded arguments the model chose.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCall is a tool invocation requested by the model.
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// ToolHandler executes a tool call. args is the raw arguments object. The
+// returned value is encoded as JSON and sent back to the model.
+type ToolHandler func(ctx context.Context, args json.RawMessage) (interface{}, error)
+
+type registeredTool struct {
+	def     FunctionDefinition
+	handler ToolHandler
+}
+
+// ToolRegistry maps tool names to their definitions and handlers. It is
+// safe for concurrent use.
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]registeredTool
+}
+
+// NewToolRegistry creates an empty tool registry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]registeredTool)}
+}
+
+// Register adds a tool. It returns ErrToolExists if the name is taken.
+func (r *ToolRegistry) Register(def FunctionDefinition, handler ToolHandler) error {
+	if def.Name == "" || handler == nil {
+		return errors.New("openai: tool needs a name and a handler")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[def.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrToolExists, def.Name)
+	}
+	r.tools[def.Name] = registeredTool{def: def, handler: handler}
+	return nil
+}
+
+// Tools returns the registered tools, sorted by name, for
+// ChatCompletionRequest.Tools.
+func (r *ToolRegistry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.tools))
+	for _, t := range r.tools {
+		tools = append(tools, Tool{Type: ToolTypeFunction, Function: t.def})
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Function.Name < tools[j].Function.Name })
+	return tools
+}
+
+// Call runs the handler for call and returns the tool message answering it.
+// A handler error is also reported to the model as {"error": "..."} in the
+// message content, so the conversation can continue. The arguments are
+// passed to the handler unchecked; see ValidateCall.
+func (r *ToolRegistry) Call(ctx context.Context, call ToolCall) (ChatMessage, error) {
+	return r.call(ctx, call, false)
+}
+
+// ValidateCall checks the arguments of call against the Parameters schema
+// of the tool it names. It returns a *ToolArgumentsError if they do not
+// conform, and ErrToolNotFound for an unregistered tool.
+func (r *ToolRegistry) ValidateCall(call ToolCall) error {
+	r.mu.RLock()
+	tool, ok := r.tools[call.Function.Name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrToolNotFound, call.Function.Name)
+	}
+	return validateArguments(tool.def, callArguments(call))
+}
+
+func (r *ToolRegistry) call(ctx context.Context, call ToolCall, validate bool) (ChatMessage, error) {
+	msg := ChatMessage{Role: RoleTool, ToolCallID: call.ID}
+
+	r.mu.RLock()
+	tool, ok := r.tools[call.Function.Name]
+	r.mu.RUnlock()
+
+	var (
+		result interface{}
+		err    error
+	)
+	args := callArguments(call)
+	switch {
+	case !ok:
+		err = fmt.Errorf("%w: %s", ErrToolNotFound, call.Function.Name)
+	case validate:
+		err = validateArguments(tool.def, args)
+	}
+	if err == nil {
+		result, err = tool.handler(ctx, args)
+	}
+	var argsErr *ToolArgumentsError
+	switch {
+	case errors.As(err, &argsErr):
+		result = map[string]interface{}{"error": err.Error(), "violations": argsErr.Violations}
+	case err != nil:
+		result = map[string]string{"error": err.Error()}
+	}
+
+	content, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return msg, fmt.Errorf("openai: encode %s result: %w", call.Function.Name, marshalErr)
+	}
+	msg.Content = string(content)
+	return msg, err
+}
+
+// callArguments returns the arguments of call, {} if the model sent none.
+func callArguments(call ToolCall) json.RawMessage {
+	args := json.RawMessage(call.Function.Arguments)
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	return args
+}
+
+func validateArguments(def FunctionDefinition, args json.RawMessage) error {
+	violations, err := ValidateJSONSchema(def.Parameters, args)
+	if err != nil {
+		return fmt.Errorf("openai: tool %s: %w", def.Name, err)
+	}
+	if len(violations) > 0 {
+		return &ToolArgumentsError{Tool: def.Name, Violations: violations}
+	}
+	return nil
+}
+
+// ToolLoopConfig configures RunToolLoop.
+type ToolLoopConfig struct {
+	// MaxRounds caps the completions requested. Zero uses
+	// DefaultMaxToolRounds.
+	MaxRounds int
+	// SkipArgumentValidation passes tool call arguments to the handlers
+	// without checking them against the tools' Parameters schemas.
+	SkipArgumentValidation bool
+}
+
+// RunToolLoop requests completions of req from completer and runs the tool
+// calls each one asks for through registry, feeding the results back,
+// until the model answers without calling a tool. req.Tools defaults to
+// the registry's tools; req itself is not modified. It returns the final
+// response and the messages added to req.Messages along the way: each
+// assistant message and the tool messages answering it, then the final
+// assistant message.
+//
+// Before a handler runs, the call's arguments are checked against the
+// tool's Parameters schema unless config.SkipArgumentValidation is set.
+// Arguments that do not conform are not dispatched; the model is sent
+// {"error": "...", "violations": [{"path": ..., "message": ...}]} instead
+// so it can correct the call. Handler errors are fed back the same way, as
+// with ToolRegistry.Call, and do not end the loop. It fails with
+// ErrToolLoopLimit, returning the last response, if the model is still
+// calling tools after config.MaxRounds completions.
+func RunToolLoop(ctx context.Context, completer Completer, req *ChatCompletionRequest, registry *ToolRegistry, config ToolLoopConfig) (*ChatCompletionResponse, []ChatMessage, error) {
+	maxRounds := config.MaxRounds
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxToolRounds
+	}
+	round := *req
+	round.Messages = append([]ChatMessage(nil), req.Messages...)
+	if len(round.Tools) == 0 {
+		round.Tools = registry.Tools()
+	}
+
+	var added []ChatMessage
+	for i := 0; ; i++ {
+		resp, err := completer.CreateChatCompletion(ctx, &round)
+		if err != nil {
+			return nil, added, err
+		}
+		if len(resp.Choices) == 0 {
+			return resp, added, errors.New("openai: completion has no choices")
+		}
+		reply := resp.Choices[0].Message
+		added = append(added, reply)
+		if len(reply.ToolCalls) == 0 {
+			return resp, added, nil
+		}
+		if i+1 >= maxRounds {
+			return resp, added, fmt.Errorf("%w: %d rounds", ErrToolLoopLimit, maxRounds)
+		}
+
+		round.Messages = append(round.Messages, reply)
+		for _, call := range reply.ToolCalls {
+			msg, err := registry.call(ctx, call, !config.SkipArgumentValidation)
+			if msg.Content == "" && err != nil {
+				// The result could not be encoded; nothing can be fed back.
+				return resp, added, err
+			}
+			round.Messages = append(round.Messages, msg)
+			added = append(added, msg)
+		}
+		if err := ctx.Err(); err != nil {
+			return resp, added, err
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// scriptedCompleter replies with its messages in turn and records the
+// requests it was sent.
+type scriptedCompleter struct {
+	replies  []ChatMessage
+	requests []*ChatCompletionRequest
+}
+
+func (s *scriptedCompleter) CreateChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	s.requests = append(s.requests, req)
+	reply := s.replies[0]
+	if len(s.replies) > 1 {
+		s.replies = s.replies[1:]
+	}
+	return &ChatCompletionResponse{Choices: []ChatCompletionChoice{{Message: reply}}}, nil
+}
+
+func (s *scriptedCompleter) CreateChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (ChatStream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func toolCallReply(id, args string) ChatMessage {
+	return ChatMessage{Role: RoleAssistant, ToolCalls: []ToolCall{{
+		ID:       id,
+		Type:     ToolTypeFunction,
+		Function: FunctionCall{Name: "transfer", Arguments: args},
+	}}}
+}
+
+func TestRunToolLoopValidatesArguments(t *testing.T) {
+	type transferArgs struct {
+		To     string `json:"to"`
+		Amount uint64 `json:"amount"`
+	}
+	var dispatched []transferArgs
+	newRegistry := func() *ToolRegistry {
+		registry := NewToolRegistry()
+		err := registry.Register(FunctionDefinition{
+			Name: "transfer",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"to": {"type": "string", "minLength": 1},
+					"amount": {"type": "integer", "minimum": 1}
+				},
+				"required": ["to", "amount"],
+				"additionalProperties": false
+			}`),
+		}, func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var args transferArgs
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return nil, err
+			}
+			dispatched = append(dispatched, args)
+			return "ok", nil
+		})
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		return registry
+	}
+
+	malformed := `{"to": 42, "amount": "lots", "memo": "x"}`
+	completer := &scriptedCompleter{replies: []ChatMessage{
+		toolCallReply("call_1", malformed),
+		toolCallReply("call_2", `{"to": "alice", "amount": 5}`),
+		{Role: RoleAssistant, Content: "sent"},
+	}}
+	req := &ChatCompletionRequest{Messages: []ChatMessage{{Role: RoleUser, Content: "pay alice"}}}
+	resp, added, err := RunToolLoop(context.Background(), completer, req, newRegistry(), ToolLoopConfig{})
+	if err != nil {
+		t.Fatalf("RunToolLoop: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "sent" || len(added) != 5 || len(req.Messages) != 1 {
+		t.Fatalf("response = %+v, added = %+v", resp, added)
+	}
+	if len(dispatched) != 1 || dispatched[0] != (transferArgs{To: "alice", Amount: 5}) {
+		t.Fatalf("dispatched = %+v, want only the valid call", dispatched)
+	}
+	if len(completer.requests[0].Tools) != 1 {
+		t.Fatalf("tools = %+v, want the registry's", completer.requests[0].Tools)
+	}
+
+	// The model was told what was wrong with the malformed call.
+	var feedback struct {
+		Error      string            `json:"error"`
+		Violations []SchemaViolation `json:"violations"`
+	}
+	if err := json.Unmarshal([]byte(added[1].Content), &feedback); err != nil {
+		t.Fatalf("decode tool error %q: %v", added[1].Content, err)
+	}
+	paths := make([]string, len(feedback.Violations))
+	for i, v := range feedback.Violations {
+		paths[i] = v.Path
+	}
+	if added[1].ToolCallID != "call_1" || !strings.Contains(feedback.Error, "invalid tool arguments") ||
+		strings.Join(paths, ",") != "$.amount,$.memo,$.to" {
+		t.Fatalf("tool error = %+v", feedback)
+	}
+
+	// Opting out dispatches the arguments as they are.
+	dispatched = nil
+	completer = &scriptedCompleter{replies: []ChatMessage{
+		toolCallReply("call_1", `{"to": "bob"}`),
+		{Role: RoleAssistant, Content: "sent"},
+	}}
+	if _, _, err := RunToolLoop(context.Background(), completer, req, newRegistry(), ToolLoopConfig{SkipArgumentValidation: true}); err != nil {
+		t.Fatalf("RunToolLoop: %v", err)
+	}
+	if len(dispatched) != 1 || dispatched[0].To != "bob" {
+		t.Fatalf("dispatched = %+v, want the unvalidated call", dispatched)
+	}
+
+	completer = &scriptedCompleter{replies: []ChatMessage{toolCallReply("call_1", malformed)}}
+	if _, _, err := RunToolLoop(context.Background(), completer, req, newRegistry(), ToolLoopConfig{MaxRounds: 3}); !errors.Is(err, ErrToolLoopLimit) {
+		t.Fatalf("RunToolLoop with a model that never stops = %v, want ErrToolLoopLimit", err)
+	}
+	if len(completer.requests) != 3 {
+		t.Fatalf("completions = %d, want 3", len(completer.requests))
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"kind": {"enum": ["a", "b"]},
+			"ratio": {"type": "number", "exclusiveMaximum": 1},
+			"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "maxItems": 2},
+			"id": {"anyOf": [{"type": "string"}, {"type": "integer"}]}
+		}
+	}`)
+	tests := []struct {
+		value string
+		paths []string
+	}{
+		{`{"kind": "a", "ratio": 0.5, "tags": ["x"], "id": 3}`, nil},
+		{`{"kind": "c"}`, []string{"$.kind"}},
+		{`{"ratio": 1}`, []string{"$.ratio"}},
+		{`{"tags": ["ok", "Bad", "x"]}`, []string{"$.tags", "$.tags[1]"}},
+		{`{"id": true}`, []string{"$.id", "$.id"}},
+		{`[]`, []string{"$"}},
+		{`{"kind": `, []string{"$"}},
+	}
+	for _, tt := range tests {
+		violations, err := ValidateJSONSchema(schema, json.RawMessage(tt.value))
+		if err != nil {
+			t.Fatalf("ValidateJSONSchema(%s): %v", tt.value, err)
+		}
+		var paths []string
+		for _, v := range violations {
+			paths = append(paths, v.Path)
+		}
+		if strings.Join(paths, ",") != strings.Join(tt.paths, ",") {
+			t.Errorf("ValidateJSONSchema(%s) = %+v, want violations at %v", tt.value, violations, tt.paths)
+		}
+	}
+
+	// A pattern that does not compile is never taken as a match.
+	violations, err := ValidateJSONSchema(json.RawMessage(`{"type": "string", "pattern": "[a-"}`), json.RawMessage(`"abc"`))
+	if err != nil || len(violations) != 1 || !strings.Contains(violations[0].Message, "invalid") {
+		t.Fatalf("invalid pattern = %+v, %v; want one violation", violations, err)
+	}
+}
@@ -0,0 +1,227 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mr-tron/base58"
+)
+
+// ValidateAddress checks that address is a base58 encoded 32-byte public key.
+// The error wraps ErrInvalidAddress and says what is wrong: an invalid
+// base58 character and its position, the wrong decoded length, or that the
+// address looks like a placeholder copied from an example.
+func ValidateAddress(address string) error {
+	decoded, err := base58.Decode(address)
+	if err == nil && len(decoded) == 32 {
+		return nil
+	}
+	if isPlaceholderAddress(address) {
+		return fmt.Errorf("%w: %q looks like a placeholder; pass a real base58 public key, such as one from Wallet.PublicKey", ErrInvalidAddress, address)
+	}
+	if address == "" {
+		return fmt.Errorf("%w: empty address", ErrInvalidAddress)
+	}
+	for i, r := range []rune(address) {
+		if !strings.ContainsRune(base58Alphabet, r) {
+			return fmt.Errorf("%w: %q: invalid base58 character %q at position %d (base58 has no 0, O, I, or l)", ErrInvalidAddress, address, r, i+1)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %q: %v", ErrInvalidAddress, address, err)
+	}
+	return fmt.Errorf("%w: %q: decoded length %d, want 32", ErrInvalidAddress, address, len(decoded))
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// placeholderWords are fragments of the stand-in addresses found in
+// examples and templates.
+var placeholderWords = []string{"address", "your", "placeholder", "example", "pubkey", "public_key", "wallet", "todo", "xxx", "..."}
+
+// isPlaceholderAddress reports whether an invalid address looks like a
+// stand-in for a real one, such as "your_address_here" or "<ADDRESS>".
+func isPlaceholderAddress(address string) bool {
+	lower := strings.ToLower(strings.TrimSpace(address))
+	if len(lower) >= 2 && strings.ContainsRune("<{[", rune(lower[0])) && strings.ContainsRune(">}]", rune(lower[len(lower)-1])) {
+		return true
+	}
+	for _, word := range placeholderWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBalance returns the balance of address in lamports. It accepts
+// WithCommitment and WithMinContextSlot.
+func (c *Client) GetBalance(ctx context.Context, address string, opts ...CallOption) (uint64, error) {
+	if err := ValidateAddress(address); err != nil {
+		return 0, err
+	}
+	o, err := c.callOptions(opts, optMinContextSlot)
+	if err != nil {
+		return 0, err
+	}
+
+	var result contextResult
+	params := []interface{}{address, o.config(false)}
+	if err := c.call(ctx, "getBalance", params, &result); err != nil {
+		return 0, fmt.Errorf("get balance of %s: %w", address, err)
+	}
+
+	var balance uint64
+	if err := json.Unmarshal(result.Value, &balance); err != nil {
+		return 0, fmt.Errorf("decode balance: %w", err)
+	}
+	return balance, nil
+}
+
+// AccountInfo is the state of an on-chain account.
+type AccountInfo struct {
+	Lamports uint64
+	Owner    string
+	Data     []byte
+	// Parsed holds the node's JSON rendering of the data when it was
+	// requested with EncodingJSONParsed and the node could parse it; Data
+	// is then empty.
+	Parsed     json.RawMessage
+	Executable bool
+	RentEpoch  uint64
+	// Slice is set when the account was read WithDataSlice: Data then
+	// holds only that part of the account data, and may be shorter than
+	// requested if the data ends sooner.
+	Slice *DataSlice
+}
+
+type rpcAccountInfo struct {
+	Lamports   uint64          `json:"lamports"`
+	Owner      string          `json:"owner"`
+	Data       json.RawMessage `json:"data"`
+	Executable bool            `json:"executable"`
+	RentEpoch  uint64          `json:"rentEpoch"`
+}
+
+func (a *rpcAccountInfo) decode() (*AccountInfo, error) {
+	info := &AccountInfo{
+		Lamports:   a.Lamports,
+		Owner:      a.Owner,
+		Executable: a.Executable,
+		RentEpoch:  a.RentEpoch,
+	}
+
+	// Encoded data is a [data, encoding] pair; parsed data is an object.
+	var encoded [2]string
+	if err := json.Unmarshal(a.Data, &encoded); err != nil {
+		info.Parsed = a.Data
+		return info, nil
+	}
+	var err error
+	switch encoded[1] {
+	case EncodingBase64:
+		info.Data, err = base64.StdEncoding.DecodeString(encoded[0])
+	case EncodingBase58:
+		info.Data, err = base58.Decode(encoded[0])
+	default:
+		err = fmt.Errorf("unsupported encoding %q", encoded[1])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode account data: %w", err)
+	}
+	return info, nil
+}
+
+// GetAccountInfo returns the account at address. It returns
+// ErrAccountNotFound if the account does not exist. It accepts
+// WithCommitment, WithMinContextSlot, WithEncoding, and WithDataSlice.
+func (c *Client) GetAccountInfo(ctx context.Context, address string, opts ...CallOption) (*AccountInfo, error) {
+	if err := ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	o, err := c.callOptions(opts, optAccountData)
+	if err != nil {
+		return nil, err
+	}
+
+	var result contextResult
+	params := []interface{}{address, o.config(true)}
+	if err := c.call(ctx, "getAccountInfo", params, &result); err != nil {
+		return nil, fmt.Errorf("get account info of %s: %w", address, err)
+	}
+
+	var info *rpcAccountInfo
+	if err := json.Unmarshal(result.Value, &info); err != nil {
+		return nil, fmt.Errorf("decode account info: %w", err)
+	}
+	if info == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, address)
+	}
+	return o.decodeAccount(info)
+}
+
+// MaxMultipleAccounts is the most addresses getMultipleAccounts accepts in
+// one call.
+const MaxMultipleAccounts = 100
+
+// GetMultipleAccounts returns the accounts at addresses in one call, in
+// the same order, with nil for accounts that do not exist. At most
+// MaxMultipleAccounts addresses can be read at once. It accepts the same
+// options as GetAccountInfo.
+func (c *Client) GetMultipleAccounts(ctx context.Context, addresses []string, opts ...CallOption) ([]*AccountInfo, error) {
+	if len(addresses) > MaxMultipleAccounts {
+		return nil, fmt.Errorf("get %d accounts: at most %d per call", len(addresses), MaxMultipleAccounts)
+	}
+	for _, address := range addresses {
+		if err := ValidateAddress(address); err != nil {
+			return nil, err
+		}
+	}
+	o, err := c.callOptions(opts, optAccountData)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	var result contextResult
+	params := []interface{}{addresses, o.config(true)}
+	if err := c.call(ctx, "getMultipleAccounts", params, &result); err != nil {
+		return nil, fmt.Errorf("get %d accounts: %w", len(addresses), err)
+	}
+
+	var raw []*rpcAccountInfo
+	if err := json.Unmarshal(result.Value, &raw); err != nil {
+		return nil, fmt.Errorf("decode accounts: %w", err)
+	}
+	if len(raw) != len(addresses) {
+		return nil, fmt.Errorf("decode accounts: got %d, want %d", len(raw), len(addresses))
+	}
+	accounts := make([]*AccountInfo, len(raw))
+	for i, info := range raw {
+		if info == nil {
+			continue
+		}
+		if accounts[i], err = o.decodeAccount(info); err != nil {
+			return nil, fmt.Errorf("%s: %w", addresses[i], err)
+		}
+	}
+	return accounts, nil
+}
+
+// decodeAccount decodes an account read with o, recording its data slice.
+func (o callOptions) decodeAccount(raw *rpcAccountInfo) (*AccountInfo, error) {
+	info, err := raw.decode()
+	if err != nil {
+		return nil, err
+	}
+	if o.dataSlice != nil {
+		slice := *o.dataSlice
+		info.Slice = &slice
+	}
+	return info, nil
+}
@@ -0,0 +1,35 @@
+package solana
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateAddressExplainsErrors(t *testing.T) {
+	wallet, _ := NewWallet()
+	if err := ValidateAddress(wallet.PublicKey()); err != nil {
+		t.Fatalf("valid address: %v", err)
+	}
+
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"your_address_here", "looks like a placeholder"},
+		{"invalid_address", "looks like a placeholder"},
+		{"<RECIPIENT>", "looks like a placeholder"},
+		{"", "empty address"},
+		{"4Nd1mBQtrMJVYVfKf2PJy9NZUZdTAsp7D4xWLs4gDB40", `invalid base58 character '0' at position 44`},
+		{"4Nd1mBQtrMJVYVfKf2PJy9NZ", "decoded length"},
+	}
+	for _, tt := range tests {
+		err := ValidateAddress(tt.address)
+		if !errors.Is(err, ErrInvalidAddress) {
+			t.Fatalf("ValidateAddress(%q) = %v, want ErrInvalidAddress", tt.address, err)
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("ValidateAddress(%q) = %q, want it to mention %q", tt.address, err, tt.want)
+		}
+	}
+}
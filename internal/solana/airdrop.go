@@ -0,0 +1,216 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// Airdrop pacing defaults applied when AirdropConfig leaves them unset.
+const (
+	DefaultAirdropSpacing        = time.Second
+	DefaultAirdropMaxRetries     = 3
+	DefaultAirdropBackoff        = 2 * time.Second
+	DefaultAirdropMaxBackoff     = 30 * time.Second
+	DefaultAirdropAttemptTimeout = 30 * time.Second
+	DefaultFundAttempts          = 3
+	DefaultFundConfirmTimeout    = time.Minute
+)
+
+var (
+	// ErrAirdropOnMainnet is returned when an airdrop is requested from a
+	// node on mainnet, which has no faucet. It usually means the client
+	// points at the wrong network.
+	ErrAirdropOnMainnet = errors.New("solana: airdrops are not available on mainnet")
+	// ErrAirdropLimitReached is returned when the faucet still refuses an
+	// airdrop because of its rate limit after the configured retries, or
+	// when the context deadline leaves no time to back off. Callers should
+	// wait before asking again; the faucet's own error is wrapped as well.
+	ErrAirdropLimitReached = errors.New("solana: airdrop rate limit reached")
+)
+
+// AirdropConfig paces RequestAirdrop. Faucets enforce strict per-client
+// limits, so airdrops are sent one at a time, at least Spacing apart, and a
+// request rejected by the faucet's limit is retried after an exponentially
+// growing backoff. Each faucet request is bounded by AttemptTimeout so a
+// slow faucet cannot stall the caller.
+type AirdropConfig = utils.AirdropConfig
+
+// WithAirdropConfig replaces the airdrop pacing set by the Airdrop section
+// of the client config.
+func WithAirdropConfig(config AirdropConfig) ClientOption {
+	return func(c *Client) {
+		c.airdrops = newAirdropLimiter(config)
+	}
+}
+
+// airdropLimiter serializes airdrops and counts their outcomes. It is
+// separate from any general RPC limiting because faucet limits are far
+// stricter than the node's.
+type airdropLimiter struct {
+	config AirdropConfig
+	slot   chan struct{} // holds one token; taking it grants the next airdrop
+	last   time.Time     // guarded by slot
+
+	successes atomic.Uint64
+	failures  atomic.Uint64
+	backoffs  atomic.Uint64
+}
+
+func newAirdropLimiter(config AirdropConfig) *airdropLimiter {
+	if config.Spacing <= 0 {
+		config.Spacing = DefaultAirdropSpacing
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultAirdropMaxRetries
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = DefaultAirdropBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultAirdropMaxBackoff
+	}
+	if config.AttemptTimeout <= 0 {
+		config.AttemptTimeout = DefaultAirdropAttemptTimeout
+	}
+	if config.FundAttempts <= 0 {
+		config.FundAttempts = DefaultFundAttempts
+	}
+	if config.ConfirmTimeout <= 0 {
+		config.ConfirmTimeout = DefaultFundConfirmTimeout
+	}
+	l := &airdropLimiter{config: config, slot: make(chan struct{}, 1)}
+	l.slot <- struct{}{}
+	return l
+}
+
+// do runs send once the previous airdrop is at least Spacing old, retrying
+// with backoff while the faucet reports its limit. Other airdrops wait
+// until it returns. Each call to send gets a context bounded by
+// AttemptTimeout. A backoff that would outlast ctx's deadline is not
+// started; the limit error is returned at once instead.
+func (l *airdropLimiter) do(ctx context.Context, send func(ctx context.Context) error) error {
+	select {
+	case <-l.slot:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { l.slot <- struct{}{} }()
+
+	backoff := l.config.Backoff
+	for attempt := 0; ; attempt++ {
+		if err := sleepContext(ctx, time.Until(l.last.Add(l.config.Spacing))); err != nil {
+			return err
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, l.config.AttemptTimeout)
+		err := send(attemptCtx)
+		cancel()
+		l.last = time.Now()
+		if err == nil {
+			l.successes.Add(1)
+			return nil
+		}
+		if !isAirdropLimit(err) {
+			l.failures.Add(1)
+			return utils.WrapAttempts(err, attempt+1)
+		}
+		if attempt >= l.config.MaxRetries || utils.RemainingBudget(ctx) <= backoff {
+			l.failures.Add(1)
+			return utils.WrapAttempts(fmt.Errorf("%w: %w", ErrAirdropLimitReached, err), attempt+1)
+		}
+
+		l.backoffs.Add(1)
+		if err := sleepContext(ctx, backoff); err != nil {
+			l.failures.Add(1)
+			return utils.WrapAttempts(fmt.Errorf("backing off: %w", err), attempt+1)
+		}
+		if backoff *= 2; backoff > l.config.MaxBackoff {
+			backoff = l.config.MaxBackoff
+		}
+	}
+}
+
+func (l *airdropLimiter) metrics() map[string]interface{} {
+	return map[string]interface{}{
+		"success_total": l.successes.Load(),
+		"failure_total": l.failures.Load(),
+		"backoff_total": l.backoffs.Load(),
+	}
+}
+
+// isAirdropLimit reports whether err is the faucet refusing an airdrop
+// because of its rate limit. Faucets signal this either with HTTP 429 or
+// with an internal RPC error whose message mentions the limit.
+func isAirdropLimit(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		msg := strings.ToLower(rpcErr.Message)
+		return strings.Contains(msg, "rate limit") ||
+			strings.Contains(msg, "airdrop limit") ||
+			strings.Contains(msg, "run dry")
+	}
+	return false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RequestAirdrop asks the cluster's faucet for lamports for address and
+// returns the airdrop transaction signature. Airdrops are paced as
+// configured by the Airdrop config section or WithAirdropConfig; once the
+// faucet's rate limit outlasts the retries the error wraps
+// ErrAirdropLimitReached. It refuses with ErrAirdropOnMainnet,
+// without touching the faucet pacing, when DetectCluster identifies
+// mainnet; if detection fails it logs a warning and sends the request
+// anyway.
+func (c *Client) RequestAirdrop(ctx context.Context, address string, lamports uint64) (_ string, err error) {
+	defer wrapOp(&err, "request airdrop of %d lamports to %s", lamports, address)
+	if err := ValidateAddress(address); err != nil {
+		return "", err
+	}
+
+	cluster, err := c.DetectCluster(ctx)
+	switch {
+	case err != nil:
+		c.logger.Warn("Could not verify cluster before airdrop", map[string]interface{}{
+			"error": err.Error(),
+		})
+	case cluster == ClusterMainnet:
+		return "", ErrAirdropOnMainnet
+	}
+
+	var signature string
+	params := []interface{}{
+		address,
+		lamports,
+		map[string]interface{}{"commitment": c.commitment()},
+	}
+	err = c.airdrops.do(ctx, func(ctx context.Context) error {
+		return c.call(ctx, "requestAirdrop", params, &signature)
+	})
+	if err != nil {
+		return "", err
+	}
+	return signature, nil
+}
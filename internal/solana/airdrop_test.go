@@ -0,0 +1,107 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestAirdropBacksOffOnFaucetLimit(t *testing.T) {
+	attempts := 0
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getGenesisHash": func(json.RawMessage) (interface{}, error) {
+			return clusterPresets[ClusterDevnet].genesisHash, nil
+		},
+		"requestAirdrop": func(json.RawMessage) (interface{}, error) {
+			attempts++
+			if attempts < 3 {
+				return nil, &RPCError{Code: -32603, Message: "Internal error: airdrop request failed. This can happen when the rate limit is reached."}
+			}
+			return "sig", nil
+		},
+	})
+	client, err := NewClient(&utils.SolanaConfig{Endpoint: rpc.srv.URL}, WithAirdropConfig(AirdropConfig{
+		Spacing: time.Millisecond,
+		Backoff: time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	sig, err := client.RequestAirdrop(context.Background(), testAddress, LamportsPerSOL)
+	if err != nil || sig != "sig" {
+		t.Fatalf("RequestAirdrop = %q, %v", sig, err)
+	}
+	m := client.GetMetrics()["airdrops"].(map[string]interface{})
+	if m["success_total"] != uint64(1) || m["backoff_total"] != uint64(2) || m["failure_total"] != uint64(0) {
+		t.Fatalf("airdrop metrics = %v", m)
+	}
+}
+
+func TestAirdropLimitReached(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getGenesisHash": func(json.RawMessage) (interface{}, error) {
+			return clusterPresets[ClusterDevnet].genesisHash, nil
+		},
+		"requestAirdrop": func(json.RawMessage) (interface{}, error) {
+			return nil, &RPCError{Code: -32603, Message: "Internal error: airdrop request failed. This can happen when the rate limit is reached."}
+		},
+	})
+	client, err := NewClient(&utils.SolanaConfig{
+		Endpoint: rpc.srv.URL,
+		Airdrop:  utils.AirdropConfig{Spacing: time.Millisecond, Backoff: time.Millisecond, MaxRetries: 1},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	_, err = client.RequestAirdrop(context.Background(), testAddress, LamportsPerSOL)
+	var rpcErr *RPCError
+	if !errors.Is(err, ErrAirdropLimitReached) || !errors.As(err, &rpcErr) {
+		t.Fatalf("RequestAirdrop = %v, want ErrAirdropLimitReached wrapping the faucet error", err)
+	}
+	if n := rpc.count("requestAirdrop"); n != 2 {
+		t.Fatalf("requestAirdrop calls = %d, want 2 with MaxRetries 1", n)
+	}
+
+	// A deadline shorter than the backoff fails at once instead of
+	// sleeping until it expires.
+	client, _ = NewClient(&utils.SolanaConfig{
+		Endpoint: rpc.srv.URL,
+		Airdrop:  utils.AirdropConfig{Spacing: time.Millisecond, Backoff: time.Hour},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.RequestAirdrop(ctx, testAddress, LamportsPerSOL); !errors.Is(err, ErrAirdropLimitReached) {
+		t.Fatalf("RequestAirdrop = %v, want ErrAirdropLimitReached", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("RequestAirdrop took %v despite the short deadline", elapsed)
+	}
+}
+
+func TestAirdropAttemptTimeout(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getGenesisHash": func(json.RawMessage) (interface{}, error) {
+			return clusterPresets[ClusterDevnet].genesisHash, nil
+		},
+		"requestAirdrop": func(json.RawMessage) (interface{}, error) {
+			time.Sleep(200 * time.Millisecond)
+			return "sig", nil
+		},
+	})
+	client, err := NewClient(&utils.SolanaConfig{Endpoint: rpc.srv.URL}, WithAirdropConfig(AirdropConfig{
+		AttemptTimeout: 20 * time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if _, err := client.RequestAirdrop(context.Background(), testAddress, LamportsPerSOL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RequestAirdrop = %v, want a deadline error", err)
+	}
+}
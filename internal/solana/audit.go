@@ -0,0 +1,76 @@
+package solana
+
+import (
+	"context"
+	"time"
+)
+
+// Audited operations, as reported in AuditEvent.Operation.
+const (
+	AuditSendTransaction    = "send_transaction"
+	AuditMintTokens         = "mint_tokens"
+	AuditTransferTokens     = "transfer_tokens"
+	AuditBurnTokens         = "burn_tokens"
+	AuditSendRawTransaction = "send_raw_transaction"
+	AuditSweepBalance       = "sweep_balance"
+)
+
+// Audit results.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent records one call to a fund-moving operation. Amount is in
+// lamports for AuditSendTransaction and AuditSweepBalance and in token base
+// units otherwise.
+type AuditEvent struct {
+	Operation string    `json:"operation"`
+	From      string    `json:"from"`
+	To        string    `json:"to,omitempty"`
+	Mint      string    `json:"mint,omitempty"`
+	Amount    uint64    `json:"amount"`
+	Signature string    `json:"signature,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// AuditHook receives an AuditEvent for every call to SendTransaction,
+// SendRawTransaction, SweepBalance, MintTokens, TransferTokens, and
+// BurnTokens, and for every transfer of BatchTransferTokens. ctx is the
+// caller's context.
+//
+// The hook runs synchronously, exactly once per call or batched transfer,
+// after the broadcast has been answered (or the failure that prevented it)
+// and before the operation returns. A failed event with a Signature may
+// still land: the transaction was signed and possibly broadcast before the
+// error. Because the hook runs after the broadcast, a process crash in
+// between loses the event; callers needing a write-ahead record must log
+// before calling.
+type AuditHook func(ctx context.Context, event AuditEvent)
+
+// WithAuditHook registers hook for fund-moving operations. Without it
+// auditing is a no-op.
+func WithAuditHook(hook AuditHook) ClientOption {
+	return func(c *Client) {
+		c.auditHook = hook
+	}
+}
+
+// audit completes event from the operation's results and passes it to the
+// audit hook. It is deferred before wrapOp so the recorded error carries
+// the operation context.
+func (c *Client) audit(ctx context.Context, event AuditEvent, signature *string, err *error) {
+	if c.auditHook == nil {
+		return
+	}
+	event.Signature = *signature
+	event.Timestamp = time.Now()
+	event.Result = AuditSuccess
+	if *err != nil {
+		event.Result = AuditFailure
+		event.Error = (*err).Error()
+	}
+	c.auditHook(ctx, event)
+}
@@ -0,0 +1,79 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestAuditHook(t *testing.T) {
+	broadcasts := 0
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getLatestBlockhash": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"blockhash":            SystemProgramID.String(),
+				"lastValidBlockHeight": 100,
+			}), nil
+		},
+		"getTokenSupply": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{"amount": "0", "decimals": 6}), nil
+		},
+		"sendTransaction": func(json.RawMessage) (interface{}, error) {
+			if broadcasts++; broadcasts > 1 {
+				return nil, &RPCError{Code: -32002, Message: "Transaction simulation failed"}
+			}
+			return "sig", nil
+		},
+	})
+	var events []AuditEvent
+	client, err := NewClient(&utils.SolanaConfig{Endpoint: rpc.srv.URL}, WithAuditHook(func(_ context.Context, event AuditEvent) {
+		// The broadcast has been answered by the time the hook runs.
+		if event.Signature != "" && rpc.count("sendTransaction") == 0 {
+			t.Error("audit hook ran before the broadcast")
+		}
+		events = append(events, event)
+	}))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	from, _ := client.CreateWallet()
+	to, _ := NewWallet()
+	mint, _ := NewWallet()
+	client.SetPayer(from)
+
+	sig, err := client.SendTransaction(context.Background(), from.PublicKey(), to.PublicKey(), 1000)
+	if err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	_, burnErr := client.BurnTokens(context.Background(), mint.PublicKey(), from.PublicKey(), 5)
+	if burnErr == nil {
+		t.Fatal("BurnTokens succeeded despite the failed broadcast")
+	}
+	if _, err := client.TransferTokens(context.Background(), mint.PublicKey(), to.PublicKey(), from.PublicKey(), 1); err == nil {
+		t.Fatal("TransferTokens from an unregistered owner succeeded")
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("events = %+v, want 3", events)
+	}
+	send := events[0]
+	if send.Operation != AuditSendTransaction || send.From != from.PublicKey() || send.To != to.PublicKey() ||
+		send.Amount != 1000 || send.Signature != sig || send.Result != AuditSuccess || send.Timestamp.IsZero() {
+		t.Fatalf("send event = %+v", send)
+	}
+	burn := events[1]
+	if burn.Operation != AuditBurnTokens || burn.Mint != mint.PublicKey() || burn.Result != AuditFailure ||
+		burn.Signature == "" || burn.Error != burnErr.Error() {
+		t.Fatalf("burn event = %+v", burn)
+	}
+	var rpcErr *RPCError
+	if !errors.As(burnErr, &rpcErr) {
+		t.Fatalf("BurnTokens error = %v", burnErr)
+	}
+	if transfer := events[2]; transfer.Operation != AuditTransferTokens || transfer.Result != AuditFailure || transfer.Signature != "" {
+		t.Fatalf("transfer event = %+v", transfer)
+	}
+}
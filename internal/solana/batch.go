@@ -0,0 +1,99 @@
+package solana
+
+import (
+	"context"
+	"time"
+)
+
+// WithNotificationBatching delivers subscription notifications in batches
+// on Subscription.Batches instead of one at a time on Subscription.C, so a
+// consumer of frequent updates handles them in bulk. A batch is delivered
+// once window has passed since its first notification or once it holds
+// maxSize notifications, whichever comes first; maxSize of zero or less
+// uses DefaultNotificationBuffer. Notifications keep their order within
+// and across batches.
+//
+// While the consumer is busy, the pending batch keeps filling up to
+// maxSize; further notifications wait in the subscription's buffer and
+// are dropped, as without batching, once it is full. Batching is off by
+// default; a window of zero or less disables it.
+func WithNotificationBatching(window time.Duration, maxSize int) ClientOption {
+	return func(c *Client) {
+		c.batchWindow = window
+		c.batchSize = maxSize
+		if c.batchSize <= 0 {
+			c.batchSize = DefaultNotificationBuffer
+		}
+	}
+}
+
+// forwardBatches collects notifications into batches and delivers them on
+// s.batches until the subscription ends. It returns like forward; a batch
+// pending when the connection drops is delivered first.
+func (s *Subscription) forwardBatches(ctx context.Context, window time.Duration, maxSize int) error {
+	in := s.current().notifications
+	var (
+		batch   []Notification
+		out     chan<- []Notification // set while a batch is ready
+		timer   *time.Timer
+		timeout <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	ready := func() {
+		out, timeout = s.batches, nil
+		if timer != nil && !timer.Stop() {
+			// Drain an expiry not yet received, so Reset starts afresh.
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+
+	for {
+		// A full batch waits for the consumer before taking more.
+		recv := in
+		if len(batch) >= maxSize {
+			recv = nil
+		}
+		select {
+		case payload, ok := <-recv:
+			if !ok {
+				if len(batch) > 0 {
+					select {
+					case s.batches <- batch:
+					case <-ctx.Done():
+						return nil
+					}
+				}
+				return s.closedError()
+			}
+			n, ok := s.decode(payload)
+			if !ok {
+				continue
+			}
+			batch = append(batch, n)
+			switch {
+			case len(batch) >= maxSize:
+				ready()
+			case len(batch) == 1 && out == nil:
+				if timer == nil {
+					timer = time.NewTimer(window)
+				} else {
+					timer.Reset(window)
+				}
+				timeout = timer.C
+			}
+		case <-timeout:
+			ready()
+		case out <- batch:
+			batch, out = nil, nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestNotificationBatching(t *testing.T) {
+	rpc := newFakeRPC(t, nil)
+	rpc.pubsub = func(conn *websocket.Conn) {
+		for {
+			var req struct {
+				ID uint64 `json:"id"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": 1})
+			for lamports := 1; lamports <= 5; lamports++ {
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "accountNotification",
+					"params": map[string]interface{}{
+						"subscription": 1,
+						"result":       withContext(map[string]interface{}{"lamports": lamports}),
+					},
+				})
+			}
+		}
+	}
+	client := rpc.client(t)
+	WithNotificationBatching(50*time.Millisecond, 3)(client)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, err := client.SubscribeToAccountChanges(ctx, wallet.PublicKey())
+	if err != nil {
+		t.Fatalf("SubscribeToAccountChanges: %v", err)
+	}
+
+	lamports := func(batch []Notification) []int {
+		var out []int
+		for _, n := range batch {
+			var account struct {
+				Lamports int `json:"lamports"`
+			}
+			json.Unmarshal(n.Value, &account)
+			out = append(out, account.Lamports)
+		}
+		return out
+	}
+	// The first batch is cut at the size limit, the second by the window.
+	start := time.Now()
+	first := lamports(<-sub.Batches())
+	second := lamports(<-sub.Batches())
+	if len(first) != 3 || first[0] != 1 || first[2] != 3 || len(second) != 2 || second[0] != 4 || second[1] != 5 {
+		t.Fatalf("batches %v, %v; want [1 2 3], [4 5]", first, second)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("partial batch delivered after %v, before the window", elapsed)
+	}
+
+	cancel()
+	if _, ok := <-sub.Batches(); ok {
+		t.Fatal("Batches received after the subscription ended")
+	}
+	if _, ok := <-sub.C(); ok {
+		t.Fatal("C received a notification under batching")
+	}
+}
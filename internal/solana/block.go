@@ -0,0 +1,191 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Transaction detail levels for GetBlock.
+const (
+	TransactionDetailsFull       = "full"
+	TransactionDetailsAccounts   = "accounts"
+	TransactionDetailsSignatures = "signatures"
+	TransactionDetailsNone       = "none"
+)
+
+// RPC error codes reporting that a slot has no block: it was skipped, or
+// its block is missing from the node's ledger or long-term storage.
+const (
+	rpcCodeBlockNotAvailable       = -32004
+	rpcCodeSlotSkipped             = -32007
+	rpcCodeLongTermStorageSlotSkip = -32009
+	rpcCodeBlockStatusNotAvailable = -32014
+)
+
+// ErrBlockNotAvailable is returned when a slot has no block, because the
+// slot was skipped or the node no longer stores it.
+var ErrBlockNotAvailable = errors.New("solana: block not available")
+
+// Block is a confirmed block.
+type Block struct {
+	Blockhash         string             `json:"blockhash"`
+	PreviousBlockhash string             `json:"previousBlockhash"`
+	ParentSlot        uint64             `json:"parentSlot"`
+	BlockHeight       *uint64            `json:"blockHeight"`
+	BlockTime         *int64             `json:"blockTime"`
+	Transactions      []BlockTransaction `json:"-"`
+	Signatures        []string           `json:"signatures,omitempty"`
+	Rewards           []BlockReward      `json:"rewards,omitempty"`
+}
+
+// BlockTransaction is a transaction included in a block. Transaction holds
+// the serialized transaction and is empty at the "accounts" detail level.
+type BlockTransaction struct {
+	Transaction []byte
+	Meta        *TransactionMeta
+	// Version is "legacy" or the version number, e.g. "0".
+	Version string
+}
+
+// TransactionMeta is the execution status of a transaction.
+type TransactionMeta struct {
+	Err                  json.RawMessage `json:"err"`
+	Fee                  uint64          `json:"fee"`
+	PreBalances          []uint64        `json:"preBalances"`
+	PostBalances         []uint64        `json:"postBalances"`
+	LogMessages          []string        `json:"logMessages"`
+	ComputeUnitsConsumed *uint64         `json:"computeUnitsConsumed"`
+}
+
+// Failed reports whether the transaction failed.
+func (m *TransactionMeta) Failed() bool {
+	return len(m.Err) > 0 && string(m.Err) != "null"
+}
+
+// BlockReward is a reward credited when a block was produced.
+type BlockReward struct {
+	Pubkey      string `json:"pubkey"`
+	Lamports    int64  `json:"lamports"`
+	PostBalance uint64 `json:"postBalance"`
+	RewardType  string `json:"rewardType"`
+	Commission  *uint8 `json:"commission,omitempty"`
+}
+
+// BlockOption configures GetBlock.
+type BlockOption func(*blockOptions)
+
+type blockOptions struct {
+	transactionDetails string
+	rewards            bool
+}
+
+// WithTransactionDetails sets how much transaction data GetBlock returns:
+// one of the TransactionDetails* levels. The default is full.
+func WithTransactionDetails(level string) BlockOption {
+	return func(o *blockOptions) {
+		o.transactionDetails = level
+	}
+}
+
+// WithRewards sets whether GetBlock returns block rewards. The default is
+// false.
+func WithRewards(rewards bool) BlockOption {
+	return func(o *blockOptions) {
+		o.rewards = rewards
+	}
+}
+
+// GetBlock returns the block produced at slot. Version 0 transactions are
+// accepted. It returns ErrBlockNotAvailable when the slot has no block.
+func (c *Client) GetBlock(ctx context.Context, slot uint64, opts ...BlockOption) (*Block, error) {
+	o := blockOptions{transactionDetails: TransactionDetailsFull}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	params := []interface{}{
+		slot,
+		map[string]interface{}{
+			"commitment":                     c.blockCommitment(),
+			"encoding":                       "base64",
+			"transactionDetails":             o.transactionDetails,
+			"rewards":                        o.rewards,
+			"maxSupportedTransactionVersion": 0,
+		},
+	}
+
+	var raw *struct {
+		Block
+		Transactions []struct {
+			Transaction json.RawMessage  `json:"transaction"`
+			Meta        *TransactionMeta `json:"meta"`
+			Version     json.RawMessage  `json:"version"`
+		} `json:"transactions"`
+	}
+	if err := c.call(ctx, "getBlock", params, &raw); err != nil {
+		return nil, blockError(slot, err)
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("%w: slot %d", ErrBlockNotAvailable, slot)
+	}
+
+	block := raw.Block
+	for i, tx := range raw.Transactions {
+		decoded := BlockTransaction{
+			Meta:    tx.Meta,
+			Version: strings.Trim(string(tx.Version), `"`),
+		}
+		// Full details encode the transaction as [data, "base64"]; the
+		// accounts level returns an object without the raw bytes.
+		var encoded [2]string
+		if json.Unmarshal(tx.Transaction, &encoded) == nil {
+			data, err := base64.StdEncoding.DecodeString(encoded[0])
+			if err != nil {
+				return nil, fmt.Errorf("decode transaction %d of slot %d: %w", i, slot, err)
+			}
+			decoded.Transaction = data
+		}
+		block.Transactions = append(block.Transactions, decoded)
+	}
+	return &block, nil
+}
+
+// GetBlockTime returns the estimated production time of the block at slot
+// as a Unix timestamp. It returns ErrBlockNotAvailable when the slot has no
+// block or no recorded time.
+func (c *Client) GetBlockTime(ctx context.Context, slot uint64) (int64, error) {
+	var blockTime *int64
+	if err := c.call(ctx, "getBlockTime", []interface{}{slot}, &blockTime); err != nil {
+		return 0, blockError(slot, err)
+	}
+	if blockTime == nil {
+		return 0, fmt.Errorf("%w: slot %d has no block time", ErrBlockNotAvailable, slot)
+	}
+	return *blockTime, nil
+}
+
+// blockCommitment is the client's commitment, raised to confirmed because
+// block queries do not accept processed.
+func (c *Client) blockCommitment() string {
+	if commitment := c.commitment(); commitment != CommitmentProcessed {
+		return commitment
+	}
+	return CommitmentConfirmed
+}
+
+// blockError maps the RPC errors for missing or skipped slots to
+// ErrBlockNotAvailable.
+func blockError(slot uint64, err error) error {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case rpcCodeBlockNotAvailable, rpcCodeSlotSkipped, rpcCodeLongTermStorageSlotSkip, rpcCodeBlockStatusNotAvailable:
+			return fmt.Errorf("%w: slot %d: %w", ErrBlockNotAvailable, slot, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestGetBlock(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getBlock": func(params json.RawMessage) (interface{}, error) {
+			var p []json.RawMessage
+			json.Unmarshal(params, &p)
+			if string(p[0]) == "7" {
+				return nil, &RPCError{Code: -32007, Message: "Slot 7 was skipped"}
+			}
+			return map[string]interface{}{
+				"blockhash":  "hash",
+				"parentSlot": 8,
+				"blockTime":  1700000000,
+				"transactions": []interface{}{
+					map[string]interface{}{
+						"transaction": []string{"AQID", "base64"},
+						"meta":        map[string]interface{}{"err": nil, "fee": 5000},
+						"version":     0,
+					},
+					map[string]interface{}{
+						"transaction": []string{"", "base64"},
+						"meta":        map[string]interface{}{"err": map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}},
+						"version":     "legacy",
+					},
+				},
+			}, nil
+		},
+	})
+	client := rpc.client(t)
+
+	block, err := client.GetBlock(context.Background(), 9)
+	if err != nil {
+		t.Fatalf("GetBlock: %v", err)
+	}
+	if *block.BlockTime != 1700000000 || len(block.Transactions) != 2 {
+		t.Fatalf("block = %+v", block)
+	}
+	v0, legacy := block.Transactions[0], block.Transactions[1]
+	if v0.Version != "0" || len(v0.Transaction) != 3 || v0.Meta.Failed() {
+		t.Fatalf("v0 transaction = %+v", v0)
+	}
+	if legacy.Version != "legacy" || !legacy.Meta.Failed() {
+		t.Fatalf("legacy transaction = %+v", legacy)
+	}
+
+	if _, err := client.GetBlock(context.Background(), 7); !errors.Is(err, ErrBlockNotAvailable) {
+		t.Fatalf("GetBlock(skipped) = %v, want ErrBlockNotAvailable", err)
+	}
+}
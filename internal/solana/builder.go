@@ -0,0 +1,177 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// TransactionBuilder assembles a transaction whose fee payer may differ from
+// the accounts moving funds, as in sponsored transactions where a relayer
+// pays fees on a user's behalf. Build, Sign, and Send are separate steps so
+// a transaction can be built in one place and signed or sent in another.
+//
+// The setters return the builder for chaining. A builder is not safe for
+// concurrent use.
+type TransactionBuilder struct {
+	feePayer     PublicKey
+	hasFeePayer  bool
+	instructions []Instruction
+	signers      []Signer
+
+	blockhash            Hash
+	hasBlockhash         bool
+	lastValidBlockHeight uint64
+
+	fee uint64 // recorded on the SentTransaction; see applyFeeFloor
+}
+
+// NewTransactionBuilder returns an empty builder.
+func NewTransactionBuilder() *TransactionBuilder {
+	return &TransactionBuilder{}
+}
+
+// SetFeePayer sets the account that pays the transaction fee. It must be
+// among the signers when the transaction is signed.
+func (b *TransactionBuilder) SetFeePayer(feePayer PublicKey) *TransactionBuilder {
+	b.feePayer = feePayer
+	b.hasFeePayer = true
+	return b
+}
+
+// AddInstruction appends instructions in execution order.
+func (b *TransactionBuilder) AddInstruction(instructions ...Instruction) *TransactionBuilder {
+	b.instructions = append(b.instructions, instructions...)
+	return b
+}
+
+// AddSigner adds signers, such as wallets, that sign the transaction. Each
+// must be a required signer: the fee payer or an account an instruction
+// marks as a signer.
+func (b *TransactionBuilder) AddSigner(signers ...Signer) *TransactionBuilder {
+	b.signers = append(b.signers, signers...)
+	return b
+}
+
+// SetRecentBlockhash sets the blockhash the transaction is built against and
+// the last block height at which it remains valid. Send fetches the latest
+// blockhash when none is set.
+func (b *TransactionBuilder) SetRecentBlockhash(blockhash Hash, lastValidBlockHeight uint64) *TransactionBuilder {
+	b.blockhash = blockhash
+	b.lastValidBlockHeight = lastValidBlockHeight
+	b.hasBlockhash = true
+	return b
+}
+
+// Build compiles the unsigned transaction. It fails if the fee payer,
+// instructions, or recent blockhash are missing.
+func (b *TransactionBuilder) Build() (*Transaction, error) {
+	switch {
+	case !b.hasFeePayer:
+		return nil, errors.New("build transaction: fee payer is required")
+	case !b.hasBlockhash:
+		return nil, errors.New("build transaction: recent blockhash is required")
+	}
+	msg, err := NewMessage(b.feePayer, b.instructions, b.blockhash)
+	if err != nil {
+		return nil, fmt.Errorf("build transaction: %w", err)
+	}
+	return NewTransaction(msg), nil
+}
+
+// Sign builds the transaction and signs it with every added signer. It
+// returns ErrMissingSignature, naming the accounts, if a required signer
+// was not added.
+func (b *TransactionBuilder) Sign() (*Transaction, error) {
+	tx, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	provided := make(map[PublicKey]bool, len(b.signers))
+	for _, signer := range b.signers {
+		key, err := signerKey(signer)
+		if err != nil {
+			return nil, err
+		}
+		provided[key] = true
+	}
+	var missing []string
+	for _, key := range tx.Message.Signers() {
+		if !provided[key] {
+			missing = append(missing, key.String())
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: no signer for %s", ErrMissingSignature, strings.Join(missing, ", "))
+	}
+
+	if err := tx.Sign(b.signers...); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Send signs the transaction and broadcasts it through client, returning
+// its signature. If no blockhash was set, the latest is fetched and used for
+// this call only; the builder is left unchanged. The signed transaction is
+// tracked so ResendTransaction can re-broadcast it.
+func (b *TransactionBuilder) Send(ctx context.Context, client *Client) (string, error) {
+	return b.send(ctx, client, broadcastOptions{})
+}
+
+func (b *TransactionBuilder) send(ctx context.Context, client *Client, options broadcastOptions) (string, error) {
+	if !b.hasBlockhash {
+		latest, err := client.getLatestBlockhash(ctx)
+		if err != nil {
+			return "", err
+		}
+		recent, err := HashFromBase58(latest.Blockhash)
+		if err != nil {
+			return "", err
+		}
+		// The fetched blockhash is for this send only; b stays unset so a
+		// later Send fetches a fresh one.
+		fetched := *b
+		b = fetched.SetRecentBlockhash(recent, latest.LastValidBlockHeight)
+	}
+
+	tx, err := b.Sign()
+	if err != nil {
+		return "", err
+	}
+	return client.sendSigned(ctx, tx, b.lastValidBlockHeight, b.fee, options)
+}
+
+// sendSigned tracks tx for ResendTransaction and broadcasts it with
+// options, which resends reuse.
+func (c *Client) sendSigned(ctx context.Context, tx *Transaction, lastValidBlockHeight, fee uint64, options broadcastOptions) (string, error) {
+	raw, err := tx.Serialize()
+	if err != nil {
+		return "", err
+	}
+
+	sent := &SentTransaction{
+		Signature:            tx.Signature(),
+		LastValidBlockHeight: lastValidBlockHeight,
+		Fee:                  fee,
+		SentAt:               time.Now(),
+		raw:                  base64.StdEncoding.EncodeToString(raw),
+		options:              options,
+	}
+	c.trackTransaction(sent)
+
+	if err := c.broadcast(ctx, sent); err != nil {
+		return sent.Signature, err
+	}
+	if fee == 0 {
+		fee = DefaultLamportsPerSignature * uint64(len(tx.Signatures))
+	}
+	utils.UsageRecorderFrom(ctx).AddFee(fee)
+	return sent.Signature, nil
+}
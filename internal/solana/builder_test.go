@@ -0,0 +1,72 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestTransactionBuilderSponsoredTransfer(t *testing.T) {
+	relayer, _ := NewWallet()
+	user, _ := NewWallet()
+	recipient, _ := NewWallet()
+	transfer := TransferInstruction(user.Key(), recipient.Key(), 1000)
+
+	builder := NewTransactionBuilder().
+		SetFeePayer(relayer.Key()).
+		AddInstruction(transfer).
+		AddSigner(user)
+
+	if _, err := builder.Build(); err == nil {
+		t.Fatal("Build succeeded without a recent blockhash")
+	}
+
+	var sent string
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getLatestBlockhash": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"blockhash":            SystemProgramID.String(),
+				"lastValidBlockHeight": 100,
+			}), nil
+		},
+		"sendTransaction": func(params json.RawMessage) (interface{}, error) {
+			var p []string
+			json.Unmarshal(params, &p)
+			sent = p[0]
+			return "sig", nil
+		},
+	})
+	client := rpc.client(t)
+
+	// The relayer pays the fee, so it must sign too.
+	if _, err := builder.Send(context.Background(), client); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("Send without the fee payer's signature = %v, want ErrMissingSignature", err)
+	}
+	if sent != "" {
+		t.Fatal("transaction was broadcast without all signatures")
+	}
+
+	signature, err := builder.AddSigner(relayer).Send(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(sent)
+	// One byte of signature count, then two signatures, then the message
+	// whose first account key is the fee payer.
+	if raw[0] != 2 {
+		t.Fatalf("signature count = %d, want 2", raw[0])
+	}
+	feePayer := relayer.Key()
+	if payer := raw[1+2*64+3+1 : 1+2*64+3+1+32]; string(payer) != string(feePayer[:]) {
+		t.Fatal("fee payer is not the first account key")
+	}
+	if tracked, ok := client.TrackedTransaction(signature); !ok || tracked.LastValidBlockHeight != 100 {
+		t.Fatalf("tracked = %+v, %v", tracked, ok)
+	}
+	// The fetched blockhash was for that send only.
+	if _, err := builder.Build(); err == nil {
+		t.Fatal("Send left its fetched blockhash on the builder")
+	}
+}
@@ -0,0 +1,366 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// DefaultRequestTimeout bounds an RPC call whose context has no deadline
+// when the config leaves RequestTimeout unset. The other transport
+// settings default to the utils.Default* transport values.
+const DefaultRequestTimeout = 30 * time.Second
+
+// Client is a Solana JSON-RPC client.
+type Client struct {
+	config     *utils.SolanaConfig
+	endpoint   string
+	httpClient *http.Client
+	transport  *utils.Transport
+	logger     *utils.Logger
+	metrics    *clientMetrics
+	retries    *utils.RetryBudget
+	recorder   *utils.Recorder
+	airdrops   *airdropLimiter
+	auditHook  AuditHook
+	endpoints  *endpointPool
+	stopProbe  chan struct{}
+	closeOnce  sync.Once
+	nextID     atomic.Uint64
+
+	dedupWindow int
+	batchWindow time.Duration
+	batchSize   int
+
+	resumeSubscriptions bool
+	resumeMaxBackoff    time.Duration
+
+	// writeSlot is the highest slot at which a transaction was confirmed.
+	writeSlot atomic.Uint64
+	// gzipRejected is set once a node refuses a compressed request body.
+	gzipRejected atomic.Bool
+
+	walletsMu sync.RWMutex
+	wallets   map[string]Signer
+	payer     Signer
+
+	sentMu sync.Mutex
+	sent   map[string]*SentTransaction
+
+	wsMu sync.Mutex
+	ws   *wsConn
+
+	clusterMu sync.Mutex
+	cluster   Cluster
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithLogger makes the client log through logger. Entries are prefixed with
+// the logger's prefix followed by "Solana". Without it the client derives its
+// logger from utils.DefaultLogger.
+func WithLogger(logger *utils.Logger) ClientOption {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger.Named("Solana")
+		}
+	}
+}
+
+// WithRetryBudget makes the client draw every retry, such as a resend under
+// RetryUntilConfirmed, from budget. Share one budget across components so a
+// widespread outage stops retries everywhere at once.
+func WithRetryBudget(budget *utils.RetryBudget) ClientOption {
+	return func(c *Client) {
+		c.retries = budget
+	}
+}
+
+// WithRoundTripper sends RPC calls through rt instead of the pooled
+// transport, e.g. to stub the node in tests. Connection metrics then stay at
+// zero.
+func WithRoundTripper(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		if rt != nil {
+			c.httpClient = &http.Client{Transport: rt}
+		}
+	}
+}
+
+// WithRecorder records every RPC call and its response through recorder
+// or, in utils.ReplayMode, answers calls from its recordings without
+// contacting the node; see utils.Recorder for how calls are matched. It
+// wraps the transport set by WithRoundTripper, if any. Subscriptions are
+// not recorded.
+func WithRecorder(recorder *utils.Recorder) ClientOption {
+	return func(c *Client) {
+		c.recorder = recorder
+	}
+}
+
+// NewClient creates a Solana client from config. Unset transport settings
+// fall back to DefaultRequestTimeout and the utils.Default* transport
+// values.
+func NewClient(config *utils.SolanaConfig, opts ...ClientOption) (*Client, error) {
+	if config == nil {
+		return nil, fmt.Errorf("%w: nil config", ErrInvalidConfig)
+	}
+	if config.Endpoint == "" && config.Cluster == "" {
+		return nil, fmt.Errorf("%w: endpoint or cluster is required", ErrInvalidConfig)
+	}
+
+	if err := utils.ValidateHeaders(config.Headers, reservedHeaders...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	cfg := *config
+	if err := applyCluster(&cfg); err != nil {
+		return nil, err
+	}
+	applyTransportDefaults(&cfg)
+	endpoints, err := newEndpointPool(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := newTransport(&cfg)
+	c := &Client{
+		config:     &cfg,
+		endpoint:   cfg.Endpoint,
+		httpClient: &http.Client{Transport: transport},
+		transport:  transport,
+		logger:     utils.DefaultLogger().Named("Solana"),
+		metrics:    newClientMetrics(cfg.LatencyBuckets, cfg.MetricsWindow),
+		airdrops:   newAirdropLimiter(cfg.Airdrop),
+		endpoints:  endpoints,
+		wallets:    make(map[string]Signer),
+		sent:       make(map[string]*SentTransaction),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.recorder != nil {
+		c.httpClient = &http.Client{Transport: c.recorder.Wrap(c.httpClient.Transport)}
+	}
+	if cfg.ExpectedGenesis != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+		defer cancel()
+		if err := c.VerifyNetwork(ctx, expectedGenesis(cfg.ExpectedGenesis)); err != nil {
+			return nil, err
+		}
+	}
+	if endpoints.strategy == RoutingLatency && len(endpoints.endpoints) > 1 {
+		c.stopProbe = make(chan struct{})
+		go c.probeLoop(cfg.Routing.ProbeInterval, c.stopProbe)
+	}
+	return c, nil
+}
+
+// applyCluster fills the endpoints of cfg from its cluster preset. Explicit
+// endpoints take precedence.
+func applyCluster(cfg *utils.SolanaConfig) error {
+	if cfg.Cluster == "" {
+		return nil
+	}
+	cluster, err := ParseCluster(cfg.Cluster)
+	if err != nil {
+		return err
+	}
+	preset := clusterPresets[cluster]
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = preset.endpoint
+		if cfg.WSEndpoint == "" {
+			cfg.WSEndpoint = preset.wsEndpoint
+		}
+	}
+	return nil
+}
+
+// reservedHeaders are set by the client itself and rejected in
+// SolanaConfig.Headers.
+var reservedHeaders = []string{
+	"Content-Type",
+	"Content-Encoding",
+	"Content-Length",
+	"Connection",
+	"Upgrade",
+	"Sec-WebSocket-Key",
+	"Sec-WebSocket-Version",
+	"Sec-WebSocket-Extensions",
+	"Sec-WebSocket-Protocol",
+}
+
+func applyTransportDefaults(cfg *utils.SolanaConfig) {
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = utils.DefaultDialTimeout
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = DefaultRequestTimeout
+	}
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = utils.DefaultKeepAlive
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = utils.DefaultIdleConnTimeout
+	}
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = utils.DefaultMaxIdleConns
+	}
+	if cfg.MaxConnsPerHost <= 0 {
+		cfg.MaxConnsPerHost = utils.DefaultMaxConnsPerHost
+	}
+	if cfg.WebSocket.PingInterval == 0 {
+		cfg.WebSocket.PingInterval = DefaultPingInterval
+	}
+	if cfg.WebSocket.PongTimeout <= 0 {
+		cfg.WebSocket.PongTimeout = DefaultPongTimeout
+	}
+	if cfg.Routing.ProbeInterval <= 0 {
+		cfg.Routing.ProbeInterval = DefaultProbeInterval
+	}
+	if cfg.Compression.MinRequestSize <= 0 {
+		cfg.Compression.MinRequestSize = DefaultGzipMinRequestSize
+	}
+}
+
+// newTransport returns the pooled transport for cfg, shared with every
+// client configured alike. No client-wide Timeout is set on the HTTP
+// client: RequestTimeout is applied per call only when the caller's context
+// has no deadline, so an explicit deadline always takes precedence.
+func newTransport(cfg *utils.SolanaConfig) *utils.Transport {
+	return utils.SharedTransport(utils.TransportConfig{
+		DialTimeout:     cfg.DialTimeout,
+		KeepAlive:       cfg.KeepAlive,
+		IdleConnTimeout: cfg.IdleConnTimeout,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		MaxConnsPerHost: cfg.MaxConnsPerHost,
+	})
+}
+
+// Close stops endpoint probing and closes the WebSocket connection. The
+// pooled HTTP transport is shared with other clients configured alike, so
+// its connections are left open for them.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		if c.stopProbe != nil {
+			close(c.stopProbe)
+		}
+	})
+
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+	if c.ws != nil {
+		c.ws.close(ErrSubscriptionClosed)
+		c.ws = nil
+	}
+	return nil
+}
+
+// Call issues an arbitrary JSON-RPC request and decodes its result into
+// out, which may be nil to discard it. The request shares the endpoint,
+// headers, timeouts, and metrics of the typed methods. Prefer a typed method
+// when one exists: Call is an escape hatch for RPC methods the client does
+// not wrap yet, and leaves validating params and interpreting the result to
+// the caller. RPC failures are returned as *RPCError.
+func (c *Client) Call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	if method == "" {
+		return fmt.Errorf("call: method is required")
+	}
+	return c.call(ctx, method, params, out)
+}
+
+// call issues a JSON-RPC request and decodes the result into out.
+func (c *Client) call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	start := time.Now()
+	err := c.doCall(ctx, method, params, out)
+	elapsed := time.Since(start)
+	c.metrics.observe(method, elapsed, err)
+	utils.UsageRecorderFrom(ctx).AddRPCCall(elapsed)
+	return err
+}
+
+// doCall tries each endpoint in the pool's order until one answers. Only
+// requests that are safe to repeat move on after a possible delivery; see
+// replayable.
+func (c *Client) doCall(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.RequestTimeout)
+		defer cancel()
+	}
+
+	var err error
+	for _, endpoint := range c.endpoints.order() {
+		if err = c.post(ctx, endpoint, method, params, out); err == nil {
+			c.endpoints.succeeded(endpoint)
+			return nil
+		}
+		// A lagging node is not down; another may have caught up.
+		if errors.Is(err, ErrMinContextSlotNotReached) {
+			continue
+		}
+		if !failoverable(ctx, err) || !replayable(method) && !undelivered(err) {
+			return err
+		}
+		c.endpoints.failed(endpoint)
+	}
+	return err
+}
+
+// post issues a JSON-RPC request to endpoint and decodes the result into
+// out.
+func (c *Client) post(ctx context.Context, endpoint, method string, params []interface{}, out interface{}) error {
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal %s request: %w", method, err)
+	}
+
+	data, status, err := c.exchange(ctx, endpoint, method, body)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return &HTTPStatusError{Method: method, StatusCode: status, Body: string(bytes.TrimSpace(data))}
+	}
+
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(data, &rpcResp); err != nil {
+		return fmt.Errorf("decode %s response: %w", method, err)
+	}
+	if rpcResp.Error != nil {
+		if rpcResp.Error.Code == rpcCodeMinContextSlotNotReached {
+			return fmt.Errorf("%s: %w: %w", method, ErrMinContextSlotNotReached, rpcResp.Error)
+		}
+		return fmt.Errorf("%s: %w", method, rpcResp.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+		return fmt.Errorf("decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// commitment returns the configured commitment level, defaulting to
+// "confirmed".
+func (c *Client) commitment() string {
+	if c.config.Commitment != "" {
+		return c.config.Commitment
+	}
+	return "confirmed"
+}
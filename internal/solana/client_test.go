@@ -0,0 +1,160 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+const testAddress = "11111111111111111111111111111111"
+
+func newTestServer(t *testing.T, delay time.Duration, result string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%s}`, result)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewClientAppliesTransportDefaults(t *testing.T) {
+	client, err := NewClient(&utils.SolanaConfig{Endpoint: "http://localhost"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if client.config.DialTimeout != utils.DefaultDialTimeout {
+		t.Errorf("DialTimeout = %v, want %v", client.config.DialTimeout, utils.DefaultDialTimeout)
+	}
+	if client.config.RequestTimeout != DefaultRequestTimeout {
+		t.Errorf("RequestTimeout = %v, want %v", client.config.RequestTimeout, DefaultRequestTimeout)
+	}
+	if transport := client.transport; transport.MaxConnsPerHost != utils.DefaultMaxConnsPerHost {
+		t.Errorf("MaxConnsPerHost = %d, want %d", transport.MaxConnsPerHost, utils.DefaultMaxConnsPerHost)
+	}
+}
+
+func TestConcurrentCallsReuseConnections(t *testing.T) {
+	srv := newTestServer(t, time.Millisecond, `{"context":{"slot":1},"value":5}`)
+	config := &utils.SolanaConfig{Endpoint: srv.URL, MaxConnsPerHost: 3}
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	other, _ := NewClient(config)
+	if other.transport != client.transport {
+		t.Fatal("clients with the same settings do not share a transport")
+	}
+	other.Close()
+	// The shared transport counts the dials of every earlier client, so
+	// count this test's on a transport of its own.
+	client.transport = utils.NewTransport(utils.TransportConfig{MaxConnsPerHost: 3})
+	client.httpClient = &http.Client{Transport: client.transport}
+	t.Cleanup(client.transport.CloseIdleConnections)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := client.GetBalance(context.Background(), testAddress); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if dials := client.transport.Dials(); dials > 3 {
+		t.Fatalf("200 concurrent calls opened %d connections, want at most 3", dials)
+	}
+	if open := client.GetMetrics()["open_connections"].(int64); open < 1 || open > 3 {
+		t.Fatalf("open_connections = %d, want 1-3", open)
+	}
+}
+
+func TestRequestTimeoutAppliesWithoutDeadline(t *testing.T) {
+	srv := newTestServer(t, 200*time.Millisecond, `{"context":{"slot":1},"value":5}`)
+	client, err := NewClient(&utils.SolanaConfig{
+		Endpoint:       srv.URL,
+		RequestTimeout: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	_, err = client.GetBalance(context.Background(), testAddress)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetBalance error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestContextDeadlineOverridesRequestTimeout(t *testing.T) {
+	srv := newTestServer(t, 50*time.Millisecond, `{"context":{"slot":1},"value":5}`)
+	client, err := NewClient(&utils.SolanaConfig{
+		Endpoint:       srv.URL,
+		RequestTimeout: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	balance, err := client.GetBalance(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance != 5 {
+		t.Fatalf("balance = %d, want 5", balance)
+	}
+}
+
+func TestCustomHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1},"value":5}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(&utils.SolanaConfig{
+		Endpoint:  srv.URL,
+		UserAgent: "vae/1.0",
+		Headers:   map[string]string{"X-Route": "east", "User-Agent": "ignored"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if _, err := client.GetBalance(context.Background(), testAddress); err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if got.Get("User-Agent") != "vae/1.0" || got.Get("X-Route") != "east" {
+		t.Fatalf("headers = %v", got)
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Fatalf("Content-Type = %q", got.Get("Content-Type"))
+	}
+
+	_, err = NewClient(&utils.SolanaConfig{
+		Endpoint: srv.URL,
+		Headers:  map[string]string{"content-type": "text/plain"},
+	})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewClient with reserved header = %v, want ErrInvalidConfig", err)
+	}
+}
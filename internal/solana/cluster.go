@@ -0,0 +1,146 @@
+package solana
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Cluster identifies a Solana network.
+type Cluster string
+
+// Known clusters. ClusterUnknown is returned by DetectCluster for a network
+// it does not recognise.
+const (
+	ClusterMainnet  Cluster = "mainnet-beta"
+	ClusterDevnet   Cluster = "devnet"
+	ClusterTestnet  Cluster = "testnet"
+	ClusterLocalnet Cluster = "localnet"
+	ClusterUnknown  Cluster = "unknown"
+)
+
+type clusterPreset struct {
+	endpoint    string
+	wsEndpoint  string
+	genesisHash string
+}
+
+// clusterPresets are the public endpoints and genesis hashes of the known
+// clusters. A local validator generates a new genesis on every reset, so
+// localnet is recognised by its address instead.
+var clusterPresets = map[Cluster]clusterPreset{
+	ClusterMainnet: {
+		endpoint:    "https://api.mainnet-beta.solana.com",
+		genesisHash: "5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dw2N9d",
+	},
+	ClusterDevnet: {
+		endpoint:    "https://api.devnet.solana.com",
+		genesisHash: "EtWTRABZaYq6iMfeYKouRu166VU2xqa1wcaWoxPkrZBG",
+	},
+	ClusterTestnet: {
+		endpoint:    "https://api.testnet.solana.com",
+		genesisHash: "4uhcVJyU9pJkvQyS88uRDiswHXSCkY3zQawwpjk2NsNY",
+	},
+	ClusterLocalnet: {
+		endpoint:   "http://127.0.0.1:8899",
+		wsEndpoint: "ws://127.0.0.1:8900",
+	},
+}
+
+// Endpoint returns the cluster's public RPC endpoint, or "" for an unknown
+// cluster.
+func (c Cluster) Endpoint() string {
+	return clusterPresets[c].endpoint
+}
+
+// GenesisHash returns the genesis hash of the cluster, or "" for localnet
+// and unknown clusters.
+func (c Cluster) GenesisHash() string {
+	return clusterPresets[c].genesisHash
+}
+
+// ParseCluster returns the cluster named s. "mainnet" is accepted as an
+// alias for mainnet-beta.
+func ParseCluster(s string) (Cluster, error) {
+	if s == "mainnet" {
+		return ClusterMainnet, nil
+	}
+	c := Cluster(s)
+	if _, ok := clusterPresets[c]; !ok {
+		return "", fmt.Errorf("%w: unknown cluster %q", ErrInvalidConfig, s)
+	}
+	return c, nil
+}
+
+// GetGenesisHash returns the genesis hash of the node's network.
+func (c *Client) GetGenesisHash(ctx context.Context) (string, error) {
+	var hash string
+	if err := c.call(ctx, "getGenesisHash", nil, &hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// VerifyNetwork checks that every endpoint of the client, including
+// fallbacks, serves the network with genesis hash expected, such as
+// ClusterMainnet.GenesisHash(). A mismatch fails with ErrNetworkMismatch,
+// naming the endpoint and the network it serves instead.
+func (c *Client) VerifyNetwork(ctx context.Context, expected string) error {
+	if expected == "" {
+		return fmt.Errorf("%w: expected genesis hash is empty", ErrInvalidConfig)
+	}
+	for _, endpoint := range c.endpoints.order() {
+		var hash string
+		if err := c.post(ctx, endpoint, "getGenesisHash", nil, &hash); err != nil {
+			return fmt.Errorf("verify network of %s: %w", endpointLabel(endpoint), err)
+		}
+		if hash != expected {
+			return fmt.Errorf("%w: %s has genesis %s (%s), want %s (%s)", ErrNetworkMismatch,
+				endpointLabel(endpoint), hash, clusterForGenesis(hash, endpoint), expected, clusterForGenesis(expected, ""))
+		}
+	}
+	return nil
+}
+
+// expectedGenesis resolves SolanaConfig.ExpectedGenesis, which may name a
+// cluster, to a genesis hash.
+func expectedGenesis(s string) string {
+	if cluster, err := ParseCluster(s); err == nil && cluster.GenesisHash() != "" {
+		return cluster.GenesisHash()
+	}
+	return s
+}
+
+// DetectCluster reports which known cluster the endpoint belongs to by
+// comparing its genesis hash with the public clusters'. A node on a loopback
+// address with an unrecognised genesis is reported as ClusterLocalnet.
+// The result is cached after the first successful call.
+func (c *Client) DetectCluster(ctx context.Context) (Cluster, error) {
+	c.clusterMu.Lock()
+	defer c.clusterMu.Unlock()
+	if c.cluster != "" {
+		return c.cluster, nil
+	}
+
+	hash, err := c.GetGenesisHash(ctx)
+	if err != nil {
+		return ClusterUnknown, fmt.Errorf("detect cluster: %w", err)
+	}
+	c.cluster = clusterForGenesis(hash, c.endpoint)
+	return c.cluster, nil
+}
+
+func clusterForGenesis(hash, endpoint string) Cluster {
+	for cluster, preset := range clusterPresets {
+		if preset.genesisHash != "" && preset.genesisHash == hash {
+			return cluster
+		}
+	}
+	if u, err := url.Parse(endpoint); err == nil {
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+			return ClusterLocalnet
+		}
+	}
+	return ClusterUnknown
+}
@@ -0,0 +1,89 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestClusterPresets(t *testing.T) {
+	client, err := NewClient(&utils.SolanaConfig{Cluster: "devnet"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if client.endpoint != ClusterDevnet.Endpoint() {
+		t.Fatalf("endpoint = %q, want %q", client.endpoint, ClusterDevnet.Endpoint())
+	}
+	if _, err := NewClient(&utils.SolanaConfig{Cluster: "moon"}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewClient with unknown cluster = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestAirdropRefusedOnMainnet(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getGenesisHash": func(json.RawMessage) (interface{}, error) {
+			return clusterPresets[ClusterMainnet].genesisHash, nil
+		},
+		"requestAirdrop": func(json.RawMessage) (interface{}, error) {
+			return "sig", nil
+		},
+	})
+	client := rpc.client(t)
+
+	cluster, err := client.DetectCluster(context.Background())
+	if err != nil || cluster != ClusterMainnet {
+		t.Fatalf("DetectCluster = %v, %v", cluster, err)
+	}
+	if _, err := client.RequestAirdrop(context.Background(), testAddress, 1); !errors.Is(err, ErrAirdropOnMainnet) {
+		t.Fatalf("RequestAirdrop = %v, want ErrAirdropOnMainnet", err)
+	}
+	if rpc.count("requestAirdrop") != 0 || rpc.count("getGenesisHash") != 1 {
+		t.Fatal("airdrop was sent or the detected cluster was not cached")
+	}
+}
+
+func TestDetectLocalnet(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getGenesisHash": func(json.RawMessage) (interface{}, error) {
+			return "random-local-genesis", nil
+		},
+	})
+	// httptest servers listen on 127.0.0.1.
+	cluster, err := rpc.client(t).DetectCluster(context.Background())
+	if err != nil || cluster != ClusterLocalnet {
+		t.Fatalf("DetectCluster = %v, %v, want localnet", cluster, err)
+	}
+}
+
+func TestVerifyNetwork(t *testing.T) {
+	genesis := func(hash string) *fakeRPC {
+		return newFakeRPC(t, map[string]rpcHandler{
+			"getGenesisHash": func(json.RawMessage) (interface{}, error) { return hash, nil },
+		})
+	}
+	devnet, mainnet := genesis(ClusterDevnet.GenesisHash()), genesis(ClusterMainnet.GenesisHash())
+
+	client := devnet.client(t)
+	if err := client.VerifyNetwork(context.Background(), ClusterDevnet.GenesisHash()); err != nil {
+		t.Fatalf("VerifyNetwork on the expected network: %v", err)
+	}
+	if err := client.VerifyNetwork(context.Background(), ClusterMainnet.GenesisHash()); !errors.Is(err, ErrNetworkMismatch) {
+		t.Fatalf("VerifyNetwork on the wrong network = %v, want ErrNetworkMismatch", err)
+	}
+
+	if _, err := NewClient(&utils.SolanaConfig{Endpoint: devnet.srv.URL, ExpectedGenesis: "devnet"}); err != nil {
+		t.Fatalf("NewClient on the expected network: %v", err)
+	}
+	// A misconfigured fallback is caught too.
+	_, err := NewClient(&utils.SolanaConfig{
+		Endpoint:          devnet.srv.URL,
+		FallbackEndpoints: []string{mainnet.srv.URL},
+		ExpectedGenesis:   ClusterDevnet.GenesisHash(),
+	})
+	if !errors.Is(err, ErrNetworkMismatch) {
+		t.Fatalf("NewClient with a mainnet fallback = %v, want ErrNetworkMismatch", err)
+	}
+}
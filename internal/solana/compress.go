@@ -0,0 +1,113 @@
+package solana
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// DefaultGzipMinRequestSize is the smallest request body compressed when
+// request compression is enabled. Smaller bodies gain little and cost CPU.
+const DefaultGzipMinRequestSize = 16 << 10
+
+// exchange posts body to endpoint and returns the response body, decoded
+// if it was gzipped, and status. With request compression enabled a large
+// body is sent gzipped; if the node rejects that with 415, compression is
+// turned off for the client and the body is resent plain.
+func (c *Client) exchange(ctx context.Context, endpoint, method string, body []byte) ([]byte, int, error) {
+	payload, gzipped := c.compressRequest(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, 0, fmt.Errorf("create %s request: %w", method, err)
+	}
+	utils.ApplyHeaders(req.Header, c.config.Headers, c.config.UserAgent)
+	req.Header.Set("Content-Type", "application/json")
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if c.config.Compression.Responses {
+		// Setting the header ourselves stops the transport from
+		// decompressing transparently, so the savings can be counted.
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if gzipped && resp.StatusCode == http.StatusUnsupportedMediaType {
+		if c.gzipRejected.CompareAndSwap(false, true) {
+			c.logger.Warn("RPC node rejected a compressed request; sending plain bodies", map[string]interface{}{
+				"endpoint": endpointLabel(endpoint),
+			})
+		}
+		return c.exchange(ctx, endpoint, method, body)
+	}
+	data, err := c.readResponse(resp)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read %s response: %w", method, err)
+	}
+	// Savings count only once the node has accepted the compressed body.
+	if gzipped && resp.StatusCode < http.StatusBadRequest {
+		c.metrics.requestBytesSaved.Add(uint64(len(body) - len(payload)))
+	}
+	return data, resp.StatusCode, nil
+}
+
+// compressRequest gzips body when request compression is enabled, the
+// body is large enough, and no node has rejected compressed bodies. It
+// returns body unchanged if compressing would not make it smaller.
+func (c *Client) compressRequest(body []byte) ([]byte, bool) {
+	cfg := c.config.Compression
+	if !cfg.Requests || len(body) < cfg.MinRequestSize || c.gzipRejected.Load() {
+		return body, false
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(body)
+	if err := zw.Close(); err != nil || buf.Len() >= len(body) {
+		return body, false
+	}
+	return buf.Bytes(), true
+}
+
+// readResponse reads resp's body, decompressing it if the node gzipped it.
+func (c *Client) readResponse(resp *http.Response) ([]byte, error) {
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return io.ReadAll(resp.Body)
+	}
+	counted := &countingReader{r: resp.Body}
+	zr, err := gzip.NewReader(counted)
+	if err != nil {
+		return nil, fmt.Errorf("gzip response: %w", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("gzip response: %w", err)
+	}
+	// Counted only after the whole body decompressed.
+	if saved := len(data) - counted.n; saved > 0 {
+		c.metrics.responseBytesSaved.Add(uint64(saved))
+	}
+	return data, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
@@ -0,0 +1,125 @@
+package solana
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestGzipResponses(t *testing.T) {
+	padding := strings.Repeat("a", 4096)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":%q}`, padding)
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			io.WriteString(w, body)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, body)
+		zw.Close()
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&utils.SolanaConfig{
+		Endpoint:    srv.URL,
+		Compression: utils.CompressionConfig{Responses: true},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	var got string
+	if err := client.Call(context.Background(), "getProgramAccounts", nil, &got); err != nil || got != padding {
+		t.Fatalf("Call = %d bytes, %v", len(got), err)
+	}
+	saved := client.GetMetrics()["gzip_bytes_saved"].(map[string]interface{})
+	if saved["response"].(uint64) < 4000 || saved["request"] != uint64(0) {
+		t.Fatalf("bytes saved = %v", saved)
+	}
+}
+
+func TestGzipRequestsFallBack(t *testing.T) {
+	var gzipped, rejected atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gzipped.Add(1)
+			rejected.Add(1)
+			http.Error(w, "unsupported", http.StatusUnsupportedMediaType)
+			return
+		}
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":null}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(&utils.SolanaConfig{
+		Endpoint:    srv.URL,
+		Compression: utils.CompressionConfig{Requests: true, MinRequestSize: 64},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	large := []interface{}{strings.Repeat("k", 1024)}
+	for i := 0; i < 2; i++ {
+		if err := client.Call(context.Background(), "getMultipleAccounts", large, nil); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if gzipped.Load() != 1 || rejected.Load() != 1 {
+		t.Fatalf("compressed attempts = %d, want 1 before falling back", gzipped.Load())
+	}
+
+	// A compressed body the node failed on saved nothing.
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+	client, _ = NewClient(&utils.SolanaConfig{
+		Endpoint:    failing.URL,
+		Compression: utils.CompressionConfig{Requests: true, MinRequestSize: 64},
+	})
+	if err := client.Call(context.Background(), "getMultipleAccounts", large, nil); err == nil {
+		t.Fatal("Call succeeded against a failing node")
+	}
+	if saved := client.GetMetrics()["gzip_bytes_saved"].(map[string]interface{}); saved["request"] != uint64(0) {
+		t.Fatalf("request savings counted for a failed call: %v", saved)
+	}
+
+	// A node that accepts compressed bodies receives them intact.
+	var decoded bytes.Buffer
+	accepting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, _ := gzip.NewReader(r.Body)
+			io.Copy(&decoded, zr)
+		}
+		io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":null}`)
+	}))
+	t.Cleanup(accepting.Close)
+	client, _ = NewClient(&utils.SolanaConfig{
+		Endpoint:    accepting.URL,
+		Compression: utils.CompressionConfig{Requests: true, MinRequestSize: 64},
+	})
+	if err := client.Call(context.Background(), "getMultipleAccounts", large, nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if !strings.Contains(decoded.String(), "getMultipleAccounts") {
+		t.Fatalf("decoded body = %q", decoded.String())
+	}
+	if saved := client.GetMetrics()["gzip_bytes_saved"].(map[string]interface{}); saved["request"].(uint64) == 0 {
+		t.Fatal("request savings not counted")
+	}
+}
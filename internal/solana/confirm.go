@@ -0,0 +1,87 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ConfirmTransactionWS waits until signature reaches commitment using a
+// signatureSubscribe notification instead of polling. If the WebSocket is
+// unavailable or disabled, or the subscription fails, it falls back to
+// ConfirmTransaction.
+func (c *Client) ConfirmTransactionWS(ctx context.Context, signature, commitment string) error {
+	if commitment == "" {
+		commitment = c.commitment()
+	}
+	if _, ok := commitmentRank[commitment]; !ok {
+		return fmt.Errorf("unknown commitment %q", commitment)
+	}
+
+	ws, err := c.websocket(ctx)
+	if err != nil {
+		c.logger.Debug("WebSocket unavailable, polling for confirmation", map[string]interface{}{
+			"signature": signature,
+			"error":     err.Error(),
+		})
+		return c.ConfirmTransaction(ctx, signature, commitment)
+	}
+
+	sub, err := ws.subscribe(ctx, "signatureSubscribe", "signatureUnsubscribe", []interface{}{
+		signature,
+		map[string]interface{}{"commitment": commitment},
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.logger.Debug("signatureSubscribe failed, polling for confirmation", map[string]interface{}{
+			"signature": signature,
+			"error":     err.Error(),
+		})
+		return c.ConfirmTransaction(ctx, signature, commitment)
+	}
+
+	// The transaction may have reached the commitment before the
+	// subscription was established, in which case no notification comes.
+	status, err := c.getSignatureStatus(ctx, signature)
+	if err == nil && status != nil {
+		if status.failed() {
+			sub.unsubscribe(ctx)
+			return &TransactionError{Signature: signature, Err: status.Err}
+		}
+		if commitmentReached(status.ConfirmationStatus, commitment) {
+			c.noteWriteSlot(status.Slot)
+			sub.unsubscribe(ctx)
+			return nil
+		}
+	}
+
+	select {
+	case payload, ok := <-sub.notifications:
+		if !ok {
+			return c.ConfirmTransaction(ctx, signature, commitment)
+		}
+		// The server cancels signature subscriptions after notifying.
+		sub.remove()
+
+		var notification contextResult
+		if err := json.Unmarshal(payload, &notification); err != nil {
+			return fmt.Errorf("decode signature notification: %w", err)
+		}
+		var value struct {
+			Err json.RawMessage `json:"err"`
+		}
+		if err := json.Unmarshal(notification.Value, &value); err != nil {
+			return fmt.Errorf("decode signature notification: %w", err)
+		}
+		if len(value.Err) > 0 && string(value.Err) != "null" {
+			return &TransactionError{Signature: signature, Err: value.Err}
+		}
+		c.noteWriteSlot(notification.Context.Slot)
+		return nil
+	case <-ctx.Done():
+		sub.unsubscribe(context.Background())
+		return ctx.Err()
+	}
+}
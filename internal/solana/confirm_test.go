@@ -0,0 +1,96 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// servePubSub acknowledges signatureSubscribe and, if notify is true, sends a
+// successful signature notification straight after.
+func servePubSub(notify bool) func(conn *websocket.Conn) {
+	return func(conn *websocket.Conn) {
+		for {
+			var req struct {
+				ID     uint64 `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": 7})
+			if req.Method == "signatureSubscribe" && notify {
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "signatureNotification",
+					"params": map[string]interface{}{
+						"subscription": 7,
+						"result":       withContext(map[string]interface{}{"err": nil}),
+					},
+				})
+			}
+		}
+	}
+}
+
+func TestConfirmTransactionWSNotification(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getSignatureStatuses": func(json.RawMessage) (interface{}, error) {
+			return withContext([]interface{}{nil}), nil
+		},
+	})
+	rpc.pubsub = servePubSub(true)
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.ConfirmTransactionWS(ctx, "sig", CommitmentConfirmed); err != nil {
+		t.Fatalf("ConfirmTransactionWS: %v", err)
+	}
+}
+
+func TestConfirmTransactionWSAlreadyConfirmed(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getSignatureStatuses": func(json.RawMessage) (interface{}, error) {
+			return withContext([]interface{}{map[string]interface{}{
+				"slot": 1, "confirmationStatus": "finalized",
+			}}), nil
+		},
+	})
+	rpc.pubsub = servePubSub(false)
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.ConfirmTransactionWS(ctx, "sig", CommitmentConfirmed); err != nil {
+		t.Fatalf("ConfirmTransactionWS: %v", err)
+	}
+}
+
+func TestConfirmTransactionWSFallsBackToPolling(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getSignatureStatuses": func(json.RawMessage) (interface{}, error) {
+			return withContext([]interface{}{map[string]interface{}{
+				"slot": 1, "confirmationStatus": "confirmed",
+			}}), nil
+		},
+	})
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.ConfirmTransactionWS(ctx, "sig", CommitmentConfirmed); err != nil {
+		t.Fatalf("ConfirmTransactionWS: %v", err)
+	}
+	if rpc.count("getSignatureStatuses") == 0 {
+		t.Fatal("did not fall back to polling")
+	}
+}
@@ -0,0 +1,70 @@
+package solana
+
+import "crypto/sha256"
+
+// DefaultDedupWindow is a reasonable window for WithNotificationDedup: large
+// enough to cover the replay a provider sends after a reconnect.
+const DefaultDedupWindow = 256
+
+// WithNotificationDedup drops subscription notifications identical to one of
+// the last window notifications on the same subscription. Some providers
+// replay recent notifications when a connection is re-established, which
+// consumers would otherwise process twice.
+//
+// A notification is identified by its slot and a digest of its value, which
+// includes the account data and so stands in for the account version.
+// Distinct updates in the same slot differ in content and are all delivered.
+// Each subscription remembers at most window notifications. Dedup is off by
+// default; a window of zero or less disables it.
+func WithNotificationDedup(window int) ClientOption {
+	return func(c *Client) {
+		c.dedupWindow = window
+	}
+}
+
+type dedupKey struct {
+	slot   uint64
+	digest [sha256.Size]byte
+}
+
+// notificationDedup remembers the most recent notifications in a ring. It is
+// used only by a subscription's forwarding goroutine and is not locked.
+type notificationDedup struct {
+	seen map[dedupKey]struct{}
+	ring []dedupKey
+	next int
+	full bool
+}
+
+func newNotificationDedup(window int) *notificationDedup {
+	if window <= 0 {
+		return nil
+	}
+	return &notificationDedup{
+		seen: make(map[dedupKey]struct{}, window),
+		ring: make([]dedupKey, window),
+	}
+}
+
+// duplicate reports whether n was seen within the window, recording it if
+// not. A nil dedup never reports duplicates.
+func (d *notificationDedup) duplicate(n Notification) bool {
+	if d == nil {
+		return false
+	}
+	key := dedupKey{slot: n.Slot, digest: sha256.Sum256(n.Value)}
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	if d.full {
+		delete(d.seen, d.ring[d.next])
+	}
+	d.ring[d.next] = key
+	d.seen[key] = struct{}{}
+	d.next++
+	if d.next == len(d.ring) {
+		d.next = 0
+		d.full = true
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSubscriptionDedup(t *testing.T) {
+	var unsubscribes atomic.Int32
+	rpc := newFakeRPC(t, nil)
+	rpc.pubsub = func(conn *websocket.Conn) {
+		for {
+			var req struct {
+				ID     uint64 `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method == "accountUnsubscribe" {
+				unsubscribes.Add(1)
+				conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": true})
+				continue
+			}
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": 1})
+			// A replayed notification, then a distinct update in the same
+			// slot, then one that pushes the first out of the window.
+			for _, lamports := range []int{5, 5, 6, 7, 5} {
+				conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "accountNotification",
+					"params": map[string]interface{}{
+						"subscription": 1,
+						"result":       withContext(map[string]interface{}{"lamports": lamports}),
+					},
+				})
+			}
+		}
+	}
+	client := rpc.client(t)
+	WithNotificationDedup(2)(client)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, err := client.SubscribeToAccountChanges(ctx, wallet.PublicKey())
+	if err != nil {
+		t.Fatalf("SubscribeToAccountChanges: %v", err)
+	}
+
+	var got []int
+	for _, want := range []int{5, 6, 7, 5} {
+		n := <-sub.C()
+		var account struct {
+			Lamports int `json:"lamports"`
+		}
+		json.Unmarshal(n.Value, &account)
+		got = append(got, account.Lamports)
+		if account.Lamports != want {
+			t.Fatalf("delivered lamports %v, want prefix of [5 6 7 5]", got)
+		}
+	}
+	if n := sub.Duplicates(); n != 1 {
+		t.Fatalf("Duplicates = %d, want 1", n)
+	}
+	if n := client.GetMetrics()["duplicate_notifications"]; n != uint64(1) {
+		t.Fatalf("duplicate_notifications = %v, want 1", n)
+	}
+}
+
+func TestNotificationDedupDisabled(t *testing.T) {
+	d := newNotificationDedup(0)
+	n := Notification{Slot: 1, Value: json.RawMessage(`{}`)}
+	if d.duplicate(n) || d.duplicate(n) {
+		t.Fatal("disabled dedup reported a duplicate")
+	}
+}
@@ -0,0 +1,197 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// KeyedTokenAccount is a decoded token account together with its address
+// and the token program that owns it.
+type KeyedTokenAccount struct {
+	Address PublicKey
+	Program PublicKey
+	TokenAccount
+}
+
+// ApproveTokens lets delegate transfer up to amount base units out of the
+// token account account. owner must be a registered wallet owning account;
+// the payer wallet pays the fee. A later approval replaces the previous
+// delegate and allowance.
+func (c *Client) ApproveTokens(ctx context.Context, account, delegate, owner string, amount uint64, opts ...TokenOption) (_ string, err error) {
+	defer wrapOp(&err, "approve %s for %d from %s", delegate, amount, account)
+	o := newTokenOptions(opts)
+	payer, ownerSigner, ownerKey, accountKey, err := c.delegateParties(account, owner)
+	if err != nil {
+		return "", err
+	}
+	delegateKey, err := PublicKeyFromBase58(delegate)
+	if err != nil {
+		return "", err
+	}
+	instruction := ApproveInstruction(o.programID, accountKey, delegateKey, ownerKey, amount)
+	return c.sendInstructions(ctx, payer, []Instruction{instruction}, ownerSigner)
+}
+
+// ApproveTokensChecked is ApproveTokens using ApproveChecked, which fails on
+// chain unless account holds mint. The mint's decimals are looked up and
+// checked by the token program as well.
+func (c *Client) ApproveTokensChecked(ctx context.Context, account, mint, delegate, owner string, amount uint64, opts ...TokenOption) (_ string, err error) {
+	defer wrapOp(&err, "approve %s for %d of %s from %s", delegate, amount, mint, account)
+	o := newTokenOptions(opts)
+	payer, ownerSigner, ownerKey, accountKey, err := c.delegateParties(account, owner)
+	if err != nil {
+		return "", err
+	}
+	mintKey, err := PublicKeyFromBase58(mint)
+	if err != nil {
+		return "", err
+	}
+	delegateKey, err := PublicKeyFromBase58(delegate)
+	if err != nil {
+		return "", err
+	}
+	decimals, err := c.mintDecimals(ctx, mint)
+	if err != nil {
+		return "", err
+	}
+	instruction := ApproveCheckedInstruction(o.programID, accountKey, mintKey, delegateKey, ownerKey, amount, decimals)
+	return c.sendInstructions(ctx, payer, []Instruction{instruction}, ownerSigner)
+}
+
+// RevokeTokens removes the delegate of the token account account. owner
+// must be a registered wallet owning account; the payer wallet pays the fee.
+func (c *Client) RevokeTokens(ctx context.Context, account, owner string, opts ...TokenOption) (_ string, err error) {
+	defer wrapOp(&err, "revoke delegate of %s", account)
+	o := newTokenOptions(opts)
+	payer, ownerSigner, ownerKey, accountKey, err := c.delegateParties(account, owner)
+	if err != nil {
+		return "", err
+	}
+	instruction := RevokeInstruction(o.programID, accountKey, ownerKey)
+	return c.sendInstructions(ctx, payer, []Instruction{instruction}, ownerSigner)
+}
+
+// delegateParties resolves the fee payer, the owner's signer and key, and
+// the token account for a delegate operation.
+func (c *Client) delegateParties(account, owner string) (Signer, Signer, PublicKey, PublicKey, error) {
+	payer, _, err := c.payerSigner()
+	if err != nil {
+		return nil, nil, PublicKey{}, PublicKey{}, err
+	}
+	ownerSigner, ownerKey, err := c.signer(owner)
+	if err != nil {
+		return nil, nil, PublicKey{}, PublicKey{}, err
+	}
+	accountKey, err := PublicKeyFromBase58(account)
+	if err != nil {
+		return nil, nil, PublicKey{}, PublicKey{}, err
+	}
+	return payer, ownerSigner, ownerKey, accountKey, nil
+}
+
+// GetTokenAccountsByDelegate returns the token accounts, under both the
+// Token and Token-2022 programs, that delegate may spend from. It accepts
+// WithCommitment and WithMinContextSlot.
+func (c *Client) GetTokenAccountsByDelegate(ctx context.Context, delegate string, opts ...CallOption) ([]KeyedTokenAccount, error) {
+	if err := ValidateAddress(delegate); err != nil {
+		return nil, err
+	}
+	o, err := c.callOptions(opts, optMinContextSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []KeyedTokenAccount
+	for _, program := range []PublicKey{TokenProgramID, Token2022ProgramID} {
+		var result contextResult
+		params := []interface{}{
+			delegate,
+			map[string]interface{}{"programId": program.String()},
+			o.config(true),
+		}
+		if err := c.call(ctx, "getTokenAccountsByDelegate", params, &result); err != nil {
+			return nil, fmt.Errorf("get token accounts of delegate %s: %w", delegate, err)
+		}
+		keyed, err := decodeKeyedTokenAccounts(result.Value, program)
+		if err != nil {
+			return nil, fmt.Errorf("get token accounts of delegate %s: %w", delegate, err)
+		}
+		accounts = append(accounts, keyed...)
+	}
+	return accounts, nil
+}
+
+// decodeKeyedTokenAccounts decodes a list of {pubkey, account} objects
+// returned by the getTokenAccountsBy* methods.
+func decodeKeyedTokenAccounts(value json.RawMessage, program PublicKey) ([]KeyedTokenAccount, error) {
+	var entries []struct {
+		Pubkey  string         `json:"pubkey"`
+		Account rpcAccountInfo `json:"account"`
+	}
+	if err := json.Unmarshal(value, &entries); err != nil {
+		return nil, fmt.Errorf("decode token accounts: %w", err)
+	}
+
+	accounts := make([]KeyedTokenAccount, 0, len(entries))
+	for _, entry := range entries {
+		address, err := PublicKeyFromBase58(entry.Pubkey)
+		if err != nil {
+			return nil, err
+		}
+		info, err := entry.Account.decode()
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := DecodeTokenAccount(info.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", address, err)
+		}
+		accounts = append(accounts, KeyedTokenAccount{Address: address, Program: program, TokenAccount: *decoded})
+	}
+	return accounts, nil
+}
+
+// ApproveInstruction builds an SPL Token Approve instruction letting
+// delegate transfer up to amount out of source.
+func ApproveInstruction(programID, source, delegate, owner PublicKey, amount uint64) Instruction {
+	return Instruction{
+		ProgramID: programID,
+		Accounts: []AccountMeta{
+			{PublicKey: source, IsWritable: true},
+			{PublicKey: delegate},
+			{PublicKey: owner, IsSigner: true},
+		},
+		Data: append([]byte{tokenInstructionApprove}, putUint64(amount)...),
+	}
+}
+
+// ApproveCheckedInstruction builds an SPL Token ApproveChecked instruction,
+// which also verifies the mint and its decimals.
+func ApproveCheckedInstruction(programID, source, mint, delegate, owner PublicKey, amount uint64, decimals uint8) Instruction {
+	data := append([]byte{tokenInstructionApproveChecked}, putUint64(amount)...)
+	data = append(data, decimals)
+	return Instruction{
+		ProgramID: programID,
+		Accounts: []AccountMeta{
+			{PublicKey: source, IsWritable: true},
+			{PublicKey: mint},
+			{PublicKey: delegate},
+			{PublicKey: owner, IsSigner: true},
+		},
+		Data: data,
+	}
+}
+
+// RevokeInstruction builds an SPL Token Revoke instruction clearing the
+// delegate of source.
+func RevokeInstruction(programID, source, owner PublicKey) Instruction {
+	return Instruction{
+		ProgramID: programID,
+		Accounts: []AccountMeta{
+			{PublicKey: source, IsWritable: true},
+			{PublicKey: owner, IsSigner: true},
+		},
+		Data: []byte{tokenInstructionRevoke},
+	}
+}
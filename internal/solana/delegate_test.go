@@ -0,0 +1,98 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+func TestApproveCheckedInstruction(t *testing.T) {
+	keys := make([]PublicKey, 4)
+	for i := range keys {
+		w, _ := NewWallet()
+		keys[i] = w.Key()
+	}
+	source, mint, delegate, owner := keys[0], keys[1], keys[2], keys[3]
+
+	ix := ApproveCheckedInstruction(TokenProgramID, source, mint, delegate, owner, 750, 6)
+	if len(ix.Data) != 10 || ix.Data[0] != tokenInstructionApproveChecked ||
+		binary.LittleEndian.Uint64(ix.Data[1:]) != 750 || ix.Data[9] != 6 {
+		t.Fatalf("data = %v", ix.Data)
+	}
+	want := []AccountMeta{
+		{PublicKey: source, IsWritable: true},
+		{PublicKey: mint},
+		{PublicKey: delegate},
+		{PublicKey: owner, IsSigner: true},
+	}
+	for i, meta := range ix.Accounts {
+		if meta != want[i] {
+			t.Errorf("account %d = %+v, want %+v", i, meta, want[i])
+		}
+	}
+
+	revoke := RevokeInstruction(Token2022ProgramID, source, owner)
+	if revoke.ProgramID != Token2022ProgramID || len(revoke.Data) != 1 || revoke.Data[0] != tokenInstructionRevoke {
+		t.Fatalf("revoke = %+v", revoke)
+	}
+}
+
+func TestGetTokenAccountsByDelegate(t *testing.T) {
+	keys := make([]PublicKey, 4)
+	for i := range keys {
+		w, _ := NewWallet()
+		keys[i] = w.Key()
+	}
+	mint, owner, delegate, address := keys[0], keys[1], keys[2], keys[3]
+	data := make([]byte, TokenAccountSize)
+	copy(data[0:], mint[:])
+	copy(data[32:], owner[:])
+	binary.LittleEndian.PutUint64(data[64:], 500)
+	binary.LittleEndian.PutUint32(data[72:], 1)
+	copy(data[76:], delegate[:])
+	data[108] = byte(TokenAccountInitialized)
+	binary.LittleEndian.PutUint64(data[121:], 100)
+
+	var programs []string
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getTokenAccountsByDelegate": func(params json.RawMessage) (interface{}, error) {
+			var p []json.RawMessage
+			json.Unmarshal(params, &p)
+			var filter struct {
+				ProgramID string `json:"programId"`
+			}
+			json.Unmarshal(p[1], &filter)
+			programs = append(programs, filter.ProgramID)
+			if filter.ProgramID != TokenProgramID.String() {
+				return withContext([]interface{}{}), nil
+			}
+			return withContext([]interface{}{map[string]interface{}{
+				"pubkey": address.String(),
+				"account": map[string]interface{}{
+					"lamports": 1,
+					"owner":    TokenProgramID.String(),
+					"data":     []string{base64.StdEncoding.EncodeToString(data), "base64"},
+				},
+			}}), nil
+		},
+	})
+	client := rpc.client(t)
+
+	accounts, err := client.GetTokenAccountsByDelegate(context.Background(), delegate.String())
+	if err != nil {
+		t.Fatalf("GetTokenAccountsByDelegate: %v", err)
+	}
+	if len(programs) != 2 || programs[0] != TokenProgramID.String() || programs[1] != Token2022ProgramID.String() {
+		t.Fatalf("queried programs = %v", programs)
+	}
+	if len(accounts) != 1 {
+		t.Fatalf("got %d accounts, want 1", len(accounts))
+	}
+	got := accounts[0]
+	if got.Address != address || got.Program != TokenProgramID || got.Owner != owner ||
+		got.Delegate == nil || *got.Delegate != delegate || got.DelegatedAmount != 100 {
+		t.Fatalf("account = %+v", got)
+	}
+}
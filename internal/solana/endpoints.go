@@ -0,0 +1,282 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// Endpoint routing strategies for utils.RoutingConfig.Strategy.
+const (
+	RoutingFailover = "failover"
+	RoutingLatency  = "latency"
+)
+
+// Routing defaults applied when the config leaves them unset.
+const (
+	DefaultProbeInterval    = 10 * time.Second
+	DefaultEndpointCooldown = 30 * time.Second
+)
+
+// probeLatencyWeight is the weight of a new probe in an endpoint's smoothed
+// latency, so one slow probe does not reorder endpoints on its own.
+const probeLatencyWeight = 0.3
+
+// endpointPool tracks the RPC endpoints of a client and the order to try
+// them in. An endpoint whose call failed is tried after the others until its
+// cooldown passes or it answers again.
+type endpointPool struct {
+	strategy string
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	endpoints []*endpointState
+}
+
+type endpointState struct {
+	url      string
+	latency  time.Duration // smoothed probe latency; zero until probed
+	failedAt time.Time     // zero while healthy
+	failures uint64
+}
+
+func newEndpointPool(cfg *utils.SolanaConfig) (*endpointPool, error) {
+	p := &endpointPool{
+		strategy: cfg.Routing.Strategy,
+		cooldown: cfg.Routing.Cooldown,
+		now:      time.Now,
+	}
+	switch p.strategy {
+	case "":
+		p.strategy = RoutingFailover
+	case RoutingFailover, RoutingLatency:
+	default:
+		return nil, fmt.Errorf("%w: unknown routing strategy %q", ErrInvalidConfig, p.strategy)
+	}
+	if p.cooldown <= 0 {
+		p.cooldown = DefaultEndpointCooldown
+	}
+
+	seen := make(map[string]bool)
+	for _, endpoint := range append([]string{cfg.Endpoint}, cfg.FallbackEndpoints...) {
+		if endpoint == "" {
+			return nil, fmt.Errorf("%w: empty fallback endpoint", ErrInvalidConfig)
+		}
+		if seen[endpoint] {
+			continue
+		}
+		seen[endpoint] = true
+		p.endpoints = append(p.endpoints, &endpointState{url: endpoint})
+	}
+	return p, nil
+}
+
+// order returns the endpoints to try, best first. Endpoints in cooldown
+// come last, most recently failed last of all, so a call still succeeds if
+// only they are up.
+func (p *endpointPool) order() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var healthy, cooling []*endpointState
+	for _, e := range p.endpoints {
+		if e.failedAt.IsZero() || now.Sub(e.failedAt) >= p.cooldown {
+			healthy = append(healthy, e)
+		} else {
+			cooling = append(cooling, e)
+		}
+	}
+	if p.strategy == RoutingLatency {
+		// Unprobed endpoints keep their configured order after the
+		// probed ones.
+		sort.SliceStable(healthy, func(i, j int) bool {
+			a, b := healthy[i].latency, healthy[j].latency
+			return a != 0 && (b == 0 || a < b)
+		})
+	}
+	sort.SliceStable(cooling, func(i, j int) bool {
+		return cooling[i].failedAt.Before(cooling[j].failedAt)
+	})
+
+	urls := make([]string, 0, len(p.endpoints))
+	for _, e := range append(healthy, cooling...) {
+		urls = append(urls, e.url)
+	}
+	return urls
+}
+
+func (p *endpointPool) succeeded(endpoint string) {
+	p.update(endpoint, func(e *endpointState) {
+		e.failedAt = time.Time{}
+	})
+}
+
+func (p *endpointPool) failed(endpoint string) {
+	p.update(endpoint, func(e *endpointState) {
+		e.failedAt = p.now()
+		e.failures++
+	})
+}
+
+// probed records a health probe of endpoint that took d.
+func (p *endpointPool) probed(endpoint string, d time.Duration, err error) {
+	if err != nil {
+		p.failed(endpoint)
+		return
+	}
+	p.update(endpoint, func(e *endpointState) {
+		e.failedAt = time.Time{}
+		if e.latency == 0 {
+			e.latency = d
+		} else {
+			e.latency = time.Duration(probeLatencyWeight*float64(d) + (1-probeLatencyWeight)*float64(e.latency))
+		}
+	})
+}
+
+func (p *endpointPool) update(endpoint string, fn func(*endpointState)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.endpoints {
+		if e.url == endpoint {
+			fn(e)
+			return
+		}
+	}
+}
+
+// endpointStats is the metrics view of one endpoint.
+type endpointStats struct {
+	label    string
+	latency  time.Duration
+	healthy  bool
+	failures uint64
+}
+
+func (p *endpointPool) stats() []endpointStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	out := make([]endpointStats, len(p.endpoints))
+	for i, e := range p.endpoints {
+		out[i] = endpointStats{
+			label:    endpointLabel(e.url),
+			latency:  e.latency,
+			healthy:  e.failedAt.IsZero() || now.Sub(e.failedAt) >= p.cooldown,
+			failures: e.failures,
+		}
+	}
+	return out
+}
+
+func (p *endpointPool) metrics() map[string]interface{} {
+	out := make(map[string]interface{})
+	for _, s := range p.stats() {
+		out[s.label] = map[string]interface{}{
+			"latency_seconds": s.latency.Seconds(),
+			"healthy":         s.healthy,
+			"failures_total":  s.failures,
+		}
+	}
+	return out
+}
+
+// endpointLabel identifies an endpoint in metrics by scheme and host only,
+// since providers often put API keys in the path or query.
+func endpointLabel(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "invalid"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// failoverable reports whether a failed request should be retried on the
+// next endpoint: the endpoint did not answer, or answered with an overload
+// or server error. JSON-RPC errors come from a working node and are
+// returned as is.
+func failoverable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var rpcErr *RPCError
+	return !errors.As(err, &rpcErr)
+}
+
+// replayable reports whether method may be sent to another endpoint after
+// a failed one may already have processed it: reads, and sendTransaction,
+// whose signature makes the transaction land at most once. Other writes,
+// such as requestAirdrop or a method sent through Call, could take effect
+// twice and fail over only when the request was not delivered.
+func replayable(method string) bool {
+	switch method {
+	case "sendTransaction", "simulateTransaction", "isBlockhashValid", "minimumLedgerSlot":
+		return true
+	}
+	return strings.HasPrefix(method, "get")
+}
+
+// undelivered reports whether err shows the request never reached a node:
+// the connection could not be made, or the endpoint refused it for rate
+// limiting.
+func undelivered(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var statusErr *HTTPStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests
+}
+
+// probeLoop probes every endpoint each interval until stop is closed.
+func (c *Client) probeLoop(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.probeEndpoints(context.Background())
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// probeEndpoints measures the getHealth round trip of every endpoint
+// concurrently. Probes are not counted in the RPC metrics.
+func (c *Client) probeEndpoints(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, endpoint := range c.endpoints.order() {
+		wg.Add(1)
+		go func(endpoint string) {
+			defer wg.Done()
+			start := time.Now()
+			var status string
+			err := c.post(ctx, endpoint, "getHealth", nil, &status)
+			if err == nil && status != "ok" {
+				err = fmt.Errorf("%w: status %q", ErrNodeUnhealthy, status)
+			}
+			c.endpoints.probed(endpoint, time.Since(start), err)
+		}(endpoint)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,95 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestEndpointFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getSlot": func(json.RawMessage) (interface{}, error) { return 42, nil },
+	})
+
+	client, err := NewClient(&utils.SolanaConfig{Endpoint: down.URL, FallbackEndpoints: []string{rpc.srv.URL}})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		var slot uint64
+		if err := client.Call(context.Background(), "getSlot", nil, &slot); err != nil || slot != 42 {
+			t.Fatalf("call %d = %d, %v", i, slot, err)
+		}
+	}
+	// The failed primary is skipped while it cools down.
+	if got := client.endpoints.order(); got[0] != rpc.srv.URL {
+		t.Fatalf("order = %v, want the fallback first", got)
+	}
+	primary := client.GetMetrics()["endpoints"].(map[string]interface{})[endpointLabel(down.URL)].(map[string]interface{})
+	if primary["failures_total"] != uint64(1) || primary["healthy"] != false {
+		t.Fatalf("primary metrics = %v", primary)
+	}
+
+	// A write that may have reached the failed node is not repeated
+	// elsewhere, unless the node was never reached.
+	var airdrops int
+	writes := newFakeRPC(t, map[string]rpcHandler{
+		"requestAirdrop": func(json.RawMessage) (interface{}, error) { airdrops++; return "sig", nil },
+	})
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	for _, primary := range []string{down.URL, closed.URL} {
+		client, err := NewClient(&utils.SolanaConfig{Endpoint: primary, FallbackEndpoints: []string{writes.srv.URL}})
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		client.Call(context.Background(), "requestAirdrop", nil, nil)
+	}
+	if airdrops != 1 {
+		t.Fatalf("airdrop failed over %d times, want once, from the unreachable node", airdrops)
+	}
+
+	if _, err := NewClient(&utils.SolanaConfig{Endpoint: down.URL, Routing: utils.RoutingConfig{Strategy: "random"}}); err == nil {
+		t.Fatal("unknown routing strategy accepted")
+	}
+}
+
+func TestEndpointLatencyRouting(t *testing.T) {
+	health := func(delay time.Duration) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	slow, fast := health(50*time.Millisecond), health(0)
+
+	client, err := NewClient(&utils.SolanaConfig{
+		Endpoint:          slow.URL,
+		FallbackEndpoints: []string{fast.URL},
+		Routing:           utils.RoutingConfig{Strategy: RoutingLatency, ProbeInterval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer client.Close()
+
+	client.probeEndpoints(context.Background())
+	if got := client.endpoints.order(); got[0] != fast.URL {
+		t.Fatalf("order = %v, want the faster endpoint first", got)
+	}
+	stats := client.GetMetrics()["endpoints"].(map[string]interface{})
+	if latency := stats[endpointLabel(slow.URL)].(map[string]interface{})["latency_seconds"].(float64); latency < 0.05 {
+		t.Fatalf("slow endpoint latency = %v", latency)
+	}
+}
@@ -0,0 +1,90 @@
+package solana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidConfig is returned when the client configuration is unusable.
+	ErrInvalidConfig = errors.New("solana: invalid config")
+	// ErrInvalidAddress is returned when an address is not a valid base58
+	// encoded 32-byte public key.
+	ErrInvalidAddress = errors.New("solana: invalid address")
+	// ErrAccountNotFound is returned when an account does not exist.
+	ErrAccountNotFound = errors.New("solana: account not found")
+	// ErrMintMismatch is returned when a token account belongs to a
+	// different mint than the one requested.
+	ErrMintMismatch = errors.New("solana: token account mint mismatch")
+	// ErrInvalidAccountData is returned when account data does not match
+	// the layout or owner expected for the requested account type.
+	ErrInvalidAccountData = errors.New("solana: invalid account data")
+	// ErrMethodNotSupported is returned when the RPC node does not offer a
+	// method, as some providers disable parts of the API.
+	ErrMethodNotSupported = errors.New("solana: method not supported by node")
+	// ErrWalletNotFound is returned when no registered wallet can sign for an
+	// address.
+	ErrWalletNotFound = errors.New("solana: wallet not found")
+	// ErrMissingSignature is returned when serializing a transaction that
+	// lacks a required signature.
+	ErrMissingSignature = errors.New("solana: missing signature")
+	// ErrInvalidTransaction is returned when serialized transaction bytes
+	// cannot be decoded.
+	ErrInvalidTransaction = errors.New("solana: invalid transaction")
+	// ErrInvalidSignature is returned when a transaction signature does not
+	// verify against its signer and message, or a signature is not 64
+	// bytes long.
+	ErrInvalidSignature = errors.New("solana: invalid signature")
+	// ErrInsufficientFunds is returned when an account cannot pay for a
+	// transfer and its fee.
+	ErrInsufficientFunds = errors.New("solana: insufficient funds")
+	// ErrBlockhashExpired is returned once the network block height passes a
+	// transaction's last valid block height. The transaction can no longer
+	// land, so it is safe to rebuild and send it again.
+	ErrBlockhashExpired = errors.New("solana: blockhash expired")
+	// ErrTransactionNotTracked is returned when resending a signature the
+	// client did not send.
+	ErrTransactionNotTracked = errors.New("solana: transaction not tracked")
+	// ErrInvalidMnemonic is returned when a recovery phrase is malformed.
+	ErrInvalidMnemonic = errors.New("solana: invalid mnemonic")
+	// ErrInvalidKeypair is returned when a keypair file does not hold a
+	// valid Solana CLI keypair.
+	ErrInvalidKeypair = errors.New("solana: invalid keypair file")
+	// ErrNetworkMismatch is returned when an RPC endpoint serves a
+	// different network than the one expected.
+	ErrNetworkMismatch = errors.New("solana: endpoint serves the wrong network")
+	// ErrMinContextSlotNotReached is returned when a call made with
+	// WithMinContextSlot reached only nodes that have not caught up to the
+	// slot. The node is healthy, so the call can be retried.
+	ErrMinContextSlotNotReached = errors.New("solana: min context slot not reached")
+	// ErrLamportsOverflow is returned when lamport arithmetic or a SOL
+	// conversion exceeds the range of a uint64.
+	ErrLamportsOverflow = errors.New("solana: lamports overflow")
+	// ErrLamportsUnderflow is returned when lamport arithmetic or a SOL
+	// conversion would go below zero.
+	ErrLamportsUnderflow = errors.New("solana: lamports underflow")
+	// ErrUnsupportedOption is returned when a call is given an option it
+	// does not apply, such as a CallOption a query ignores or WithFeeFloor
+	// on a signed transaction.
+	ErrUnsupportedOption = errors.New("solana: option not supported")
+)
+
+// wrapOp prefixes a non-nil *err with the operation that produced it, so a
+// failure deep in the RPC layer names the call that triggered it. Use it
+// with defer in methods with named results.
+func wrapOp(err *error, format string, args ...interface{}) {
+	if *err != nil {
+		*err = fmt.Errorf(format+": %w", append(args, *err)...)
+	}
+}
+
+// TransactionError reports that a transaction landed but failed on chain.
+type TransactionError struct {
+	Signature string
+	Err       json.RawMessage
+}
+
+func (e *TransactionError) Error() string {
+	return fmt.Sprintf("solana: transaction %s failed: %s", e.Signature, e.Err)
+}
@@ -0,0 +1,233 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ComputeBudgetProgramID is the native program that sets a transaction's
+// compute unit limit and priority fee.
+var ComputeBudgetProgramID = MustPublicKey("ComputeBudget111111111111111111111111111111")
+
+// Compute Budget instruction discriminators.
+const (
+	computeBudgetSetComputeUnitLimit = 2
+	computeBudgetSetComputeUnitPrice = 3
+)
+
+// DefaultComputeUnitLimit is the compute unit limit requested alongside a
+// priority fee added by a fee floor when FeeFloor leaves it unset.
+const DefaultComputeUnitLimit = 200_000
+
+// DefaultLamportsPerSignature is the network's base fee per signature. It
+// estimates the fee of transactions sent without WithFeeFloor.
+const DefaultLamportsPerSignature = 5000
+
+// ErrFeeCapExceeded is returned when a transaction's base fee alone exceeds
+// the MaxFee of its fee floor.
+var ErrFeeCapExceeded = errors.New("solana: fee exceeds cap")
+
+// MaxPrioritizationFeeAccounts is the most accounts getRecentPrioritizationFees
+// accepts in one call.
+const MaxPrioritizationFeeAccounts = 128
+
+// DefaultFeePercentile is the percentile of recent fees RecommendedPriorityFee
+// callers typically bid at: above most recent transactions without paying
+// for the outliers.
+const DefaultFeePercentile = 75
+
+// PrioritizationFee is the lowest priority fee, in micro-lamports per compute
+// unit, paid by a transaction landed in Slot. With writable accounts given,
+// only transactions locking any of them count.
+type PrioritizationFee struct {
+	Slot              uint64 `json:"slot"`
+	PrioritizationFee uint64 `json:"prioritizationFee"`
+}
+
+// GetRecentPrioritizationFees returns per-slot priority fees for the recent
+// slots the node has cached. Passing the accounts a transaction will write
+// restricts the samples to transactions contending for those accounts, which
+// tracks localized congestion far better than the global figure. Duplicate
+// accounts are ignored; more than MaxPrioritizationFeeAccounts distinct
+// accounts is an error.
+func (c *Client) GetRecentPrioritizationFees(ctx context.Context, writableAccounts []string) ([]PrioritizationFee, error) {
+	seen := make(map[string]bool, len(writableAccounts))
+	accounts := make([]string, 0, len(writableAccounts))
+	for _, account := range writableAccounts {
+		if seen[account] {
+			continue
+		}
+		if err := ValidateAddress(account); err != nil {
+			return nil, err
+		}
+		seen[account] = true
+		accounts = append(accounts, account)
+	}
+	if len(accounts) > MaxPrioritizationFeeAccounts {
+		return nil, fmt.Errorf("get recent prioritization fees: %d accounts, at most %d allowed", len(accounts), MaxPrioritizationFeeAccounts)
+	}
+
+	var params []interface{}
+	if len(accounts) > 0 {
+		params = []interface{}{accounts}
+	}
+	var fees []PrioritizationFee
+	if err := c.call(ctx, "getRecentPrioritizationFees", params, &fees); err != nil {
+		return nil, fmt.Errorf("get recent prioritization fees: %w", err)
+	}
+	return fees, nil
+}
+
+// RecommendedPriorityFee returns the nearest-rank percentile of fees in
+// micro-lamports per compute unit, e.g. DefaultFeePercentile. percentile is
+// clamped to [0, 100]. It returns 0 when fees is empty.
+func RecommendedPriorityFee(fees []PrioritizationFee, percentile float64) uint64 {
+	if len(fees) == 0 {
+		return 0
+	}
+	samples := make([]uint64, len(fees))
+	for i, fee := range fees {
+		samples[i] = fee.PrioritizationFee
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	percentile = math.Max(0, math.Min(100, percentile))
+	rank := int(math.Ceil(percentile / 100 * float64(len(samples))))
+	if rank < 1 {
+		rank = 1
+	}
+	return samples[rank-1]
+}
+
+// FeeFloor makes SendTransaction price the built message with
+// getFeeForMessage and raise its total fee to at least Multiplier times the
+// base fee, adding a priority fee when needed.
+type FeeFloor struct {
+	// Multiplier is the minimum total fee as a multiple of the base fee.
+	// Values below 1 add no priority fee.
+	Multiplier float64
+	// MaxFee caps the total fee in lamports; zero means no cap. The floor
+	// is lowered to the cap, and a base fee above it fails the send with
+	// ErrFeeCapExceeded.
+	MaxFee uint64
+	// ComputeUnitLimit is requested alongside the priority fee, which is
+	// charged per unit. Zero uses DefaultComputeUnitLimit.
+	ComputeUnitLimit uint32
+}
+
+// WithFeeFloor applies floor to SendTransaction. The computed fee is
+// recorded in the SentTransaction returned by TrackedTransaction.
+func WithFeeFloor(floor FeeFloor) SendOption {
+	return func(o *sendOptions) {
+		o.feeFloor = &floor
+	}
+}
+
+// GetFeeForMessage returns the fee in lamports the network would charge for
+// msg, including any priority fee its compute budget instructions set. It
+// returns ErrBlockhashExpired when the node no longer knows msg's
+// blockhash. It accepts WithCommitment and WithMinContextSlot.
+func (c *Client) GetFeeForMessage(ctx context.Context, msg *Message, opts ...CallOption) (uint64, error) {
+	o, err := c.callOptions(opts, optMinContextSlot)
+	if err != nil {
+		return 0, err
+	}
+	var result contextResult
+	params := []interface{}{base64.StdEncoding.EncodeToString(msg.Serialize()), o.config(false)}
+	if err := c.call(ctx, "getFeeForMessage", params, &result); err != nil {
+		return 0, fmt.Errorf("get fee for message: %w", err)
+	}
+	var fee *uint64
+	if err := json.Unmarshal(result.Value, &fee); err != nil {
+		return 0, fmt.Errorf("get fee for message: decode fee: %w", err)
+	}
+	if fee == nil {
+		return 0, fmt.Errorf("get fee for message: %w: %s", ErrBlockhashExpired, msg.RecentBlockhash)
+	}
+	return *fee, nil
+}
+
+// applyFeeFloor sets the latest blockhash on b, prices instructions with
+// getFeeForMessage, and returns them with compute budget instructions
+// prepended when the base fee is below the floor. The resulting total fee is
+// recorded on b.
+func (c *Client) applyFeeFloor(ctx context.Context, b *TransactionBuilder, instructions []Instruction, floor FeeFloor) ([]Instruction, error) {
+	latest, err := c.getLatestBlockhash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := HashFromBase58(latest.Blockhash)
+	if err != nil {
+		return nil, err
+	}
+	b.SetRecentBlockhash(recent, latest.LastValidBlockHeight)
+
+	msg, err := NewMessage(b.feePayer, instructions, recent)
+	if err != nil {
+		return nil, err
+	}
+	base, err := c.GetFeeForMessage(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if floor.MaxFee > 0 && base > floor.MaxFee {
+		return nil, fmt.Errorf("%w: base fee %d lamports, cap %d", ErrFeeCapExceeded, base, floor.MaxFee)
+	}
+
+	target := uint64(math.Ceil(float64(base) * floor.Multiplier))
+	if floor.MaxFee > 0 && target > floor.MaxFee {
+		target = floor.MaxFee
+	}
+	if target <= base {
+		b.fee = base
+		return instructions, nil
+	}
+
+	limit := uint64(floor.ComputeUnitLimit)
+	if limit == 0 {
+		limit = DefaultComputeUnitLimit
+	}
+	// The priority fee is price micro-lamports per unit over limit units,
+	// rounded up to whole lamports. Round the price up to reach the floor,
+	// unless that would overshoot the cap.
+	priority := target - base
+	price := (priority*1_000_000 + limit - 1) / limit
+	if floor.MaxFee > 0 && base+priorityFee(price, limit) > floor.MaxFee {
+		price = priority * 1_000_000 / limit
+	}
+	b.fee = base + priorityFee(price, limit)
+
+	return append([]Instruction{
+		SetComputeUnitLimitInstruction(uint32(limit)),
+		SetComputeUnitPriceInstruction(price),
+	}, instructions...), nil
+}
+
+// priorityFee is the lamports charged for price micro-lamports per compute
+// unit over limit units.
+func priorityFee(price, limit uint64) uint64 {
+	return (price*limit + 999_999) / 1_000_000
+}
+
+// SetComputeUnitLimitInstruction builds a Compute Budget instruction
+// requesting limit compute units for the transaction.
+func SetComputeUnitLimitInstruction(limit uint32) Instruction {
+	return Instruction{
+		ProgramID: ComputeBudgetProgramID,
+		Data:      append([]byte{computeBudgetSetComputeUnitLimit}, putUint32(limit)...),
+	}
+}
+
+// SetComputeUnitPriceInstruction builds a Compute Budget instruction paying
+// microLamports per compute unit as a priority fee.
+func SetComputeUnitPriceInstruction(microLamports uint64) Instruction {
+	return Instruction{
+		ProgramID: ComputeBudgetProgramID,
+		Data:      append([]byte{computeBudgetSetComputeUnitPrice}, putUint64(microLamports)...),
+	}
+}
@@ -0,0 +1,117 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestGetRecentPrioritizationFees(t *testing.T) {
+	var accounts [][]string
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getRecentPrioritizationFees": func(params json.RawMessage) (interface{}, error) {
+			var p [][]string
+			json.Unmarshal(params, &p)
+			accounts = append(accounts, p...)
+			return []map[string]interface{}{
+				{"slot": 10, "prioritizationFee": 0},
+				{"slot": 11, "prioritizationFee": 5000},
+				{"slot": 12, "prioritizationFee": 1000},
+				{"slot": 13, "prioritizationFee": 2000},
+			}, nil
+		},
+	})
+	client := rpc.client(t)
+
+	wallet, _ := NewWallet()
+	fees, err := client.GetRecentPrioritizationFees(context.Background(), []string{wallet.PublicKey(), wallet.PublicKey()})
+	if err != nil {
+		t.Fatalf("GetRecentPrioritizationFees: %v", err)
+	}
+	if len(fees) != 4 || fees[1].Slot != 11 || fees[1].PrioritizationFee != 5000 {
+		t.Fatalf("fees = %+v", fees)
+	}
+	if len(accounts) != 1 || len(accounts[0]) != 1 || accounts[0][0] != wallet.PublicKey() {
+		t.Fatalf("accounts sent = %v, want the deduplicated wallet", accounts)
+	}
+
+	for percentile, want := range map[float64]uint64{0: 0, 50: 1000, DefaultFeePercentile: 2000, 100: 5000, 150: 5000} {
+		if got := RecommendedPriorityFee(fees, percentile); got != want {
+			t.Errorf("RecommendedPriorityFee(p%v) = %d, want %d", percentile, got, want)
+		}
+	}
+
+	tooMany := make([]string, MaxPrioritizationFeeAccounts+1)
+	for i := range tooMany {
+		w, _ := NewWallet()
+		tooMany[i] = w.PublicKey()
+	}
+	if _, err := client.GetRecentPrioritizationFees(context.Background(), tooMany); err == nil {
+		t.Fatal("more than MaxPrioritizationFeeAccounts accounts were accepted")
+	}
+	if n := rpc.count("getRecentPrioritizationFees"); n != 1 {
+		t.Fatalf("RPC calls = %d, want 1", n)
+	}
+}
+
+func TestSendTransactionFeeFloor(t *testing.T) {
+	var sent [][]byte
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getLatestBlockhash": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"blockhash":            SystemProgramID.String(),
+				"lastValidBlockHeight": 100,
+			}), nil
+		},
+		"getFeeForMessage": func(json.RawMessage) (interface{}, error) {
+			return withContext(5000), nil
+		},
+		"sendTransaction": func(params json.RawMessage) (interface{}, error) {
+			var p []string
+			json.Unmarshal(params, &p)
+			raw, _ := base64.StdEncoding.DecodeString(p[0])
+			sent = append(sent, raw)
+			return "sig", nil
+		},
+	})
+	client := rpc.client(t)
+	from, _ := client.CreateWallet()
+	to, _ := NewWallet()
+	send := func(floor FeeFloor) (*SentTransaction, error) {
+		sig, err := client.SendTransaction(context.Background(), from.PublicKey(), to.PublicKey(), 1, WithFeeFloor(floor))
+		if err != nil {
+			return nil, err
+		}
+		tx, _ := client.TrackedTransaction(sig)
+		return tx, nil
+	}
+
+	tx, err := send(FeeFloor{Multiplier: 3})
+	if err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	if tx.Fee != 15000 {
+		t.Fatalf("fee = %d, want 15000", tx.Fee)
+	}
+	// 10000 lamports over 200k units is 50000 micro-lamports per unit.
+	price := SetComputeUnitPriceInstruction(50_000)
+	if !bytes.Contains(sent[0], ComputeBudgetProgramID[:]) || !bytes.Contains(sent[0], price.Data) {
+		t.Fatal("broadcast transaction lacks the compute unit price")
+	}
+
+	if tx, err := send(FeeFloor{Multiplier: 3, MaxFee: 12000}); err != nil || tx.Fee != 12000 {
+		t.Fatalf("capped send = %+v, %v", tx, err)
+	}
+	if tx, err := send(FeeFloor{Multiplier: 1}); err != nil || tx.Fee != 5000 || bytes.Contains(sent[2], ComputeBudgetProgramID[:]) {
+		t.Fatalf("send at the base fee = %+v, %v", tx, err)
+	}
+	if _, err := send(FeeFloor{Multiplier: 2, MaxFee: 4000}); !errors.Is(err, ErrFeeCapExceeded) {
+		t.Fatalf("send over the cap = %v, want ErrFeeCapExceeded", err)
+	}
+	if len(sent) != 3 {
+		t.Fatalf("broadcasts = %d, want 3", len(sent))
+	}
+}
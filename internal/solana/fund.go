@@ -0,0 +1,101 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// ErrFundingFailed is returned when FundWallet runs out of attempts before
+// the wallet holds the requested balance. The last attempt's error is
+// wrapped as well.
+var ErrFundingFailed = errors.New("solana: wallet funding failed")
+
+// FundWallet makes sure address holds at least lamports, as test and CI
+// setups need before they can send anything. It returns at once if the
+// balance already suffices. Otherwise it airdrops the shortfall, waits for
+// the airdrop to confirm, and reads the balance back at or after the
+// confirmation slot. A round that fails on a transient error, such as the
+// faucet's rate limit, a dropped airdrop, or a lagging node, is retried
+// from the balance check after a backoff, up to FundAttempts rounds as set
+// in AirdropConfig. Errors that retrying cannot fix, such as
+// ErrAirdropOnMainnet or an invalid address, are returned immediately.
+func (c *Client) FundWallet(ctx context.Context, address string, lamports uint64) (err error) {
+	defer wrapOp(&err, "fund %s with %d lamports", address, lamports)
+	if err := ValidateAddress(address); err != nil {
+		return err
+	}
+
+	config := c.airdrops.config
+	backoff := config.Backoff
+	for attempt := 1; ; attempt++ {
+		funded, err := c.fundOnce(ctx, address, lamports, config.ConfirmTimeout)
+		if funded {
+			return nil
+		}
+		if !fundRetryable(ctx, err) {
+			return utils.WrapAttempts(err, attempt)
+		}
+		if attempt >= config.FundAttempts || utils.RemainingBudget(ctx) <= backoff {
+			return utils.WrapAttempts(fmt.Errorf("%w: %w", ErrFundingFailed, err), attempt)
+		}
+
+		c.logger.Warn("Funding attempt failed, retrying", map[string]interface{}{
+			"address": address,
+			"attempt": attempt,
+			"backoff": backoff.String(),
+			"error":   err.Error(),
+		})
+		if err := sleepContext(ctx, backoff); err != nil {
+			return utils.WrapAttempts(fmt.Errorf("backing off: %w", err), attempt)
+		}
+		if backoff *= 2; backoff > config.MaxBackoff {
+			backoff = config.MaxBackoff
+		}
+	}
+}
+
+// fundOnce runs one round of FundWallet. It reports whether address holds
+// at least lamports, and otherwise why this round did not get it there.
+func (c *Client) fundOnce(ctx context.Context, address string, lamports uint64, confirmTimeout time.Duration) (bool, error) {
+	balance, err := c.GetBalance(ctx, address, WithMinContextSlot(c.LastWriteSlot()))
+	if err != nil {
+		return false, err
+	}
+	if balance >= lamports {
+		return true, nil
+	}
+
+	signature, err := c.RequestAirdrop(ctx, address, lamports-balance)
+	if err != nil {
+		return false, err
+	}
+	confirmCtx, cancel := context.WithTimeout(ctx, confirmTimeout)
+	err = c.ConfirmTransaction(confirmCtx, signature, "")
+	cancel()
+	if err != nil {
+		return false, err
+	}
+
+	balance, err = c.GetBalance(ctx, address, WithMinContextSlot(c.LastWriteSlot()))
+	if err != nil {
+		return false, err
+	}
+	if balance < lamports {
+		return false, fmt.Errorf("balance %d after airdrop %s is below %d", balance, signature, lamports)
+	}
+	return true, nil
+}
+
+// fundRetryable reports whether a failed FundWallet round may succeed if
+// run again. Only a caller's cancelled context and errors about the
+// request itself are final.
+func fundRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, ErrAirdropOnMainnet) && !errors.Is(err, ErrInvalidAddress)
+}
@@ -0,0 +1,111 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestFundWalletRetriesFailedAirdrop(t *testing.T) {
+	var balance, airdrops atomic.Uint64
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getGenesisHash": func(json.RawMessage) (interface{}, error) {
+			return clusterPresets[ClusterDevnet].genesisHash, nil
+		},
+		"getBalance": func(json.RawMessage) (interface{}, error) {
+			return withContext(balance.Load()), nil
+		},
+		"requestAirdrop": func(json.RawMessage) (interface{}, error) {
+			return fmt.Sprintf("sig%d", airdrops.Add(1)), nil
+		},
+		"getSignatureStatuses": func(params json.RawMessage) (interface{}, error) {
+			var p [][]string
+			json.Unmarshal(params, &p)
+			// The first airdrop fails on chain; the second lands.
+			if p[0][0] == "sig1" {
+				return withContext([]interface{}{map[string]interface{}{
+					"slot": 4, "confirmationStatus": "confirmed", "err": map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}},
+				}}), nil
+			}
+			balance.Store(LamportsPerSOL)
+			return withContext([]interface{}{map[string]interface{}{
+				"slot": 5, "confirmationStatus": "confirmed", "err": nil,
+			}}), nil
+		},
+	})
+	client, err := NewClient(&utils.SolanaConfig{
+		Endpoint: rpc.srv.URL,
+		Airdrop:  utils.AirdropConfig{Spacing: time.Millisecond, Backoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	if err := client.FundWallet(context.Background(), testAddress, LamportsPerSOL); err != nil {
+		t.Fatalf("FundWallet: %v", err)
+	}
+	if n := rpc.count("requestAirdrop"); n != 2 {
+		t.Fatalf("requestAirdrop calls = %d, want 2", n)
+	}
+	if slot := client.LastWriteSlot(); slot != 5 {
+		t.Fatalf("LastWriteSlot = %d, want 5", slot)
+	}
+
+	// An already funded wallet is left alone.
+	if err := client.FundWallet(context.Background(), testAddress, LamportsPerSOL/2); err != nil {
+		t.Fatalf("FundWallet funded: %v", err)
+	}
+	if n := rpc.count("requestAirdrop"); n != 2 {
+		t.Fatalf("requestAirdrop calls = %d after funded wallet, want 2", n)
+	}
+}
+
+func TestFundWalletGivesUp(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getGenesisHash": func(json.RawMessage) (interface{}, error) {
+			return clusterPresets[ClusterDevnet].genesisHash, nil
+		},
+		"getBalance": func(json.RawMessage) (interface{}, error) {
+			return withContext(0), nil
+		},
+		"requestAirdrop": func(json.RawMessage) (interface{}, error) {
+			return nil, &RPCError{Code: -32603, Message: "Internal error"}
+		},
+	})
+	client, err := NewClient(&utils.SolanaConfig{
+		Endpoint: rpc.srv.URL,
+		Airdrop:  utils.AirdropConfig{Spacing: time.Millisecond, Backoff: time.Millisecond, FundAttempts: 2},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	err = client.FundWallet(context.Background(), testAddress, LamportsPerSOL)
+	var rpcErr *RPCError
+	if !errors.Is(err, ErrFundingFailed) || !errors.As(err, &rpcErr) {
+		t.Fatalf("FundWallet = %v, want ErrFundingFailed wrapping the faucet error", err)
+	}
+	if n := rpc.count("requestAirdrop"); n != 2 {
+		t.Fatalf("requestAirdrop calls = %d, want 2 with FundAttempts 2", n)
+	}
+
+	// Mainnet is never retried.
+	mainnet := newFakeRPC(t, map[string]rpcHandler{
+		"getGenesisHash": func(json.RawMessage) (interface{}, error) {
+			return clusterPresets[ClusterMainnet].genesisHash, nil
+		},
+		"getBalance": func(json.RawMessage) (interface{}, error) {
+			return withContext(0), nil
+		},
+	})
+	client = mainnet.client(t)
+	if err := client.FundWallet(context.Background(), testAddress, LamportsPerSOL); !errors.Is(err, ErrAirdropOnMainnet) || errors.Is(err, ErrFundingFailed) {
+		t.Fatalf("FundWallet on mainnet = %v, want ErrAirdropOnMainnet", err)
+	}
+}
@@ -0,0 +1,32 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// rpcCodeNodeUnhealthy is returned by getHealth when the node is behind.
+const rpcCodeNodeUnhealthy = -32005
+
+// ErrNodeUnhealthy is returned by GetHealth when the node reports itself
+// unhealthy, usually because it has fallen behind the cluster.
+var ErrNodeUnhealthy = errors.New("solana: node is unhealthy")
+
+// GetHealth returns nil if the node reports itself healthy. It is cheap and
+// suitable for readiness checks.
+func (c *Client) GetHealth(ctx context.Context) error {
+	var status string
+	err := c.call(ctx, "getHealth", nil, &status)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == rpcCodeNodeUnhealthy {
+		return fmt.Errorf("%w: %w", ErrNodeUnhealthy, err)
+	}
+	if err != nil {
+		return err
+	}
+	if status != "ok" {
+		return fmt.Errorf("%w: status %q", ErrNodeUnhealthy, status)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestGetHealth(t *testing.T) {
+	healthy := true
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getHealth": func(json.RawMessage) (interface{}, error) {
+			if healthy {
+				return "ok", nil
+			}
+			return nil, &RPCError{Code: rpcCodeNodeUnhealthy, Message: "Node is behind by 42 slots"}
+		},
+	})
+	client := rpc.client(t)
+
+	if err := client.GetHealth(context.Background()); err != nil {
+		t.Fatalf("GetHealth: %v", err)
+	}
+	healthy = false
+	if err := client.GetHealth(context.Background()); !errors.Is(err, ErrNodeUnhealthy) {
+		t.Fatalf("GetHealth = %v, want ErrNodeUnhealthy", err)
+	}
+}
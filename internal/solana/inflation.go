@@ -0,0 +1,64 @@
+package solana
+
+import (
+	"context"
+	"fmt"
+)
+
+// InflationRate is the inflation rate for the current epoch.
+type InflationRate struct {
+	Total      float64 `json:"total"`
+	Validator  float64 `json:"validator"`
+	Foundation float64 `json:"foundation"`
+	Epoch      uint64  `json:"epoch"`
+}
+
+// InflationReward is the staking reward credited to an address for an epoch.
+type InflationReward struct {
+	Epoch         uint64 `json:"epoch"`
+	EffectiveSlot uint64 `json:"effectiveSlot"`
+	Amount        uint64 `json:"amount"`
+	PostBalance   uint64 `json:"postBalance"`
+	Commission    *uint8 `json:"commission,omitempty"`
+}
+
+// GetInflationRate returns the inflation rate for the current epoch.
+func (c *Client) GetInflationRate(ctx context.Context) (*InflationRate, error) {
+	var rate InflationRate
+	if err := c.call(ctx, "getInflationRate", nil, &rate); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// GetInflationReward returns the inflation rewards for addresses in epoch,
+// fetched in a single call. The result is index-aligned with addresses; an
+// entry is nil when the address earned no reward in that epoch. It accepts
+// WithCommitment and WithMinContextSlot.
+func (c *Client) GetInflationReward(ctx context.Context, addresses []string, epoch uint64, opts ...CallOption) ([]*InflationReward, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	for _, address := range addresses {
+		if err := ValidateAddress(address); err != nil {
+			return nil, err
+		}
+	}
+
+	o, err := c.callOptions(opts, optMinContextSlot)
+	if err != nil {
+		return nil, err
+	}
+	config := o.config(false)
+	config["epoch"] = epoch
+	params := []interface{}{addresses, config}
+
+	var rewards []*InflationReward
+	if err := c.call(ctx, "getInflationReward", params, &rewards); err != nil {
+		return nil, err
+	}
+	if len(rewards) != len(addresses) {
+		return nil, fmt.Errorf("getInflationReward: got %d entries for %d addresses", len(rewards), len(addresses))
+	}
+	return rewards, nil
+}
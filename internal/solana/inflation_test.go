@@ -0,0 +1,86 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestGetInflationRate(t *testing.T) {
+	srv := newTestServer(t, 0, `{"epoch":612,"foundation":0.0,"total":0.04587,"validator":0.04587}`)
+	client, err := NewClient(&utils.SolanaConfig{Endpoint: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	rate, err := client.GetInflationRate(context.Background())
+	if err != nil {
+		t.Fatalf("GetInflationRate: %v", err)
+	}
+	if *rate != (InflationRate{Total: 0.04587, Validator: 0.04587, Foundation: 0, Epoch: 612}) {
+		t.Fatalf("rate = %+v", rate)
+	}
+}
+
+func TestGetInflationReward(t *testing.T) {
+	// The node answers null for an address that earned nothing.
+	srv := newTestServer(t, 0, `[{"epoch":611,"effectiveSlot":264384000,"amount":2500,"postBalance":1000002500,"commission":7},null]`)
+	client, err := NewClient(&utils.SolanaConfig{Endpoint: srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	addresses := []string{testAddress, TokenProgramID.String()}
+	rewards, err := client.GetInflationReward(context.Background(), addresses, 611)
+	if err != nil {
+		t.Fatalf("GetInflationReward: %v", err)
+	}
+	if len(rewards) != 2 || rewards[1] != nil {
+		t.Fatalf("rewards = %v, want a reward and a nil entry", rewards)
+	}
+	r := rewards[0]
+	if r.Epoch != 611 || r.EffectiveSlot != 264384000 || r.Amount != 2500 || r.PostBalance != 1000002500 ||
+		r.Commission == nil || *r.Commission != 7 {
+		t.Fatalf("reward = %+v", r)
+	}
+
+	if _, err := client.GetInflationReward(context.Background(), addresses[:1], 611); err == nil {
+		t.Fatal("GetInflationReward accepted 2 entries for 1 address")
+	}
+	if _, err := client.GetInflationReward(context.Background(), []string{"not-an-address"}, 611); !errors.Is(err, ErrInvalidAddress) {
+		t.Fatalf("invalid address: %v, want ErrInvalidAddress", err)
+	}
+	if rewards, err := client.GetInflationReward(context.Background(), nil, 611); rewards != nil || err != nil {
+		t.Fatalf("no addresses = %v, %v, want no call", rewards, err)
+	}
+}
+
+func TestGetInflationRewardParams(t *testing.T) {
+	var sent []string
+	var config map[string]interface{}
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getInflationReward": func(params json.RawMessage) (interface{}, error) {
+			var p []json.RawMessage
+			json.Unmarshal(params, &p)
+			json.Unmarshal(p[0], &sent)
+			json.Unmarshal(p[1], &config)
+			return []interface{}{nil, nil}, nil
+		},
+	})
+	client := rpc.client(t)
+
+	addresses := []string{testAddress, TokenProgramID.String()}
+	if _, err := client.GetInflationReward(context.Background(), addresses, 611, WithCommitment(CommitmentFinalized), WithMinContextSlot(42)); err != nil {
+		t.Fatalf("GetInflationReward: %v", err)
+	}
+	if len(sent) != 2 || sent[0] != addresses[0] || sent[1] != addresses[1] {
+		t.Fatalf("addresses = %v, want %v", sent, addresses)
+	}
+	// JSON numbers decode as float64.
+	if config["epoch"] != float64(611) || config["commitment"] != CommitmentFinalized || config["minContextSlot"] != float64(42) {
+		t.Fatalf("config = %v", config)
+	}
+}
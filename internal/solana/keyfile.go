@@ -0,0 +1,94 @@
+package solana
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LoadWalletFromFile reads a keypair file in the Solana CLI format, as
+// written by solana-keygen: a JSON array of the 64 bytes of the secret key,
+// the 32-byte seed followed by the public key. It fails with
+// ErrInvalidKeypair if the file is not such an array, or if its public key
+// is off the curve or does not belong to the seed. Errors reading the file,
+// such as fs.ErrPermission or fs.ErrNotExist, are wrapped.
+func LoadWalletFromFile(path string) (*Wallet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load keypair: %w", err)
+	}
+
+	var values []int
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%w: %s is not a JSON byte array: %v", ErrInvalidKeypair, path, err)
+	}
+	if len(values) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%w: %s holds %d bytes, want %d", ErrInvalidKeypair, path, len(values), ed25519.PrivateKeySize)
+	}
+	key := make([]byte, len(values))
+	for i, v := range values {
+		if v < 0 || v > 255 {
+			return nil, fmt.Errorf("%w: %s: value %d at index %d is not a byte", ErrInvalidKeypair, path, v, i)
+		}
+		key[i] = byte(v)
+	}
+
+	var stored PublicKey
+	copy(stored[:], key[ed25519.SeedSize:])
+	if !IsOnCurve(stored) {
+		return nil, fmt.Errorf("%w: %s: public key is not on the curve", ErrInvalidKeypair, path)
+	}
+	// WalletFromPrivateKey trusts the stored public key, so derive it.
+	wallet, err := WalletFromPrivateKey(ed25519.NewKeyFromSeed(key[:ed25519.SeedSize]))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(wallet.publicKey[:], stored[:]) {
+		return nil, fmt.Errorf("%w: %s: public key does not match the secret key", ErrInvalidKeypair, path)
+	}
+	return wallet, nil
+}
+
+// SaveWalletToFile writes wallet's secret key to path in the Solana CLI
+// format read by LoadWalletFromFile and solana-keygen. The file is created
+// with 0600 permissions, replacing any file at path, and is written to a
+// temporary file first so a failed save leaves the old one intact.
+func SaveWalletToFile(wallet *Wallet, path string) (err error) {
+	values := make([]int, len(wallet.privateKey))
+	for i, b := range wallet.privateKey {
+		values[i] = int(b)
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("save keypair: %w", err)
+	}
+
+	// CreateTemp creates the file with 0600 permissions.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("save keypair: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save keypair: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save keypair: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save keypair: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("save keypair: %w", err)
+	}
+	return nil
+}
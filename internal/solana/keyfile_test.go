@@ -0,0 +1,74 @@
+package solana
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalletFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "id.json")
+
+	wallet, _ := NewWallet()
+	if err := SaveWalletToFile(wallet, path); err != nil {
+		t.Fatalf("SaveWalletToFile: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("permissions = %o, want 600", perm)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "[") || strings.Count(string(data), ",") != 63 {
+		t.Fatalf("file = %s, want a JSON array of 64 numbers", data)
+	}
+
+	loaded, err := LoadWalletFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadWalletFromFile: %v", err)
+	}
+	if loaded.PublicKey() != wallet.PublicKey() {
+		t.Fatalf("loaded %s, want %s", loaded.PublicKey(), wallet.PublicKey())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("directory holds %d entries, want only the keypair", len(entries))
+	}
+
+	other, _ := NewWallet()
+	mismatched := append([]byte(nil), wallet.privateKey[:32]...)
+	mismatched = append(mismatched, other.publicKey[:]...)
+	for name, contents := range map[string]string{
+		"not json":   "not a keypair",
+		"base58":     `"` + wallet.PublicKey() + `"`,
+		"short":      "[1,2,3]",
+		"not a byte": "[" + strings.Repeat("1,", 63) + "256]",
+		"mismatched": byteArray(mismatched),
+	} {
+		bad := filepath.Join(dir, "bad.json")
+		os.WriteFile(bad, []byte(contents), 0o600)
+		if _, err := LoadWalletFromFile(bad); !errors.Is(err, ErrInvalidKeypair) {
+			t.Errorf("%s: err = %v, want ErrInvalidKeypair", name, err)
+		}
+	}
+
+	if _, err := LoadWalletFromFile(filepath.Join(dir, "missing.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("missing file: err = %v, want fs.ErrNotExist", err)
+	}
+}
+
+// byteArray encodes b as a JSON array of numbers, as the Solana CLI does.
+func byteArray(b []byte) string {
+	values := make([]int, len(b))
+	for i, v := range b {
+		values[i] = int(v)
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
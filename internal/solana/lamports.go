@@ -0,0 +1,80 @@
+package solana
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Lamports is an amount of SOL in lamports, the unit balances and
+// transfers are denominated in. Its arithmetic reports overflow instead of
+// wrapping, and it prints in SOL.
+type Lamports uint64
+
+// FromSOL converts an amount in SOL to lamports, rounding to the nearest
+// lamport. It fails with ErrLamportsUnderflow for a negative amount that
+// does not round to zero and ErrLamportsOverflow for one too large for a
+// uint64. SOL amounts are float64, exact only to about 15 significant
+// digits; use lamport amounts when precision matters.
+func FromSOL(sol float64) (Lamports, error) {
+	if math.IsNaN(sol) {
+		return 0, fmt.Errorf("solana: invalid SOL amount %v", sol)
+	}
+	lamports := math.Round(sol * LamportsPerSOL)
+	switch {
+	case lamports < 0:
+		return 0, fmt.Errorf("%w: %v SOL", ErrLamportsUnderflow, sol)
+	case lamports >= math.MaxUint64:
+		// MaxUint64 converts to 2^64, the first value that does not fit.
+		return 0, fmt.Errorf("%w: %v SOL", ErrLamportsOverflow, sol)
+	}
+	return Lamports(lamports), nil
+}
+
+// ToSOL returns l in SOL. Amounts above 2^53 lamports, about nine million
+// SOL, lose precision.
+func (l Lamports) ToSOL() float64 {
+	return float64(l) / LamportsPerSOL
+}
+
+// Add returns l+other, or ErrLamportsOverflow if the sum does not fit.
+func (l Lamports) Add(other Lamports) (Lamports, error) {
+	sum := l + other
+	if sum < l {
+		return 0, fmt.Errorf("%w: %d + %d lamports", ErrLamportsOverflow, uint64(l), uint64(other))
+	}
+	return sum, nil
+}
+
+// Sub returns l-other, or ErrLamportsUnderflow if other is larger.
+func (l Lamports) Sub(other Lamports) (Lamports, error) {
+	if other > l {
+		return 0, fmt.Errorf("%w: %d - %d lamports", ErrLamportsUnderflow, uint64(l), uint64(other))
+	}
+	return l - other, nil
+}
+
+// String formats l in SOL without trailing zeros, such as "1.5 SOL" or
+// "0.000005 SOL". It is exact for every amount.
+func (l Lamports) String() string {
+	whole := strconv.FormatUint(uint64(l)/LamportsPerSOL, 10)
+	frac := uint64(l) % LamportsPerSOL
+	if frac == 0 {
+		return whole + " SOL"
+	}
+	digits := strings.TrimRight(fmt.Sprintf("%09d", frac), "0")
+	return whole + "." + digits + " SOL"
+}
+
+// GetBalanceLamports is GetBalance returning a Lamports amount.
+func (c *Client) GetBalanceLamports(ctx context.Context, address string, opts ...CallOption) (Lamports, error) {
+	balance, err := c.GetBalance(ctx, address, opts...)
+	return Lamports(balance), err
+}
+
+// SendLamports is SendTransaction taking a Lamports amount.
+func (c *Client) SendLamports(ctx context.Context, from, to string, amount Lamports, opts ...SendOption) (signature string, err error) {
+	return c.SendTransaction(ctx, from, to, uint64(amount), opts...)
+}
@@ -0,0 +1,69 @@
+package solana
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestLamportsArithmetic(t *testing.T) {
+	if sum, err := Lamports(math.MaxUint64 - 1).Add(1); err != nil || sum != math.MaxUint64 {
+		t.Fatalf("Add up to the maximum = %d, %v", sum, err)
+	}
+	if _, err := Lamports(math.MaxUint64).Add(1); !errors.Is(err, ErrLamportsOverflow) {
+		t.Fatalf("Add past the maximum = %v, want ErrLamportsOverflow", err)
+	}
+	if diff, err := Lamports(5).Sub(5); err != nil || diff != 0 {
+		t.Fatalf("Sub to zero = %d, %v", diff, err)
+	}
+	if _, err := Lamports(5).Sub(6); !errors.Is(err, ErrLamportsUnderflow) {
+		t.Fatalf("Sub below zero = %v, want ErrLamportsUnderflow", err)
+	}
+}
+
+func TestLamportsSOLConversion(t *testing.T) {
+	for _, tt := range []struct {
+		sol  float64
+		want Lamports
+	}{
+		{1.5, 1_500_000_000},
+		{0.1, 100_000_000},
+		{0.000000001, 1},
+		{0.0000000014, 1},
+		{0.0000000015, 2},
+		{-0.0000000004, 0},
+		{9_000_000, 9_000_000_000_000_000},
+	} {
+		if got, err := FromSOL(tt.sol); err != nil || got != tt.want {
+			t.Errorf("FromSOL(%v) = %d, %v, want %d", tt.sol, got, err, tt.want)
+		}
+	}
+	for _, sol := range []float64{18_446_744_074, math.Inf(1)} {
+		if _, err := FromSOL(sol); !errors.Is(err, ErrLamportsOverflow) {
+			t.Errorf("FromSOL(%v) = %v, want ErrLamportsOverflow", sol, err)
+		}
+	}
+	for _, sol := range []float64{-0.000000001, math.Inf(-1)} {
+		if _, err := FromSOL(sol); !errors.Is(err, ErrLamportsUnderflow) {
+			t.Errorf("FromSOL(%v) = %v, want ErrLamportsUnderflow", sol, err)
+		}
+	}
+	if _, err := FromSOL(math.NaN()); err == nil {
+		t.Error("FromSOL(NaN) succeeded")
+	}
+	if sol := Lamports(2_500_000_000).ToSOL(); sol != 2.5 {
+		t.Errorf("ToSOL = %v, want 2.5", sol)
+	}
+
+	for l, want := range map[Lamports]string{
+		0:              "0 SOL",
+		1_500_000_000:  "1.5 SOL",
+		5_000:          "0.000005 SOL",
+		1:              "0.000000001 SOL",
+		math.MaxUint64: "18446744073.709551615 SOL",
+	} {
+		if got := l.String(); got != want {
+			t.Errorf("Lamports(%d).String() = %q, want %q", uint64(l), got, want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// Token-2022 accounts carrying extensions are padded to TokenAccountSize
+// and followed by a one-byte account type, then the extension data.
+const (
+	tokenAccountTypeOffset = TokenAccountSize
+	tokenAccountTypeMint   = 1
+	tokenAccountTypeToken  = 2
+)
+
+// Mint is a decoded SPL token mint.
+type Mint struct {
+	// MintAuthority may mint new tokens; nil once minting is disabled.
+	MintAuthority   *PublicKey
+	Supply          uint64
+	Decimals        uint8
+	IsInitialized   bool
+	FreezeAuthority *PublicKey
+}
+
+// TokenAccountState is the state of an SPL token account.
+type TokenAccountState uint8
+
+// Token account states.
+const (
+	TokenAccountUninitialized TokenAccountState = iota
+	TokenAccountInitialized
+	TokenAccountFrozen
+)
+
+// TokenAccount is a decoded SPL token account.
+type TokenAccount struct {
+	Mint   PublicKey
+	Owner  PublicKey
+	Amount uint64
+	// Delegate may transfer up to DelegatedAmount on the owner's behalf.
+	Delegate *PublicKey
+	State    TokenAccountState
+	// NativeReserve is set for wrapped SOL accounts and holds the
+	// rent-exempt reserve in lamports.
+	NativeReserve   *uint64
+	DelegatedAmount uint64
+	CloseAuthority  *PublicKey
+}
+
+// DecodeMint decodes mint account data in the SPL Token layout. Token-2022
+// mints with extensions are accepted; the extension data is ignored.
+func DecodeMint(data []byte) (*Mint, error) {
+	if err := checkTokenLayout(data, MintSize, tokenAccountTypeMint); err != nil {
+		return nil, fmt.Errorf("decode mint: %w", err)
+	}
+	d := layoutDecoder{data: data}
+	m := &Mint{
+		MintAuthority: d.optionalKey(),
+		Supply:        d.uint64(),
+		Decimals:      d.byte(),
+	}
+	initialized := d.byte()
+	m.FreezeAuthority = d.optionalKey()
+	if d.err == nil && initialized > 1 {
+		d.err = fmt.Errorf("%w: invalid is_initialized %d", ErrInvalidAccountData, initialized)
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("decode mint: %w", d.err)
+	}
+	m.IsInitialized = initialized == 1
+	return m, nil
+}
+
+// DecodeTokenAccount decodes token account data in the SPL Token layout.
+// Token-2022 accounts with extensions are accepted; the extension data is
+// ignored.
+func DecodeTokenAccount(data []byte) (*TokenAccount, error) {
+	if err := checkTokenLayout(data, TokenAccountSize, tokenAccountTypeToken); err != nil {
+		return nil, fmt.Errorf("decode token account: %w", err)
+	}
+	d := layoutDecoder{data: data}
+	a := &TokenAccount{
+		Mint:     d.key(),
+		Owner:    d.key(),
+		Amount:   d.uint64(),
+		Delegate: d.optionalKey(),
+		State:    TokenAccountState(d.byte()),
+	}
+	a.NativeReserve = d.optionalUint64()
+	a.DelegatedAmount = d.uint64()
+	a.CloseAuthority = d.optionalKey()
+	if d.err == nil && a.State > TokenAccountFrozen {
+		d.err = fmt.Errorf("%w: invalid state %d", ErrInvalidAccountData, a.State)
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("decode token account: %w", d.err)
+	}
+	return a, nil
+}
+
+// GetMint fetches and decodes the mint at address. It returns
+// ErrInvalidAccountData if the account is not owned by a token program or
+// does not hold a mint. It accepts WithCommitment and WithMinContextSlot.
+func (c *Client) GetMint(ctx context.Context, address string, opts ...CallOption) (*Mint, error) {
+	info, err := c.getTokenProgramAccount(ctx, address, opts)
+	if err != nil {
+		return nil, fmt.Errorf("get mint %s: %w", address, err)
+	}
+	return DecodeMint(info.Data)
+}
+
+// GetTokenAccount fetches and decodes the token account at address. It
+// returns ErrInvalidAccountData if the account is not owned by a token
+// program or does not hold a token account. It accepts WithCommitment and
+// WithMinContextSlot.
+func (c *Client) GetTokenAccount(ctx context.Context, address string, opts ...CallOption) (*TokenAccount, error) {
+	info, err := c.getTokenProgramAccount(ctx, address, opts)
+	if err != nil {
+		return nil, fmt.Errorf("get token account %s: %w", address, err)
+	}
+	return DecodeTokenAccount(info.Data)
+}
+
+// getTokenProgramAccount fetches address with its raw data. opts may not
+// set WithEncoding or WithDataSlice since the decoders need all the bytes.
+func (c *Client) getTokenProgramAccount(ctx context.Context, address string, opts []CallOption) (*AccountInfo, error) {
+	if _, err := c.callOptions(opts, optMinContextSlot); err != nil {
+		return nil, err
+	}
+	info, err := c.GetAccountInfo(ctx, address, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if info.Owner != TokenProgramID.String() && info.Owner != Token2022ProgramID.String() {
+		return nil, fmt.Errorf("%w: %s is owned by %s, not a token program", ErrInvalidAccountData, address, info.Owner)
+	}
+	return info, nil
+}
+
+// checkTokenLayout verifies data is either exactly size bytes, or a
+// Token-2022 account with extensions tagged as accountType.
+func checkTokenLayout(data []byte, size int, accountType byte) error {
+	switch {
+	case len(data) == size:
+		return nil
+	case len(data) > tokenAccountTypeOffset && data[tokenAccountTypeOffset] == accountType:
+		return nil
+	default:
+		return fmt.Errorf("%w: %d bytes, want %d", ErrInvalidAccountData, len(data), size)
+	}
+}
+
+// layoutDecoder reads little-endian fields in sequence. The first error is
+// kept and later reads return zero values.
+type layoutDecoder struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (d *layoutDecoder) next(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	if d.off+n > len(d.data) {
+		d.err = fmt.Errorf("%w: truncated at offset %d", ErrInvalidAccountData, d.off)
+		return make([]byte, n)
+	}
+	b := d.data[d.off : d.off+n]
+	d.off += n
+	return b
+}
+
+func (d *layoutDecoder) byte() byte {
+	return d.next(1)[0]
+}
+
+func (d *layoutDecoder) uint64() uint64 {
+	return binary.LittleEndian.Uint64(d.next(8))
+}
+
+func (d *layoutDecoder) key() PublicKey {
+	var k PublicKey
+	copy(k[:], d.next(32))
+	return k
+}
+
+// option reads a COption tag: a little-endian uint32 that is 0 for None and
+// 1 for Some. The value that follows is always present in the layout.
+func (d *layoutDecoder) option() bool {
+	offset := d.off
+	tag := binary.LittleEndian.Uint32(d.next(4))
+	if tag > 1 && d.err == nil {
+		d.err = fmt.Errorf("%w: invalid COption tag %d at offset %d", ErrInvalidAccountData, tag, offset)
+	}
+	return tag == 1
+}
+
+func (d *layoutDecoder) optionalKey() *PublicKey {
+	some := d.option()
+	k := d.key()
+	if !some {
+		return nil
+	}
+	return &k
+}
+
+func (d *layoutDecoder) optionalUint64() *uint64 {
+	some := d.option()
+	v := d.uint64()
+	if !some {
+		return nil
+	}
+	return &v
+}
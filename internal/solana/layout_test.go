@@ -0,0 +1,98 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDecodeMint(t *testing.T) {
+	wallet, _ := NewWallet()
+	authority := wallet.Key()
+	data := make([]byte, MintSize)
+	binary.LittleEndian.PutUint32(data[0:], 1)
+	copy(data[4:], authority[:])
+	binary.LittleEndian.PutUint64(data[36:], 1_000_000)
+	data[44] = 6
+	data[45] = 1
+
+	mint, err := DecodeMint(data)
+	if err != nil {
+		t.Fatalf("DecodeMint: %v", err)
+	}
+	if mint.MintAuthority == nil || *mint.MintAuthority != authority ||
+		mint.Supply != 1_000_000 || mint.Decimals != 6 || !mint.IsInitialized || mint.FreezeAuthority != nil {
+		t.Fatalf("mint = %+v", mint)
+	}
+
+	// Token-2022 mints with extensions are padded and tagged.
+	extended := make([]byte, TokenAccountSize+8)
+	copy(extended, data)
+	extended[TokenAccountSize] = tokenAccountTypeMint
+	if _, err := DecodeMint(extended); err != nil {
+		t.Fatalf("DecodeMint with extensions: %v", err)
+	}
+
+	for name, bad := range map[string][]byte{
+		"short":        data[:MintSize-1],
+		"token layout": make([]byte, TokenAccountSize),
+		"bad option":   append([]byte{2, 0, 0, 0}, data[4:]...),
+	} {
+		if _, err := DecodeMint(bad); !errors.Is(err, ErrInvalidAccountData) {
+			t.Errorf("%s: DecodeMint error = %v, want ErrInvalidAccountData", name, err)
+		}
+	}
+}
+
+func TestGetTokenAccount(t *testing.T) {
+	keys := make([]PublicKey, 3)
+	for i := range keys {
+		w, _ := NewWallet()
+		keys[i] = w.Key()
+	}
+	mint, owner, delegate := keys[0], keys[1], keys[2]
+	data := make([]byte, TokenAccountSize)
+	copy(data[0:], mint[:])
+	copy(data[32:], owner[:])
+	binary.LittleEndian.PutUint64(data[64:], 500)
+	binary.LittleEndian.PutUint32(data[72:], 1)
+	copy(data[76:], delegate[:])
+	data[108] = byte(TokenAccountFrozen)
+	binary.LittleEndian.PutUint32(data[109:], 1)
+	binary.LittleEndian.PutUint64(data[113:], 2039280)
+	binary.LittleEndian.PutUint64(data[121:], 100)
+
+	programOwner := TokenProgramID.String()
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getAccountInfo": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"lamports": 1,
+				"owner":    programOwner,
+				"data":     []string{base64.StdEncoding.EncodeToString(data), "base64"},
+			}), nil
+		},
+	})
+	client := rpc.client(t)
+
+	account, err := client.GetTokenAccount(context.Background(), owner.String())
+	if err != nil {
+		t.Fatalf("GetTokenAccount: %v", err)
+	}
+	if account.Mint != mint || account.Owner != owner || account.Amount != 500 ||
+		account.Delegate == nil || *account.Delegate != delegate || account.State != TokenAccountFrozen ||
+		account.NativeReserve == nil || *account.NativeReserve != 2039280 ||
+		account.DelegatedAmount != 100 || account.CloseAuthority != nil {
+		t.Fatalf("account = %+v", account)
+	}
+
+	if _, err := client.GetMint(context.Background(), owner.String()); !errors.Is(err, ErrInvalidAccountData) {
+		t.Fatalf("GetMint on a token account error = %v, want ErrInvalidAccountData", err)
+	}
+	programOwner = SystemProgramID.String()
+	if _, err := client.GetTokenAccount(context.Background(), owner.String()); !errors.Is(err, ErrInvalidAccountData) {
+		t.Fatalf("GetTokenAccount on a system account error = %v, want ErrInvalidAccountData", err)
+	}
+}
@@ -0,0 +1,60 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RPC error codes for ledger queries a node cannot answer.
+const (
+	rpcCodeMethodNotFound = -32601
+	rpcCodeNoSnapshot     = -32008
+)
+
+// ErrNoSnapshot is returned by GetHighestSnapshotSlot when the node has no
+// snapshot.
+var ErrNoSnapshot = errors.New("solana: node has no snapshot")
+
+// SnapshotSlotInfo is the highest slot of the node's snapshots. Incremental
+// is nil when the node has no incremental snapshot.
+type SnapshotSlotInfo struct {
+	Full        uint64  `json:"full"`
+	Incremental *uint64 `json:"incremental,omitempty"`
+}
+
+// GetFirstAvailableBlock returns the lowest slot the node still has a block
+// for. GetBlock requests for earlier slots will fail with
+// ErrBlockNotAvailable.
+func (c *Client) GetFirstAvailableBlock(ctx context.Context) (uint64, error) {
+	var slot uint64
+	if err := c.call(ctx, "getFirstAvailableBlock", nil, &slot); err != nil {
+		return 0, ledgerError("getFirstAvailableBlock", err)
+	}
+	return slot, nil
+}
+
+// GetHighestSnapshotSlot returns the highest full and incremental snapshot
+// slots the node has. It returns ErrNoSnapshot when the node has none.
+func (c *Client) GetHighestSnapshotSlot(ctx context.Context) (*SnapshotSlotInfo, error) {
+	var info SnapshotSlotInfo
+	if err := c.call(ctx, "getHighestSnapshotSlot", nil, &info); err != nil {
+		return nil, ledgerError("getHighestSnapshotSlot", err)
+	}
+	return &info, nil
+}
+
+// ledgerError maps a disabled method to ErrMethodNotSupported and a missing
+// snapshot to ErrNoSnapshot.
+func ledgerError(method string, err error) error {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case rpcCodeMethodNotFound:
+			return fmt.Errorf("%w: %w", ErrMethodNotSupported, err)
+		case rpcCodeNoSnapshot:
+			return fmt.Errorf("%w: %w", ErrNoSnapshot, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,30 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestLedgerQueries(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getHighestSnapshotSlot": func(json.RawMessage) (interface{}, error) {
+			return map[string]interface{}{"full": 100, "incremental": 110}, nil
+		},
+	})
+	client := rpc.client(t)
+
+	info, err := client.GetHighestSnapshotSlot(context.Background())
+	if err != nil {
+		t.Fatalf("GetHighestSnapshotSlot: %v", err)
+	}
+	if info.Full != 100 || info.Incremental == nil || *info.Incremental != 110 {
+		t.Fatalf("snapshot = %+v", info)
+	}
+
+	// The fake RPC answers unknown methods with "Method not found".
+	if _, err := client.GetFirstAvailableBlock(context.Background()); !errors.Is(err, ErrMethodNotSupported) {
+		t.Fatalf("GetFirstAvailableBlock = %v, want ErrMethodNotSupported", err)
+	}
+}
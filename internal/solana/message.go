@@ -0,0 +1,40 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+)
+
+// SignMessage signs message off-chain with wallet's key, as a browser
+// wallet's signMessage does: the signature is the plain 64-byte Ed25519
+// signature of the raw bytes, with no transaction around them. Use it to
+// prove control of an address, e.g. by signing a login nonce; the message
+// should name the application and be used once so a signature cannot be
+// replayed elsewhere.
+func SignMessage(wallet *Wallet, message []byte) ([]byte, error) {
+	if wallet == nil {
+		return nil, errors.New("sign message: nil wallet")
+	}
+	if len(message) == 0 {
+		return nil, errors.New("sign message: empty message")
+	}
+	return wallet.Sign(message)
+}
+
+// VerifySignature reports whether signature is the Ed25519 signature of
+// message by pubkey, a base58 address, as produced by SignMessage or a
+// browser wallet's signMessage. It returns an error wrapping
+// ErrInvalidAddress or ErrInvalidSignature when pubkey or signature is
+// malformed, and false without an error when a well-formed signature does
+// not match.
+func VerifySignature(pubkey string, message, signature []byte) (bool, error) {
+	key, err := PublicKeyFromBase58(pubkey)
+	if err != nil {
+		return false, err
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return false, fmt.Errorf("%w: signature is %d bytes, want %d", ErrInvalidSignature, len(signature), ed25519.SignatureSize)
+	}
+	return ed25519.Verify(key[:], message, signature), nil
+}
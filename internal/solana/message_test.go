@@ -0,0 +1,71 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/mr-tron/base58"
+)
+
+func TestSignAndVerifyMessage(t *testing.T) {
+	// RFC 8032 section 7.1 test vectors. Browser wallets sign messages
+	// with plain Ed25519 too, so their signatures verify the same way.
+	vectors := []struct {
+		seed, pubkey, message, signature string
+	}{
+		{
+			seed:      "4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+			pubkey:    "3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c",
+			message:   "72",
+			signature: "92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00",
+		},
+		{
+			seed:      "c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+			pubkey:    "fc51cd8e6218a1a38da47ed00230f0580816ed13ba3303ac5deb911548908025",
+			message:   "af82",
+			signature: "6291d657deec24024827e69c3abe01a30ce548a284743a445e3680d7db5ac3ac18ff9b538d16f290ae67f760984dc6594a7c15e9716ed28dc027beceea1ec40a",
+		},
+	}
+	for _, v := range vectors {
+		seed, _ := hex.DecodeString(v.seed)
+		pubkey, _ := hex.DecodeString(v.pubkey)
+		message, _ := hex.DecodeString(v.message)
+		want, _ := hex.DecodeString(v.signature)
+		address := base58.Encode(pubkey)
+
+		wallet, err := WalletFromPrivateKey(ed25519.NewKeyFromSeed(seed))
+		if err != nil || wallet.PublicKey() != address {
+			t.Fatalf("wallet from seed %s = %v, %v, want %s", v.seed, wallet.PublicKey(), err, address)
+		}
+		signature, err := SignMessage(wallet, message)
+		if err != nil || hex.EncodeToString(signature) != v.signature {
+			t.Fatalf("SignMessage(%s) = %x, %v, want %s", v.message, signature, err, v.signature)
+		}
+		if ok, err := VerifySignature(address, message, want); !ok || err != nil {
+			t.Fatalf("VerifySignature of the test vector = %v, %v", ok, err)
+		}
+		want[0] ^= 1
+		if ok, err := VerifySignature(address, message, want); ok || err != nil {
+			t.Fatalf("VerifySignature of a corrupt signature = %v, %v, want false", ok, err)
+		}
+	}
+
+	wallet, _ := NewWallet()
+	nonce := []byte("Sign in to example.com\nNonce: 8f3a1c")
+	signature, _ := SignMessage(wallet, nonce)
+	other, _ := NewWallet()
+	if ok, err := VerifySignature(other.PublicKey(), nonce, signature); ok || err != nil {
+		t.Fatalf("VerifySignature by another key = %v, %v, want false", ok, err)
+	}
+	if _, err := VerifySignature(wallet.PublicKey(), nonce, signature[:63]); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("short signature: %v, want ErrInvalidSignature", err)
+	}
+	if _, err := VerifySignature("your_address_here", nonce, signature); !errors.Is(err, ErrInvalidAddress) {
+		t.Fatalf("placeholder address: %v, want ErrInvalidAddress", err)
+	}
+	if _, err := SignMessage(wallet, nil); err == nil {
+		t.Fatal("SignMessage signed an empty message")
+	}
+}
@@ -0,0 +1,169 @@
+package solana
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// clientMetrics records RPC call counts and per-method latency.
+type clientMetrics struct {
+	requests atomic.Uint64
+	errors   atomic.Uint64
+	// duplicates counts notifications dropped by WithNotificationDedup.
+	duplicates atomic.Uint64
+	// resyncs counts subscriptions resumed by WithResumableSubscriptions.
+	resyncs atomic.Uint64
+	// requestBytesSaved and responseBytesSaved count bytes gzip kept off
+	// the wire.
+	requestBytesSaved  atomic.Uint64
+	responseBytesSaved atomic.Uint64
+
+	buckets []float64
+	latency *utils.Histogram
+	window  *utils.SlidingWindow
+	// pingRTT is the round trip of WebSocket keepalive pings.
+	pingRTT *utils.Histogram
+
+	mu       sync.Mutex
+	byMethod map[string]*utils.Histogram
+}
+
+func newClientMetrics(buckets []float64, window time.Duration) *clientMetrics {
+	return &clientMetrics{
+		buckets:  buckets,
+		latency:  utils.NewHistogram(buckets),
+		window:   utils.NewSlidingWindow(window, 0, nil),
+		pingRTT:  utils.NewHistogram(buckets),
+		byMethod: make(map[string]*utils.Histogram),
+	}
+}
+
+func (m *clientMetrics) observe(method string, d time.Duration, err error) {
+	m.requests.Add(1)
+	if err != nil {
+		m.errors.Add(1)
+	}
+	m.latency.Observe(d)
+	m.window.Record(err != nil)
+
+	m.mu.Lock()
+	h, ok := m.byMethod[method]
+	if !ok {
+		h = utils.NewHistogram(m.buckets)
+		m.byMethod[method] = h
+	}
+	m.mu.Unlock()
+	h.Observe(d)
+}
+
+func (m *clientMetrics) methodSnapshots() map[string]utils.HistogramSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]utils.HistogramSnapshot, len(m.byMethod))
+	for method, h := range m.byMethod {
+		out[method] = h.Snapshot()
+	}
+	return out
+}
+
+func (m *clientMetrics) reset() {
+	m.requests.Store(0)
+	m.errors.Store(0)
+	m.duplicates.Store(0)
+	m.resyncs.Store(0)
+	m.requestBytesSaved.Store(0)
+	m.responseBytesSaved.Store(0)
+	m.latency.Reset()
+	m.window.Reset()
+	m.pingRTT.Reset()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byMethod = make(map[string]*utils.Histogram)
+}
+
+// GetMetrics returns RPC counters and latency summaries. "latency" covers all
+// calls; "latency_by_method" breaks it down per RPC method. "window" counts
+// calls and errors over the last MetricsWindow only. "duplicate_notifications"
+// counts notifications dropped by WithNotificationDedup, and
+// "subscription_resyncs" the subscriptions resumed and reconciled after a
+// dropped connection under WithResumableSubscriptions. "ws_ping_rtt" is
+// the round trip of WebSocket keepalive pings. "endpoints" reports, per RPC
+// endpoint host, the smoothed latency probed under the "latency" routing
+// strategy, whether it is healthy, and how many calls to it failed.
+// "gzip_bytes_saved" counts the request and response bytes compression kept
+// off the wire.
+func (c *Client) GetMetrics() map[string]interface{} {
+	return map[string]interface{}{
+		"requests_total":          c.metrics.requests.Load(),
+		"errors_total":            c.metrics.errors.Load(),
+		"latency":                 c.metrics.latency.Snapshot(),
+		"latency_by_method":       c.metrics.methodSnapshots(),
+		"window":                  c.metrics.window.Snapshot(),
+		"airdrops":                c.airdrops.metrics(),
+		"open_connections":        c.transport.OpenConnections(),
+		"duplicate_notifications": c.metrics.duplicates.Load(),
+		"subscription_resyncs":    c.metrics.resyncs.Load(),
+		"ws_ping_rtt":             c.metrics.pingRTT.Snapshot(),
+		"endpoints":               c.endpoints.metrics(),
+		"gzip_bytes_saved": map[string]interface{}{
+			"request":  c.metrics.requestBytesSaved.Load(),
+			"response": c.metrics.responseBytesSaved.Load(),
+		},
+	}
+}
+
+// ResetMetrics zeroes all counters and histograms, e.g. between tests.
+func (c *Client) ResetMetrics() {
+	c.metrics.reset()
+}
+
+// CollectPrometheus implements utils.PrometheusCollector.
+func (c *Client) CollectPrometheus(w *utils.PrometheusWriter) {
+	w.Counter("solana_rpc_requests_total", "Total Solana RPC calls.", float64(c.metrics.requests.Load()), nil)
+	w.Counter("solana_rpc_errors_total", "Failed Solana RPC calls.", float64(c.metrics.errors.Load()), nil)
+
+	w.Gauge("solana_open_connections", "Open connections in the RPC transport pool, shared by clients configured alike.", float64(c.transport.OpenConnections()), nil)
+
+	w.Counter("solana_airdrops_total", "Airdrop requests by outcome.", float64(c.airdrops.successes.Load()), map[string]string{"result": "success"})
+	w.Counter("solana_airdrops_total", "Airdrop requests by outcome.", float64(c.airdrops.failures.Load()), map[string]string{"result": "failure"})
+	w.Counter("solana_airdrop_backoffs_total", "Airdrops retried after hitting the faucet limit.", float64(c.airdrops.backoffs.Load()), nil)
+
+	w.Counter("solana_duplicate_notifications_total", "Subscription notifications dropped as duplicates.", float64(c.metrics.duplicates.Load()), nil)
+	w.Counter("solana_subscription_resyncs_total", "Subscriptions resumed and reconciled after a dropped connection.", float64(c.metrics.resyncs.Load()), nil)
+
+	w.Counter("solana_gzip_bytes_saved_total", "Bytes gzip kept off the wire.", float64(c.metrics.requestBytesSaved.Load()), map[string]string{"direction": "request"})
+	w.Counter("solana_gzip_bytes_saved_total", "Bytes gzip kept off the wire.", float64(c.metrics.responseBytesSaved.Load()), map[string]string{"direction": "response"})
+
+	w.Histogram("solana_ws_ping_rtt_seconds", "Round trip of WebSocket keepalive pings.", c.metrics.pingRTT.Snapshot(), nil)
+
+	endpoints := c.endpoints.stats()
+	for _, e := range endpoints {
+		w.Gauge("solana_endpoint_latency_seconds", "Smoothed getHealth latency of an RPC endpoint.", e.latency.Seconds(), map[string]string{"endpoint": e.label})
+	}
+	for _, e := range endpoints {
+		healthy := 0.0
+		if e.healthy {
+			healthy = 1
+		}
+		w.Gauge("solana_endpoint_healthy", "Whether an RPC endpoint is preferred for calls.", healthy, map[string]string{"endpoint": e.label})
+	}
+	for _, e := range endpoints {
+		w.Counter("solana_endpoint_failures_total", "Calls an RPC endpoint failed to answer.", float64(e.failures), map[string]string{"endpoint": e.label})
+	}
+
+	snaps := c.metrics.methodSnapshots()
+	methods := make([]string, 0, len(snaps))
+	for method := range snaps {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		w.Histogram("solana_rpc_latency_seconds", "Solana RPC call latency.", snaps[method],
+			map[string]string{"method": method})
+	}
+}
@@ -0,0 +1,134 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// MaxDerivedWallets bounds a single DeriveWallets call.
+const MaxDerivedWallets = 10_000
+
+// hardenedOffset is added to an index to make it a hardened derivation
+// step. Ed25519 derivation supports only hardened steps.
+const hardenedOffset = 1 << 31
+
+// WalletFromMnemonic derives the wallet at m/44'/501'/index'/0', the path
+// used by Phantom, Solflare, and solana-keygen for account index. The
+// mnemonic is an English BIP-39 phrase with no passphrase; its words are
+// not checked against the word list.
+func WalletFromMnemonic(mnemonic string, index int) (*Wallet, error) {
+	wallets, err := DeriveWallets(mnemonic, index, 1)
+	if err != nil {
+		return nil, err
+	}
+	return wallets[0], nil
+}
+
+// DeriveWallets derives count wallets at m/44'/501'/i'/0' for i from start,
+// in order. The wallet at each index is the one WalletFromMnemonic returns
+// for it, so a fleet can be regenerated from the phrase alone. count may be
+// at most MaxDerivedWallets.
+func DeriveWallets(mnemonic string, start, count int) ([]*Wallet, error) {
+	switch {
+	case start < 0 || count < 0:
+		return nil, fmt.Errorf("derive wallets: start %d and count %d must not be negative", start, count)
+	case count > MaxDerivedWallets:
+		return nil, fmt.Errorf("derive wallets: count %d exceeds %d", count, MaxDerivedWallets)
+	case int64(start)+int64(count) > hardenedOffset:
+		return nil, fmt.Errorf("derive wallets: index %d out of range", int64(start)+int64(count)-1)
+	}
+	seed, err := mnemonicSeed(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	// m/44'/501' is shared by every wallet; derive it once.
+	account := newDerivationKey(seed).child(44).child(501)
+	wallets := make([]*Wallet, count)
+	for i := range wallets {
+		key := account.child(uint32(start + i)).child(0)
+		wallet, err := WalletFromPrivateKey(ed25519.NewKeyFromSeed(key.key[:]))
+		if err != nil {
+			return nil, err
+		}
+		wallets[i] = wallet
+	}
+	return wallets, nil
+}
+
+// mnemonicSeed returns the BIP-39 seed of mnemonic with an empty passphrase.
+func mnemonicSeed(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("%w: %d words, want 12, 15, 18, 21, or 24", ErrInvalidMnemonic, len(words))
+	}
+	for i, word := range words {
+		for _, r := range word {
+			if r < 'a' || r > 'z' {
+				return nil, fmt.Errorf("%w: word %d is not lowercase English", ErrInvalidMnemonic, i+1)
+			}
+		}
+	}
+	return pbkdf2SHA512([]byte(strings.Join(words, " ")), []byte("mnemonic"), 2048, 64), nil
+}
+
+// derivationKey is a SLIP-0010 Ed25519 extended private key.
+type derivationKey struct {
+	key       [32]byte
+	chainCode [32]byte
+}
+
+func newDerivationKey(seed []byte) derivationKey {
+	return splitDerivation(hmacSHA512([]byte("ed25519 seed"), seed))
+}
+
+// child derives the hardened child at index.
+func (k derivationKey) child(index uint32) derivationKey {
+	data := make([]byte, 0, 1+32+4)
+	data = append(data, 0)
+	data = append(data, k.key[:]...)
+	data = binary.BigEndian.AppendUint32(data, index+hardenedOffset)
+	return splitDerivation(hmacSHA512(k.chainCode[:], data))
+}
+
+func splitDerivation(sum []byte) derivationKey {
+	var k derivationKey
+	copy(k.key[:], sum[:32])
+	copy(k.chainCode[:], sum[32:])
+	return k
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// pbkdf2SHA512 is PBKDF2 (RFC 8018) with HMAC-SHA512.
+func pbkdf2SHA512(password, salt []byte, iterations, keyLen int) []byte {
+	mac := hmac.New(sha512.New, password)
+	var out []byte
+	for block := uint32(1); len(out) < keyLen; block++ {
+		mac.Reset()
+		mac.Write(salt)
+		mac.Write(binary.BigEndian.AppendUint32(nil, block))
+		u := mac.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			mac.Reset()
+			mac.Write(u)
+			u = mac.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
@@ -0,0 +1,67 @@
+package solana
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestMnemonicDerivationVectors(t *testing.T) {
+	// BIP-39 reference vector with an empty passphrase.
+	seed, err := mnemonicSeed(testMnemonic)
+	if err != nil {
+		t.Fatalf("mnemonicSeed: %v", err)
+	}
+	if got := hex.EncodeToString(seed); got != "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4" {
+		t.Fatalf("seed = %s", got)
+	}
+
+	// SLIP-0010 Ed25519 test vector 1.
+	vectorSeed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master := newDerivationKey(vectorSeed)
+	if got := hex.EncodeToString(master.key[:]); got != "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7" {
+		t.Fatalf("m key = %s", got)
+	}
+	child := master.child(0)
+	if got := hex.EncodeToString(child.key[:]); got != "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3" {
+		t.Fatalf("m/0' key = %s", got)
+	}
+}
+
+func TestDeriveWallets(t *testing.T) {
+	wallets, err := DeriveWallets(testMnemonic, 3, 4)
+	if err != nil {
+		t.Fatalf("DeriveWallets: %v", err)
+	}
+	seen := make(map[string]bool)
+	for i, w := range wallets {
+		single, err := WalletFromMnemonic(testMnemonic, 3+i)
+		if err != nil {
+			t.Fatalf("WalletFromMnemonic(%d): %v", 3+i, err)
+		}
+		if single.PublicKey() != w.PublicKey() {
+			t.Fatalf("index %d: DeriveWallets gave %s, WalletFromMnemonic %s", 3+i, w.PublicKey(), single.PublicKey())
+		}
+		if seen[w.PublicKey()] {
+			t.Fatalf("index %d repeats a wallet", 3+i)
+		}
+		seen[w.PublicKey()] = true
+	}
+
+	again, _ := DeriveWallets("  "+strings.ReplaceAll(testMnemonic, " ", "\n")+" ", 3, 1)
+	if again[0].PublicKey() != wallets[0].PublicKey() {
+		t.Fatal("whitespace changed the derived wallet")
+	}
+
+	for _, bad := range []struct{ start, count int }{{-1, 1}, {0, -1}, {0, MaxDerivedWallets + 1}, {hardenedOffset - 1, 2}} {
+		if _, err := DeriveWallets(testMnemonic, bad.start, bad.count); err == nil {
+			t.Errorf("DeriveWallets(%d, %d) succeeded", bad.start, bad.count)
+		}
+	}
+	if _, err := DeriveWallets("abandon about", 0, 1); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("short mnemonic error = %v, want ErrInvalidMnemonic", err)
+	}
+}
@@ -0,0 +1,39 @@
+package solana
+
+import "context"
+
+// ClusterNode is a node of the cluster as seen through gossip. Addresses
+// are host:port; a node that does not offer a service, such as a validator
+// without public RPC, has that address empty.
+type ClusterNode struct {
+	Pubkey       string `json:"pubkey"`
+	Gossip       string `json:"gossip"`
+	TPU          string `json:"tpu"`
+	TPUQUIC      string `json:"tpuQuic"`
+	RPC          string `json:"rpc"`
+	PubSub       string `json:"pubsub"`
+	Version      string `json:"version"`
+	FeatureSet   uint32 `json:"featureSet"`
+	ShredVersion uint16 `json:"shredVersion"`
+}
+
+// GetClusterNodes returns every node participating in the cluster, as
+// known to the node answering the call. It takes no commitment.
+func (c *Client) GetClusterNodes(ctx context.Context) ([]ClusterNode, error) {
+	var nodes []ClusterNode
+	if err := c.call(ctx, "getClusterNodes", nil, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// RPCNodes returns the nodes in nodes that serve RPC.
+func RPCNodes(nodes []ClusterNode) []ClusterNode {
+	var out []ClusterNode
+	for _, n := range nodes {
+		if n.RPC != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestGetClusterNodes(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getClusterNodes": func(params json.RawMessage) (interface{}, error) {
+			if len(params) > 0 && string(params) != "null" && string(params) != "[]" {
+				t.Errorf("params = %s, want none", params)
+			}
+			return json.RawMessage(`[
+				{"pubkey":"9QxCLckBiJc783jnMvXZubK4wH86Eqqvashtrwvcsgkv","gossip":"10.239.6.48:8001","tpu":"10.239.6.48:8856",
+				 "rpc":"10.239.6.48:8899","pubsub":"10.239.6.48:8900","version":"1.18.22","featureSet":3469865029,"shredVersion":50093},
+				{"pubkey":"7Np41oeYqPefeNQEHSv1UDhYrehxin3NStELsSKCT4K2","gossip":"10.239.6.49:8001","tpu":"10.239.6.49:8856",
+				 "rpc":null,"pubsub":null,"version":null,"featureSet":null,"shredVersion":50093}
+			]`), nil
+		},
+	})
+	nodes, err := rpc.client(t).GetClusterNodes(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusterNodes: %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("nodes = %+v", nodes)
+	}
+	if n := nodes[0]; n.RPC != "10.239.6.48:8899" || n.Gossip != "10.239.6.48:8001" || n.Version != "1.18.22" || n.FeatureSet != 3469865029 {
+		t.Fatalf("node = %+v", n)
+	}
+	if n := nodes[1]; n.RPC != "" || n.Version != "" || n.TPU != "10.239.6.49:8856" {
+		t.Fatalf("node without RPC = %+v", n)
+	}
+	if served := RPCNodes(nodes); len(served) != 1 || served[0].Pubkey != nodes[0].Pubkey {
+		t.Fatalf("RPCNodes = %+v", served)
+	}
+}
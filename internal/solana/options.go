@@ -0,0 +1,156 @@
+package solana
+
+import "fmt"
+
+// Account data encodings accepted by WithEncoding.
+const (
+	EncodingBase64     = "base64"
+	EncodingBase58     = "base58"
+	EncodingJSONParsed = "jsonParsed"
+)
+
+// CallOption configures a single query. Query methods take a variadic
+// ...CallOption so new settings can be added without changing signatures;
+// calls without options behave as before.
+//
+// Available options and their defaults:
+//   - WithCommitment: the client's configured commitment, or "confirmed".
+//   - WithMinContextSlot: unset, so any node state is accepted.
+//   - WithEncoding: EncodingBase64. Only methods returning account data
+//     accept it.
+//   - WithDataSlice: unset, so account data is returned whole. Only
+//     GetAccountInfo, GetMultipleAccounts, and GetProgramAccounts accept
+//     it.
+//
+// Every method accepts WithCommitment; each documents which other options
+// it accepts. Passing an option a method does not apply fails the call with
+// ErrUnsupportedOption rather than silently dropping the setting.
+type CallOption func(*callOptions)
+
+// optionSet lists the options beyond WithCommitment that a query method
+// applies. callOptions rejects the rest.
+type optionSet uint8
+
+const (
+	optMinContextSlot optionSet = 1 << iota
+	optEncoding
+	optDataSlice
+	optNonCirculatingAccounts
+
+	// optAccountData is what methods returning account data accept.
+	optAccountData = optMinContextSlot | optEncoding | optDataSlice
+)
+
+type callOptions struct {
+	commitment     string
+	minContextSlot *uint64
+	encoding       string
+	dataSlice      *DataSlice
+	// nonCirculatingAccounts asks GetSupply for the account list.
+	nonCirculatingAccounts bool
+}
+
+// WithCommitment overrides the client's commitment for one call.
+func WithCommitment(commitment string) CallOption {
+	return func(o *callOptions) {
+		o.commitment = commitment
+	}
+}
+
+// WithMinContextSlot makes the node fail the call unless it has reached
+// slot, so a read never observes state older than a previous write. Use
+// Client.LastWriteSlot for the slot of the client's own writes.
+func WithMinContextSlot(slot uint64) CallOption {
+	return func(o *callOptions) {
+		o.minContextSlot = &slot
+	}
+}
+
+// WithEncoding selects how account data is encoded on the wire. With
+// EncodingJSONParsed, accounts of programs the node can parse are returned
+// in AccountInfo.Parsed instead of Data.
+func WithEncoding(encoding string) CallOption {
+	return func(o *callOptions) {
+		o.encoding = encoding
+	}
+}
+
+// MaxAccountDataLength is the largest data an account can hold, 10 MiB.
+const MaxAccountDataLength = 10 * 1024 * 1024
+
+// DataSlice selects Length bytes of account data starting at Offset. A
+// slice reaching past the end of the data is cut short by the node.
+type DataSlice struct {
+	Offset int
+	Length int
+}
+
+// WithDataSlice makes the node return only slice of the account data
+// instead of all of it, saving bandwidth when reading a few bytes of a
+// large account. The returned AccountInfo records the slice. Offset and
+// Length must be non-negative and end within MaxAccountDataLength, and
+// the slice cannot be combined with EncodingJSONParsed.
+func WithDataSlice(slice DataSlice) CallOption {
+	return func(o *callOptions) {
+		o.dataSlice = &slice
+	}
+}
+
+// callOptions applies opts over the client's defaults, failing with
+// ErrUnsupportedOption if one sets an option outside accepts.
+func (c *Client) callOptions(opts []CallOption, accepts optionSet) (callOptions, error) {
+	o := callOptions{commitment: c.commitment()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for _, unsupported := range []struct {
+		set  bool
+		opt  optionSet
+		name string
+	}{
+		{o.minContextSlot != nil, optMinContextSlot, "WithMinContextSlot"},
+		{o.encoding != "", optEncoding, "WithEncoding"},
+		{o.dataSlice != nil, optDataSlice, "WithDataSlice"},
+		{o.nonCirculatingAccounts, optNonCirculatingAccounts, "WithNonCirculatingAccounts"},
+	} {
+		if unsupported.set && accepts&unsupported.opt == 0 {
+			return o, fmt.Errorf("%w: %s", ErrUnsupportedOption, unsupported.name)
+		}
+	}
+	if o.encoding == "" {
+		o.encoding = EncodingBase64
+	}
+	if _, ok := commitmentRank[o.commitment]; !ok {
+		return o, fmt.Errorf("unknown commitment %q", o.commitment)
+	}
+	switch o.encoding {
+	case EncodingBase64, EncodingBase58, EncodingJSONParsed:
+	default:
+		return o, fmt.Errorf("unsupported encoding %q", o.encoding)
+	}
+	if s := o.dataSlice; s != nil {
+		if s.Offset < 0 || s.Length < 0 || s.Offset > MaxAccountDataLength-s.Length {
+			return o, fmt.Errorf("data slice [%d, +%d) outside the %d byte account data limit", s.Offset, s.Length, MaxAccountDataLength)
+		}
+		if o.encoding == EncodingJSONParsed {
+			return o, fmt.Errorf("data slice cannot be used with encoding %q", o.encoding)
+		}
+	}
+	return o, nil
+}
+
+// config returns the RPC configuration object. Encoding is included only
+// for methods that return account data.
+func (o callOptions) config(withEncoding bool) map[string]interface{} {
+	config := map[string]interface{}{"commitment": o.commitment}
+	if o.minContextSlot != nil {
+		config["minContextSlot"] = *o.minContextSlot
+	}
+	if withEncoding {
+		config["encoding"] = o.encoding
+		if o.dataSlice != nil {
+			config["dataSlice"] = map[string]interface{}{"offset": o.dataSlice.Offset, "length": o.dataSlice.Length}
+		}
+	}
+	return config
+}
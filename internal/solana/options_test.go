@@ -0,0 +1,176 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+	"github.com/mr-tron/base58"
+)
+
+func TestCallOptions(t *testing.T) {
+	var configs []map[string]interface{}
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getBalance": func(params json.RawMessage) (interface{}, error) {
+			var p []json.RawMessage
+			json.Unmarshal(params, &p)
+			var config map[string]interface{}
+			json.Unmarshal(p[1], &config)
+			configs = append(configs, config)
+			return withContext(42), nil
+		},
+		"getAccountInfo": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"lamports": 1,
+				"owner":    SystemProgramID.String(),
+				"data":     []string{base58.Encode([]byte("hello")), EncodingBase58},
+			}), nil
+		},
+	})
+	client := rpc.client(t)
+	wallet, _ := NewWallet()
+
+	if _, err := client.GetBalance(context.Background(), wallet.PublicKey()); err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if _, err := client.GetBalance(context.Background(), wallet.PublicKey(),
+		WithCommitment(CommitmentFinalized), WithMinContextSlot(500)); err != nil {
+		t.Fatalf("GetBalance with options: %v", err)
+	}
+	if configs[0]["commitment"] != CommitmentConfirmed || configs[0]["minContextSlot"] != nil {
+		t.Fatalf("default config = %v", configs[0])
+	}
+	if configs[1]["commitment"] != CommitmentFinalized || configs[1]["minContextSlot"] != float64(500) {
+		t.Fatalf("config with options = %v", configs[1])
+	}
+	if _, err := client.GetBalance(context.Background(), wallet.PublicKey(), WithCommitment("soon")); err == nil {
+		t.Fatal("unknown commitment was accepted")
+	}
+	// Options a method does not apply are rejected, not dropped.
+	if _, err := client.GetBalance(context.Background(), wallet.PublicKey(), WithEncoding(EncodingBase58)); !errors.Is(err, ErrUnsupportedOption) {
+		t.Fatalf("GetBalance with an encoding = %v, want ErrUnsupportedOption", err)
+	}
+	if _, err := client.GetMint(context.Background(), wallet.PublicKey(), WithDataSlice(DataSlice{Length: 1})); !errors.Is(err, ErrUnsupportedOption) {
+		t.Fatalf("GetMint with a data slice = %v, want ErrUnsupportedOption", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("%d calls sent, want the rejected ones kept local", len(configs))
+	}
+
+	info, err := client.GetAccountInfo(context.Background(), wallet.PublicKey(), WithEncoding(EncodingBase58))
+	if err != nil || string(info.Data) != "hello" {
+		t.Fatalf("GetAccountInfo = %+v, %v", info, err)
+	}
+}
+
+func TestMinContextSlotReadAfterWrite(t *testing.T) {
+	// node answers reads only once it has reached the requested slot.
+	node := func(slot uint64) *fakeRPC {
+		return newFakeRPC(t, map[string]rpcHandler{
+			"getBalance": func(params json.RawMessage) (interface{}, error) {
+				var p []json.RawMessage
+				json.Unmarshal(params, &p)
+				var config struct {
+					MinContextSlot uint64 `json:"minContextSlot"`
+				}
+				json.Unmarshal(p[1], &config)
+				if config.MinContextSlot > slot {
+					return nil, &RPCError{Code: rpcCodeMinContextSlotNotReached, Message: "Minimum context slot has not been reached"}
+				}
+				return withContext(slot), nil
+			},
+			"getSignatureStatuses": func(json.RawMessage) (interface{}, error) {
+				return withContext([]interface{}{map[string]interface{}{
+					"slot":               200,
+					"confirmationStatus": CommitmentConfirmed,
+				}}), nil
+			},
+		})
+	}
+	lagging, current := node(150), node(250)
+	client, err := NewClient(&utils.SolanaConfig{Endpoint: lagging.srv.URL, FallbackEndpoints: []string{current.srv.URL}})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if err := client.ConfirmTransaction(context.Background(), "sig", ""); err != nil {
+		t.Fatalf("ConfirmTransaction: %v", err)
+	}
+	if got := client.LastWriteSlot(); got != 200 {
+		t.Fatalf("LastWriteSlot = %d, want 200", got)
+	}
+
+	wallet, _ := NewWallet()
+	balance, err := client.GetBalance(context.Background(), wallet.PublicKey(), WithMinContextSlot(client.LastWriteSlot()))
+	if err != nil || balance != 250 {
+		t.Fatalf("GetBalance = %d, %v; want the caught-up node's answer", balance, err)
+	}
+	// Lagging is not failing: the primary keeps its place.
+	if got := client.endpoints.order(); got[0] != lagging.srv.URL {
+		t.Fatalf("order = %v, want the lagging primary first", got)
+	}
+
+	_, err = client.GetBalance(context.Background(), wallet.PublicKey(), WithMinContextSlot(300))
+	if !errors.Is(err, ErrMinContextSlotNotReached) {
+		t.Fatalf("GetBalance past every node = %v, want ErrMinContextSlotNotReached", err)
+	}
+}
+
+func TestDataSlice(t *testing.T) {
+	var slices []interface{}
+	account := map[string]interface{}{
+		"lamports": 1,
+		"owner":    SystemProgramID.String(),
+		"data":     []string{"aGVs", EncodingBase64},
+	}
+	record := func(params json.RawMessage) {
+		var p []json.RawMessage
+		json.Unmarshal(params, &p)
+		var config map[string]interface{}
+		json.Unmarshal(p[1], &config)
+		slices = append(slices, config["dataSlice"])
+	}
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getAccountInfo": func(params json.RawMessage) (interface{}, error) {
+			record(params)
+			return withContext(account), nil
+		},
+		"getMultipleAccounts": func(params json.RawMessage) (interface{}, error) {
+			record(params)
+			return withContext([]interface{}{account, nil}), nil
+		},
+	})
+	client := rpc.client(t)
+	a, _ := NewWallet()
+	b, _ := NewWallet()
+
+	slice := DataSlice{Offset: 8, Length: 3}
+	info, err := client.GetAccountInfo(context.Background(), a.PublicKey(), WithDataSlice(slice))
+	if err != nil || string(info.Data) != "hel" || info.Slice == nil || *info.Slice != slice {
+		t.Fatalf("GetAccountInfo = %+v, %v", info, err)
+	}
+	accounts, err := client.GetMultipleAccounts(context.Background(), []string{a.PublicKey(), b.PublicKey()}, WithDataSlice(slice))
+	if err != nil || len(accounts) != 2 || *accounts[0].Slice != slice || accounts[1] != nil {
+		t.Fatalf("GetMultipleAccounts = %v, %v", accounts, err)
+	}
+	want := map[string]interface{}{"offset": float64(8), "length": float64(3)}
+	for i, got := range slices {
+		if m, _ := got.(map[string]interface{}); m["offset"] != want["offset"] || m["length"] != want["length"] {
+			t.Fatalf("call %d sent dataSlice %v, want %v", i, got, want)
+		}
+	}
+
+	if info, err := client.GetAccountInfo(context.Background(), a.PublicKey()); err != nil || info.Slice != nil {
+		t.Fatalf("GetAccountInfo without a slice = %+v, %v", info, err)
+	}
+	for _, opts := range [][]CallOption{
+		{WithDataSlice(DataSlice{Offset: -1, Length: 3})},
+		{WithDataSlice(DataSlice{Offset: MaxAccountDataLength, Length: 1})},
+		{WithDataSlice(slice), WithEncoding(EncodingJSONParsed)},
+	} {
+		if _, err := client.GetAccountInfo(context.Background(), a.PublicKey(), opts...); err == nil {
+			t.Errorf("GetAccountInfo accepted options %d", len(opts))
+		}
+	}
+}
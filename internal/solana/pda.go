@@ -0,0 +1,55 @@
+package solana
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"filippo.io/edwards25519"
+)
+
+// ErrNoViableBump is returned when no bump seed yields an off-curve address.
+var ErrNoViableBump = errors.New("solana: unable to find a viable program address bump seed")
+
+const maxSeedLength = 32
+
+// IsOnCurve reports whether key is a valid Ed25519 point. Program derived
+// addresses are always off the curve.
+func IsOnCurve(key PublicKey) bool {
+	_, err := new(edwards25519.Point).SetBytes(key[:])
+	return err == nil
+}
+
+// CreateProgramAddress derives a program address from seeds. It fails if the
+// result lies on the Ed25519 curve.
+func CreateProgramAddress(seeds [][]byte, programID PublicKey) (PublicKey, error) {
+	h := sha256.New()
+	for _, seed := range seeds {
+		if len(seed) > maxSeedLength {
+			return PublicKey{}, fmt.Errorf("seed length %d exceeds %d", len(seed), maxSeedLength)
+		}
+		h.Write(seed)
+	}
+	h.Write(programID[:])
+	h.Write([]byte("ProgramDerivedAddress"))
+
+	var key PublicKey
+	copy(key[:], h.Sum(nil))
+	if IsOnCurve(key) {
+		return PublicKey{}, errors.New("derived address is on the curve")
+	}
+	return key, nil
+}
+
+// FindProgramAddress returns the first off-curve program address for seeds,
+// searching bump seeds from 255 downwards, and the bump that produced it.
+func FindProgramAddress(seeds [][]byte, programID PublicKey) (PublicKey, uint8, error) {
+	for bump := 255; bump >= 0; bump-- {
+		withBump := append(append([][]byte(nil), seeds...), []byte{uint8(bump)})
+		key, err := CreateProgramAddress(withBump, programID)
+		if err == nil {
+			return key, uint8(bump), nil
+		}
+	}
+	return PublicKey{}, 0, ErrNoViableBump
+}
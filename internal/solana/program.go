@@ -0,0 +1,105 @@
+package solana
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+)
+
+// MaxMemcmpBytes is the longest byte string a memcmp filter may compare.
+const MaxMemcmpBytes = 128
+
+// AccountFilter narrows the accounts GetProgramAccounts returns. Build one
+// with FilterDataSize or FilterMemcmp; an account must pass every filter.
+type AccountFilter struct {
+	dataSize *uint64
+	offset   int
+	bytes    []byte
+}
+
+// FilterDataSize keeps accounts whose data is exactly size bytes long.
+func FilterDataSize(size uint64) AccountFilter {
+	return AccountFilter{dataSize: &size}
+}
+
+// FilterMemcmp keeps accounts whose data holds bytes at offset.
+func FilterMemcmp(offset int, bytes []byte) AccountFilter {
+	return AccountFilter{offset: offset, bytes: append([]byte(nil), bytes...)}
+}
+
+func (f AccountFilter) validate() error {
+	if f.dataSize != nil {
+		return nil
+	}
+	switch {
+	case f.offset < 0 || f.offset > MaxAccountDataLength:
+		return fmt.Errorf("memcmp offset %d outside the %d byte account data limit", f.offset, MaxAccountDataLength)
+	case len(f.bytes) == 0 || len(f.bytes) > MaxMemcmpBytes:
+		return fmt.Errorf("memcmp of %d bytes, want 1 to %d", len(f.bytes), MaxMemcmpBytes)
+	}
+	return nil
+}
+
+func (f AccountFilter) rpc() map[string]interface{} {
+	if f.dataSize != nil {
+		return map[string]interface{}{"dataSize": *f.dataSize}
+	}
+	return map[string]interface{}{"memcmp": map[string]interface{}{
+		"offset": f.offset,
+		"bytes":  base58.Encode(f.bytes),
+	}}
+}
+
+// KeyedAccount is an account together with its address.
+type KeyedAccount struct {
+	Address PublicKey
+	AccountInfo
+}
+
+// GetProgramAccounts returns the accounts owned by program that pass every
+// filter. It accepts WithCommitment, WithMinContextSlot, WithEncoding, and
+// WithDataSlice. The node returns all matches at once, which for a large
+// program can be slow or time out; see ScanProgramAccounts.
+func (c *Client) GetProgramAccounts(ctx context.Context, program string, filters []AccountFilter, opts ...CallOption) ([]KeyedAccount, error) {
+	if err := ValidateAddress(program); err != nil {
+		return nil, err
+	}
+	o, err := c.callOptions(opts, optAccountData)
+	if err != nil {
+		return nil, err
+	}
+	config := o.config(true)
+	if len(filters) > 0 {
+		rpcFilters := make([]interface{}, len(filters))
+		for i, f := range filters {
+			if err := f.validate(); err != nil {
+				return nil, err
+			}
+			rpcFilters[i] = f.rpc()
+		}
+		config["filters"] = rpcFilters
+	}
+
+	var entries []struct {
+		Pubkey  string         `json:"pubkey"`
+		Account rpcAccountInfo `json:"account"`
+	}
+	if err := c.call(ctx, "getProgramAccounts", []interface{}{program, config}, &entries); err != nil {
+		return nil, fmt.Errorf("get accounts of program %s: %w", program, err)
+	}
+
+	accounts := make([]KeyedAccount, 0, len(entries))
+	for _, entry := range entries {
+		address, err := PublicKeyFromBase58(entry.Pubkey)
+		if err != nil {
+			return nil, err
+		}
+		info, err := o.decodeAccount(&entry.Account)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", address, err)
+		}
+		accounts = append(accounts, KeyedAccount{Address: address, AccountInfo: *info})
+	}
+	return accounts, nil
+}
@@ -0,0 +1,155 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+)
+
+// maxBlockhashAge is how many blocks a blockhash stays valid for.
+const maxBlockhashAge = 150
+
+// DeserializeTransaction decodes a base64 encoded legacy transaction, as
+// produced by Transaction.Serialize, for inspection or SendRawTransaction.
+// Signatures left as zero bytes, as for a partially signed transaction,
+// are decoded as nil. Malformed input fails with ErrInvalidTransaction;
+// signatures are not checked, see Transaction.Verify.
+func DeserializeTransaction(serialized string) (*Transaction, error) {
+	data, err := base64.StdEncoding.DecodeString(serialized)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not base64: %v", ErrInvalidTransaction, err)
+	}
+	d := &txDecoder{data: data}
+
+	tx := &Transaction{Signatures: make([][]byte, d.shortVec())}
+	for i := range tx.Signatures {
+		sig := d.next(ed25519.SignatureSize)
+		if !isZero(sig) {
+			tx.Signatures[i] = append([]byte(nil), sig...)
+		}
+	}
+
+	if len(d.data) > d.off && d.data[d.off]&0x80 != 0 && d.err == nil {
+		return nil, fmt.Errorf("%w: versioned messages are not supported", ErrInvalidTransaction)
+	}
+	msg := &Message{}
+	msg.Header.NumRequiredSignatures = d.byte()
+	msg.Header.NumReadonlySignedAccounts = d.byte()
+	msg.Header.NumReadonlyUnsignedAccounts = d.byte()
+	msg.AccountKeys = make([]PublicKey, d.shortVec())
+	for i := range msg.AccountKeys {
+		copy(msg.AccountKeys[i][:], d.next(32))
+	}
+	copy(msg.RecentBlockhash[:], d.next(32))
+	msg.Instructions = make([]CompiledInstruction, d.shortVec())
+	for i := range msg.Instructions {
+		ix := &msg.Instructions[i]
+		ix.ProgramIDIndex = d.byte()
+		ix.Accounts = append([]uint8(nil), d.next(d.shortVec())...)
+		ix.Data = append([]byte(nil), d.next(d.shortVec())...)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if d.off != len(d.data) {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidTransaction, len(d.data)-d.off)
+	}
+	tx.Message = msg
+	if err := msg.validate(len(tx.Signatures)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// validate checks that the header and instruction indexes are consistent
+// with the account keys.
+func (m *Message) validate(signatures int) error {
+	h := m.Header
+	keys := len(m.AccountKeys)
+	switch {
+	case h.NumRequiredSignatures == 0:
+		return fmt.Errorf("%w: no required signatures", ErrInvalidTransaction)
+	case int(h.NumRequiredSignatures) != signatures:
+		return fmt.Errorf("%w: %d signatures for %d required signers", ErrInvalidTransaction, signatures, h.NumRequiredSignatures)
+	case int(h.NumRequiredSignatures)+int(h.NumReadonlyUnsignedAccounts) > keys,
+		h.NumReadonlySignedAccounts >= h.NumRequiredSignatures:
+		return fmt.Errorf("%w: header does not match %d account keys", ErrInvalidTransaction, keys)
+	}
+	for i, ix := range m.Instructions {
+		if int(ix.ProgramIDIndex) >= keys {
+			return fmt.Errorf("%w: instruction %d program index %d out of range", ErrInvalidTransaction, i, ix.ProgramIDIndex)
+		}
+		for _, a := range ix.Accounts {
+			if int(a) >= keys {
+				return fmt.Errorf("%w: instruction %d account index %d out of range", ErrInvalidTransaction, i, a)
+			}
+		}
+	}
+	return nil
+}
+
+// Verify checks that every required signature is present and valid for the
+// message. It returns ErrMissingSignature or ErrInvalidSignature naming the
+// signer otherwise.
+func (tx *Transaction) Verify() error {
+	data := tx.Message.Serialize()
+	for i, key := range tx.Message.Signers() {
+		if i >= len(tx.Signatures) || len(tx.Signatures[i]) == 0 {
+			return fmt.Errorf("%w: %s", ErrMissingSignature, key)
+		}
+		if !ed25519.Verify(ed25519.PublicKey(key[:]), data, tx.Signatures[i]) {
+			return fmt.Errorf("%w: %s", ErrInvalidSignature, key)
+		}
+	}
+	return nil
+}
+
+// txDecoder reads the transaction wire format in sequence. The first error
+// is kept and later reads return zero values.
+type txDecoder struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (d *txDecoder) next(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	if d.off+n > len(d.data) {
+		d.err = fmt.Errorf("%w: truncated at offset %d", ErrInvalidTransaction, d.off)
+		return make([]byte, n)
+	}
+	b := d.data[d.off : d.off+n]
+	d.off += n
+	return b
+}
+
+func (d *txDecoder) byte() byte {
+	return d.next(1)[0]
+}
+
+// shortVec reads a compact-u16 length.
+func (d *txDecoder) shortVec() int {
+	n := 0
+	for i := 0; i < 3; i++ {
+		b := d.byte()
+		n |= int(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return n
+		}
+	}
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: compact-u16 too long at offset %d", ErrInvalidTransaction, d.off)
+	}
+	return 0
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSendRawTransaction(t *testing.T) {
+	var sent []string
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getBlockHeight": func(json.RawMessage) (interface{}, error) {
+			return 1000, nil
+		},
+		"sendTransaction": func(params json.RawMessage) (interface{}, error) {
+			var p []interface{}
+			json.Unmarshal(params, &p)
+			sent = append(sent, p[0].(string))
+			return "sig", nil
+		},
+	})
+	client := rpc.client(t)
+	payer, _ := NewWallet()
+	from, _ := NewWallet()
+	to, _ := NewWallet()
+
+	builder := NewTransactionBuilder().
+		SetFeePayer(payer.Key()).
+		AddInstruction(TransferInstruction(from.Key(), to.Key(), 500)).
+		SetRecentBlockhash(Hash{7}, 0)
+	encode := func(tx *Transaction) string {
+		raw, err := tx.Serialize()
+		if err != nil {
+			t.Fatalf("Serialize: %v", err)
+		}
+		return base64.StdEncoding.EncodeToString(raw)
+	}
+
+	signed, err := builder.AddSigner(payer, from).Sign()
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	serialized := encode(signed)
+
+	decoded, err := DeserializeTransaction(serialized)
+	if err != nil {
+		t.Fatalf("DeserializeTransaction: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Message, signed.Message) || decoded.Signature() != signed.Signature() {
+		t.Fatalf("round trip = %+v, want %+v", decoded, signed)
+	}
+	if err := decoded.Verify(); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	sig, err := client.SendRawTransaction(context.Background(), serialized)
+	if err != nil || sig != signed.Signature() {
+		t.Fatalf("SendRawTransaction = %q, %v; want %q", sig, err, signed.Signature())
+	}
+	if len(sent) != 1 || sent[0] != serialized {
+		t.Fatalf("broadcast %v, want the transaction unchanged", sent)
+	}
+	tracked, ok := client.TrackedTransaction(sig)
+	if !ok || tracked.LastValidBlockHeight != 1000+maxBlockhashAge {
+		t.Fatalf("tracked = %+v, %v", tracked, ok)
+	}
+
+	// A partially signed transaction decodes, but is not sent.
+	partial, _ := builder.Build()
+	partial.Sign(payer)
+	partial.Signatures[1] = make([]byte, 64)
+	decoded, err = DeserializeTransaction(encode(partial))
+	if err != nil || decoded.Signatures[1] != nil {
+		t.Fatalf("partially signed = %v, %v; want a nil signature", decoded.Signatures, err)
+	}
+	if _, err := client.SendRawTransaction(context.Background(), encode(partial)); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("partially signed send = %v, want ErrMissingSignature", err)
+	}
+
+	tampered, _ := base64.StdEncoding.DecodeString(serialized)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := client.SendRawTransaction(context.Background(), base64.StdEncoding.EncodeToString(tampered)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered send = %v, want ErrInvalidSignature", err)
+	}
+	if _, err := client.SendRawTransaction(context.Background(), serialized, WithFeeFloor(FeeFloor{Multiplier: 2})); !errors.Is(err, ErrUnsupportedOption) {
+		t.Fatalf("send with a fee floor = %v, want ErrUnsupportedOption", err)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(serialized)
+	for name, input := range map[string]string{
+		"not base64": "%%%",
+		"empty":      "",
+		"truncated":  base64.StdEncoding.EncodeToString(raw[:len(raw)-3]),
+		"trailing":   base64.StdEncoding.EncodeToString(append(raw, 0)),
+	} {
+		if _, err := client.SendRawTransaction(context.Background(), input); !errors.Is(err, ErrInvalidTransaction) {
+			t.Errorf("%s: err = %v, want ErrInvalidTransaction", name, err)
+		}
+	}
+	if len(sent) != 1 {
+		t.Fatalf("broadcasts = %d, want only the valid transaction", len(sent))
+	}
+}
@@ -0,0 +1,182 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultResubscribeBackoff is the first wait between attempts to resume a
+// subscription; it doubles up to the maximum set with
+// WithResumableSubscriptions.
+const DefaultResubscribeBackoff = 500 * time.Millisecond
+
+// DefaultResubscribeMaxBackoff caps the wait between attempts to resume a
+// subscription when WithResumableSubscriptions is given no maximum.
+const DefaultResubscribeMaxBackoff = 30 * time.Second
+
+// WithResumableSubscriptions keeps account subscriptions alive when the
+// WebSocket connection drops. Instead of ending with ErrSubscriptionClosed,
+// a subscription re-subscribes on a new connection, retrying with backoff
+// up to maxBackoff (DefaultResubscribeMaxBackoff if zero or less) until it
+// succeeds or its context ends.
+//
+// Each subscription tracks the last slot it saw. Once re-subscribed, it
+// reads the account with getAccountInfo, no older than that slot, and
+// delivers the result as a Notification with Resynced set before resuming
+// live notifications, so a change made while disconnected is not missed.
+// Live notifications older than the resynced state are discarded. This is
+// best-effort: the resync reports the account as it is now, and changes
+// that came and went while disconnected are not replayed.
+//
+// Program subscriptions cannot be reconciled this way and still end when
+// the connection drops. Resumption is off by default.
+func WithResumableSubscriptions(maxBackoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.resumeSubscriptions = true
+		c.resumeMaxBackoff = maxBackoff
+		if c.resumeMaxBackoff <= 0 {
+			c.resumeMaxBackoff = DefaultResubscribeMaxBackoff
+		}
+	}
+}
+
+// resume replaces the subscription after its connection dropped with cause
+// and delivers a resynced notification. It returns once live notifications
+// can be forwarded again, or when ctx ends first.
+func (s *Subscription) resume(ctx context.Context, batched bool, cause error) {
+	c := s.client
+	c.logger.Warn("Subscription connection dropped, resubscribing", map[string]interface{}{
+		"subscription": s.String(),
+		"error":        cause.Error(),
+	})
+
+	var sub *wsSubscription
+	var n Notification
+	backoff := DefaultResubscribeBackoff
+	for {
+		var err error
+		if sub == nil {
+			sub, err = s.resubscribe(ctx)
+		}
+		if err == nil {
+			if n, err = s.reconcile(ctx); err == nil {
+				break
+			}
+			if !sub.conn.alive() {
+				sub = nil
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		c.logger.Debug("Subscription not resumed, retrying", map[string]interface{}{
+			"subscription": s.String(),
+			"error":        err.Error(),
+			"backoff":      backoff.String(),
+		})
+		if sleepContext(ctx, backoff) != nil {
+			break
+		}
+		if backoff *= 2; backoff > c.resumeMaxBackoff {
+			backoff = c.resumeMaxBackoff
+		}
+	}
+
+	if sub != nil {
+		s.swap(sub)
+		// Unsubscribe may have reached the old subscription only.
+		if s.unsubscribed.Load() {
+			unsubCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultUnsubscribeTimeout)
+			sub.unsubscribe(unsubCtx)
+			cancel()
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.floor = n.Slot
+	s.observe(n.Slot)
+	s.resyncs.Add(1)
+	s.metrics.resyncs.Add(1)
+	c.logger.Info("Subscription resumed", map[string]interface{}{
+		"subscription": s.String(),
+		"slot":         n.Slot,
+	})
+
+	if batched {
+		select {
+		case s.batches <- []Notification{n}:
+		case <-ctx.Done():
+		}
+		return
+	}
+	select {
+	case s.notifications <- n:
+	case <-ctx.Done():
+	}
+}
+
+// resubscribe subscribes again, dialing a new connection if needed.
+func (s *Subscription) resubscribe(ctx context.Context) (*wsSubscription, error) {
+	ws, err := s.client.websocket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := ws.subscribe(ctx, s.method, s.unsubscribeMethod, s.client.subscribeParams(s.target))
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", s.method, s.target, err)
+	}
+	return sub, nil
+}
+
+// reconcile reads the account as a resynced notification, in the encoding
+// live notifications use, no older than the last slot seen.
+func (s *Subscription) reconcile(ctx context.Context) (Notification, error) {
+	options := callOptions{commitment: s.client.commitment(), encoding: EncodingBase64}
+	if last := s.LastSlot(); last > 0 {
+		options.minContextSlot = &last
+	}
+	var result contextResult
+	if err := s.client.call(ctx, "getAccountInfo", []interface{}{s.target, options.config(true)}, &result); err != nil {
+		return Notification{}, fmt.Errorf("resync %s: %w", s.target, err)
+	}
+	value := result.Value
+	if len(value) == 0 {
+		value = json.RawMessage("null")
+	}
+	return Notification{Slot: result.Context.Slot, Value: value, Resynced: true}, nil
+}
+
+// swap makes sub the subscription's server-side subscription, keeping the
+// dropped count of the one it replaces.
+func (s *Subscription) swap(sub *wsSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.droppedBefore += s.sub.dropped.Load()
+	s.sub = sub
+}
+
+// observe records slot as seen if it is the newest so far.
+func (s *Subscription) observe(slot uint64) {
+	for {
+		last := s.lastSlot.Load()
+		if slot <= last || s.lastSlot.CompareAndSwap(last, slot) {
+			return
+		}
+	}
+}
+
+// LastSlot returns the slot of the newest notification the subscription
+// has received, or zero before the first.
+func (s *Subscription) LastSlot() uint64 {
+	return s.lastSlot.Load()
+}
+
+// Resyncs returns how many times the subscription was resumed after a
+// dropped connection under WithResumableSubscriptions.
+func (s *Subscription) Resyncs() uint64 {
+	return s.resyncs.Load()
+}
@@ -0,0 +1,109 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestResumableSubscriptionResyncs(t *testing.T) {
+	notify := func(conn *websocket.Conn, id, slot uint64, lamports int) {
+		conn.WriteJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "accountNotification",
+			"params": map[string]interface{}{
+				"subscription": id,
+				"result": map[string]interface{}{
+					"context": map[string]interface{}{"slot": slot},
+					"value":   map[string]interface{}{"lamports": lamports},
+				},
+			},
+		})
+	}
+	var connections atomic.Int32
+	var minSlot atomic.Uint64
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getAccountInfo": func(params json.RawMessage) (interface{}, error) {
+			var p []struct {
+				MinContextSlot uint64 `json:"minContextSlot"`
+			}
+			json.Unmarshal(params, &p)
+			minSlot.Store(p[1].MinContextSlot)
+			return map[string]interface{}{
+				"context": map[string]interface{}{"slot": 10},
+				"value":   map[string]interface{}{"lamports": 8},
+			}, nil
+		},
+	})
+	rpc.pubsub = func(conn *websocket.Conn) {
+		n := connections.Add(1)
+		var req struct {
+			ID uint64 `json:"id"`
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": n})
+		if n == 1 {
+			// Drop the connection after one notification.
+			notify(conn, 1, 3, 5)
+			return
+		}
+		// A stale notification the resync supersedes, then a live one.
+		notify(conn, 2, 7, 6)
+		notify(conn, 2, 11, 9)
+		for conn.ReadJSON(&req) == nil {
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": true})
+		}
+	}
+	client := rpc.client(t)
+	WithResumableSubscriptions(0)(client)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := client.SubscribeToAccountChanges(ctx, wallet.PublicKey())
+	if err != nil {
+		t.Fatalf("SubscribeToAccountChanges: %v", err)
+	}
+
+	type delivery struct {
+		slot     uint64
+		lamports int
+		resynced bool
+	}
+	want := []delivery{{3, 5, false}, {10, 8, true}, {11, 9, false}}
+	for i, w := range want {
+		var n Notification
+		select {
+		case n = <-sub.C():
+		case <-ctx.Done():
+			t.Fatalf("notification %d not delivered; Err = %v", i, sub.Err())
+		}
+		var account struct {
+			Lamports int `json:"lamports"`
+		}
+		json.Unmarshal(n.Value, &account)
+		if got := (delivery{n.Slot, account.Lamports, n.Resynced}); got != w {
+			t.Fatalf("notification %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if minSlot.Load() != 3 {
+		t.Fatalf("resync minContextSlot = %d, want the last seen slot 3", minSlot.Load())
+	}
+	if sub.LastSlot() != 11 || sub.Resyncs() != 1 {
+		t.Fatalf("LastSlot = %d, Resyncs = %d", sub.LastSlot(), sub.Resyncs())
+	}
+	if n := client.GetMetrics()["subscription_resyncs"]; n != uint64(1) {
+		t.Fatalf("subscription_resyncs = %v, want 1", n)
+	}
+	if err := client.Unsubscribe(ctx, sub); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package solana
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+// RPCError is an error object returned by the JSON-RPC endpoint.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// rpcCodeMinContextSlotNotReached is the JSON-RPC error code for a node
+// behind a call's minContextSlot.
+const rpcCodeMinContextSlotNotReached = -32016
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("solana rpc error %d: %s", e.Code, e.Message)
+}
+
+// HTTPStatusError is returned when the RPC endpoint answers with a non-2xx
+// HTTP status instead of a JSON-RPC response.
+type HTTPStatusError struct {
+	Method     string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected HTTP status %d: %s", e.Method, e.StatusCode, e.Body)
+}
+
+// contextResult wraps results returned with an RPC response context.
+type contextResult struct {
+	Context struct {
+		Slot uint64 `json:"slot"`
+	} `json:"context"`
+	Value json.RawMessage `json:"value"`
+}
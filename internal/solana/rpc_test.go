@@ -0,0 +1,145 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// rpcHandler returns the result for a JSON-RPC call, or an *RPCError.
+type rpcHandler func(params json.RawMessage) (interface{}, error)
+
+// fakeRPC is an in-process JSON-RPC server that dispatches by method and
+// counts calls.
+type fakeRPC struct {
+	mu       sync.Mutex
+	handlers map[string]rpcHandler
+	calls    map[string]int
+	srv      *httptest.Server
+
+	// pubsub, when set, serves WebSocket upgrades on the same URL.
+	pubsub func(conn *websocket.Conn)
+}
+
+func newFakeRPC(t *testing.T, handlers map[string]rpcHandler) *fakeRPC {
+	t.Helper()
+	f := &fakeRPC{handlers: handlers, calls: make(map[string]int)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeRPC) serve(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		if f.pubsub == nil {
+			http.Error(w, "websocket not supported", http.StatusNotFound)
+			return
+		}
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		f.pubsub(conn)
+		return
+	}
+
+	var req struct {
+		ID     uint64          `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.calls[req.Method]++
+	handler, ok := f.handlers[req.Method]
+	f.mu.Unlock()
+
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if !ok {
+		resp["error"] = &RPCError{Code: -32601, Message: "Method not found"}
+	} else if result, err := handler(req.Params); err != nil {
+		resp["error"] = err
+	} else {
+		resp["result"] = result
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeRPC) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func (f *fakeRPC) client(t *testing.T) *Client {
+	t.Helper()
+	client, err := NewClient(&utils.SolanaConfig{Endpoint: f.srv.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func (f *fakeRPC) wsURL() string {
+	return "ws" + strings.TrimPrefix(f.srv.URL, "http")
+}
+
+func withContext(value interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"context": map[string]interface{}{"slot": 1},
+		"value":   value,
+	}
+}
+
+func TestCall(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getGenesisHash": func(json.RawMessage) (interface{}, error) {
+			return "genesis", nil
+		},
+	})
+	client := rpc.client(t)
+
+	var hash string
+	if err := client.Call(context.Background(), "getGenesisHash", nil, &hash); err != nil || hash != "genesis" {
+		t.Fatalf("Call = %q, %v", hash, err)
+	}
+	var rpcErr *RPCError
+	if err := client.Call(context.Background(), "notAMethod", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf("Call(unknown) = %v, want RPC method-not-found error", err)
+	}
+	if client.GetMetrics()["requests_total"] != uint64(2) {
+		t.Fatal("Call bypassed client metrics")
+	}
+}
+
+func TestErrorsCarryOperationContext(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getBalance": func(json.RawMessage) (interface{}, error) {
+			return nil, &RPCError{Code: -32005, Message: "Node is behind"}
+		},
+	})
+	wallet, _ := NewWallet()
+
+	_, err := rpc.client(t).GetBalance(context.Background(), wallet.PublicKey())
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32005 {
+		t.Fatalf("GetBalance = %v, want the RPC error", err)
+	}
+	want := "get balance of " + wallet.PublicKey() + ": getBalance: "
+	if !strings.HasPrefix(err.Error(), want) {
+		t.Fatalf("error = %q, want prefix %q", err, want)
+	}
+}
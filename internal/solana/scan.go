@@ -0,0 +1,112 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+)
+
+// ScanCursor records how far ScanProgramAccounts got, so an interrupted
+// scan can resume. It marshals to JSON for storing between runs.
+type ScanCursor struct {
+	// Partitions is the number of partitions the scan is split into.
+	Partitions int `json:"partitions"`
+	// Partition is the partition being scanned.
+	Partition int `json:"partition"`
+	// After is the address of the last account delivered from Partition,
+	// or empty if none has been.
+	After string `json:"after,omitempty"`
+}
+
+// Done reports whether the scan has covered every partition.
+func (c ScanCursor) Done() bool {
+	return c.Partitions > 0 && c.Partition >= c.Partitions
+}
+
+// ProgramScanConfig configures ScanProgramAccounts.
+type ProgramScanConfig struct {
+	// Filters apply to every page, as with GetProgramAccounts.
+	Filters []AccountFilter
+	// PartitionOffset is where in the account data the bytes the scan is
+	// partitioned on start. Pick a field that is spread evenly, such as a
+	// public key stored in the account.
+	PartitionOffset int
+	// PartitionBytes is how many bytes at PartitionOffset select a
+	// partition: 1 splits the scan into 256 calls, 2 into 65,536. Zero
+	// means 1.
+	PartitionBytes int
+	// Cursor resumes an earlier scan. Zero starts from the beginning.
+	Cursor ScanCursor
+}
+
+// ScanProgramAccounts delivers the accounts owned by program to fn without
+// fetching them in a single call. The accounts are split into partitions
+// by the value of the config.PartitionBytes bytes at
+// config.PartitionOffset of their data, each fetched with its own
+// getProgramAccounts call filtered by memcmp, so only one partition is
+// held in memory and each call stays small enough not to time out.
+// Accounts whose data is too short to hold the partition bytes match no
+// partition and are never delivered; add FilterDataSize to make that
+// explicit. opts apply to every call, as with GetProgramAccounts.
+//
+// Within a partition accounts are delivered in address order. fn receives
+// each account with the cursor to store once it is processed; passing
+// that cursor in config.Cursor resumes the scan after the account, even
+// if the partition has changed since. Returning an error from fn stops the
+// scan. The returned cursor is where the scan stopped, Done once every
+// partition was delivered; on error, resume from it.
+func (c *Client) ScanProgramAccounts(ctx context.Context, program string, config ProgramScanConfig, fn func(KeyedAccount, ScanCursor) error, opts ...CallOption) (ScanCursor, error) {
+	width := config.PartitionBytes
+	if width == 0 {
+		width = 1
+	}
+	if width != 1 && width != 2 {
+		return config.Cursor, fmt.Errorf("scan %s: partition bytes %d, want 1 or 2", program, width)
+	}
+	partitions := 1 << (8 * width)
+	cursor := config.Cursor
+	if cursor.Partitions == 0 {
+		cursor = ScanCursor{Partitions: partitions}
+	}
+	if cursor.Partitions != partitions || cursor.Partition < 0 {
+		return cursor, fmt.Errorf("scan %s: cursor for %d partitions does not fit a scan of %d", program, cursor.Partitions, partitions)
+	}
+	var after *PublicKey
+	if cursor.After != "" {
+		key, err := PublicKeyFromBase58(cursor.After)
+		if err != nil {
+			return cursor, fmt.Errorf("scan %s: cursor: %w", program, err)
+		}
+		after = &key
+	}
+
+	for ; cursor.Partition < partitions; cursor.Partition, cursor.After, after = cursor.Partition+1, "", nil {
+		if err := ctx.Err(); err != nil {
+			return cursor, err
+		}
+		prefix := []byte{byte(cursor.Partition)}
+		if width == 2 {
+			prefix = []byte{byte(cursor.Partition >> 8), byte(cursor.Partition)}
+		}
+		filters := append(config.Filters[:len(config.Filters):len(config.Filters)], FilterMemcmp(config.PartitionOffset, prefix))
+		accounts, err := c.GetProgramAccounts(ctx, program, filters, opts...)
+		if err != nil {
+			return cursor, fmt.Errorf("scan partition %d of %d: %w", cursor.Partition, partitions, err)
+		}
+		sort.Slice(accounts, func(i, j int) bool {
+			return bytes.Compare(accounts[i].Address[:], accounts[j].Address[:]) < 0
+		})
+		for _, account := range accounts {
+			if after != nil && bytes.Compare(account.Address[:], after[:]) <= 0 {
+				continue
+			}
+			next := ScanCursor{Partitions: partitions, Partition: cursor.Partition, After: account.Address.String()}
+			if err := fn(account, next); err != nil {
+				return cursor, err
+			}
+			cursor = next
+		}
+	}
+	return cursor, nil
+}
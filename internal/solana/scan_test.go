@@ -0,0 +1,111 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mr-tron/base58"
+)
+
+func TestScanProgramAccounts(t *testing.T) {
+	program, _ := NewWallet()
+	type stored struct {
+		address string
+		data    []byte
+	}
+	var accounts []stored
+	for i := 0; i < 30; i++ {
+		w, _ := NewWallet()
+		key := w.Key()
+		// The partition byte is at offset 8, after a fixed discriminator.
+		data := append([]byte("ACCOUNT\x00"), key[:]...)
+		accounts = append(accounts, stored{address: w.PublicKey(), data: data})
+	}
+	accounts = append(accounts, stored{address: program.PublicKey(), data: []byte("short")})
+
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getProgramAccounts": func(params json.RawMessage) (interface{}, error) {
+			var p []json.RawMessage
+			json.Unmarshal(params, &p)
+			var config struct {
+				Filters []struct {
+					DataSize *int `json:"dataSize"`
+					Memcmp   *struct {
+						Offset int    `json:"offset"`
+						Bytes  string `json:"bytes"`
+					} `json:"memcmp"`
+				} `json:"filters"`
+			}
+			json.Unmarshal(p[1], &config)
+			var out []interface{}
+			for _, a := range accounts {
+				match := true
+				for _, f := range config.Filters {
+					if f.DataSize != nil && len(a.data) != *f.DataSize {
+						match = false
+					}
+					if m := f.Memcmp; m != nil {
+						want, _ := base58.Decode(m.Bytes)
+						if len(a.data) < m.Offset+len(want) || !bytes.Equal(a.data[m.Offset:m.Offset+len(want)], want) {
+							match = false
+						}
+					}
+				}
+				if match {
+					out = append(out, map[string]interface{}{
+						"pubkey": a.address,
+						"account": map[string]interface{}{
+							"lamports": 1,
+							"owner":    program.PublicKey(),
+							"data":     []string{base64.StdEncoding.EncodeToString(a.data), "base64"},
+						},
+					})
+				}
+			}
+			return out, nil
+		},
+	})
+	client := rpc.client(t)
+
+	config := ProgramScanConfig{Filters: []AccountFilter{FilterDataSize(40)}, PartitionOffset: 8}
+	seen := make(map[string]int)
+	stop := errors.New("interrupted")
+	cursor, err := client.ScanProgramAccounts(context.Background(), program.PublicKey(), config, func(a KeyedAccount, _ ScanCursor) error {
+		if len(seen) == 12 {
+			return stop
+		}
+		seen[a.Address.String()]++
+		return nil
+	})
+	if !errors.Is(err, stop) || cursor.Done() {
+		t.Fatalf("interrupted scan = %+v, %v", cursor, err)
+	}
+
+	// Resume from a cursor that went through JSON, as a job would store it.
+	data, _ := json.Marshal(cursor)
+	json.Unmarshal(data, &config.Cursor)
+	cursor, err = client.ScanProgramAccounts(context.Background(), program.PublicKey(), config, func(a KeyedAccount, _ ScanCursor) error {
+		seen[a.Address.String()]++
+		return nil
+	})
+	if err != nil || !cursor.Done() {
+		t.Fatalf("resumed scan = %+v, %v", cursor, err)
+	}
+	if len(seen) != 30 {
+		t.Fatalf("delivered %d accounts, want 30", len(seen))
+	}
+	for address, n := range seen {
+		if n != 1 {
+			t.Fatalf("%s delivered %d times", address, n)
+		}
+	}
+
+	config.Cursor = ScanCursor{Partitions: 65536}
+	if _, err := client.ScanProgramAccounts(context.Background(), program.PublicKey(), config, func(KeyedAccount, ScanCursor) error { return nil }); err == nil {
+		t.Fatal("scan accepted a cursor of another partitioning")
+	}
+}
@@ -0,0 +1,466 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// DefaultConfirmInterval is the polling interval used while waiting for a
+// transaction to confirm.
+const DefaultConfirmInterval = 2 * time.Second
+
+// Commitment levels in increasing order of finality.
+const (
+	CommitmentProcessed = "processed"
+	CommitmentConfirmed = "confirmed"
+	CommitmentFinalized = "finalized"
+)
+
+var commitmentRank = map[string]int{
+	CommitmentProcessed: 1,
+	CommitmentConfirmed: 2,
+	CommitmentFinalized: 3,
+}
+
+// SentTransaction is a signed transaction the client has broadcast and may
+// safely re-broadcast until LastValidBlockHeight passes.
+type SentTransaction struct {
+	Signature            string
+	LastValidBlockHeight uint64
+	// Fee is the total fee in lamports computed for the transaction when
+	// it was sent with WithFeeFloor, and zero otherwise.
+	Fee    uint64
+	SentAt time.Time
+
+	raw     string
+	options broadcastOptions
+}
+
+// SendOption configures SendTransaction.
+type SendOption func(*sendOptions)
+
+type sendOptions struct {
+	retryUntilConfirmed bool
+	retryInterval       time.Duration
+	commitment          string
+	feeFloor            *FeeFloor
+	broadcast           broadcastOptions
+}
+
+// broadcastOptions are the sendTransaction settings a transaction is
+// broadcast, and re-broadcast, with.
+type broadcastOptions struct {
+	skipPreflight       bool
+	preflightCommitment string
+	maxRetries          *uint
+}
+
+// RetryUntilConfirmed makes SendTransaction re-broadcast the same signed
+// transaction every interval until it reaches the client's commitment or its
+// blockhash expires. A non-positive interval uses DefaultConfirmInterval.
+// Each resend draws from the client's retry budget, if any, and
+// SendTransaction fails with utils.ErrRetryBudgetExhausted once it runs out.
+func RetryUntilConfirmed(interval time.Duration) SendOption {
+	return func(o *sendOptions) {
+		o.retryUntilConfirmed = true
+		if interval > 0 {
+			o.retryInterval = interval
+		}
+	}
+}
+
+// SkipPreflight makes the node broadcast the transaction without first
+// simulating it, saving a simulation round trip for senders that already
+// simulated it or are confident it succeeds. The risk is that a transaction
+// that would fail, e.g. for lack of funds or a stale blockhash, is no
+// longer rejected up front: the send succeeds and the failure only shows
+// when confirming, possibly after the fee was charged on chain. Preflight
+// is on by default.
+func SkipPreflight() SendOption {
+	return func(o *sendOptions) {
+		o.broadcast.skipPreflight = true
+	}
+}
+
+// WithPreflightCommitment sets the commitment of the state preflight
+// simulates against, instead of the client's. "processed" sees the latest
+// state, including transactions sent just before, at the risk of
+// simulating on a fork that is later dropped.
+func WithPreflightCommitment(commitment string) SendOption {
+	return func(o *sendOptions) {
+		o.broadcast.preflightCommitment = commitment
+	}
+}
+
+// WithMaxRetries sets how many times the RPC node itself retries
+// forwarding the transaction to the leader. Without it the node retries
+// until the blockhash expires. Zero leaves re-broadcasting to the caller,
+// e.g. with RetryUntilConfirmed, which gives it control over pacing when
+// the network is congested.
+func WithMaxRetries(n uint) SendOption {
+	return func(o *sendOptions) {
+		o.broadcast.maxRetries = &n
+	}
+}
+
+// validate rejects an unknown preflight commitment before anything is
+// signed or sent.
+func (o broadcastOptions) validate() error {
+	if o.preflightCommitment == "" {
+		return nil
+	}
+	if _, ok := commitmentRank[o.preflightCommitment]; !ok {
+		return fmt.Errorf("unknown preflight commitment %q", o.preflightCommitment)
+	}
+	return nil
+}
+
+// SignatureStatus is the processing status of a transaction.
+type SignatureStatus struct {
+	Slot               uint64          `json:"slot"`
+	Confirmations      *uint64         `json:"confirmations"`
+	Err                json.RawMessage `json:"err"`
+	ConfirmationStatus string          `json:"confirmationStatus"`
+}
+
+func (s *SignatureStatus) failed() bool {
+	return len(s.Err) > 0 && string(s.Err) != "null"
+}
+
+type latestBlockhash struct {
+	Blockhash            string `json:"blockhash"`
+	LastValidBlockHeight uint64 `json:"lastValidBlockHeight"`
+}
+
+// SendTransaction transfers lamports from a registered wallet to to and
+// returns the transaction signature. The signed transaction is tracked so
+// ResendTransaction can re-broadcast it without risking a duplicate transfer;
+// with WithFeeFloor its Fee records the fee paid. Resends use the same
+// SkipPreflight, WithPreflightCommitment, and WithMaxRetries settings. The
+// call is reported to the audit hook, if any.
+func (c *Client) SendTransaction(ctx context.Context, from, to string, lamports uint64, opts ...SendOption) (signature string, err error) {
+	defer c.audit(ctx, AuditEvent{Operation: AuditSendTransaction, From: from, To: to, Amount: lamports}, &signature, &err)
+	defer wrapOp(&err, "send %d lamports from %s to %s", lamports, from, to)
+	options := sendOptions{retryInterval: DefaultConfirmInterval, commitment: c.commitment()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := options.broadcast.validate(); err != nil {
+		return "", err
+	}
+
+	signer, fromKey, err := c.signer(from)
+	if err != nil {
+		return "", err
+	}
+	toKey, err := PublicKeyFromBase58(to)
+	if err != nil {
+		return "", err
+	}
+
+	builder := NewTransactionBuilder().SetFeePayer(fromKey).AddSigner(signer)
+	instructions := []Instruction{TransferInstruction(fromKey, toKey, lamports)}
+	if options.feeFloor != nil {
+		if instructions, err = c.applyFeeFloor(ctx, builder, instructions, *options.feeFloor); err != nil {
+			return "", err
+		}
+	}
+	signature, err = builder.AddInstruction(instructions...).send(ctx, c, options.broadcast)
+	if err != nil {
+		return signature, err
+	}
+	if options.retryUntilConfirmed {
+		return signature, c.retryUntilConfirmed(ctx, signature, options)
+	}
+	return signature, nil
+}
+
+// SendRawTransaction broadcasts a transaction signed elsewhere, given base64
+// encoded as from Transaction.Serialize. It is decoded and its signatures
+// verified first, so a malformed or incompletely signed transaction fails
+// locally with ErrInvalidTransaction, ErrMissingSignature, or
+// ErrInvalidSignature. It is then sent like SendTransaction's: with
+// preflight checks unless SkipPreflight is given, tracked for
+// ResendTransaction, and retried under RetryUntilConfirmed. The blockhash
+// age is unknown, so the tracked LastValidBlockHeight is the latest it could
+// be. WithFeeFloor cannot apply to a signed transaction and is rejected with
+// ErrUnsupportedOption. The call is reported to the audit hook, if any, with
+// the fee payer as From.
+func (c *Client) SendRawTransaction(ctx context.Context, serialized string, opts ...SendOption) (signature string, err error) {
+	event := AuditEvent{Operation: AuditSendRawTransaction}
+	defer func() { c.audit(ctx, event, &signature, &err) }()
+	defer wrapOp(&err, "send raw transaction")
+	options := sendOptions{retryInterval: DefaultConfirmInterval, commitment: c.commitment()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.feeFloor != nil {
+		return "", fmt.Errorf("%w: a fee floor cannot change a signed transaction", ErrUnsupportedOption)
+	}
+	if err := options.broadcast.validate(); err != nil {
+		return "", err
+	}
+
+	tx, err := DeserializeTransaction(serialized)
+	if err != nil {
+		return "", err
+	}
+	event.From = tx.Message.AccountKeys[0].String()
+	if err := tx.Verify(); err != nil {
+		return "", err
+	}
+
+	height, err := c.GetBlockHeight(ctx)
+	if err != nil {
+		return "", err
+	}
+	signature, err = c.sendSigned(ctx, tx, height+maxBlockhashAge, 0, options.broadcast)
+	if err != nil {
+		return signature, err
+	}
+	if options.retryUntilConfirmed {
+		return signature, c.retryUntilConfirmed(ctx, signature, options)
+	}
+	return signature, nil
+}
+
+// sendInstructions builds a transaction paid for by feePayer, signs it with
+// feePayer and signers, tracks it for ResendTransaction, and broadcasts it.
+func (c *Client) sendInstructions(ctx context.Context, feePayer Signer, instructions []Instruction, signers ...Signer) (string, error) {
+	feePayerKey, err := signerKey(feePayer)
+	if err != nil {
+		return "", err
+	}
+	return NewTransactionBuilder().
+		SetFeePayer(feePayerKey).
+		AddInstruction(instructions...).
+		AddSigner(feePayer).
+		AddSigner(signers...).
+		Send(ctx, c)
+}
+
+// ResendTransaction re-broadcasts the exact signed transaction previously sent
+// with signature. Because the signature is unchanged the network processes it
+// at most once. It returns ErrBlockhashExpired once the transaction can no
+// longer land.
+func (c *Client) ResendTransaction(ctx context.Context, signature string) error {
+	sent, ok := c.TrackedTransaction(signature)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTransactionNotTracked, signature)
+	}
+
+	height, err := c.GetBlockHeight(ctx)
+	if err != nil {
+		return err
+	}
+	if height > sent.LastValidBlockHeight {
+		c.untrackTransaction(signature)
+		return fmt.Errorf("%w: %s at block height %d, last valid %d", ErrBlockhashExpired, signature, height, sent.LastValidBlockHeight)
+	}
+	return c.broadcast(ctx, sent)
+}
+
+// TrackedTransaction returns the tracked send for signature, if any.
+func (c *Client) TrackedTransaction(signature string) (*SentTransaction, bool) {
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+	sent, ok := c.sent[signature]
+	return sent, ok
+}
+
+func (c *Client) trackTransaction(sent *SentTransaction) {
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+	c.sent[sent.Signature] = sent
+}
+
+func (c *Client) untrackTransaction(signature string) {
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+	delete(c.sent, signature)
+}
+
+func (c *Client) retryUntilConfirmed(ctx context.Context, signature string, options sendOptions) error {
+	ticker := time.NewTicker(options.retryInterval)
+	defer ticker.Stop()
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		status, err := c.getSignatureStatus(ctx, signature)
+		if err != nil {
+			return err
+		}
+		if status != nil {
+			if status.failed() {
+				c.untrackTransaction(signature)
+				return &TransactionError{Signature: signature, Err: status.Err}
+			}
+			if commitmentReached(status.ConfirmationStatus, options.commitment) {
+				c.noteWriteSlot(status.Slot)
+				c.untrackTransaction(signature)
+				return nil
+			}
+			continue
+		}
+
+		if err := c.retries.Acquire(); err != nil {
+			return fmt.Errorf("resend %s: %w", signature, utils.WrapAttempts(err, attempt))
+		}
+		if err := c.ResendTransaction(ctx, signature); err != nil {
+			return fmt.Errorf("resend %s: %w", signature, utils.WrapAttempts(err, attempt))
+		}
+	}
+}
+
+func (c *Client) broadcast(ctx context.Context, sent *SentTransaction) error {
+	config := map[string]interface{}{
+		"encoding":            "base64",
+		"preflightCommitment": c.commitment(),
+	}
+	if sent.options.preflightCommitment != "" {
+		config["preflightCommitment"] = sent.options.preflightCommitment
+	}
+	if sent.options.skipPreflight {
+		config["skipPreflight"] = true
+	}
+	if sent.options.maxRetries != nil {
+		config["maxRetries"] = *sent.options.maxRetries
+	}
+	return c.call(ctx, "sendTransaction", []interface{}{sent.raw, config}, nil)
+}
+
+func (c *Client) getLatestBlockhash(ctx context.Context) (*latestBlockhash, error) {
+	var result contextResult
+	params := []interface{}{map[string]interface{}{"commitment": c.commitment()}}
+	if err := c.call(ctx, "getLatestBlockhash", params, &result); err != nil {
+		return nil, err
+	}
+	var blockhash latestBlockhash
+	if err := json.Unmarshal(result.Value, &blockhash); err != nil {
+		return nil, fmt.Errorf("decode blockhash: %w", err)
+	}
+	return &blockhash, nil
+}
+
+// GetBlockHeight returns the current block height. It accepts
+// WithCommitment and WithMinContextSlot.
+func (c *Client) GetBlockHeight(ctx context.Context, opts ...CallOption) (uint64, error) {
+	o, err := c.callOptions(opts, optMinContextSlot)
+	if err != nil {
+		return 0, err
+	}
+	var height uint64
+	params := []interface{}{o.config(false)}
+	if err := c.call(ctx, "getBlockHeight", params, &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// getSignatureStatus returns the status of signature, or nil if the node has
+// not seen it.
+func (c *Client) getSignatureStatus(ctx context.Context, signature string) (*SignatureStatus, error) {
+	var result contextResult
+	params := []interface{}{
+		[]string{signature},
+		map[string]interface{}{"searchTransactionHistory": true},
+	}
+	if err := c.call(ctx, "getSignatureStatuses", params, &result); err != nil {
+		return nil, err
+	}
+	var statuses []*SignatureStatus
+	if err := json.Unmarshal(result.Value, &statuses); err != nil {
+		return nil, fmt.Errorf("decode signature statuses: %w", err)
+	}
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	return statuses[0], nil
+}
+
+// GetTransactionStatus returns the confirmation status of signature, or
+// "unknown" if the node has not seen it.
+func (c *Client) GetTransactionStatus(ctx context.Context, signature string) (string, error) {
+	status, err := c.getSignatureStatus(ctx, signature)
+	if err != nil {
+		return "", fmt.Errorf("get status of %s: %w", signature, err)
+	}
+	if status == nil {
+		return "unknown", nil
+	}
+	if status.failed() {
+		return "failed", nil
+	}
+	return status.ConfirmationStatus, nil
+}
+
+// ConfirmTransaction waits until signature reaches commitment or ctx is done.
+func (c *Client) ConfirmTransaction(ctx context.Context, signature, commitment string) error {
+	if commitment == "" {
+		commitment = c.commitment()
+	}
+	if _, ok := commitmentRank[commitment]; !ok {
+		return fmt.Errorf("unknown commitment %q", commitment)
+	}
+
+	for attempt := 1; ; attempt++ {
+		status, err := c.getSignatureStatus(ctx, signature)
+		if err != nil {
+			return fmt.Errorf("confirm %s: %w", signature, utils.WrapAttempts(err, attempt))
+		}
+		if status != nil {
+			if status.failed() {
+				return &TransactionError{Signature: signature, Err: status.Err}
+			}
+			if commitmentReached(status.ConfirmationStatus, commitment) {
+				c.noteWriteSlot(status.Slot)
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(DefaultConfirmInterval):
+		}
+	}
+}
+
+// LastWriteSlot returns the highest slot at which a transaction confirmed
+// through the client landed, or zero before the first confirmation. Pass
+// it to WithMinContextSlot so a read served by any endpoint reflects those
+// writes:
+//
+//	balance, err := client.GetBalance(ctx, address, WithMinContextSlot(client.LastWriteSlot()))
+//
+// A lagging endpoint answers such a read with an error and the next one is
+// tried; if none has caught up the call fails with
+// ErrMinContextSlotNotReached and can be retried.
+func (c *Client) LastWriteSlot() uint64 {
+	return c.writeSlot.Load()
+}
+
+// noteWriteSlot raises the last write slot to slot.
+func (c *Client) noteWriteSlot(slot uint64) {
+	for {
+		current := c.writeSlot.Load()
+		if slot <= current || c.writeSlot.CompareAndSwap(current, slot) {
+			return
+		}
+	}
+}
+
+// commitmentReached reports whether status is at least as final as target.
+func commitmentReached(status, target string) bool {
+	got, ok := commitmentRank[status]
+	return ok && got >= commitmentRank[target]
+}
@@ -0,0 +1,172 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestRetryUntilConfirmedResendsSameTransaction(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads []string
+	)
+	statusCalls := 0
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getLatestBlockhash": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"blockhash":            SystemProgramID.String(),
+				"lastValidBlockHeight": 100,
+			}), nil
+		},
+		"sendTransaction": func(params json.RawMessage) (interface{}, error) {
+			var p []interface{}
+			json.Unmarshal(params, &p)
+			mu.Lock()
+			payloads = append(payloads, p[0].(string))
+			mu.Unlock()
+			return "sig", nil
+		},
+		"getBlockHeight": func(json.RawMessage) (interface{}, error) {
+			return 50, nil
+		},
+		"getSignatureStatuses": func(json.RawMessage) (interface{}, error) {
+			statusCalls++
+			if statusCalls < 3 {
+				return withContext([]interface{}{nil}), nil
+			}
+			return withContext([]interface{}{map[string]interface{}{
+				"slot": 10, "confirmationStatus": "confirmed",
+			}}), nil
+		},
+	})
+	client := rpc.client(t)
+	from, _ := client.CreateWallet()
+	to, _ := NewWallet()
+
+	ctx, usage := utils.WithUsageRecorder(context.Background())
+	sig, err := client.SendTransaction(ctx, from.PublicKey(), to.PublicKey(), 1000,
+		RetryUntilConfirmed(time.Millisecond))
+	if err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	if sig == "" {
+		t.Fatal("empty signature")
+	}
+	// Resends are the same transaction, so the fee is counted once.
+	if fee := usage.Usage().FeeLamports; fee != DefaultLamportsPerSignature {
+		t.Fatalf("recorded fee = %d, want %d", fee, DefaultLamportsPerSignature)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 3 {
+		t.Fatalf("broadcasts = %d, want 3", len(payloads))
+	}
+	for _, p := range payloads[1:] {
+		if p != payloads[0] {
+			t.Fatal("resend changed the signed transaction")
+		}
+	}
+	if _, ok := client.TrackedTransaction(sig); ok {
+		t.Fatal("confirmed transaction still tracked")
+	}
+}
+
+func TestResendTransactionExpired(t *testing.T) {
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getLatestBlockhash": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"blockhash":            SystemProgramID.String(),
+				"lastValidBlockHeight": 100,
+			}), nil
+		},
+		"sendTransaction": func(json.RawMessage) (interface{}, error) {
+			return "sig", nil
+		},
+		"getBlockHeight": func(json.RawMessage) (interface{}, error) {
+			return 101, nil
+		},
+	})
+	client := rpc.client(t)
+	from, _ := client.CreateWallet()
+	to, _ := NewWallet()
+
+	sig, err := client.SendTransaction(context.Background(), from.PublicKey(), to.PublicKey(), 1)
+	if err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	if err := client.ResendTransaction(context.Background(), sig); !errors.Is(err, ErrBlockhashExpired) {
+		t.Fatalf("ResendTransaction = %v, want ErrBlockhashExpired", err)
+	}
+	if err := client.ResendTransaction(context.Background(), sig); !errors.Is(err, ErrTransactionNotTracked) {
+		t.Fatalf("ResendTransaction after expiry = %v, want ErrTransactionNotTracked", err)
+	}
+}
+
+func TestSendTransactionPreflightOptions(t *testing.T) {
+	var configs []map[string]interface{}
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getLatestBlockhash": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"blockhash":            SystemProgramID.String(),
+				"lastValidBlockHeight": 100,
+			}), nil
+		},
+		"sendTransaction": func(params json.RawMessage) (interface{}, error) {
+			var p []json.RawMessage
+			json.Unmarshal(params, &p)
+			var config map[string]interface{}
+			json.Unmarshal(p[1], &config)
+			configs = append(configs, config)
+			return "sig", nil
+		},
+		"getBlockHeight": func(json.RawMessage) (interface{}, error) {
+			return 50, nil
+		},
+	})
+	client := rpc.client(t)
+	from, _ := client.CreateWallet()
+	to, _ := NewWallet()
+
+	if _, err := client.SendTransaction(context.Background(), from.PublicKey(), to.PublicKey(), 1000); err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	sig, err := client.SendTransaction(context.Background(), from.PublicKey(), to.PublicKey(), 2000,
+		SkipPreflight(), WithPreflightCommitment(CommitmentProcessed), WithMaxRetries(0))
+	if err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	// A resend keeps the options of the original send.
+	if err := client.ResendTransaction(context.Background(), sig); err != nil {
+		t.Fatalf("ResendTransaction: %v", err)
+	}
+
+	if len(configs) != 3 {
+		t.Fatalf("broadcasts = %d, want 3", len(configs))
+	}
+	if _, ok := configs[0]["skipPreflight"]; ok || configs[0]["preflightCommitment"] != CommitmentConfirmed {
+		t.Fatalf("default config = %v, want preflight at the client commitment", configs[0])
+	}
+	if _, ok := configs[0]["maxRetries"]; ok {
+		t.Fatalf("default config = %v, want node default maxRetries", configs[0])
+	}
+	for _, config := range configs[1:] {
+		if config["skipPreflight"] != true || config["preflightCommitment"] != CommitmentProcessed || config["maxRetries"] != float64(0) {
+			t.Fatalf("config = %v", config)
+		}
+	}
+
+	if _, err := client.SendTransaction(context.Background(), from.PublicKey(), to.PublicKey(), 1000,
+		WithPreflightCommitment("soon")); err == nil {
+		t.Fatal("SendTransaction accepted an unknown preflight commitment")
+	}
+	if len(configs) != 3 {
+		t.Fatal("invalid preflight commitment was broadcast")
+	}
+}
@@ -0,0 +1,69 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// Signer signs transactions for one account. Wallet holds its key in
+// memory; other implementations can forward Sign to an HSM or a key
+// management service so the private key never enters the process.
+type Signer interface {
+	// PublicKey returns the base58 encoded public key of the account.
+	PublicKey() string
+	// Sign returns the 64-byte Ed25519 signature of message.
+	Sign(message []byte) ([]byte, error)
+}
+
+// signerKey returns the public key of signer.
+func signerKey(signer Signer) (PublicKey, error) {
+	if w, ok := signer.(*Wallet); ok {
+		return w.Key(), nil
+	}
+	key, err := PublicKeyFromBase58(signer.PublicKey())
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("signer: %w", err)
+	}
+	return key, nil
+}
+
+// signWith signs message with signer as key and checks the signature, so a
+// misbehaving remote signer fails here rather than at the node.
+func signWith(signer Signer, key PublicKey, message []byte) ([]byte, error) {
+	signature, err := signer.Sign(message)
+	if err != nil {
+		return nil, fmt.Errorf("sign as %s: %w", key, err)
+	}
+	if len(signature) != ed25519.SignatureSize || !ed25519.Verify(key[:], message, signature) {
+		return nil, fmt.Errorf("%w: signer for %s returned a signature that does not verify", ErrInvalidSignature, key)
+	}
+	return signature, nil
+}
+
+// AddSigner registers signer with the client so it can sign transactions
+// sent from its address, as AddWallet does for a wallet.
+func (c *Client) AddSigner(signer Signer) error {
+	key, err := signerKey(signer)
+	if err != nil {
+		return err
+	}
+	c.walletsMu.Lock()
+	defer c.walletsMu.Unlock()
+	c.wallets[key.String()] = signer
+	return nil
+}
+
+// signer returns the registered signer for address and its public key.
+func (c *Client) signer(address string) (Signer, PublicKey, error) {
+	c.walletsMu.RLock()
+	signer, ok := c.wallets[address]
+	c.walletsMu.RUnlock()
+	if !ok {
+		return nil, PublicKey{}, fmt.Errorf("%w: %s", ErrWalletNotFound, address)
+	}
+	key, err := signerKey(signer)
+	if err != nil {
+		return nil, PublicKey{}, err
+	}
+	return signer, key, nil
+}
@@ -0,0 +1,91 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// remoteSigner signs through a wallet it does not expose, as an HSM would.
+type remoteSigner struct {
+	wallet  *Wallet
+	corrupt bool
+	err     error
+	calls   int
+}
+
+func (s *remoteSigner) PublicKey() string { return s.wallet.PublicKey() }
+
+func (s *remoteSigner) Sign(message []byte) ([]byte, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	signature, _ := s.wallet.Sign(message)
+	if s.corrupt {
+		signature[0] ^= 1
+	}
+	return signature, nil
+}
+
+func TestSendTransactionWithRemoteSigner(t *testing.T) {
+	wallet, _ := NewWallet()
+	recipient, _ := NewWallet()
+	signer := &remoteSigner{wallet: wallet}
+
+	var sent string
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getLatestBlockhash": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"blockhash":            SystemProgramID.String(),
+				"lastValidBlockHeight": 100,
+			}), nil
+		},
+		"sendTransaction": func(params json.RawMessage) (interface{}, error) {
+			var p []string
+			json.Unmarshal(params, &p)
+			sent = p[0]
+			return "sig", nil
+		},
+	})
+	client := rpc.client(t)
+	if err := client.AddSigner(signer); err != nil {
+		t.Fatalf("AddSigner: %v", err)
+	}
+
+	if _, err := client.SendTransaction(context.Background(), wallet.PublicKey(), recipient.PublicKey(), 1000); err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	if signer.calls != 1 {
+		t.Fatalf("remote signer called %d times, want 1", signer.calls)
+	}
+	tx, err := DeserializeTransaction(sent)
+	if err != nil {
+		t.Fatalf("DeserializeTransaction: %v", err)
+	}
+	if err := tx.Verify(); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	signer.corrupt = true
+	if _, err := client.SendTransaction(context.Background(), wallet.PublicKey(), recipient.PublicKey(), 1000); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("SendTransaction with a bad signature = %v, want ErrInvalidSignature", err)
+	}
+
+	failure := errors.New("hsm unavailable")
+	signer.corrupt, signer.err = false, failure
+	if _, err := client.SendTransaction(context.Background(), wallet.PublicKey(), recipient.PublicKey(), 1000); !errors.Is(err, failure) {
+		t.Fatalf("SendTransaction with a failing signer = %v, want %v", err, failure)
+	}
+	if n := rpc.count("sendTransaction"); n != 1 {
+		t.Fatalf("sendTransaction called %d times, want 1", n)
+	}
+
+	if err := client.SetPayerSigner(signer); err != nil {
+		t.Fatalf("SetPayerSigner: %v", err)
+	}
+	if payer, _, err := client.payerSigner(); err != nil || payer != Signer(signer) {
+		t.Fatalf("payer = %v, %v; want the remote signer", payer, err)
+	}
+}
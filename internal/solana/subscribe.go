@@ -0,0 +1,370 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultUnsubscribeTimeout bounds the server-side unsubscribe sent when a
+// subscription's context ends.
+const DefaultUnsubscribeTimeout = 5 * time.Second
+
+// DefaultBatchSubscribeConcurrency bounds the subscriptions SubscribeToAccounts
+// has in flight at once.
+const DefaultBatchSubscribeConcurrency = 16
+
+// Notification is one update delivered by a subscription. Value is the
+// notification's value in the node's JSON encoding: an account for
+// SubscribeToAccountChanges, a {pubkey, account} object for
+// SubscribeToProgram. Account data is base64 encoded. Resynced marks the
+// account state read after a subscription was resumed under
+// WithResumableSubscriptions rather than a change pushed by the node.
+type Notification struct {
+	Slot     uint64
+	Value    json.RawMessage
+	Resynced bool
+}
+
+// Subscription is a live PubSub subscription. Notifications are delivered
+// on C until the subscription ends, at which point C is closed and Err
+// reports why.
+type Subscription struct {
+	method            string
+	unsubscribeMethod string
+	target            string
+	cancel            context.CancelFunc
+	// client is set when the subscription resumes after a dropped
+	// connection.
+	client *Client
+
+	notifications chan Notification
+	batches       chan []Notification
+	unsubscribed  atomic.Bool
+
+	dedup      *notificationDedup
+	duplicates atomic.Uint64
+	metrics    *clientMetrics
+
+	lastSlot atomic.Uint64
+	resyncs  atomic.Uint64
+	// floor is the slot of the last resync; older notifications are
+	// discarded. It is used only by the forwarding goroutine.
+	floor uint64
+
+	// mu guards sub, which a resume replaces, and err.
+	mu            sync.Mutex
+	sub           *wsSubscription
+	droppedBefore uint64
+	err           error
+}
+
+// SubscribeToAccountChanges notifies on every change to the account at
+// address. Cancelling ctx unsubscribes on the server and closes the
+// notification channel.
+func (c *Client) SubscribeToAccountChanges(ctx context.Context, address string) (*Subscription, error) {
+	if err := ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	return c.subscribe(ctx, "accountSubscribe", "accountUnsubscribe", address, true)
+}
+
+// AccountSubscriptionResult is the outcome of subscribing to one address in
+// SubscribeToAccounts: a Subscription, or the error that prevented it.
+type AccountSubscriptionResult struct {
+	Address      string
+	Subscription *Subscription
+	Err          error
+}
+
+// AccountSubscriptionResults holds one result per address passed to
+// SubscribeToAccounts, in the same order.
+type AccountSubscriptionResults []AccountSubscriptionResult
+
+// Subscriptions returns the successful subscriptions by address.
+func (r AccountSubscriptionResults) Subscriptions() map[string]*Subscription {
+	subs := make(map[string]*Subscription, len(r))
+	for _, res := range r {
+		if res.Err == nil {
+			subs[res.Address] = res.Subscription
+		}
+	}
+	return subs
+}
+
+// Failed returns the addresses that could not be subscribed, to pass to
+// SubscribeToAccounts again. Addresses that failed with ErrInvalidAddress
+// are left out, since retrying cannot help them.
+func (r AccountSubscriptionResults) Failed() []string {
+	var failed []string
+	for _, res := range r {
+		if res.Err != nil && !errors.Is(res.Err, ErrInvalidAddress) {
+			failed = append(failed, res.Address)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of the addresses that failed, or returns nil if
+// every address was subscribed.
+func (r AccountSubscriptionResults) Err() error {
+	var errs []error
+	for _, res := range r {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SubscribeToAccounts subscribes to changes of each account in addresses,
+// as SubscribeToAccountChanges does, and reports the outcome per address
+// instead of failing the whole batch. Addresses are validated locally
+// first, so a malformed one fails with ErrInvalidAddress without reaching
+// the node. Up to DefaultBatchSubscribeConcurrency subscriptions are set up
+// at once. Cancelling ctx ends every subscription of the batch.
+func (c *Client) SubscribeToAccounts(ctx context.Context, addresses []string) AccountSubscriptionResults {
+	results := make(AccountSubscriptionResults, len(addresses))
+	sem := make(chan struct{}, DefaultBatchSubscribeConcurrency)
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		results[i].Address = address
+		if err := ValidateAddress(address); err != nil {
+			results[i].Err = err
+			continue
+		}
+		wg.Add(1)
+		go func(res *AccountSubscriptionResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			res.Subscription, res.Err = c.subscribe(ctx, "accountSubscribe", "accountUnsubscribe", res.Address, true)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	if failed := len(results.Failed()); failed > 0 {
+		c.logger.Warn("Some account subscriptions failed", map[string]interface{}{
+			"requested": len(addresses),
+			"failed":    failed,
+		})
+	}
+	return results
+}
+
+// SubscribeToProgram notifies on every change to an account owned by
+// programID. Cancelling ctx unsubscribes on the server and closes the
+// notification channel.
+func (c *Client) SubscribeToProgram(ctx context.Context, programID string) (*Subscription, error) {
+	if err := ValidateAddress(programID); err != nil {
+		return nil, err
+	}
+	return c.subscribe(ctx, "programSubscribe", "programUnsubscribe", programID, true)
+}
+
+// Unsubscribe ends sub, cancelling it on the server. The notification
+// channel is closed and sub.Err returns nil. Unsubscribing twice is a no-op.
+func (c *Client) Unsubscribe(ctx context.Context, sub *Subscription) error {
+	if sub.unsubscribed.Swap(true) {
+		return nil
+	}
+	err := sub.current().unsubscribe(ctx)
+	sub.cancel()
+	return err
+}
+
+// subscribe starts a subscription. batched applies WithNotificationBatching;
+// the client's own consumers read C and pass false.
+func (c *Client) subscribe(ctx context.Context, method, unsubscribeMethod, target string, batched bool) (*Subscription, error) {
+	ws, err := c.websocket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := ws.subscribe(ctx, method, unsubscribeMethod, c.subscribeParams(target))
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, target, err)
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	s := &Subscription{
+		method:            method,
+		unsubscribeMethod: unsubscribeMethod,
+		target:            target,
+		sub:               sub,
+		cancel:            cancel,
+		notifications:     make(chan Notification, DefaultNotificationBuffer),
+		batches:           make(chan []Notification),
+		dedup:             newNotificationDedup(c.dedupWindow),
+		metrics:           c.metrics,
+	}
+	if c.resumeSubscriptions && method == "accountSubscribe" {
+		s.client = c
+	}
+	var batchWindow time.Duration
+	if batched {
+		batchWindow = c.batchWindow
+	}
+	go s.run(subCtx, ctx, batchWindow, c.batchSize)
+	return s, nil
+}
+
+// subscribeParams returns the parameters of an account or program
+// subscription to target.
+func (c *Client) subscribeParams(target string) []interface{} {
+	return []interface{}{
+		target,
+		map[string]interface{}{"commitment": c.commitment(), "encoding": "base64"},
+	}
+}
+
+// C returns the notification channel. It is closed when the subscription
+// ends. Under WithNotificationBatching it receives nothing; read Batches
+// instead.
+func (s *Subscription) C() <-chan Notification {
+	return s.notifications
+}
+
+// Batches returns the channel notifications are delivered on, in batches,
+// under WithNotificationBatching. Without it Batches receives nothing. It is
+// closed when the subscription ends.
+func (s *Subscription) Batches() <-chan []Notification {
+	return s.batches
+}
+
+// Err returns why the subscription ended once C is closed: nil after
+// Unsubscribe, an error wrapping the context's error when the context
+// passed to subscribe ended, or ErrSubscriptionClosed when the connection
+// dropped and the subscription was not resumed.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Dropped returns how many notifications were discarded because the
+// consumer fell behind.
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.droppedBefore + s.sub.dropped.Load()
+}
+
+// Duplicates returns how many notifications were discarded as duplicates
+// under WithNotificationDedup.
+func (s *Subscription) Duplicates() uint64 {
+	return s.duplicates.Load()
+}
+
+func (s *Subscription) String() string {
+	return fmt.Sprintf("%s(%s)#%d", s.method, s.target, s.current().id)
+}
+
+// current returns the server-side subscription.
+func (s *Subscription) current() *wsSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sub
+}
+
+// run forwards notifications until the subscription ends. parent is the
+// caller's context, used to tell its cancellation apart from Unsubscribe.
+// A positive batchWindow delivers them in batches. A resumable
+// subscription resumes when the connection drops.
+func (s *Subscription) run(ctx, parent context.Context, batchWindow time.Duration, batchSize int) {
+	defer close(s.notifications)
+	defer close(s.batches)
+	defer s.cancel()
+
+	var err error
+	for {
+		if batchWindow > 0 {
+			err = s.forwardBatches(ctx, batchWindow, batchSize)
+		} else {
+			err = s.forward(ctx)
+		}
+		if err == nil || s.client == nil || s.unsubscribed.Load() {
+			break
+		}
+		if s.resume(ctx, batchWindow > 0, err); ctx.Err() != nil {
+			err = nil
+			break
+		}
+	}
+	s.finish(parent, err)
+}
+
+// forward delivers notifications one at a time on s.notifications. It
+// returns nil when ctx ends and the closed error when the connection does.
+func (s *Subscription) forward(ctx context.Context) error {
+	in := s.current().notifications
+	for {
+		select {
+		case payload, ok := <-in:
+			if !ok {
+				return s.closedError()
+			}
+			n, ok := s.decode(payload)
+			if !ok {
+				continue
+			}
+			select {
+			case s.notifications <- n:
+			case <-ctx.Done():
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// decode parses a notification payload. It reports false for payloads that
+// cannot be parsed and for duplicates, which are counted.
+func (s *Subscription) decode(payload []byte) (Notification, bool) {
+	var result contextResult
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return Notification{}, false
+	}
+	n := Notification{Slot: result.Context.Slot, Value: result.Value}
+	if n.Slot < s.floor {
+		return Notification{}, false
+	}
+	s.observe(n.Slot)
+	if s.dedup.duplicate(n) {
+		s.duplicates.Add(1)
+		s.metrics.duplicates.Add(1)
+		return Notification{}, false
+	}
+	return n, true
+}
+
+// finish records why the subscription ended and, if the caller's context
+// ended it, cancels it on the server.
+func (s *Subscription) finish(parent context.Context, err error) {
+	if s.unsubscribed.Load() {
+		err = nil
+	} else if parent.Err() != nil {
+		err = fmt.Errorf("%s: %w", s, context.Cause(parent))
+		unsubCtx, cancel := context.WithTimeout(context.WithoutCancel(parent), DefaultUnsubscribeTimeout)
+		s.current().unsubscribe(unsubCtx)
+		cancel()
+	}
+
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+func (s *Subscription) closedError() error {
+	conn := s.current().conn
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.err != nil {
+		return fmt.Errorf("%s: %w: %w", s, ErrSubscriptionClosed, conn.err)
+	}
+	return fmt.Errorf("%s: %w", s, ErrSubscriptionClosed)
+}
@@ -0,0 +1,196 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// serveAccountPubSub acknowledges subscriptions with increasing IDs, sends
+// one account notification for each, and counts unsubscribes.
+func serveAccountPubSub(unsubscribes *atomic.Int32) func(conn *websocket.Conn) {
+	return func(conn *websocket.Conn) {
+		var nextID uint64
+		for {
+			var req struct {
+				ID     uint64        `json:"id"`
+				Method string        `json:"method"`
+				Params []interface{} `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method == "accountUnsubscribe" {
+				unsubscribes.Add(1)
+				conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": true})
+				continue
+			}
+			nextID++
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": nextID})
+			conn.WriteJSON(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "accountNotification",
+				"params": map[string]interface{}{
+					"subscription": nextID,
+					"result":       withContext(map[string]interface{}{"lamports": 5}),
+				},
+			})
+		}
+	}
+}
+
+func TestSubscriptionCancelledByContext(t *testing.T) {
+	var unsubscribes atomic.Int32
+	rpc := newFakeRPC(t, nil)
+	rpc.pubsub = serveAccountPubSub(&unsubscribes)
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	cycle := func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		sub, err := client.SubscribeToAccountChanges(ctx, wallet.PublicKey())
+		if err != nil {
+			t.Fatalf("SubscribeToAccountChanges: %v", err)
+		}
+		if n := <-sub.C(); n.Slot != 1 {
+			t.Fatalf("notification = %+v", n)
+		}
+		cancel()
+		for range sub.C() {
+		}
+		if err := sub.Err(); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Err = %v, want context.Canceled", err)
+		}
+	}
+
+	// The first cycle dials the shared connection.
+	cycle()
+	baseline := runtime.NumGoroutine()
+	const cycles = 50
+	for i := 0; i < cycles; i++ {
+		cycle()
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		t.Fatalf("goroutines = %d after %d subscribe/cancel cycles, want at most %d", n, cycles, baseline)
+	}
+	for unsubscribes.Load() != cycles+1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := unsubscribes.Load(); n != cycles+1 {
+		t.Fatalf("server saw %d unsubscribes, want %d", n, cycles+1)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	var unsubscribes atomic.Int32
+	rpc := newFakeRPC(t, nil)
+	rpc.pubsub = serveAccountPubSub(&unsubscribes)
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	sub, err := client.SubscribeToAccountChanges(context.Background(), wallet.PublicKey())
+	if err != nil {
+		t.Fatalf("SubscribeToAccountChanges: %v", err)
+	}
+	if err := client.Unsubscribe(context.Background(), sub); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	for range sub.C() {
+	}
+	if sub.Err() != nil || unsubscribes.Load() != 1 {
+		t.Fatalf("Err = %v, unsubscribes = %d", sub.Err(), unsubscribes.Load())
+	}
+	if err := client.Unsubscribe(context.Background(), sub); err != nil {
+		t.Fatalf("second Unsubscribe: %v", err)
+	}
+}
+
+func TestSubscribeWithWebSocketDisabled(t *testing.T) {
+	var unsubscribes atomic.Int32
+	rpc := newFakeRPC(t, nil)
+	var dialed atomic.Bool
+	rpc.pubsub = func(conn *websocket.Conn) {
+		dialed.Store(true)
+		serveAccountPubSub(&unsubscribes)(conn)
+	}
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	disabled := false
+	client.config.WebSocket.Enabled = &disabled
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	if _, err := client.SubscribeToAccountChanges(context.Background(), wallet.PublicKey()); !errors.Is(err, utils.ErrDisabled) {
+		t.Fatalf("SubscribeToAccountChanges error = %v, want ErrDisabled", err)
+	}
+	if dialed.Load() {
+		t.Fatal("client dialed the WebSocket while it is disabled")
+	}
+}
+
+func TestSubscribeToAccountsPartialFailure(t *testing.T) {
+	rejected, _ := NewWallet()
+	rpc := newFakeRPC(t, nil)
+	rpc.pubsub = func(conn *websocket.Conn) {
+		var nextID uint64
+		for {
+			var req struct {
+				ID     uint64        `json:"id"`
+				Method string        `json:"method"`
+				Params []interface{} `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method == "accountSubscribe" && req.Params[0] == rejected.PublicKey() {
+				conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID,
+					"error": map[string]interface{}{"code": -32602, "message": "too many subscriptions"}})
+				continue
+			}
+			nextID++
+			conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": nextID})
+		}
+	}
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+
+	a, _ := NewWallet()
+	b, _ := NewWallet()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := client.SubscribeToAccounts(ctx, []string{a.PublicKey(), "not-an-address", rejected.PublicKey(), b.PublicKey()})
+
+	if len(results) != 4 || results[1].Address != "not-an-address" {
+		t.Fatalf("results = %+v", results)
+	}
+	if !errors.Is(results[1].Err, ErrInvalidAddress) {
+		t.Fatalf("invalid address error = %v, want ErrInvalidAddress", results[1].Err)
+	}
+	subs := results.Subscriptions()
+	if len(subs) != 2 || subs[a.PublicKey()] == nil || subs[b.PublicKey()] == nil {
+		t.Fatalf("subscriptions = %v", subs)
+	}
+	if failed := results.Failed(); len(failed) != 1 || failed[0] != rejected.PublicKey() {
+		t.Fatalf("Failed = %v, want only the rejected address", failed)
+	}
+	if err := results.Err(); !errors.Is(err, ErrInvalidAddress) {
+		t.Fatalf("Err = %v", err)
+	}
+}
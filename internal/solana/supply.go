@@ -0,0 +1,63 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Supply is the network's SOL supply as of Slot.
+type Supply struct {
+	Slot           uint64
+	Total          Lamports
+	Circulating    Lamports
+	NonCirculating Lamports
+	// NonCirculatingAccounts lists the addresses holding the
+	// non-circulating supply. It is only filled when the call was made
+	// WithNonCirculatingAccounts.
+	NonCirculatingAccounts []string
+}
+
+type rpcSupply struct {
+	Total                  uint64   `json:"total"`
+	Circulating            uint64   `json:"circulating"`
+	NonCirculating         uint64   `json:"nonCirculating"`
+	NonCirculatingAccounts []string `json:"nonCirculatingAccounts"`
+}
+
+// WithNonCirculatingAccounts makes GetSupply return the addresses holding
+// the non-circulating supply. The list runs to thousands of entries on
+// mainnet, so it is left out by default.
+func WithNonCirculatingAccounts() CallOption {
+	return func(o *callOptions) {
+		o.nonCirculatingAccounts = true
+	}
+}
+
+// GetSupply returns the total, circulating, and non-circulating SOL supply.
+// It accepts WithCommitment, WithMinContextSlot, and
+// WithNonCirculatingAccounts.
+func (c *Client) GetSupply(ctx context.Context, opts ...CallOption) (*Supply, error) {
+	o, err := c.callOptions(opts, optMinContextSlot|optNonCirculatingAccounts)
+	if err != nil {
+		return nil, err
+	}
+	config := o.config(false)
+	config["excludeNonCirculatingAccountsList"] = !o.nonCirculatingAccounts
+
+	var result contextResult
+	if err := c.call(ctx, "getSupply", []interface{}{config}, &result); err != nil {
+		return nil, err
+	}
+	var supply rpcSupply
+	if err := json.Unmarshal(result.Value, &supply); err != nil {
+		return nil, fmt.Errorf("decode supply: %w", err)
+	}
+	return &Supply{
+		Slot:                   result.Context.Slot,
+		Total:                  Lamports(supply.Total),
+		Circulating:            Lamports(supply.Circulating),
+		NonCirculating:         Lamports(supply.NonCirculating),
+		NonCirculatingAccounts: supply.NonCirculatingAccounts,
+	}, nil
+}
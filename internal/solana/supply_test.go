@@ -0,0 +1,49 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestGetSupply(t *testing.T) {
+	var configs []map[string]interface{}
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getSupply": func(params json.RawMessage) (interface{}, error) {
+			var p []map[string]interface{}
+			json.Unmarshal(params, &p)
+			configs = append(configs, p[0])
+			value := map[string]interface{}{
+				"total":          uint64(600_000_000_000_000_000),
+				"circulating":    uint64(450_000_000_000_000_000),
+				"nonCirculating": uint64(150_000_000_000_000_000),
+			}
+			if p[0]["excludeNonCirculatingAccountsList"] == false {
+				value["nonCirculatingAccounts"] = []string{SystemProgramID.String()}
+			}
+			return withContext(value), nil
+		},
+	})
+	client := rpc.client(t)
+
+	supply, err := client.GetSupply(context.Background())
+	if err != nil {
+		t.Fatalf("GetSupply: %v", err)
+	}
+	if supply.Total != 600_000_000_000_000_000 || supply.Circulating+supply.NonCirculating != supply.Total ||
+		supply.Slot != 1 || supply.NonCirculatingAccounts != nil {
+		t.Fatalf("supply = %+v", supply)
+	}
+	if configs[0]["excludeNonCirculatingAccountsList"] != true || configs[0]["commitment"] != client.commitment() {
+		t.Fatalf("config = %v, want the account list excluded at the client's commitment", configs[0])
+	}
+
+	supply, err = client.GetSupply(context.Background(), WithNonCirculatingAccounts(), WithCommitment(CommitmentFinalized), WithMinContextSlot(42))
+	if err != nil {
+		t.Fatalf("GetSupply: %v", err)
+	}
+	// JSON numbers decode as float64.
+	if len(supply.NonCirculatingAccounts) != 1 || configs[1]["commitment"] != CommitmentFinalized || configs[1]["minContextSlot"] != float64(42) {
+		t.Fatalf("supply = %+v, config = %v", supply, configs[1])
+	}
+}
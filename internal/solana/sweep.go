@@ -0,0 +1,71 @@
+package solana
+
+import (
+	"context"
+	"fmt"
+)
+
+// SweepBalance transfers the whole balance of from to to, less the
+// transaction fee, leaving from empty, e.g. to decommission a test wallet.
+// from is a wallet or other Signer and need not be registered.
+// The fee is priced with getFeeForMessage for the exact message sent, so
+// nothing is left behind and the transfer cannot fail for lack of funds.
+// No rent-exempt reserve is kept: an account emptied to zero lamports is
+// closed, which is always allowed. Only plain wallets qualify; an account
+// that holds data or is owned by another program fails with
+// ErrInvalidAccountData, and one whose balance does not cover the fee with
+// ErrInsufficientFunds. The transaction is tracked for ResendTransaction
+// and the call is reported to the audit hook, if any. Lamports that reach
+// from after its balance is read are not swept.
+func (c *Client) SweepBalance(ctx context.Context, from Signer, to string) (signature string, err error) {
+	event := AuditEvent{Operation: AuditSweepBalance, From: from.PublicKey(), To: to}
+	defer func() { c.audit(ctx, event, &signature, &err) }()
+	defer wrapOp(&err, "sweep %s to %s", from.PublicKey(), to)
+	fromKey, err := signerKey(from)
+	if err != nil {
+		return "", err
+	}
+	toKey, err := PublicKeyFromBase58(to)
+	if err != nil {
+		return "", err
+	}
+
+	account, err := c.GetAccountInfo(ctx, from.PublicKey())
+	if err != nil {
+		return "", err
+	}
+	if account.Owner != SystemProgramID.String() || len(account.Data) > 0 {
+		return "", fmt.Errorf("%w: owner %s, %d bytes of data; only a plain wallet can be swept", ErrInvalidAccountData, account.Owner, len(account.Data))
+	}
+
+	latest, err := c.getLatestBlockhash(ctx)
+	if err != nil {
+		return "", err
+	}
+	recent, err := HashFromBase58(latest.Blockhash)
+	if err != nil {
+		return "", err
+	}
+	// The fee does not depend on the amount, so price the message with the
+	// whole balance and send it with the balance less the fee.
+	msg, err := NewMessage(fromKey, []Instruction{TransferInstruction(fromKey, toKey, account.Lamports)}, recent)
+	if err != nil {
+		return "", err
+	}
+	fee, err := c.GetFeeForMessage(ctx, msg)
+	if err != nil {
+		return "", err
+	}
+	if account.Lamports <= fee {
+		return "", fmt.Errorf("%w: balance %d lamports does not cover the fee of %d", ErrInsufficientFunds, account.Lamports, fee)
+	}
+	event.Amount = account.Lamports - fee
+
+	builder := NewTransactionBuilder().
+		SetFeePayer(fromKey).
+		AddSigner(from).
+		SetRecentBlockhash(recent, latest.LastValidBlockHeight).
+		AddInstruction(TransferInstruction(fromKey, toKey, event.Amount))
+	builder.fee = fee
+	return builder.Send(ctx, c)
+}
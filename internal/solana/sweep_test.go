@@ -0,0 +1,72 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSweepBalance(t *testing.T) {
+	balance := uint64(1_000_000)
+	owner := SystemProgramID.String()
+	var sent []string
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getAccountInfo": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"lamports": balance, "owner": owner, "data": []string{"", "base64"},
+			}), nil
+		},
+		"getLatestBlockhash": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"blockhash":            SystemProgramID.String(),
+				"lastValidBlockHeight": 100,
+			}), nil
+		},
+		"getFeeForMessage": func(json.RawMessage) (interface{}, error) {
+			return withContext(5000), nil
+		},
+		"sendTransaction": func(params json.RawMessage) (interface{}, error) {
+			var p []string
+			json.Unmarshal(params, &p)
+			sent = append(sent, p[0])
+			return "sig", nil
+		},
+	})
+	var events []AuditEvent
+	client := rpc.client(t)
+	client.auditHook = func(ctx context.Context, event AuditEvent) { events = append(events, event) }
+	from, _ := NewWallet()
+	to, _ := NewWallet()
+
+	sig, err := client.SweepBalance(context.Background(), from, to.PublicKey())
+	if err != nil {
+		t.Fatalf("SweepBalance: %v", err)
+	}
+	tx, err := DeserializeTransaction(sent[0])
+	if err != nil {
+		t.Fatalf("DeserializeTransaction: %v", err)
+	}
+	want := TransferInstruction(from.Key(), to.Key(), balance-5000).Data
+	if got := tx.Message.Instructions[0].Data; string(got) != string(want) {
+		t.Fatalf("transfer data = %x, want %x", got, want)
+	}
+	if tracked, ok := client.TrackedTransaction(sig); !ok || tracked.Fee != 5000 {
+		t.Fatalf("tracked = %+v, %v", tracked, ok)
+	}
+	if len(events) != 1 || events[0].Operation != AuditSweepBalance || events[0].Amount != balance-5000 {
+		t.Fatalf("audit events = %+v", events)
+	}
+
+	balance = 5000
+	if _, err := client.SweepBalance(context.Background(), from, to.PublicKey()); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("SweepBalance of the fee alone = %v, want ErrInsufficientFunds", err)
+	}
+	balance, owner = 1_000_000, TokenProgramID.String()
+	if _, err := client.SweepBalance(context.Background(), from, to.PublicKey()); !errors.Is(err, ErrInvalidAccountData) {
+		t.Fatalf("SweepBalance of a program account = %v, want ErrInvalidAccountData", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("broadcasts = %d, want 1", len(sent))
+	}
+}
@@ -0,0 +1,22 @@
+package solana
+
+// SystemProgramID is the address of the native System Program.
+var SystemProgramID = MustPublicKey("11111111111111111111111111111111")
+
+// LamportsPerSOL is the number of lamports in one SOL.
+const LamportsPerSOL = 1_000_000_000
+
+const systemInstructionTransfer uint32 = 2
+
+// TransferInstruction builds a System Program transfer of lamports.
+func TransferInstruction(from, to PublicKey, lamports uint64) Instruction {
+	data := append(putUint32(systemInstructionTransfer), putUint64(lamports)...)
+	return Instruction{
+		ProgramID: SystemProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: from, IsSigner: true, IsWritable: true},
+			{PublicKey: to, IsWritable: true},
+		},
+		Data: data,
+	}
+}
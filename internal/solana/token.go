@@ -0,0 +1,500 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Token program IDs. TokenProgramID is the original SPL Token program;
+// Token2022ProgramID is the Token Extensions program. Basic mint, account,
+// mint-to, and transfer flows are identical between them.
+var (
+	TokenProgramID                  = MustPublicKey("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	Token2022ProgramID              = MustPublicKey("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
+	AssociatedTokenAccountProgramID = MustPublicKey("ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL")
+)
+
+// Account sizes for the base (extension-free) token layouts.
+const (
+	MintSize         = 82
+	TokenAccountSize = 165
+)
+
+// DefaultTokenDecimals is used by CreateTokenMint unless WithDecimals is given.
+const DefaultTokenDecimals = 9
+
+// SPL Token instruction discriminators.
+const (
+	tokenInstructionApprove            = 4
+	tokenInstructionRevoke             = 5
+	tokenInstructionMintTo             = 7
+	tokenInstructionTransferChecked    = 12
+	tokenInstructionApproveChecked     = 13
+	tokenInstructionBurnChecked        = 15
+	tokenInstructionInitializeAccount3 = 18
+	tokenInstructionInitializeMint2    = 20
+	associatedTokenCreateIdempotent    = 1
+	systemInstructionCreateAccount     = 0
+)
+
+// ErrNoPayer is returned by operations that need a fee payer when none has
+// been configured with SetPayer or SetPayerSigner.
+var ErrNoPayer = errors.New("solana: no payer wallet configured")
+
+// TokenOption configures token operations.
+type TokenOption func(*tokenOptions)
+
+type tokenOptions struct {
+	programID       PublicKey
+	decimals        uint8
+	ensureRecipient bool
+}
+
+func newTokenOptions(opts []TokenOption) tokenOptions {
+	o := tokenOptions{programID: TokenProgramID, decimals: DefaultTokenDecimals}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithTokenProgram selects the token program, e.g. Token2022ProgramID. The
+// default is TokenProgramID. It must match the program that owns the mint.
+func WithTokenProgram(programID PublicKey) TokenOption {
+	return func(o *tokenOptions) {
+		o.programID = programID
+	}
+}
+
+// WithDecimals sets the decimals of a mint created by CreateTokenMint.
+func WithDecimals(decimals uint8) TokenOption {
+	return func(o *tokenOptions) {
+		o.decimals = decimals
+	}
+}
+
+// EnsureRecipient makes MintTokens and TransferTokens treat the destination
+// as a wallet address and create its associated token account in the same
+// transaction if it is missing. Creation is idempotent, so the transaction
+// succeeds whether or not the account already exists.
+func EnsureRecipient() TokenOption {
+	return func(o *tokenOptions) {
+		o.ensureRecipient = true
+	}
+}
+
+// SetPayer registers wallet and makes it the fee payer and mint authority for
+// token operations. Use SetPayerSigner for a payer held elsewhere, such as
+// an external key store.
+func (c *Client) SetPayer(wallet *Wallet) {
+	c.AddWallet(wallet)
+	c.walletsMu.Lock()
+	defer c.walletsMu.Unlock()
+	c.payer = wallet
+}
+
+// SetPayerSigner is SetPayer for any Signer. It fails only if payer's
+// public key is malformed.
+func (c *Client) SetPayerSigner(payer Signer) error {
+	if err := c.AddSigner(payer); err != nil {
+		return err
+	}
+	c.walletsMu.Lock()
+	defer c.walletsMu.Unlock()
+	c.payer = payer
+	return nil
+}
+
+// payerAddress returns the payer wallet's address, or "" if none is set.
+func (c *Client) payerAddress() string {
+	c.walletsMu.RLock()
+	defer c.walletsMu.RUnlock()
+	if c.payer == nil {
+		return ""
+	}
+	return c.payer.PublicKey()
+}
+
+// payerSigner returns the payer and its public key.
+func (c *Client) payerSigner() (Signer, PublicKey, error) {
+	c.walletsMu.RLock()
+	payer := c.payer
+	c.walletsMu.RUnlock()
+	if payer == nil {
+		return nil, PublicKey{}, ErrNoPayer
+	}
+	key, err := signerKey(payer)
+	if err != nil {
+		return nil, PublicKey{}, err
+	}
+	return payer, key, nil
+}
+
+// FindAssociatedTokenAddress derives the associated token account of owner
+// for mint under the given token program.
+func FindAssociatedTokenAddress(owner, mint, tokenProgramID PublicKey) (PublicKey, error) {
+	key, _, err := FindProgramAddress(
+		[][]byte{owner[:], tokenProgramID[:], mint[:]},
+		AssociatedTokenAccountProgramID,
+	)
+	return key, err
+}
+
+// CreateTokenMint creates and initializes a new mint whose mint authority is
+// the payer wallet, and returns the mint address.
+func (c *Client) CreateTokenMint(ctx context.Context, opts ...TokenOption) (_ string, err error) {
+	defer wrapOp(&err, "create token mint")
+	o := newTokenOptions(opts)
+	payer, payerKey, err := c.payerSigner()
+	if err != nil {
+		return "", err
+	}
+	mint, err := NewWallet()
+	if err != nil {
+		return "", err
+	}
+	rent, err := c.GetMinimumBalanceForRentExemption(ctx, MintSize)
+	if err != nil {
+		return "", err
+	}
+
+	instructions := []Instruction{
+		CreateAccountInstruction(payerKey, mint.Key(), rent, MintSize, o.programID),
+		InitializeMintInstruction(o.programID, mint.Key(), payerKey, o.decimals),
+	}
+	if _, err := c.sendInstructions(ctx, payer, instructions, mint); err != nil {
+		return "", err
+	}
+	return mint.PublicKey(), nil
+}
+
+// CreateTokenAccount creates the payer's associated token account for mint
+// if it does not already exist, and returns its address.
+func (c *Client) CreateTokenAccount(ctx context.Context, mint string, opts ...TokenOption) (_ string, err error) {
+	defer wrapOp(&err, "create token account for mint %s", mint)
+	o := newTokenOptions(opts)
+	payer, payerKey, err := c.payerSigner()
+	if err != nil {
+		return "", err
+	}
+	mintKey, err := PublicKeyFromBase58(mint)
+	if err != nil {
+		return "", err
+	}
+
+	ix, ata, err := CreateAssociatedTokenAccountInstruction(payerKey, payerKey, mintKey, o.programID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.sendInstructions(ctx, payer, []Instruction{ix}); err != nil {
+		return "", err
+	}
+	return ata.String(), nil
+}
+
+// MintTokens mints amount base units of mint into the token account
+// account. The payer wallet must be the mint authority. With
+// EnsureRecipient, account is the recipient wallet and its associated token
+// account is created if needed.
+func (c *Client) MintTokens(ctx context.Context, mint, account string, amount uint64, opts ...TokenOption) (signature string, err error) {
+	defer func() {
+		c.audit(ctx, AuditEvent{Operation: AuditMintTokens, From: c.payerAddress(), To: account, Mint: mint, Amount: amount}, &signature, &err)
+	}()
+	defer wrapOp(&err, "mint %d of %s to %s", amount, mint, account)
+	o := newTokenOptions(opts)
+	payer, payerKey, err := c.payerSigner()
+	if err != nil {
+		return "", err
+	}
+	mintKey, err := PublicKeyFromBase58(mint)
+	if err != nil {
+		return "", err
+	}
+	accountKey, err := PublicKeyFromBase58(account)
+	if err != nil {
+		return "", err
+	}
+
+	var instructions []Instruction
+	if o.ensureRecipient {
+		create, destination, err := c.ensureTokenAccount(ctx, payerKey, accountKey, mintKey, o.programID)
+		if err != nil {
+			return "", err
+		}
+		instructions = append(instructions, create...)
+		accountKey = destination
+	}
+	instructions = append(instructions, MintToInstruction(o.programID, mintKey, accountKey, payerKey, amount))
+	return c.sendInstructions(ctx, payer, instructions)
+}
+
+// TransferTokens transfers amount base units of mint from owner's associated
+// token account to recipient's associated token account. owner must be a
+// registered wallet; the payer wallet pays the fee. The call is reported to
+// the audit hook, if any.
+func (c *Client) TransferTokens(ctx context.Context, mint, owner, recipient string, amount uint64, opts ...TokenOption) (signature string, err error) {
+	defer c.audit(ctx, AuditEvent{Operation: AuditTransferTokens, From: owner, To: recipient, Mint: mint, Amount: amount}, &signature, &err)
+	defer wrapOp(&err, "transfer %d of %s from %s to %s", amount, mint, owner, recipient)
+	o := newTokenOptions(opts)
+	payer, payerKey, err := c.payerSigner()
+	if err != nil {
+		return "", err
+	}
+	ownerSigner, ownerKey, err := c.signer(owner)
+	if err != nil {
+		return "", err
+	}
+	mintKey, err := PublicKeyFromBase58(mint)
+	if err != nil {
+		return "", err
+	}
+	recipientKey, err := PublicKeyFromBase58(recipient)
+	if err != nil {
+		return "", err
+	}
+
+	source, err := FindAssociatedTokenAddress(ownerKey, mintKey, o.programID)
+	if err != nil {
+		return "", err
+	}
+	decimals, err := c.mintDecimals(ctx, mint)
+	if err != nil {
+		return "", err
+	}
+
+	var instructions []Instruction
+	destination, err := FindAssociatedTokenAddress(recipientKey, mintKey, o.programID)
+	if err != nil {
+		return "", err
+	}
+	if o.ensureRecipient {
+		var create []Instruction
+		create, destination, err = c.ensureTokenAccount(ctx, payerKey, recipientKey, mintKey, o.programID)
+		if err != nil {
+			return "", err
+		}
+		instructions = append(instructions, create...)
+	}
+	instructions = append(instructions,
+		TransferCheckedInstruction(o.programID, source, mintKey, destination, ownerKey, amount, decimals))
+	return c.sendInstructions(ctx, payer, instructions, ownerSigner)
+}
+
+// BurnTokens burns amount base units of mint from owner's associated token
+// account. owner must be a registered wallet; the payer wallet pays the
+// fee. The call is reported to the audit hook, if any.
+func (c *Client) BurnTokens(ctx context.Context, mint, owner string, amount uint64, opts ...TokenOption) (signature string, err error) {
+	defer c.audit(ctx, AuditEvent{Operation: AuditBurnTokens, From: owner, Mint: mint, Amount: amount}, &signature, &err)
+	defer wrapOp(&err, "burn %d of %s from %s", amount, mint, owner)
+	o := newTokenOptions(opts)
+	payer, _, err := c.payerSigner()
+	if err != nil {
+		return "", err
+	}
+	ownerSigner, ownerKey, err := c.signer(owner)
+	if err != nil {
+		return "", err
+	}
+	mintKey, err := PublicKeyFromBase58(mint)
+	if err != nil {
+		return "", err
+	}
+	account, err := FindAssociatedTokenAddress(ownerKey, mintKey, o.programID)
+	if err != nil {
+		return "", err
+	}
+	decimals, err := c.mintDecimals(ctx, mint)
+	if err != nil {
+		return "", err
+	}
+	instruction := BurnCheckedInstruction(o.programID, account, mintKey, ownerKey, amount, decimals)
+	return c.sendInstructions(ctx, payer, []Instruction{instruction}, ownerSigner)
+}
+
+// ensureTokenAccount resolves the token account that should receive mint for
+// recipient and returns the instructions needed to create it. If recipient
+// is itself a token account it is used directly after checking its mint.
+// Otherwise its associated token account is derived; an existing one is
+// checked against mint and a missing one gets an idempotent create.
+func (c *Client) ensureTokenAccount(ctx context.Context, payer, recipient, mint, programID PublicKey) ([]Instruction, PublicKey, error) {
+	info, err := c.GetAccountInfo(ctx, recipient.String())
+	if err != nil && !errors.Is(err, ErrAccountNotFound) {
+		return nil, PublicKey{}, err
+	}
+	if info != nil && info.Owner == programID.String() {
+		if err := checkTokenAccountMint(recipient, info, mint); err != nil {
+			return nil, PublicKey{}, err
+		}
+		return nil, recipient, nil
+	}
+
+	create, ata, err := CreateAssociatedTokenAccountInstruction(payer, recipient, mint, programID)
+	if err != nil {
+		return nil, PublicKey{}, err
+	}
+	existing, err := c.GetAccountInfo(ctx, ata.String())
+	switch {
+	case errors.Is(err, ErrAccountNotFound):
+		return []Instruction{create}, ata, nil
+	case err != nil:
+		return nil, PublicKey{}, err
+	}
+	if err := checkTokenAccountMint(ata, existing, mint); err != nil {
+		return nil, PublicKey{}, err
+	}
+	return nil, ata, nil
+}
+
+// checkTokenAccountMint verifies that info holds a token account for mint.
+func checkTokenAccountMint(account PublicKey, info *AccountInfo, mint PublicKey) error {
+	decoded, err := DecodeTokenAccount(info.Data)
+	if err != nil {
+		return fmt.Errorf("%w: %s is not a token account: %v", ErrMintMismatch, account, err)
+	}
+	if decoded.Mint != mint {
+		return fmt.Errorf("%w: %s holds %s, want %s", ErrMintMismatch, account, decoded.Mint, mint)
+	}
+	return nil
+}
+
+// mintDecimals returns the decimals of mint via getTokenSupply.
+func (c *Client) mintDecimals(ctx context.Context, mint string) (uint8, error) {
+	var result contextResult
+	if err := c.call(ctx, "getTokenSupply", []interface{}{mint}, &result); err != nil {
+		return 0, err
+	}
+	var supply struct {
+		Decimals uint8 `json:"decimals"`
+	}
+	if err := json.Unmarshal(result.Value, &supply); err != nil {
+		return 0, fmt.Errorf("decode token supply: %w", err)
+	}
+	return supply.Decimals, nil
+}
+
+// GetMinimumBalanceForRentExemption returns the lamports required for an
+// account of size bytes to be rent exempt. It accepts WithCommitment.
+func (c *Client) GetMinimumBalanceForRentExemption(ctx context.Context, size uint64, opts ...CallOption) (uint64, error) {
+	o, err := c.callOptions(opts, 0)
+	if err != nil {
+		return 0, err
+	}
+	params := []interface{}{size, map[string]interface{}{"commitment": o.commitment}}
+	var lamports uint64
+	if err := c.call(ctx, "getMinimumBalanceForRentExemption", params, &lamports); err != nil {
+		return 0, err
+	}
+	return lamports, nil
+}
+
+// CreateAccountInstruction builds a System Program instruction that creates
+// newAccount with space bytes owned by owner, funded by from.
+func CreateAccountInstruction(from, newAccount PublicKey, lamports, space uint64, owner PublicKey) Instruction {
+	data := putUint32(systemInstructionCreateAccount)
+	data = append(data, putUint64(lamports)...)
+	data = append(data, putUint64(space)...)
+	data = append(data, owner[:]...)
+	return Instruction{
+		ProgramID: SystemProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: from, IsSigner: true, IsWritable: true},
+			{PublicKey: newAccount, IsSigner: true, IsWritable: true},
+		},
+		Data: data,
+	}
+}
+
+// InitializeMintInstruction builds an InitializeMint2 instruction with no
+// freeze authority.
+func InitializeMintInstruction(programID, mint, mintAuthority PublicKey, decimals uint8) Instruction {
+	data := []byte{tokenInstructionInitializeMint2, decimals}
+	data = append(data, mintAuthority[:]...)
+	data = append(data, 0) // COption::None freeze authority
+	return Instruction{
+		ProgramID: programID,
+		Accounts:  []AccountMeta{{PublicKey: mint, IsWritable: true}},
+		Data:      data,
+	}
+}
+
+// InitializeAccountInstruction builds an InitializeAccount3 instruction.
+func InitializeAccountInstruction(programID, account, mint, owner PublicKey) Instruction {
+	data := append([]byte{tokenInstructionInitializeAccount3}, owner[:]...)
+	return Instruction{
+		ProgramID: programID,
+		Accounts: []AccountMeta{
+			{PublicKey: account, IsWritable: true},
+			{PublicKey: mint},
+		},
+		Data: data,
+	}
+}
+
+// MintToInstruction builds a MintTo instruction.
+func MintToInstruction(programID, mint, destination, authority PublicKey, amount uint64) Instruction {
+	return Instruction{
+		ProgramID: programID,
+		Accounts: []AccountMeta{
+			{PublicKey: mint, IsWritable: true},
+			{PublicKey: destination, IsWritable: true},
+			{PublicKey: authority, IsSigner: true},
+		},
+		Data: append([]byte{tokenInstructionMintTo}, putUint64(amount)...),
+	}
+}
+
+// TransferCheckedInstruction builds a TransferChecked instruction, which both
+// token programs accept.
+func TransferCheckedInstruction(programID, source, mint, destination, owner PublicKey, amount uint64, decimals uint8) Instruction {
+	data := append([]byte{tokenInstructionTransferChecked}, putUint64(amount)...)
+	data = append(data, decimals)
+	return Instruction{
+		ProgramID: programID,
+		Accounts: []AccountMeta{
+			{PublicKey: source, IsWritable: true},
+			{PublicKey: mint},
+			{PublicKey: destination, IsWritable: true},
+			{PublicKey: owner, IsSigner: true},
+		},
+		Data: data,
+	}
+}
+
+// BurnCheckedInstruction builds a BurnChecked instruction removing amount
+// from account and from the mint's supply.
+func BurnCheckedInstruction(programID, account, mint, owner PublicKey, amount uint64, decimals uint8) Instruction {
+	data := append([]byte{tokenInstructionBurnChecked}, putUint64(amount)...)
+	data = append(data, decimals)
+	return Instruction{
+		ProgramID: programID,
+		Accounts: []AccountMeta{
+			{PublicKey: account, IsWritable: true},
+			{PublicKey: mint, IsWritable: true},
+			{PublicKey: owner, IsSigner: true},
+		},
+		Data: data,
+	}
+}
+
+// CreateAssociatedTokenAccountInstruction builds an idempotent instruction
+// creating owner's associated token account for mint, and returns its address.
+func CreateAssociatedTokenAccountInstruction(payer, owner, mint, tokenProgramID PublicKey) (Instruction, PublicKey, error) {
+	ata, err := FindAssociatedTokenAddress(owner, mint, tokenProgramID)
+	if err != nil {
+		return Instruction{}, PublicKey{}, err
+	}
+	return Instruction{
+		ProgramID: AssociatedTokenAccountProgramID,
+		Accounts: []AccountMeta{
+			{PublicKey: payer, IsSigner: true, IsWritable: true},
+			{PublicKey: ata, IsWritable: true},
+			{PublicKey: owner},
+			{PublicKey: mint},
+			{PublicKey: SystemProgramID},
+			{PublicKey: tokenProgramID},
+		},
+		Data: []byte{associatedTokenCreateIdempotent},
+	}, ata, nil
+}
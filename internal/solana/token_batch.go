@@ -0,0 +1,184 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxTransactionSize is the largest serialized transaction, signatures
+// included, that the network accepts.
+const MaxTransactionSize = 1232
+
+// RecipientAmount is one transfer of BatchTransferTokens: Amount base units
+// to Recipient, a wallet or one of its token accounts.
+type RecipientAmount struct {
+	Recipient string
+	Amount    uint64
+}
+
+// RecipientTransferResult is the outcome of one transfer of
+// BatchTransferTokens. Signature is that of the transaction carrying the
+// transfer, shared with the other recipients of its batch; it may be set
+// alongside Err when the transaction was signed but its broadcast failed.
+type RecipientTransferResult struct {
+	Recipient string
+	Amount    uint64
+	Signature string
+	Err       error
+}
+
+// BatchTransferResults holds one result per recipient passed to
+// BatchTransferTokens, in the same order.
+type BatchTransferResults []RecipientTransferResult
+
+// Signatures returns the signature of every transaction broadcast, in the
+// order they were sent.
+func (r BatchTransferResults) Signatures() []string {
+	var signatures []string
+	seen := make(map[string]bool)
+	for _, res := range r {
+		if res.Err == nil && !seen[res.Signature] {
+			seen[res.Signature] = true
+			signatures = append(signatures, res.Signature)
+		}
+	}
+	return signatures
+}
+
+// Succeeded returns the recipients whose transfer was broadcast.
+func (r BatchTransferResults) Succeeded() []RecipientAmount {
+	var succeeded []RecipientAmount
+	for _, res := range r {
+		if res.Err == nil {
+			succeeded = append(succeeded, RecipientAmount{Recipient: res.Recipient, Amount: res.Amount})
+		}
+	}
+	return succeeded
+}
+
+// Failed returns the recipients whose transfer failed, to pass to
+// BatchTransferTokens again once their transactions are known not to have
+// landed. Recipients that failed with ErrInvalidAddress or ErrMintMismatch
+// are left out, since retrying cannot help them.
+func (r BatchTransferResults) Failed() []RecipientAmount {
+	var failed []RecipientAmount
+	for _, res := range r {
+		if res.Err != nil && !errors.Is(res.Err, ErrInvalidAddress) && !errors.Is(res.Err, ErrMintMismatch) {
+			failed = append(failed, RecipientAmount{Recipient: res.Recipient, Amount: res.Amount})
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of the recipients that failed, or returns nil if
+// every transfer was broadcast.
+func (r BatchTransferResults) Err() error {
+	var errs []error
+	for _, res := range r {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// BatchTransferTokens transfers mint from owner's associated token account
+// to every recipient, packing as many transfers into each transaction as
+// fit in MaxTransactionSize. A recipient wallet's associated token account
+// is created in the same transaction if missing, as with EnsureRecipient.
+// owner must be a registered wallet; the payer wallet pays the fees.
+//
+// Recipients are reported individually: one that cannot be resolved, such
+// as a malformed address or a token account of another mint, fails alone,
+// and a transaction that fails to broadcast fails only the recipients it
+// carried. The returned error covers failures that stop every transfer,
+// such as an unknown owner. A result without Err was broadcast; confirm
+// its Signature to know it landed. Every transfer is reported to the audit
+// hook, if any, as AuditTransferTokens.
+func (c *Client) BatchTransferTokens(ctx context.Context, mint, owner string, recipients []RecipientAmount, opts ...TokenOption) (_ BatchTransferResults, err error) {
+	defer wrapOp(&err, "batch transfer of %s from %s", mint, owner)
+	o := newTokenOptions(opts)
+	payer, payerKey, err := c.payerSigner()
+	if err != nil {
+		return nil, err
+	}
+	ownerSigner, ownerKey, err := c.signer(owner)
+	if err != nil {
+		return nil, err
+	}
+	mintKey, err := PublicKeyFromBase58(mint)
+	if err != nil {
+		return nil, err
+	}
+	source, err := FindAssociatedTokenAddress(ownerKey, mintKey, o.programID)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := c.mintDecimals(ctx, mint)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(BatchTransferResults, len(recipients))
+	var batch []int // indexes into results
+	var instructions []Instruction
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		signature, err := c.sendInstructions(ctx, payer, instructions, ownerSigner)
+		for _, i := range batch {
+			res := &results[i]
+			res.Signature, res.Err = signature, err
+			if err != nil {
+				res.Err = fmt.Errorf("transfer %d to %s: %w", res.Amount, res.Recipient, err)
+			}
+			c.audit(ctx, AuditEvent{Operation: AuditTransferTokens, From: owner, To: res.Recipient, Mint: mint, Amount: res.Amount}, &res.Signature, &res.Err)
+		}
+		batch, instructions = nil, nil
+	}
+
+	for i, r := range recipients {
+		results[i] = RecipientTransferResult{Recipient: r.Recipient, Amount: r.Amount}
+		transfer, err := c.recipientTransfer(ctx, payerKey, source, ownerKey, mintKey, r, decimals, o.programID)
+		if err != nil {
+			results[i].Err = fmt.Errorf("transfer %d to %s: %w", r.Amount, r.Recipient, err)
+			c.audit(ctx, AuditEvent{Operation: AuditTransferTokens, From: owner, To: r.Recipient, Mint: mint, Amount: r.Amount}, &results[i].Signature, &results[i].Err)
+			continue
+		}
+		packed := append(instructions[:len(instructions):len(instructions)], transfer...)
+		if size, err := transactionSize(payerKey, packed); err != nil || size > MaxTransactionSize {
+			send()
+			packed = transfer
+		}
+		batch, instructions = append(batch, i), packed
+	}
+	send()
+	return results, nil
+}
+
+// recipientTransfer returns the instructions moving r.Amount from source to
+// r.Recipient, creating its associated token account if missing.
+func (c *Client) recipientTransfer(ctx context.Context, payer, source, owner, mint PublicKey, r RecipientAmount, decimals uint8, programID PublicKey) ([]Instruction, error) {
+	recipient, err := PublicKeyFromBase58(r.Recipient)
+	if err != nil {
+		return nil, err
+	}
+	instructions, destination, err := c.ensureTokenAccount(ctx, payer, recipient, mint, programID)
+	if err != nil {
+		return nil, err
+	}
+	return append(instructions, TransferCheckedInstruction(programID, source, mint, destination, owner, r.Amount, decimals)), nil
+}
+
+// transactionSize returns the serialized size of a transaction of
+// instructions paid by feePayer, once signed.
+func transactionSize(feePayer PublicKey, instructions []Instruction) (int, error) {
+	msg, err := NewMessage(feePayer, instructions, Hash{})
+	if err != nil {
+		return 0, err
+	}
+	signers := int(msg.Header.NumRequiredSignatures)
+	return len(appendShortVec(nil, signers)) + signers*64 + len(msg.Serialize()), nil
+}
@@ -0,0 +1,90 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestBatchTransferTokens(t *testing.T) {
+	mint, _ := NewWallet()
+	other, _ := NewWallet()
+	foreign, _ := NewWallet() // a token account of another mint
+	data := make([]byte, TokenAccountSize)
+	otherKey := other.Key()
+	copy(data, otherKey[:])
+
+	var sizes []int
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getLatestBlockhash": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{
+				"blockhash":            SystemProgramID.String(),
+				"lastValidBlockHeight": 100,
+			}), nil
+		},
+		"getTokenSupply": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{"amount": "0", "decimals": 6}), nil
+		},
+		"getAccountInfo": func(params json.RawMessage) (interface{}, error) {
+			var p []interface{}
+			json.Unmarshal(params, &p)
+			if p[0] == foreign.PublicKey() {
+				return withContext(map[string]interface{}{
+					"lamports": 1,
+					"owner":    TokenProgramID.String(),
+					"data":     []string{base64.StdEncoding.EncodeToString(data), "base64"},
+				}), nil
+			}
+			return withContext(nil), nil
+		},
+		"sendTransaction": func(params json.RawMessage) (interface{}, error) {
+			var p []string
+			json.Unmarshal(params, &p)
+			raw, _ := base64.StdEncoding.DecodeString(p[0])
+			if sizes = append(sizes, len(raw)); len(sizes) == 2 {
+				return nil, &RPCError{Code: -32002, Message: "Transaction simulation failed"}
+			}
+			return "sig", nil
+		},
+	})
+	client := rpc.client(t)
+	var audited int
+	WithAuditHook(func(context.Context, AuditEvent) { audited++ })(client)
+	owner, _ := client.CreateWallet()
+	client.SetPayer(owner)
+
+	recipients := []RecipientAmount{{Recipient: "not-an-address", Amount: 1}, {Recipient: foreign.PublicKey(), Amount: 1}}
+	for i := 0; i < 40; i++ {
+		w, _ := NewWallet()
+		recipients = append(recipients, RecipientAmount{Recipient: w.PublicKey(), Amount: uint64(i + 1)})
+	}
+	results, err := client.BatchTransferTokens(context.Background(), mint.PublicKey(), owner.PublicKey(), recipients)
+	if err != nil {
+		t.Fatalf("BatchTransferTokens: %v", err)
+	}
+
+	if len(sizes) < 3 {
+		t.Fatalf("sent %d transactions, want the 40 transfers split into several", len(sizes))
+	}
+	for i, size := range sizes {
+		if size > MaxTransactionSize {
+			t.Fatalf("transaction %d is %d bytes, over the %d byte limit", i, size, MaxTransactionSize)
+		}
+	}
+	if !errors.Is(results[0].Err, ErrInvalidAddress) || !errors.Is(results[1].Err, ErrMintMismatch) {
+		t.Fatalf("unresolvable recipients = %v, %v", results[0].Err, results[1].Err)
+	}
+	// Only the second transaction's recipients failed to broadcast.
+	failed := results.Failed()
+	if len(failed) == 0 || len(failed)+len(results.Succeeded())+2 != len(recipients) {
+		t.Fatalf("%d failed and %d succeeded of %d", len(failed), len(results.Succeeded()), len(recipients))
+	}
+	if n := len(results.Signatures()); n != len(sizes)-1 {
+		t.Fatalf("%d signatures for %d successful transactions", n, len(sizes)-1)
+	}
+	if results.Err() == nil || audited != len(recipients) {
+		t.Fatalf("Err = %v, audited %d of %d", results.Err(), audited, len(recipients))
+	}
+}
@@ -0,0 +1,111 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFindAssociatedTokenAddressDependsOnProgram(t *testing.T) {
+	owner, _ := NewWallet()
+	mint, _ := NewWallet()
+
+	legacy, err := FindAssociatedTokenAddress(owner.Key(), mint.Key(), TokenProgramID)
+	if err != nil {
+		t.Fatalf("FindAssociatedTokenAddress: %v", err)
+	}
+	again, _ := FindAssociatedTokenAddress(owner.Key(), mint.Key(), TokenProgramID)
+	if legacy != again {
+		t.Fatal("derivation is not deterministic")
+	}
+	token2022, err := FindAssociatedTokenAddress(owner.Key(), mint.Key(), Token2022ProgramID)
+	if err != nil {
+		t.Fatalf("FindAssociatedTokenAddress: %v", err)
+	}
+	if legacy == token2022 {
+		t.Fatal("token program did not affect the derived address")
+	}
+	for _, key := range []PublicKey{legacy, token2022} {
+		if IsOnCurve(key) {
+			t.Fatalf("derived address %s is on the curve", key)
+		}
+	}
+	if !IsOnCurve(owner.Key()) {
+		t.Fatal("wallet public key reported off the curve")
+	}
+}
+
+func TestCreateAssociatedTokenAccountInstructionUsesProgram(t *testing.T) {
+	payer, _ := NewWallet()
+	mint, _ := NewWallet()
+
+	ix, ata, err := CreateAssociatedTokenAccountInstruction(payer.Key(), payer.Key(), mint.Key(), Token2022ProgramID)
+	if err != nil {
+		t.Fatalf("CreateAssociatedTokenAccountInstruction: %v", err)
+	}
+	if ix.Accounts[1].PublicKey != ata {
+		t.Fatal("instruction does not target the derived address")
+	}
+	if ix.Accounts[5].PublicKey != Token2022ProgramID {
+		t.Fatal("instruction does not reference the selected token program")
+	}
+}
+
+func TestEnsureTokenAccount(t *testing.T) {
+	payer, _ := NewWallet()
+	owner, _ := NewWallet()
+	mint, _ := NewWallet()
+	other, _ := NewWallet()
+	ata, _ := FindAssociatedTokenAddress(owner.Key(), mint.Key(), TokenProgramID)
+
+	tokenAccount := func(mint PublicKey) map[string]interface{} {
+		data := make([]byte, TokenAccountSize)
+		copy(data, mint[:])
+		return map[string]interface{}{
+			"lamports": 1,
+			"owner":    TokenProgramID.String(),
+			"data":     []string{base64.StdEncoding.EncodeToString(data), "base64"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing map[string]interface{}
+		creates  bool
+		err      error
+	}{
+		{name: "missing", creates: true},
+		{name: "existing", existing: tokenAccount(mint.Key())},
+		{name: "wrong mint", existing: tokenAccount(other.Key()), err: ErrMintMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpc := newFakeRPC(t, map[string]rpcHandler{
+				"getAccountInfo": func(params json.RawMessage) (interface{}, error) {
+					var p []interface{}
+					json.Unmarshal(params, &p)
+					if p[0] == ata.String() && tt.existing != nil {
+						return withContext(tt.existing), nil
+					}
+					return withContext(nil), nil
+				},
+			})
+			ixs, destination, err := rpc.client(t).ensureTokenAccount(context.Background(),
+				payer.Key(), owner.Key(), mint.Key(), TokenProgramID)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ensureTokenAccount error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if destination != ata {
+				t.Fatalf("destination = %s, want %s", destination, ata)
+			}
+			if got := len(ixs) == 1; got != tt.creates {
+				t.Fatalf("create instructions = %d, want creation %v", len(ixs), tt.creates)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package solana
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+)
+
+// PublicKey is a 32-byte Ed25519 public key or program address.
+type PublicKey [32]byte
+
+// PublicKeyFromBase58 decodes a base58 address.
+func PublicKeyFromBase58(address string) (PublicKey, error) {
+	var key PublicKey
+	if err := ValidateAddress(address); err != nil {
+		return key, err
+	}
+	decoded, _ := base58.Decode(address)
+	copy(key[:], decoded)
+	return key, nil
+}
+
+// MustPublicKey decodes a base58 address and panics if it is invalid. It is
+// intended for well-known program IDs.
+func MustPublicKey(address string) PublicKey {
+	key, err := PublicKeyFromBase58(address)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// String returns the base58 encoding of the key.
+func (p PublicKey) String() string {
+	return base58.Encode(p[:])
+}
+
+// Hash is a 32-byte SHA-256 hash such as a recent blockhash.
+type Hash [32]byte
+
+// HashFromBase58 decodes a base58 hash.
+func HashFromBase58(s string) (Hash, error) {
+	var h Hash
+	decoded, err := base58.Decode(s)
+	if err != nil {
+		return h, fmt.Errorf("decode hash %q: %w", s, err)
+	}
+	if len(decoded) != len(h) {
+		return h, fmt.Errorf("decode hash %q: length %d, want %d", s, len(decoded), len(h))
+	}
+	copy(h[:], decoded)
+	return h, nil
+}
+
+// String returns the base58 encoding of the hash.
+func (h Hash) String() string {
+	return base58.Encode(h[:])
+}
+
+// AccountMeta describes an account referenced by an instruction.
+type AccountMeta struct {
+	PublicKey  PublicKey
+	IsSigner   bool
+	IsWritable bool
+}
+
+// Instruction is a single program invocation.
+type Instruction struct {
+	ProgramID PublicKey
+	Accounts  []AccountMeta
+	Data      []byte
+}
+
+// MessageHeader describes how many of the message's accounts are signers and
+// read-only.
+type MessageHeader struct {
+	NumRequiredSignatures       uint8
+	NumReadonlySignedAccounts   uint8
+	NumReadonlyUnsignedAccounts uint8
+}
+
+// CompiledInstruction is an instruction whose accounts are indexes into the
+// message account keys.
+type CompiledInstruction struct {
+	ProgramIDIndex uint8
+	Accounts       []uint8
+	Data           []byte
+}
+
+// Message is a legacy transaction message.
+type Message struct {
+	Header          MessageHeader
+	AccountKeys     []PublicKey
+	RecentBlockhash Hash
+	Instructions    []CompiledInstruction
+}
+
+// NewMessage compiles instructions into a message paid for by feePayer.
+func NewMessage(feePayer PublicKey, instructions []Instruction, recentBlockhash Hash) (*Message, error) {
+	if len(instructions) == 0 {
+		return nil, errors.New("message has no instructions")
+	}
+
+	type keyMeta struct {
+		signer   bool
+		writable bool
+	}
+	order := []PublicKey{feePayer}
+	metas := map[PublicKey]*keyMeta{feePayer: {signer: true, writable: true}}
+
+	add := func(key PublicKey, signer, writable bool) {
+		if m, ok := metas[key]; ok {
+			m.signer = m.signer || signer
+			m.writable = m.writable || writable
+			return
+		}
+		metas[key] = &keyMeta{signer: signer, writable: writable}
+		order = append(order, key)
+	}
+	for _, ix := range instructions {
+		for _, acc := range ix.Accounts {
+			add(acc.PublicKey, acc.IsSigner, acc.IsWritable)
+		}
+		add(ix.ProgramID, false, false)
+	}
+
+	// Accounts are ordered: writable signers, read-only signers, writable
+	// non-signers, read-only non-signers. The fee payer stays first.
+	var groups [4][]PublicKey
+	for _, key := range order {
+		m := metas[key]
+		switch {
+		case m.signer && m.writable:
+			groups[0] = append(groups[0], key)
+		case m.signer:
+			groups[1] = append(groups[1], key)
+		case m.writable:
+			groups[2] = append(groups[2], key)
+		default:
+			groups[3] = append(groups[3], key)
+		}
+	}
+
+	msg := &Message{RecentBlockhash: recentBlockhash}
+	for _, g := range groups {
+		msg.AccountKeys = append(msg.AccountKeys, g...)
+	}
+	if len(msg.AccountKeys) > 256 {
+		return nil, fmt.Errorf("message references %d accounts, max 256", len(msg.AccountKeys))
+	}
+	msg.Header = MessageHeader{
+		NumRequiredSignatures:       uint8(len(groups[0]) + len(groups[1])),
+		NumReadonlySignedAccounts:   uint8(len(groups[1])),
+		NumReadonlyUnsignedAccounts: uint8(len(groups[3])),
+	}
+
+	index := make(map[PublicKey]uint8, len(msg.AccountKeys))
+	for i, key := range msg.AccountKeys {
+		index[key] = uint8(i)
+	}
+	for _, ix := range instructions {
+		compiled := CompiledInstruction{
+			ProgramIDIndex: index[ix.ProgramID],
+			Accounts:       make([]uint8, len(ix.Accounts)),
+			Data:           ix.Data,
+		}
+		for i, acc := range ix.Accounts {
+			compiled.Accounts[i] = index[acc.PublicKey]
+		}
+		msg.Instructions = append(msg.Instructions, compiled)
+	}
+	return msg, nil
+}
+
+// Signers returns the account keys that must sign the message, in order.
+func (m *Message) Signers() []PublicKey {
+	return m.AccountKeys[:m.Header.NumRequiredSignatures]
+}
+
+// Serialize encodes the message in the wire format that is signed.
+func (m *Message) Serialize() []byte {
+	buf := []byte{
+		m.Header.NumRequiredSignatures,
+		m.Header.NumReadonlySignedAccounts,
+		m.Header.NumReadonlyUnsignedAccounts,
+	}
+	buf = appendShortVec(buf, len(m.AccountKeys))
+	for _, key := range m.AccountKeys {
+		buf = append(buf, key[:]...)
+	}
+	buf = append(buf, m.RecentBlockhash[:]...)
+	buf = appendShortVec(buf, len(m.Instructions))
+	for _, ix := range m.Instructions {
+		buf = append(buf, ix.ProgramIDIndex)
+		buf = appendShortVec(buf, len(ix.Accounts))
+		buf = append(buf, ix.Accounts...)
+		buf = appendShortVec(buf, len(ix.Data))
+		buf = append(buf, ix.Data...)
+	}
+	return buf
+}
+
+// Transaction is a message plus one signature per required signer.
+type Transaction struct {
+	Signatures [][]byte
+	Message    *Message
+}
+
+// NewTransaction creates an unsigned transaction for msg.
+func NewTransaction(msg *Message) *Transaction {
+	return &Transaction{
+		Signatures: make([][]byte, msg.Header.NumRequiredSignatures),
+		Message:    msg,
+	}
+}
+
+// Sign signs the transaction with each signer, each of which must be a
+// required signer. A signer that fails or returns a signature that does not
+// verify fails the call.
+func (tx *Transaction) Sign(signers ...Signer) error {
+	data := tx.Message.Serialize()
+	required := tx.Message.Signers()
+	for _, signer := range signers {
+		key, err := signerKey(signer)
+		if err != nil {
+			return err
+		}
+		index := -1
+		for i, k := range required {
+			if k == key {
+				index = i
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("wallet %s is not a signer of this transaction", key)
+		}
+		if tx.Signatures[index], err = signWith(signer, key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Signature returns the first signature, which identifies the transaction.
+func (tx *Transaction) Signature() string {
+	if len(tx.Signatures) == 0 || tx.Signatures[0] == nil {
+		return ""
+	}
+	return base58.Encode(tx.Signatures[0])
+}
+
+// Serialize encodes the signed transaction for submission. It fails if any
+// required signature is missing.
+func (tx *Transaction) Serialize() ([]byte, error) {
+	signers := tx.Message.Signers()
+	var buf []byte
+	buf = appendShortVec(buf, len(tx.Signatures))
+	for i, sig := range tx.Signatures {
+		if len(sig) != 64 {
+			return nil, fmt.Errorf("%w: %s", ErrMissingSignature, signers[i])
+		}
+		buf = append(buf, sig...)
+	}
+	return append(buf, tx.Message.Serialize()...), nil
+}
+
+// appendShortVec appends n in Solana's compact-u16 encoding.
+func appendShortVec(buf []byte, n int) []byte {
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(buf, b)
+		}
+		buf = append(buf, b|0x80)
+	}
+}
+
+func putUint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func putUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
@@ -0,0 +1,149 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// DefaultAccountPollInterval is how often WaitForAccountCondition reads the
+// account when it has no subscription.
+const DefaultAccountPollInterval = 2 * time.Second
+
+// WaitOption configures WaitForAccountCondition.
+type WaitOption func(*waitOptions)
+
+type waitOptions struct {
+	pollInterval time.Duration
+	subscribe    bool
+}
+
+// WithPollInterval sets how often the account is read while polling. A
+// non-positive interval uses DefaultAccountPollInterval.
+func WithPollInterval(interval time.Duration) WaitOption {
+	return func(o *waitOptions) {
+		if interval > 0 {
+			o.pollInterval = interval
+		}
+	}
+}
+
+// WithoutSubscription makes WaitForAccountCondition poll only, e.g. against
+// a node whose WebSocket is unreliable.
+func WithoutSubscription() WaitOption {
+	return func(o *waitOptions) {
+		o.subscribe = false
+	}
+}
+
+// WaitForAccountCondition blocks until predicate reports true for the
+// account at address, or ctx is done. predicate is called with the account
+// as it is now and after every change; it receives nil while the account
+// does not exist, so it can wait for an account to be created or closed.
+//
+// Changes arrive through an account subscription when the WebSocket is
+// available, and the account is then read only if notifications were
+// dropped. Otherwise, or once the subscription ends, the account is polled
+// every DefaultAccountPollInterval or the interval set with
+// WithPollInterval. RPC errors other than ErrAccountNotFound end the wait.
+func (c *Client) WaitForAccountCondition(ctx context.Context, address string, predicate func(*AccountInfo) bool, opts ...WaitOption) (err error) {
+	defer wrapOp(&err, "wait for account %s", address)
+	options := waitOptions{pollInterval: DefaultAccountPollInterval, subscribe: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := ValidateAddress(address); err != nil {
+		return err
+	}
+
+	var notifications <-chan Notification
+	var sub *Subscription
+	if options.subscribe {
+		subCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		if sub, err = c.subscribe(subCtx, "accountSubscribe", "accountUnsubscribe", address, false); err != nil {
+			c.logger.Debug("Account subscription unavailable, polling", map[string]interface{}{
+				"address": address,
+				"error":   err.Error(),
+			})
+			sub = nil
+		} else {
+			notifications = sub.C()
+		}
+	}
+
+	// Read the account once even when subscribed: it may already match,
+	// and no notification comes until it changes.
+	check := func() (bool, error) {
+		info, err := c.GetAccountInfo(ctx, address)
+		if errors.Is(err, ErrAccountNotFound) {
+			return predicate(nil), nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return predicate(info), nil
+	}
+	if done, err := check(); done || err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(options.pollInterval)
+	defer ticker.Stop()
+	var dropped uint64
+	for {
+		select {
+		case n, ok := <-notifications:
+			if !ok {
+				fields := map[string]interface{}{"address": address}
+				if err := sub.Err(); err != nil {
+					fields["error"] = err.Error()
+				}
+				c.logger.Debug("Account subscription ended, polling", fields)
+				notifications, sub = nil, nil
+				continue
+			}
+			info, err := decodeAccountNotification(n)
+			if err != nil {
+				return err
+			}
+			if predicate(info) {
+				return nil
+			}
+		case <-ticker.C:
+			if sub != nil {
+				d := sub.Dropped()
+				if d == dropped {
+					continue
+				}
+				dropped = d
+			}
+			if done, err := check(); done || err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// decodeAccountNotification decodes an account subscription notification,
+// returning nil for an account that was closed.
+func decodeAccountNotification(n Notification) (*AccountInfo, error) {
+	var account *rpcAccountInfo
+	if err := json.Unmarshal(n.Value, &account); err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, nil
+	}
+	info, err := account.decode()
+	if err != nil {
+		return nil, err
+	}
+	if info.Lamports == 0 && info.Owner == SystemProgramID.String() && len(info.Data) == 0 {
+		return nil, nil
+	}
+	return info, nil
+}
@@ -0,0 +1,76 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForAccountConditionPolling(t *testing.T) {
+	var reads atomic.Int32
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getAccountInfo": func(json.RawMessage) (interface{}, error) {
+			n := reads.Add(1)
+			if n == 1 {
+				return withContext(nil), nil
+			}
+			return withContext(map[string]interface{}{
+				"lamports": n,
+				"owner":    SystemProgramID.String(),
+				"data":     []string{"", EncodingBase64},
+			}), nil
+		},
+	})
+	client := rpc.client(t)
+	wallet, _ := NewWallet()
+
+	var sawMissing bool
+	err := client.WaitForAccountCondition(context.Background(), wallet.PublicKey(), func(info *AccountInfo) bool {
+		if info == nil {
+			sawMissing = true
+			return false
+		}
+		return info.Lamports >= 3
+	}, WithPollInterval(time.Millisecond), WithoutSubscription())
+	if err != nil || !sawMissing || reads.Load() != 3 {
+		t.Fatalf("WaitForAccountCondition = %v after %d reads (missing seen: %v)", err, reads.Load(), sawMissing)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = client.WaitForAccountCondition(ctx, wallet.PublicKey(), func(*AccountInfo) bool { return false },
+		WithPollInterval(time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForAccountCondition past deadline = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestWaitForAccountConditionSubscription(t *testing.T) {
+	var unsubscribes atomic.Int32
+	rpc := newFakeRPC(t, map[string]rpcHandler{
+		"getAccountInfo": func(json.RawMessage) (interface{}, error) {
+			return withContext(map[string]interface{}{"lamports": 1, "owner": SystemProgramID.String()}), nil
+		},
+	})
+	rpc.pubsub = serveAccountPubSub(&unsubscribes)
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// The notification carries 5 lamports; polling alone never would.
+	err := client.WaitForAccountCondition(ctx, wallet.PublicKey(), func(info *AccountInfo) bool {
+		return info != nil && info.Lamports == 5
+	}, WithPollInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("WaitForAccountCondition: %v", err)
+	}
+	if n := rpc.count("getAccountInfo"); n != 1 {
+		t.Fatalf("getAccountInfo called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,69 @@
+package solana
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+)
+
+// Wallet is an Ed25519 keypair held in memory.
+type Wallet struct {
+	privateKey ed25519.PrivateKey
+	publicKey  PublicKey
+}
+
+// NewWallet generates a random wallet.
+func NewWallet() (*Wallet, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate keypair: %w", err)
+	}
+	return WalletFromPrivateKey(priv)
+}
+
+// WalletFromPrivateKey creates a wallet from a 64-byte Ed25519 private key.
+func WalletFromPrivateKey(key []byte) (*Wallet, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("private key length %d, want %d", len(key), ed25519.PrivateKeySize)
+	}
+	priv := make(ed25519.PrivateKey, ed25519.PrivateKeySize)
+	copy(priv, key)
+
+	var pub PublicKey
+	copy(pub[:], priv.Public().(ed25519.PublicKey))
+	return &Wallet{privateKey: priv, publicKey: pub}, nil
+}
+
+// PublicKey returns the base58 encoded public key.
+func (w *Wallet) PublicKey() string {
+	return w.publicKey.String()
+}
+
+// Key returns the raw public key.
+func (w *Wallet) Key() PublicKey {
+	return w.publicKey
+}
+
+// Sign signs message with the wallet's private key. It implements Signer
+// and never fails.
+func (w *Wallet) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(w.privateKey, message), nil
+}
+
+// CreateWallet generates a wallet and registers it with the client so it can
+// sign transactions sent from its address.
+func (c *Client) CreateWallet() (*Wallet, error) {
+	wallet, err := NewWallet()
+	if err != nil {
+		return nil, err
+	}
+	c.AddWallet(wallet)
+	return wallet, nil
+}
+
+// AddWallet registers an existing wallet with the client.
+func (c *Client) AddWallet(wallet *Wallet) {
+	c.walletsMu.Lock()
+	defer c.walletsMu.Unlock()
+	c.wallets[wallet.PublicKey()] = wallet
+}
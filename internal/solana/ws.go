@@ -0,0 +1,401 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+// DefaultNotificationBuffer is the per-subscription notification buffer.
+const DefaultNotificationBuffer = 64
+
+// Keepalive defaults for the PubSub connection. Load balancers commonly
+// close connections idle for 60 seconds, so pings go out well within that.
+const (
+	DefaultPingInterval = 30 * time.Second
+	DefaultPongTimeout  = 10 * time.Second
+)
+
+var (
+	// ErrSubscriptionClosed is returned when a subscription's connection
+	// closes before a notification arrives.
+	ErrSubscriptionClosed = errors.New("solana: subscription closed")
+	// ErrPongTimeout is the connection error recorded when the node stops
+	// answering pings. Subscriptions on the connection end with
+	// ErrSubscriptionClosed and the next subscribe dials a new connection.
+	ErrPongTimeout = errors.New("solana: websocket pong timeout")
+)
+
+type wsMessage struct {
+	ID     *uint64         `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+	Method string          `json:"method"`
+	Params struct {
+		Subscription uint64          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+type wsPending struct {
+	resp chan wsMessage
+	sub  *wsSubscription
+	// abandoned marks a subscribe whose caller stopped waiting. If the
+	// server acknowledges it anyway, the subscription is cancelled there.
+	abandoned bool
+}
+
+// wsConn is a JSON-RPC connection to the node's PubSub WebSocket endpoint.
+type wsConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	nextID  atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[uint64]*wsPending
+	subs    map[uint64]*wsSubscription
+	closed  bool
+	err     error
+	done    chan struct{}
+
+	keepalive wsKeepalive
+}
+
+// wsKeepalive configures pings on a connection. A non-positive interval
+// disables them.
+type wsKeepalive struct {
+	interval time.Duration
+	timeout  time.Duration
+	// onPong, if set, receives the round-trip time of each answered ping.
+	onPong func(rtt time.Duration)
+}
+
+// wsSubscription delivers notification payloads for one server subscription.
+type wsSubscription struct {
+	conn              *wsConn
+	id                uint64
+	unsubscribeMethod string
+	notifications     chan json.RawMessage
+	dropped           atomic.Uint64
+
+	// mu orders deliveries against closing the channel, which can race
+	// when a subscription is cancelled while a notification arrives.
+	mu     sync.Mutex
+	closed bool
+}
+
+// wsEndpoint returns the configured WebSocket endpoint, deriving it from the
+// HTTP endpoint when unset.
+func wsEndpoint(httpEndpoint, configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	u, err := url.Parse(httpEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("derive websocket endpoint: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+	return u.String(), nil
+}
+
+func dialWS(ctx context.Context, endpoint string, header http.Header, keepalive wsKeepalive) (*wsConn, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, endpoint, header)
+	if err != nil {
+		return nil, fmt.Errorf("dial websocket %s: %w", endpoint, err)
+	}
+	w := &wsConn{
+		conn:      conn,
+		pending:   make(map[uint64]*wsPending),
+		subs:      make(map[uint64]*wsSubscription),
+		done:      make(chan struct{}),
+		keepalive: keepalive,
+	}
+	if keepalive.interval > 0 {
+		w.extendDeadline()
+		conn.SetPongHandler(w.handlePong)
+		go w.pingLoop()
+	}
+	go w.readLoop()
+	return w, nil
+}
+
+func (w *wsConn) readLoop() {
+	for {
+		var msg wsMessage
+		if err := w.conn.ReadJSON(&msg); err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				err = fmt.Errorf("%w: silent for %s", ErrPongTimeout, w.keepalive.interval+w.keepalive.timeout)
+			}
+			w.close(err)
+			return
+		}
+		w.extendDeadline()
+
+		if msg.ID != nil {
+			w.mu.Lock()
+			p, ok := w.pending[*msg.ID]
+			delete(w.pending, *msg.ID)
+			// Register the subscription before releasing the lock so a
+			// notification that immediately follows is not lost.
+			registered := false
+			if ok && p.sub != nil && msg.Error == nil && !w.closed {
+				if err := json.Unmarshal(msg.Result, &p.sub.id); err == nil {
+					w.subs[p.sub.id] = p.sub
+					registered = true
+				}
+			}
+			abandoned := ok && p.abandoned
+			w.mu.Unlock()
+			switch {
+			case abandoned && registered:
+				// The unsubscribe's reply comes through this loop, so
+				// it cannot be awaited here.
+				go w.abandon(p.sub)
+			case ok && !abandoned:
+				p.resp <- msg
+			}
+			continue
+		}
+
+		if msg.Method != "" {
+			w.mu.Lock()
+			sub, ok := w.subs[msg.Params.Subscription]
+			w.mu.Unlock()
+			if ok {
+				sub.deliver(msg.Params.Result)
+			}
+		}
+	}
+}
+
+// extendDeadline gives the node another ping interval plus the pong timeout
+// to send something. Any message counts, so a busy connection is never
+// closed for want of a pong.
+func (w *wsConn) extendDeadline() {
+	if w.keepalive.interval > 0 {
+		w.conn.SetReadDeadline(time.Now().Add(w.keepalive.interval + w.keepalive.timeout))
+	}
+}
+
+// pingLoop pings every interval until the connection closes. The payload is
+// the send time, so the pong yields the round trip.
+func (w *wsConn) pingLoop() {
+	ticker := time.NewTicker(w.keepalive.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			payload := strconv.FormatInt(time.Now().UnixNano(), 10)
+			deadline := time.Now().Add(w.keepalive.timeout)
+			if err := w.conn.WriteControl(websocket.PingMessage, []byte(payload), deadline); err != nil {
+				w.close(fmt.Errorf("ping: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// handlePong runs on the read goroutine for each pong received.
+func (w *wsConn) handlePong(payload string) error {
+	w.extendDeadline()
+	sent, err := strconv.ParseInt(payload, 10, 64)
+	if err == nil && w.keepalive.onPong != nil {
+		w.keepalive.onPong(time.Since(time.Unix(0, sent)))
+	}
+	return nil
+}
+
+func (w *wsConn) send(ctx context.Context, method string, params []interface{}, sub *wsSubscription) (wsMessage, error) {
+	id := w.nextID.Add(1)
+	p := &wsPending{resp: make(chan wsMessage, 1), sub: sub}
+
+	w.mu.Lock()
+	if w.closed {
+		err := w.err
+		w.mu.Unlock()
+		return wsMessage{}, fmt.Errorf("%w: %v", ErrSubscriptionClosed, err)
+	}
+	w.pending[id] = p
+	w.mu.Unlock()
+
+	w.writeMu.Lock()
+	err := w.conn.WriteJSON(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	w.writeMu.Unlock()
+	if err != nil {
+		w.mu.Lock()
+		delete(w.pending, id)
+		w.mu.Unlock()
+		return wsMessage{}, fmt.Errorf("%s: %w", method, err)
+	}
+
+	select {
+	case msg := <-p.resp:
+		if msg.Error != nil {
+			return msg, msg.Error
+		}
+		return msg, nil
+	case <-w.done:
+		return wsMessage{}, fmt.Errorf("%s: %w", method, ErrSubscriptionClosed)
+	case <-ctx.Done():
+		w.mu.Lock()
+		_, waiting := w.pending[id]
+		if waiting && sub != nil {
+			// Keep the request pending so a late acknowledgement
+			// cancels the subscription on the server.
+			p.abandoned = true
+		} else {
+			delete(w.pending, id)
+		}
+		w.mu.Unlock()
+		if !waiting && sub != nil {
+			// The acknowledgement was read as ctx ended.
+			if msg := <-p.resp; msg.Error == nil {
+				go w.abandon(sub)
+			}
+		}
+		return wsMessage{}, ctx.Err()
+	}
+}
+
+// abandon cancels a subscription the server acknowledged after its caller
+// gave up waiting, so it does not stay open on the server.
+func (w *wsConn) abandon(sub *wsSubscription) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultUnsubscribeTimeout)
+	defer cancel()
+	sub.unsubscribe(ctx)
+}
+
+// subscribe issues method and returns the subscription once the server has
+// acknowledged it.
+func (w *wsConn) subscribe(ctx context.Context, method, unsubscribeMethod string, params []interface{}) (*wsSubscription, error) {
+	sub := &wsSubscription{
+		conn:              w,
+		unsubscribeMethod: unsubscribeMethod,
+		notifications:     make(chan json.RawMessage, DefaultNotificationBuffer),
+	}
+	if _, err := w.send(ctx, method, params, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (w *wsConn) close(err error) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	w.err = err
+	subs := w.subs
+	w.subs = nil
+	w.mu.Unlock()
+
+	close(w.done)
+	w.conn.Close()
+	for _, sub := range subs {
+		sub.closeChannel()
+	}
+}
+
+func (w *wsConn) alive() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.closed
+}
+
+func (s *wsSubscription) deliver(payload json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.notifications <- payload:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// unsubscribe cancels the subscription on the server and closes its channel.
+func (s *wsSubscription) unsubscribe(ctx context.Context) error {
+	s.conn.mu.Lock()
+	_, registered := s.conn.subs[s.id]
+	delete(s.conn.subs, s.id)
+	s.conn.mu.Unlock()
+	s.closeChannel()
+
+	if !registered {
+		return nil
+	}
+	_, err := s.conn.send(ctx, s.unsubscribeMethod, []interface{}{s.id}, nil)
+	return err
+}
+
+// remove forgets the subscription locally without notifying the server, for
+// subscriptions the server cancels itself.
+func (s *wsSubscription) remove() {
+	s.conn.mu.Lock()
+	delete(s.conn.subs, s.id)
+	s.conn.mu.Unlock()
+	s.closeChannel()
+}
+
+func (s *wsSubscription) closeChannel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.notifications)
+	}
+}
+
+// websocket returns the shared PubSub connection, dialing it if necessary.
+// It fails with utils.ErrDisabled when subscriptions are turned off.
+func (c *Client) websocket(ctx context.Context) (*wsConn, error) {
+	if !c.config.WebSocketEnabled() {
+		return nil, fmt.Errorf("solana websocket: %w", utils.ErrDisabled)
+	}
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+
+	if c.ws != nil && c.ws.alive() {
+		return c.ws, nil
+	}
+	endpoint, err := wsEndpoint(c.endpoint, c.config.WSEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header)
+	utils.ApplyHeaders(header, c.config.Headers, c.config.UserAgent)
+	ws, err := dialWS(ctx, endpoint, header, wsKeepalive{
+		interval: c.config.WebSocket.PingInterval,
+		timeout:  c.config.WebSocket.PongTimeout,
+		onPong:   c.metrics.pingRTT.Observe,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.ws = ws
+	return ws, nil
+}
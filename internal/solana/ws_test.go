@@ -0,0 +1,124 @@
+package solana
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/labs-alone/alone-main/internal/utils"
+)
+
+func TestWebSocketKeepalive(t *testing.T) {
+	var unsubscribes atomic.Int32
+	rpc := newFakeRPC(t, nil)
+	// The server answers pings only while it reads, which
+	// serveAccountPubSub does until the connection closes.
+	rpc.pubsub = serveAccountPubSub(&unsubscribes)
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	client.config.WebSocket.PingInterval = 10 * time.Millisecond
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	sub, err := client.SubscribeToAccountChanges(context.Background(), wallet.PublicKey())
+	if err != nil {
+		t.Fatalf("SubscribeToAccountChanges: %v", err)
+	}
+	<-sub.C()
+	deadline := time.Now().Add(time.Second)
+	for client.GetMetrics()["ws_ping_rtt"].(utils.HistogramSnapshot).Count == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no ping round trip recorded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebSocketPongTimeout(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	rpc := newFakeRPC(t, nil)
+	// Acknowledge the subscription, then stop reading so pings go
+	// unanswered.
+	rpc.pubsub = func(conn *websocket.Conn) {
+		var req struct {
+			ID uint64 `json:"id"`
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": 1})
+		<-stop
+	}
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	client.config.WebSocket.PingInterval = 10 * time.Millisecond
+	client.config.WebSocket.PongTimeout = 20 * time.Millisecond
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	sub, err := client.SubscribeToAccountChanges(context.Background(), wallet.PublicKey())
+	if err != nil {
+		t.Fatalf("SubscribeToAccountChanges: %v", err)
+	}
+	select {
+	case _, ok := <-sub.C():
+		if ok {
+			t.Fatal("unexpected notification")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dead connection was not detected")
+	}
+	if err := sub.Err(); !errors.Is(err, ErrSubscriptionClosed) || !errors.Is(err, ErrPongTimeout) {
+		t.Fatalf("Err = %v, want ErrSubscriptionClosed and ErrPongTimeout", err)
+	}
+}
+
+func TestAbandonedSubscribeIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	released := make(chan struct{})
+	unsubscribed := make(chan []interface{}, 1)
+	rpc := newFakeRPC(t, nil)
+	// Give up on the subscribe before it is acknowledged, then
+	// acknowledge it anyway.
+	rpc.pubsub = func(conn *websocket.Conn) {
+		var req struct {
+			ID     uint64        `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		cancel()
+		<-released
+		conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": 7})
+		if err := conn.ReadJSON(&req); err != nil || req.Method != "accountUnsubscribe" {
+			return
+		}
+		unsubscribed <- req.Params
+		conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": true})
+		conn.ReadJSON(&req)
+	}
+	client := rpc.client(t)
+	client.config.WSEndpoint = rpc.wsURL()
+	defer client.Close()
+	wallet, _ := NewWallet()
+
+	if _, err := client.SubscribeToAccountChanges(ctx, wallet.PublicKey()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SubscribeToAccountChanges = %v, want context.Canceled", err)
+	}
+	close(released)
+	select {
+	case params := <-unsubscribed:
+		if len(params) != 1 || params[0] != float64(7) {
+			t.Fatalf("unsubscribe params = %v, want [7]", params)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("abandoned subscription was not cancelled")
+	}
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// UnlimitedBudget is the RemainingBudget of a context without a deadline.
+const UnlimitedBudget = time.Duration(math.MaxInt64)
+
+// RemainingBudget returns the time left before ctx's deadline: zero once it
+// has passed or ctx is done, and UnlimitedBudget when ctx has no deadline.
+// Calls made with ctx share this budget, so a handler making several in
+// sequence should pass ctx, or a context derived from it, to each rather
+// than a fresh timeout, and may check the budget before starting work it
+// cannot finish in time.
+func RemainingBudget(ctx context.Context) time.Duration {
+	if ctx.Err() != nil {
+		return 0
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return UnlimitedBudget
+	}
+	if left := time.Until(deadline); left > 0 {
+		return left
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRemainingBudget(t *testing.T) {
+	if got := RemainingBudget(context.Background()); got != UnlimitedBudget {
+		t.Fatalf("no deadline: budget = %v, want UnlimitedBudget", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	if got := RemainingBudget(ctx); got <= 59*time.Second || got > time.Minute {
+		t.Fatalf("budget = %v, want about a minute", got)
+	}
+	// A second call sharing ctx sees only what the first left.
+	child, cancelChild := context.WithTimeout(ctx, time.Hour)
+	defer cancelChild()
+	if got := RemainingBudget(child); got > time.Minute {
+		t.Fatalf("child budget = %v, want at most the parent's", got)
+	}
+	cancel()
+	if got := RemainingBudget(child); got != 0 {
+		t.Fatalf("cancelled: budget = %v, want 0", got)
+	}
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errLoadPanicked is returned to GetOrLoad callers sharing a load whose
+// loader panicked.
+var errLoadPanicked = errors.New("cache loader panicked")
+
+// Cache is a concurrency-safe in-process cache with per-entry expiry and
+// least-recently-used eviction. Expired entries are dropped lazily when
+// read or when they reach the LRU tail.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	maxSize int
+	ttl     time.Duration
+	entries map[K]*list.Element
+	lru     *list.List // front is most recently used
+	loads   map[K]*cacheLoad[V]
+	now     func() time.Time
+
+	hits      uint64
+	misses    uint64
+	loadCount uint64
+	loadErrs  uint64
+	evictions uint64
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // zero never expires
+}
+
+// cacheLoad is a load in flight; concurrent GetOrLoad calls for the same
+// key wait on done instead of loading again.
+type cacheLoad[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// CacheStats summarises a Cache.
+type CacheStats struct {
+	Size       int     `json:"size"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+	Loads      uint64  `json:"loads"`
+	LoadErrors uint64  `json:"load_errors"`
+	Evictions  uint64  `json:"evictions"`
+	HitRate    float64 `json:"hit_rate"`
+}
+
+// NewCache creates a cache holding at most maxSize entries, each expiring
+// ttl after it is set. maxSize <= 0 leaves the size unbounded and ttl <= 0
+// keeps entries until evicted.
+func NewCache[K comparable, V any](maxSize int, ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		maxSize: maxSize,
+		ttl:     ttl,
+		entries: make(map[K]*list.Element),
+		lru:     list.New(),
+		loads:   make(map[K]*cacheLoad[V]),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value cached for key and whether it was present and
+// unexpired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry[K, V])
+		if entry.expires.IsZero() || c.now().Before(entry.expires) {
+			c.lru.MoveToFront(elem)
+			c.hits++
+			return entry.value, true
+		}
+		c.remove(elem)
+	}
+	c.misses++
+	var zero V
+	return zero, false
+}
+
+// Set caches value for key with the cache's default TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL caches value for key, expiring after ttl. ttl <= 0 never
+// expires.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+}
+
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry[K, V])
+		entry.value, entry.expires = value, expires
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry[K, V]{key: key, value: value, expires: expires})
+	for c.maxSize > 0 && c.lru.Len() > c.maxSize {
+		c.remove(c.lru.Back())
+		c.evictions++
+	}
+}
+
+// GetOrLoad returns the cached value for key, calling loader to fill it on
+// a miss. Concurrent calls for the same key share one loader call. Loader
+// errors are returned to every waiting caller and are not cached.
+func (c *Cache[K, V]) GetOrLoad(key K, loader func() (V, error)) (V, error) {
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return value, nil
+	}
+	if load, ok := c.loads[key]; ok {
+		c.mu.Unlock()
+		<-load.done
+		return load.value, load.err
+	}
+	load := &cacheLoad[V]{done: make(chan struct{})}
+	c.loads[key] = load
+	c.loadCount++
+	c.mu.Unlock()
+
+	// Release the waiters even if loader panics.
+	defer func() {
+		c.mu.Lock()
+		delete(c.loads, key)
+		if load.err != nil {
+			c.loadErrs++
+		} else {
+			c.set(key, load.value, c.ttl)
+		}
+		c.mu.Unlock()
+		close(load.done)
+	}()
+	load.err = errLoadPanicked
+	load.value, load.err = loader()
+	return load.value, load.err
+}
+
+// Delete removes key.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Purge removes every entry. Loads in flight still complete and cache
+// their results.
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[K]*list.Element)
+	c.lru.Init()
+}
+
+// Len returns the number of entries, including expired ones not yet
+// dropped.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *Cache[K, V]) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry[K, V]).key)
+}
+
+// Stats returns hit, miss, load, and eviction counts. A GetOrLoad that
+// finds the key counts as a hit; one that loads or waits on a load counts
+// as a miss.
+func (c *Cache[K, V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := CacheStats{
+		Size:       c.lru.Len(),
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Loads:      c.loadCount,
+		LoadErrors: c.loadErrs,
+		Evictions:  c.evictions,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
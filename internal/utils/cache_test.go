@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheTTLAndEviction(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := NewCache[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, time.Second)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %d, %v", v, ok)
+	}
+
+	// "b" is least recently used, so adding "c" evicts it.
+	c.Set("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("least recently used entry was not evicted")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expired entry was returned")
+	}
+	if c.Len() != 1 {
+		t.Fatalf("Len = %d, want 1 after dropping the expired entry", c.Len())
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Evictions != 1 || stats.HitRate != 1.0/3 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestCacheGetOrLoadDeduplicates(t *testing.T) {
+	c := NewCache[string, int](0, 0)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.GetOrLoad("k", loader)
+		}(i)
+	}
+	for c.Stats().Misses < uint64(len(results)) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("loader called %d times, want 1", calls.Load())
+	}
+	for i, v := range results {
+		if v != 42 {
+			t.Fatalf("result %d = %d", i, v)
+		}
+	}
+	if v, ok := c.Get("k"); !ok || v != 42 {
+		t.Fatalf("loaded value was not cached: %d, %v", v, ok)
+	}
+
+	failure := errors.New("boom")
+	if _, err := c.GetOrLoad("bad", func() (int, error) { return 0, failure }); !errors.Is(err, failure) {
+		t.Fatalf("GetOrLoad error = %v", err)
+	}
+	if _, ok := c.Get("bad"); ok {
+		t.Fatal("failed load was cached")
+	}
+	if stats := c.Stats(); stats.Loads != 2 || stats.LoadErrors != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
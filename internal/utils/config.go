@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrDisabled is returned by calls into a subsystem turned off in config.
+var ErrDisabled = errors.New("disabled in config")
+
+// Config is the top-level application configuration. Every configured
+// subsystem is enabled unless its enabled flag is set to false.
+type Config struct {
+	Engine EngineConfig  `yaml:"engine"`
+	Solana *SolanaConfig `yaml:"solana"`
+	OpenAI *OpenAIConfig `yaml:"openai"`
+	// MetricsPush pushes metrics to a collector that does not scrape.
+	MetricsPush *MetricsPushConfig `yaml:"metrics_push"`
+}
+
+// EngineConfig configures the core engine.
+type EngineConfig struct {
+	Name string `yaml:"name"`
+
+	// Workers is the number of goroutines processing submitted requests.
+	// Zero uses core.DefaultWorkers. With AdaptiveConcurrency it is the
+	// starting limit.
+	Workers int `yaml:"workers"`
+	// QueueSize bounds the submitted requests waiting for a worker. Zero
+	// uses core.DefaultQueueSize.
+	QueueSize int `yaml:"queue_size"`
+	// AdaptiveConcurrency lets the number of requests processed at once
+	// follow latency and errors instead of staying at Workers.
+	AdaptiveConcurrency AdaptiveConcurrencyConfig `yaml:"adaptive_concurrency"`
+	// MaxInFlight caps the requests processed at once, whether passed to
+	// Process or submitted, across all workers. Zero leaves it uncapped.
+	MaxInFlight int `yaml:"max_in_flight"`
+	// InFlightPolicy is what happens to a request arriving at the
+	// MaxInFlight cap: "block" waits for a slot, bounded by the request's
+	// context, and "reject" fails it at once. Empty means "block".
+	InFlightPolicy string `yaml:"in_flight_policy"`
+	// LoadShedding rejects low-priority submissions while the queue is
+	// backed up.
+	LoadShedding LoadSheddingConfig `yaml:"load_shedding"`
+	// Preflight configures the dependency checks the engine registers
+	// for the Solana node, the OpenAI API, and the state store.
+	Preflight PreflightConfig `yaml:"preflight"`
+
+	// RequestTimeout bounds the processing of every request. Zero leaves
+	// requests bounded only by their callers' contexts.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// RequestTimeouts overrides RequestTimeout for the request types it
+	// lists; a zero entry exempts its type.
+	RequestTimeouts map[string]time.Duration `yaml:"request_timeouts"`
+
+	// MetricLabels whitelists the request labels that segment engine
+	// metrics. "type" refers to the request type. Empty means ["type"].
+	MetricLabels []string `yaml:"metric_labels"`
+	// LatencyBuckets are the request latency histogram upper bounds in
+	// seconds. Empty uses DefaultLatencyBuckets.
+	LatencyBuckets []float64 `yaml:"latency_buckets"`
+	// MetricsWindow is the span the "window" metrics count requests and
+	// failures over. Zero uses DefaultMetricsWindow.
+	MetricsWindow time.Duration `yaml:"metrics_window"`
+}
+
+// AdaptiveConcurrencyConfig configures the engine's adaptive concurrency
+// limit. Zero fields use the core package defaults.
+type AdaptiveConcurrencyConfig struct {
+	// Enabled turns the adaptive limit on. Off by default, leaving a fixed
+	// pool of EngineConfig.Workers.
+	Enabled bool `yaml:"enabled"`
+	// MinWorkers and MaxWorkers bound the limit. It starts at
+	// EngineConfig.Workers, clamped to these bounds.
+	MinWorkers int `yaml:"min_workers"`
+	MaxWorkers int `yaml:"max_workers"`
+	// TargetLatency is the average request latency above which the limit
+	// backs off. Zero ignores latency.
+	TargetLatency time.Duration `yaml:"target_latency"`
+	// MaxErrorRate is the fraction of failed requests above which the limit
+	// backs off.
+	MaxErrorRate float64 `yaml:"max_error_rate"`
+}
+
+// PreflightConfig configures the engine's built-in preflight checks.
+type PreflightConfig struct {
+	// OnStart runs the preflight checks in core.NewEngine, which fails if
+	// any of them does. Off by default, leaving it to the caller to run
+	// Engine.Preflight.
+	OnStart bool `yaml:"on_start"`
+	// Timeout bounds each built-in check. Zero uses
+	// core.DefaultPreflightTimeout.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// LoadSheddingConfig configures the engine's load shedding. Zero fields use
+// the core package defaults.
+type LoadSheddingConfig struct {
+	// Enabled turns load shedding on. Off by default, so submissions are
+	// refused only once the queue is full.
+	Enabled bool `yaml:"enabled"`
+	// HighWater is the queue depth at which shedding starts, and LowWater
+	// the depth at or below which it stops again.
+	HighWater int `yaml:"high_water"`
+	LowWater  int `yaml:"low_water"`
+	// ShedPriorities lists the request priorities rejected while shedding
+	// ("low", "normal", "high", or "critical"). Empty means low and
+	// normal.
+	ShedPriorities []string `yaml:"shed_priorities"`
+}
+
+// SolanaConfig configures the Solana RPC client.
+type SolanaConfig struct {
+	// Cluster names a known network ("mainnet-beta", "devnet", "testnet",
+	// or "localnet") whose public endpoints are used when Endpoint is
+	// unset.
+	Cluster    string `yaml:"cluster"`
+	Endpoint   string `yaml:"endpoint"`
+	WSEndpoint string `yaml:"ws_endpoint"`
+	Commitment string `yaml:"commitment"`
+	// ExpectedGenesis, when set, makes NewClient check that every endpoint
+	// serves this network and fail otherwise. It is a genesis hash or the
+	// name of a public cluster ("mainnet-beta", "devnet", or "testnet").
+	ExpectedGenesis string `yaml:"expected_genesis"`
+
+	// FallbackEndpoints are further RPC endpoints, e.g. in other regions,
+	// tried when Endpoint fails to respond. Subscriptions always use
+	// Endpoint.
+	FallbackEndpoints []string `yaml:"fallback_endpoints"`
+	// Routing chooses among Endpoint and FallbackEndpoints.
+	Routing RoutingConfig `yaml:"routing"`
+
+	// WebSocket configures PubSub subscriptions.
+	WebSocket WebSocketConfig `yaml:"websocket"`
+	// Airdrop paces and retries faucet requests.
+	Airdrop AirdropConfig `yaml:"airdrop"`
+	// Compression enables gzip on RPC bodies. It is off by default.
+	Compression CompressionConfig `yaml:"compression"`
+
+	// DialTimeout bounds establishing a TCP connection to the RPC node.
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+	// RequestTimeout bounds a single RPC call when the caller's context
+	// carries no deadline of its own.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// KeepAlive is the TCP keep-alive period for pooled connections.
+	KeepAlive time.Duration `yaml:"keep_alive"`
+	// IdleConnTimeout is how long an idle pooled connection is kept.
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout"`
+	// MaxIdleConns caps idle connections across all hosts.
+	MaxIdleConns int `yaml:"max_idle_conns"`
+	// MaxConnsPerHost caps total connections to a single host.
+	MaxConnsPerHost int `yaml:"max_conns_per_host"`
+
+	// LatencyBuckets are the RPC latency histogram upper bounds in seconds.
+	// Empty uses utils.DefaultLatencyBuckets.
+	LatencyBuckets []float64 `yaml:"latency_buckets"`
+	// MetricsWindow is the span the "window" metrics count calls and
+	// errors over. Zero uses DefaultMetricsWindow.
+	MetricsWindow time.Duration `yaml:"metrics_window"`
+
+	// UserAgent, when set, is sent as the User-Agent of every RPC request
+	// and the WebSocket handshake, overriding any User-Agent in Headers.
+	UserAgent string `yaml:"user_agent"`
+	// Headers are added to every RPC request and the WebSocket handshake.
+	// Content-Type and the WebSocket handshake headers are set by the
+	// client and cannot be overridden here.
+	Headers map[string]string `yaml:"headers"`
+}
+
+// RoutingConfig configures how the Solana client picks an RPC endpoint.
+type RoutingConfig struct {
+	// Strategy is "failover" (the default), which prefers endpoints in
+	// configured order, or "latency", which prefers the healthy endpoint
+	// with the lowest probed latency.
+	Strategy string `yaml:"strategy"`
+	// ProbeInterval is how often the "latency" strategy probes each
+	// endpoint with getHealth.
+	ProbeInterval time.Duration `yaml:"probe_interval"`
+	// Cooldown is how long an endpoint that failed a call is tried only
+	// after the others.
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+// CompressionConfig configures gzip on Solana RPC bodies.
+type CompressionConfig struct {
+	// Responses asks for gzip responses and decompresses them, counting
+	// the bytes saved. Nodes that ignore the header answer uncompressed.
+	Responses bool `yaml:"responses"`
+	// Requests gzips request bodies of at least MinRequestSize bytes. Not
+	// every provider accepts them; after one rejects a compressed body
+	// with 415 Unsupported Media Type, the client sends plain bodies.
+	Requests       bool `yaml:"requests"`
+	MinRequestSize int  `yaml:"min_request_size"`
+}
+
+// AirdropConfig configures RequestAirdrop and FundWallet. Zero fields use
+// the solana package defaults.
+type AirdropConfig struct {
+	// Spacing is the minimum time between airdrop requests.
+	Spacing time.Duration `yaml:"spacing"`
+	// MaxRetries is how many times a rate-limited airdrop is retried.
+	// Negative disables retries.
+	MaxRetries int `yaml:"max_retries"`
+	// Backoff is the wait before the first retry; it doubles up to
+	// MaxBackoff.
+	Backoff    time.Duration `yaml:"backoff"`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// AttemptTimeout bounds a single faucet request.
+	AttemptTimeout time.Duration `yaml:"attempt_timeout"`
+	// FundAttempts is how many times FundWallet runs the whole
+	// airdrop, confirm, and verify sequence before giving up.
+	FundAttempts int `yaml:"fund_attempts"`
+	// ConfirmTimeout bounds FundWallet's wait for an airdrop to confirm.
+	ConfirmTimeout time.Duration `yaml:"confirm_timeout"`
+}
+
+// WebSocketConfig configures the Solana PubSub connection.
+type WebSocketConfig struct {
+	// Enabled turns subscriptions on or off. Unset means enabled; when
+	// false the client never dials and subscribing fails with ErrDisabled.
+	Enabled *bool `yaml:"enabled"`
+
+	// PingInterval is how often the client pings the node so proxies and
+	// load balancers do not close the connection as idle. Zero uses
+	// solana.DefaultPingInterval; negative disables pings.
+	PingInterval time.Duration `yaml:"ping_interval"`
+	// PongTimeout is how long after a ping the connection may stay silent
+	// before it is considered dead and closed. Zero uses
+	// solana.DefaultPongTimeout.
+	PongTimeout time.Duration `yaml:"pong_timeout"`
+}
+
+// WebSocketEnabled reports whether PubSub subscriptions are enabled.
+func (c *SolanaConfig) WebSocketEnabled() bool {
+	return c == nil || c.WebSocket.Enabled == nil || *c.WebSocket.Enabled
+}
+
+// OpenAIConfig configures the OpenAI client.
+type OpenAIConfig struct {
+	// Enabled turns the OpenAI integration on or off. Unset means enabled
+	// when the openai section is present.
+	Enabled *bool `yaml:"enabled"`
+
+	APIKey  string        `yaml:"api_key"`
+	BaseURL string        `yaml:"base_url"`
+	Model   string        `yaml:"model"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// IsEnabled reports whether the OpenAI integration is configured and not
+// turned off.
+func (c *OpenAIConfig) IsEnabled() bool {
+	return c != nil && (c.Enabled == nil || *c.Enabled)
+}
+
+// MetricsPushConfig configures a MetricsPusher.
+type MetricsPushConfig struct {
+	// Enabled turns pushing on or off. Unset means enabled when the
+	// metrics_push section is present.
+	Enabled *bool `yaml:"enabled"`
+
+	// Protocol is "statsd", sent over UDP with DogStatsD tags, or "otlp",
+	// sent as OTLP/HTTP JSON.
+	Protocol string `yaml:"protocol"`
+	// Endpoint is the StatsD host:port, or the OTLP metrics URL such as
+	// http://localhost:4318/v1/metrics.
+	Endpoint string `yaml:"endpoint"`
+	// Interval is how often metrics are pushed. Zero uses
+	// DefaultMetricsPushInterval.
+	Interval time.Duration `yaml:"interval"`
+	// Prefix is prepended, followed by a dot, to StatsD metric names.
+	Prefix string `yaml:"prefix"`
+	// ServiceName is the OTLP service.name resource attribute.
+	ServiceName string `yaml:"service_name"`
+	// Headers are added to OTLP requests, e.g. for authentication.
+	Headers map[string]string `yaml:"headers"`
+}
+
+// IsEnabled reports whether metrics pushing is configured and not turned
+// off.
+func (c *MetricsPushConfig) IsEnabled() bool {
+	return c != nil && (c.Enabled == nil || *c.Enabled)
+}
+
+// LoadConfig reads a YAML configuration file. The OPENAI_API_KEY
+// environment variable overrides openai.api_key when set.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+		if config.OpenAI == nil {
+			config.OpenAI = &OpenAIConfig{}
+		}
+		config.OpenAI.APIKey = key
+	}
+
+	return config, nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ValidateHeaders returns an error naming the first entry of headers that
+// matches one of the reserved header names, compared case-insensitively.
+// Clients use it to refuse custom headers that would replace ones they set
+// themselves, such as Authorization.
+func ValidateHeaders(headers map[string]string, reserved ...string) error {
+	for name := range headers {
+		canonical := http.CanonicalHeaderKey(name)
+		for _, r := range reserved {
+			if canonical == http.CanonicalHeaderKey(r) {
+				return fmt.Errorf("header %q is reserved", name)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyHeaders sets headers on h, then sets User-Agent to userAgent when it
+// is non-empty, so the dedicated field takes precedence over a User-Agent
+// entry in headers.
+func ApplyHeaders(h http.Header, headers map[string]string, userAgent string) {
+	for name, value := range headers {
+		h.Set(name, value)
+	}
+	if userAgent != "" {
+		h.Set("User-Agent", userAgent)
+	}
+}
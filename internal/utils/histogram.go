@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are histogram upper bounds in seconds suited to
+// network calls, from 5ms to 30s.
+var DefaultLatencyBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30,
+}
+
+// Histogram counts observations into fixed buckets. Memory use is bounded by
+// the number of buckets regardless of how many samples are observed.
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64 // len(bounds)+1; the last bucket is +Inf
+	count  uint64
+	sum    float64
+}
+
+// HistogramSnapshot is a point-in-time copy of a Histogram with estimated
+// percentiles. Counts are cumulative, matching Prometheus buckets.
+type HistogramSnapshot struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []uint64  `json:"counts"`
+	Count  uint64    `json:"count"`
+	Sum    float64   `json:"sum"`
+	P50    float64   `json:"p50"`
+	P90    float64   `json:"p90"`
+	P99    float64   `json:"p99"`
+}
+
+// NewHistogram creates a histogram with the given upper bounds in seconds.
+// Bounds are sorted and de-duplicated; nil or empty uses
+// DefaultLatencyBuckets.
+func NewHistogram(bounds []float64) *Histogram {
+	if len(bounds) == 0 {
+		bounds = DefaultLatencyBuckets
+	}
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+	uniq := sorted[:0]
+	for i, b := range sorted {
+		if i == 0 || b != sorted[i-1] {
+			uniq = append(uniq, b)
+		}
+	}
+	return &Histogram{
+		bounds: uniq,
+		counts: make([]uint64, len(uniq)+1),
+	}
+}
+
+// Observe records a duration.
+func (h *Histogram) Observe(d time.Duration) {
+	h.ObserveValue(d.Seconds())
+}
+
+// ObserveValue records a value in the histogram's unit.
+func (h *Histogram) ObserveValue(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v)
+
+	h.mu.Lock()
+	h.counts[i]++
+	h.count++
+	h.sum += v
+	h.mu.Unlock()
+}
+
+// Snapshot returns cumulative bucket counts and estimated percentiles.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	raw := append([]uint64(nil), h.counts...)
+	count, sum := h.count, h.sum
+	h.mu.Unlock()
+
+	snap := HistogramSnapshot{
+		Bounds: append([]float64(nil), h.bounds...),
+		Counts: make([]uint64, len(h.bounds)),
+		Count:  count,
+		Sum:    sum,
+	}
+	var cum uint64
+	for i := range h.bounds {
+		cum += raw[i]
+		snap.Counts[i] = cum
+	}
+	snap.P50 = snap.Quantile(0.50)
+	snap.P90 = snap.Quantile(0.90)
+	snap.P99 = snap.Quantile(0.99)
+	return snap
+}
+
+// Reset clears all observations.
+func (h *Histogram) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+	h.count = 0
+	h.sum = 0
+}
+
+// Quantile estimates the q-th quantile (0 < q < 1) by linear interpolation
+// within the bucket that contains it. Values beyond the last bound report
+// the last bound.
+func (s HistogramSnapshot) Quantile(q float64) float64 {
+	if s.Count == 0 || len(s.Bounds) == 0 {
+		return 0
+	}
+	rank := q * float64(s.Count)
+	var prevCount uint64
+	lower := 0.0
+	for i, upper := range s.Bounds {
+		if float64(s.Counts[i]) >= rank {
+			inBucket := s.Counts[i] - prevCount
+			if inBucket == 0 {
+				return upper
+			}
+			return lower + (upper-lower)*(rank-float64(prevCount))/float64(inBucket)
+		}
+		prevCount = s.Counts[i]
+		lower = upper
+	}
+	return s.Bounds[len(s.Bounds)-1]
+}
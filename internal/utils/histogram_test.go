@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramSnapshot(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 0.2, 0.4})
+	for i := 0; i < 50; i++ {
+		h.Observe(50 * time.Millisecond)
+	}
+	for i := 0; i < 40; i++ {
+		h.Observe(150 * time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		h.Observe(time.Second)
+	}
+
+	snap := h.Snapshot()
+	if snap.Count != 100 {
+		t.Fatalf("Count = %d, want 100", snap.Count)
+	}
+	wantCounts := []uint64{50, 90, 90}
+	for i, c := range wantCounts {
+		if snap.Counts[i] != c {
+			t.Errorf("Counts[%d] = %d, want %d", i, snap.Counts[i], c)
+		}
+	}
+	if math.Abs(snap.P50-0.1) > 1e-9 {
+		t.Errorf("P50 = %v, want 0.1", snap.P50)
+	}
+	if math.Abs(snap.P90-0.2) > 1e-9 {
+		t.Errorf("P90 = %v, want 0.2", snap.P90)
+	}
+	if snap.P99 != 0.4 {
+		t.Errorf("P99 = %v, want last bound 0.4", snap.P99)
+	}
+}
+
+func TestHistogramEmpty(t *testing.T) {
+	snap := NewHistogram(nil).Snapshot()
+	if snap.Count != 0 || snap.P99 != 0 {
+		t.Fatalf("empty snapshot = %+v", snap)
+	}
+	if len(snap.Bounds) != len(DefaultLatencyBuckets) {
+		t.Fatalf("bounds = %d, want defaults", len(snap.Bounds))
+	}
+}
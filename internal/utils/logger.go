@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+const (
+	DEBUG Level = iota
+	INFO
+	WARN
+	ERROR
+	FATAL
+)
+
+// String returns the upper-case name of the level.
+func (l Level) String() string {
+	switch l {
+	case DEBUG:
+		return "DEBUG"
+	case INFO:
+		return "INFO"
+	case WARN:
+		return "WARN"
+	case ERROR:
+		return "ERROR"
+	case FATAL:
+		return "FATAL"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+// Logger is a leveled logger that writes one line per entry. Loggers derived
+// with Named share their parent's level and output.
+type Logger struct {
+	core   *loggerCore
+	prefix string
+	exit   func(int)
+}
+
+type loggerCore struct {
+	mu    sync.Mutex
+	level Level
+	out   io.Writer
+	json  bool
+	async *AsyncWriter
+}
+
+// LoggerOption configures a Logger.
+type LoggerOption func(*Logger)
+
+// WithLevel sets the minimum level that is written.
+func WithLevel(level Level) LoggerOption {
+	return func(l *Logger) {
+		l.core.level = level
+	}
+}
+
+// WithPrefix sets the component prefix included in every entry.
+func WithPrefix(prefix string) LoggerOption {
+	return func(l *Logger) {
+		l.prefix = prefix
+	}
+}
+
+// WithOutput sets the destination writer. The default is os.Stderr.
+func WithOutput(w io.Writer) LoggerOption {
+	return func(l *Logger) {
+		l.core.out = w
+	}
+}
+
+// WithJSON writes each entry as one JSON object per line, with "time",
+// "level", "logger", and "msg" keys alongside the entry's fields. A field
+// named like one of those keys is written as "fields.<name>".
+func WithJSON() LoggerOption {
+	return func(l *Logger) {
+		l.core.json = true
+	}
+}
+
+// WithAsyncWriter writes JSON lines to w through an AsyncWriter, so
+// logging never waits on a slow sink such as a socket or a log collector.
+// Entries are written out every cfg.FlushInterval or once a batch fills;
+// entries logged while the buffer is full are dropped and counted in
+// Dropped. Call Close on shutdown to flush what is buffered.
+func WithAsyncWriter(w io.Writer, cfg AsyncWriterConfig) LoggerOption {
+	return func(l *Logger) {
+		l.core.async = NewAsyncWriter(w, cfg)
+		l.core.out = l.core.async
+		l.core.json = true
+	}
+}
+
+// NewLogger creates a Logger. Without options it logs INFO and above to
+// os.Stderr.
+func NewLogger(opts ...LoggerOption) *Logger {
+	l := &Logger{
+		core: &loggerCore{level: INFO, out: os.Stderr},
+		exit: os.Exit,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Named returns a logger for a component that shares l's level and output.
+// Its prefix is appended to l's, e.g. "App.Solana".
+func (l *Logger) Named(name string) *Logger {
+	prefix := name
+	if l.prefix != "" {
+		prefix = l.prefix + "." + name
+	}
+	return &Logger{core: l.core, prefix: prefix, exit: l.exit}
+}
+
+// Level returns the minimum level that is written.
+func (l *Logger) Level() Level {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	return l.core.level
+}
+
+// SetLevel changes the minimum level that is written, including for every
+// logger derived with Named.
+func (l *Logger) SetLevel(level Level) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.level = level
+}
+
+// Prefix returns the component prefix.
+func (l *Logger) Prefix() string {
+	return l.prefix
+}
+
+// Enabled reports whether entries at level would be written.
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
+// Dropped returns how many entries were discarded because the writer set
+// with WithAsyncWriter fell behind, or zero without one.
+func (l *Logger) Dropped() uint64 {
+	if l.core.async == nil {
+		return 0
+	}
+	return l.core.async.Dropped()
+}
+
+// Flush writes out the entries buffered by WithAsyncWriter. It does
+// nothing without one.
+func (l *Logger) Flush() error {
+	if l.core.async == nil {
+		return nil
+	}
+	return l.core.async.Flush()
+}
+
+// Close flushes the entries buffered by WithAsyncWriter and stops its
+// background goroutine; entries logged afterwards are dropped. The
+// underlying writer is not closed. Close does nothing without an async
+// writer, and affects every logger derived with Named.
+func (l *Logger) Close() error {
+	if l.core.async == nil {
+		return nil
+	}
+	return l.core.async.Close()
+}
+
+// Debug logs at DEBUG level.
+func (l *Logger) Debug(msg string, fields map[string]interface{}) {
+	l.log(DEBUG, msg, fields)
+}
+
+// Info logs at INFO level.
+func (l *Logger) Info(msg string, fields map[string]interface{}) {
+	l.log(INFO, msg, fields)
+}
+
+// Warn logs at WARN level.
+func (l *Logger) Warn(msg string, fields map[string]interface{}) {
+	l.log(WARN, msg, fields)
+}
+
+// Error logs at ERROR level.
+func (l *Logger) Error(msg string, fields map[string]interface{}) {
+	l.log(ERROR, msg, fields)
+}
+
+// Fatal logs at FATAL level, flushes any async writer, and exits the
+// process.
+func (l *Logger) Fatal(msg string, fields map[string]interface{}) {
+	l.log(FATAL, msg, fields)
+	l.Close()
+	l.exit(1)
+}
+
+func (l *Logger) log(level Level, msg string, fields map[string]interface{}) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+
+	if level < l.core.level {
+		return
+	}
+	if l.core.json {
+		l.core.out.Write(l.jsonEntry(level, msg, fields))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(time.Now().UTC().Format(time.RFC3339))
+	b.WriteString(" [")
+	b.WriteString(level.String())
+	b.WriteString("]")
+	if l.prefix != "" {
+		b.WriteString(" [")
+		b.WriteString(l.prefix)
+		b.WriteString("]")
+	}
+	b.WriteString(" ")
+	b.WriteString(msg)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, fields[k])
+	}
+	b.WriteString("\n")
+
+	io.WriteString(l.core.out, b.String())
+}
+
+// jsonEntry renders an entry as a JSON line. Field values that cannot be
+// encoded, and errors, are written as their string form.
+func (l *Logger) jsonEntry(level Level, msg string, fields map[string]interface{}) []byte {
+	entry := make(map[string]interface{}, len(fields)+4)
+	for k, v := range fields {
+		switch v := v.(type) {
+		case error:
+			entry[k] = v.Error()
+		case fmt.Stringer:
+			entry[k] = v.String()
+		default:
+			if _, err := json.Marshal(v); err != nil {
+				entry[k] = fmt.Sprint(v)
+			} else {
+				entry[k] = v
+			}
+		}
+	}
+	for _, k := range []string{"time", "level", "logger", "msg"} {
+		if v, ok := entry[k]; ok {
+			entry["fields."+k] = v
+		}
+	}
+	entry["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+	if l.prefix != "" {
+		entry["logger"] = l.prefix
+	} else {
+		delete(entry, "logger")
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(map[string]string{"level": level.String(), "msg": msg, "error": err.Error()})
+	}
+	return append(line, '\n')
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = NewLogger()
+)
+
+// DefaultLogger returns the process-wide logger used by components that are
+// not given one explicitly.
+func DefaultLogger() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+// SetDefaultLogger replaces the process-wide logger. Components created
+// afterwards derive their loggers from it.
+func SetDefaultLogger(l *Logger) {
+	if l == nil {
+		return
+	}
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+// Debug logs at DEBUG level on the default logger.
+func Debug(msg string, fields map[string]interface{}) {
+	DefaultLogger().Debug(msg, fields)
+}
+
+// Info logs at INFO level on the default logger.
+func Info(msg string, fields map[string]interface{}) {
+	DefaultLogger().Info(msg, fields)
+}
+
+// Warn logs at WARN level on the default logger.
+func Warn(msg string, fields map[string]interface{}) {
+	DefaultLogger().Warn(msg, fields)
+}
+
+// Error logs at ERROR level on the default logger.
+func Error(msg string, fields map[string]interface{}) {
+	DefaultLogger().Error(msg, fields)
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLoggerNamedSharesLevelAndOutput(t *testing.T) {
+	var buf bytes.Buffer
+	root := NewLogger(WithOutput(&buf), WithPrefix("App"), WithLevel(WARN))
+	child := root.Named("Solana")
+
+	child.Info("hidden", nil)
+	root.SetLevel(INFO)
+	child.Info("shown", map[string]interface{}{"b": 2, "a": 1})
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Fatalf("entry below level was written: %q", out)
+	}
+	if !strings.Contains(out, "[INFO] [App.Solana] shown a=1 b=2") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	prev := DefaultLogger()
+	defer SetDefaultLogger(prev)
+
+	var buf bytes.Buffer
+	SetDefaultLogger(NewLogger(WithOutput(&buf)))
+	Warn("careful", nil)
+	if !strings.Contains(buf.String(), "[WARN] careful") {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+}
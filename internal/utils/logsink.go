@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Async log writer defaults applied when AsyncWriterConfig leaves them
+// unset.
+const (
+	DefaultAsyncBufferSize    = 1024
+	DefaultAsyncFlushInterval = time.Second
+	DefaultAsyncBatchBytes    = 64 << 10
+)
+
+// ErrWriterClosed is returned by writes to a closed AsyncWriter.
+var ErrWriterClosed = errors.New("async writer closed")
+
+// AsyncWriterConfig configures an AsyncWriter.
+type AsyncWriterConfig struct {
+	// BufferSize is how many entries may wait for the background writer.
+	// Entries written while it is full are dropped.
+	BufferSize int
+	// FlushInterval is how often buffered entries are written out.
+	FlushInterval time.Duration
+	// BatchBytes is the batch size at which entries are written out
+	// before the interval ends.
+	BatchBytes int
+}
+
+// AsyncWriter buffers writes and passes them to an underlying writer from a
+// background goroutine, in batches, so a slow sink such as a socket or an
+// HTTP collector never blocks the writer. When the buffer is full, writes
+// are dropped and counted instead of waiting. Close flushes what is
+// buffered.
+type AsyncWriter struct {
+	out           io.Writer
+	entries       chan []byte
+	flushes       chan chan error
+	flushInterval time.Duration
+	batchBytes    int
+
+	dropped   atomic.Uint64
+	closeOnce sync.Once
+	closing   chan struct{}
+	done      chan struct{}
+	err       error // written by run before done is closed
+}
+
+// NewAsyncWriter starts an AsyncWriter writing to out.
+func NewAsyncWriter(out io.Writer, cfg AsyncWriterConfig) *AsyncWriter {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultAsyncBufferSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultAsyncFlushInterval
+	}
+	if cfg.BatchBytes <= 0 {
+		cfg.BatchBytes = DefaultAsyncBatchBytes
+	}
+	w := &AsyncWriter{
+		out:           out,
+		entries:       make(chan []byte, cfg.BufferSize),
+		flushes:       make(chan chan error),
+		flushInterval: cfg.FlushInterval,
+		batchBytes:    cfg.BatchBytes,
+		closing:       make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p. It never blocks: if the buffer is full, p is
+// dropped and counted, and Write still reports success so callers such as
+// Logger carry on.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	select {
+	case <-w.closing:
+		w.dropped.Add(1)
+		return 0, ErrWriterClosed
+	default:
+	}
+	select {
+	case w.entries <- append([]byte(nil), p...):
+	default:
+		w.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Flush writes out everything queued so far and returns the underlying
+// writer's first error since the last flush.
+func (w *AsyncWriter) Flush() error {
+	ack := make(chan error, 1)
+	select {
+	case w.flushes <- ack:
+		return <-ack
+	case <-w.done:
+		return w.err
+	}
+}
+
+// Close flushes the buffered entries and stops the background goroutine.
+// Later writes fail with ErrWriterClosed. Close does not close the
+// underlying writer.
+func (w *AsyncWriter) Close() error {
+	w.closeOnce.Do(func() { close(w.closing) })
+	<-w.done
+	return w.err
+}
+
+// Dropped returns how many writes were discarded because the buffer was
+// full or the writer closed.
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	var (
+		batch bytes.Buffer
+		err   error
+	)
+	flush := func() {
+		if batch.Len() == 0 {
+			return
+		}
+		if _, werr := w.out.Write(batch.Bytes()); werr != nil && err == nil {
+			err = werr
+		}
+		batch.Reset()
+	}
+	// drain moves the queued entries into the batch without waiting.
+	drain := func() {
+		for {
+			select {
+			case entry := <-w.entries:
+				batch.Write(entry)
+				if batch.Len() >= w.batchBytes {
+					flush()
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case entry := <-w.entries:
+			batch.Write(entry)
+			if batch.Len() >= w.batchBytes {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case ack := <-w.flushes:
+			drain()
+			flush()
+			ack <- err
+			err = nil
+		case <-w.closing:
+			drain()
+			flush()
+			w.err = err
+			return
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter holds every write until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestLoggerAsyncWriter(t *testing.T) {
+	sink := &blockingWriter{release: make(chan struct{})}
+	logger := NewLogger(WithPrefix("App"), WithAsyncWriter(sink, AsyncWriterConfig{
+		BufferSize:    4,
+		FlushInterval: time.Hour,
+		BatchBytes:    1,
+	}))
+
+	// The first entry is taken by the background writer, which blocks on
+	// the sink; four more fill the buffer and the rest are dropped.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			logger.Named("Solana").Info("tick", map[string]interface{}{"i": i, "err": errors.New("boom")})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("logging blocked on a slow sink")
+	}
+	if dropped := logger.Dropped(); dropped < 15 {
+		t.Fatalf("Dropped = %d, want at least 15", dropped)
+	}
+
+	close(sink.release)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	if len(lines) != 20-int(logger.Dropped()) {
+		t.Fatalf("wrote %d lines with %d dropped", len(lines), logger.Dropped())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("line %q is not JSON: %v", lines[0], err)
+	}
+	if entry["level"] != "INFO" || entry["logger"] != "App.Solana" || entry["msg"] != "tick" ||
+		entry["i"] != float64(0) || entry["err"] != "boom" {
+		t.Fatalf("entry = %v", entry)
+	}
+
+	logger.Info("after close", nil)
+	if strings.Contains(sink.String(), "after close") {
+		t.Fatal("entry written after Close")
+	}
+}
+
+func TestAsyncWriterFlushesOnInterval(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		buf bytes.Buffer
+	)
+	w := NewAsyncWriter(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return buf.Write(p)
+	}), AsyncWriterConfig{FlushInterval: 5 * time.Millisecond})
+	defer w.Close()
+
+	w.Write([]byte("a\n"))
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		got := buf.String()
+		mu.Unlock()
+		if got == "a\n" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("buffered entry not flushed on the interval, got %q", got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
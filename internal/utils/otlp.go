@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// OTLP aggregation temporality; metrics here accumulate since start.
+const otlpCumulative = 2
+
+// OTLPExporter sends metrics to an OpenTelemetry collector as OTLP/HTTP
+// JSON. Counters become monotonic cumulative sums, gauges gauges, and
+// histograms cumulative explicit-bucket histograms, all starting when the
+// exporter was created.
+type OTLPExporter struct {
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	httpClient  *http.Client
+	start       time.Time
+}
+
+// NewOTLPExporter creates an exporter posting to endpoint, the collector's
+// metrics URL such as http://localhost:4318/v1/metrics. serviceName, when
+// set, is sent as the service.name resource attribute, and headers are
+// added to every request.
+func NewOTLPExporter(endpoint, serviceName string, headers map[string]string) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		headers:     headers,
+		httpClient:  &http.Client{Transport: SharedTransport(TransportConfig{})},
+		start:       time.Now(),
+	}
+}
+
+// Export implements MetricExporter.
+func (e *OTLPExporter) Export(ctx context.Context, metrics []Metric) error {
+	body, err := json.Marshal(e.request(metrics, time.Now()))
+	if err != nil {
+		return fmt.Errorf("otlp: marshal metrics: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("otlp: create request: %w", err)
+	}
+	ApplyHeaders(req.Header, e.headers, "")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<12))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("otlp: status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return nil
+}
+
+// OTLP JSON encodes 64-bit integers as decimal strings.
+type (
+	otlpRequest struct {
+		ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+	}
+	otlpResourceMetrics struct {
+		Resource     otlpResource       `json:"resource"`
+		ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes,omitempty"`
+	}
+	otlpScopeMetrics struct {
+		Scope   otlpScope    `json:"scope"`
+		Metrics []otlpMetric `json:"metrics"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpMetric struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		Sum         *otlpSum       `json:"sum,omitempty"`
+		Gauge       *otlpGauge     `json:"gauge,omitempty"`
+		Histogram   *otlpHistogram `json:"histogram,omitempty"`
+	}
+	otlpSum struct {
+		DataPoints             []otlpNumberPoint `json:"dataPoints"`
+		AggregationTemporality int               `json:"aggregationTemporality"`
+		IsMonotonic            bool              `json:"isMonotonic"`
+	}
+	otlpGauge struct {
+		DataPoints []otlpNumberPoint `json:"dataPoints"`
+	}
+	otlpHistogram struct {
+		DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+		AggregationTemporality int                  `json:"aggregationTemporality"`
+	}
+	otlpNumberPoint struct {
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+		TimeUnixNano      string          `json:"timeUnixNano"`
+		AsDouble          float64         `json:"asDouble"`
+	}
+	otlpHistogramPoint struct {
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		TimeUnixNano      string          `json:"timeUnixNano"`
+		Count             string          `json:"count"`
+		Sum               float64         `json:"sum"`
+		BucketCounts      []string        `json:"bucketCounts"`
+		ExplicitBounds    []float64       `json:"explicitBounds"`
+	}
+	otlpAttribute struct {
+		Key   string          `json:"key"`
+		Value otlpStringValue `json:"value"`
+	}
+	otlpStringValue struct {
+		StringValue string `json:"stringValue"`
+	}
+)
+
+// request groups metrics by name into one OTLP metric with a data point per
+// label set, in the order the names were first collected.
+func (e *OTLPExporter) request(metrics []Metric, now time.Time) otlpRequest {
+	start := strconv.FormatInt(e.start.UnixNano(), 10)
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+
+	var out []otlpMetric
+	index := make(map[string]int)
+	for _, m := range metrics {
+		i, ok := index[m.Name]
+		if !ok {
+			i = len(out)
+			index[m.Name] = i
+			metric := otlpMetric{Name: m.Name, Description: m.Help}
+			switch m.Kind {
+			case MetricCounter:
+				metric.Sum = &otlpSum{AggregationTemporality: otlpCumulative, IsMonotonic: true}
+			case MetricGauge:
+				metric.Gauge = &otlpGauge{}
+			case MetricHistogram:
+				metric.Histogram = &otlpHistogram{AggregationTemporality: otlpCumulative}
+			}
+			out = append(out, metric)
+		}
+
+		attrs := otlpAttributes(m.Labels)
+		switch metric := &out[i]; {
+		case metric.Sum != nil:
+			metric.Sum.DataPoints = append(metric.Sum.DataPoints, otlpNumberPoint{
+				Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: ts, AsDouble: m.Value,
+			})
+		case metric.Gauge != nil:
+			metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, otlpNumberPoint{
+				Attributes: attrs, TimeUnixNano: ts, AsDouble: m.Value,
+			})
+		case metric.Histogram != nil:
+			metric.Histogram.DataPoints = append(metric.Histogram.DataPoints, otlpHistogramPoint{
+				Attributes:        attrs,
+				StartTimeUnixNano: start,
+				TimeUnixNano:      ts,
+				Count:             strconv.FormatUint(m.Histogram.Count, 10),
+				Sum:               m.Histogram.Sum,
+				BucketCounts:      otlpBucketCounts(m.Histogram),
+				ExplicitBounds:    append([]float64{}, m.Histogram.Bounds...),
+			})
+		}
+	}
+
+	var resource otlpResource
+	if e.serviceName != "" {
+		resource.Attributes = otlpAttributes(map[string]string{"service.name": e.serviceName})
+	}
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     resource,
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: "alone"}, Metrics: out}},
+	}}}
+}
+
+// otlpBucketCounts converts the cumulative counts of snap to the per-bucket
+// counts OTLP expects, with a final bucket above the last bound.
+func otlpBucketCounts(snap HistogramSnapshot) []string {
+	counts := make([]string, 0, len(snap.Counts)+1)
+	var prev uint64
+	for _, cum := range snap.Counts {
+		counts = append(counts, strconv.FormatUint(cum-prev, 10))
+		prev = cum
+	}
+	return append(counts, strconv.FormatUint(snap.Count-prev, 10))
+}
+
+func otlpAttributes(labels map[string]string) []otlpAttribute {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]otlpAttribute, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otlpAttribute{Key: k, Value: otlpStringValue{StringValue: labels[k]}})
+	}
+	return attrs
+}
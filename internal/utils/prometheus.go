@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PrometheusCollector writes its metrics in the Prometheus text format.
+type PrometheusCollector interface {
+	CollectPrometheus(w *PrometheusWriter)
+}
+
+// PrometheusExporter serves registered collectors over HTTP in the Prometheus
+// text exposition format.
+type PrometheusExporter struct {
+	mu         sync.RWMutex
+	collectors []PrometheusCollector
+}
+
+// NewPrometheusExporter creates an exporter for collectors.
+func NewPrometheusExporter(collectors ...PrometheusCollector) *PrometheusExporter {
+	return &PrometheusExporter{collectors: collectors}
+}
+
+// Register adds a collector.
+func (e *PrometheusExporter) Register(c PrometheusCollector) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.collectors = append(e.collectors, c)
+}
+
+// ServeHTTP implements http.Handler.
+func (e *PrometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	pw := &PrometheusWriter{w: bw, seen: make(map[string]bool)}
+
+	e.mu.RLock()
+	for _, c := range e.collectors {
+		c.CollectPrometheus(pw)
+	}
+	e.mu.RUnlock()
+
+	bw.Flush()
+}
+
+// PrometheusWriter formats metric samples. HELP and TYPE lines are written
+// once per metric name.
+type PrometheusWriter struct {
+	w    *bufio.Writer
+	seen map[string]bool
+	// metrics, when set, receives the samples instead of w; see
+	// CollectMetrics.
+	metrics *[]Metric
+}
+
+// Counter writes a counter sample.
+func (p *PrometheusWriter) Counter(name, help string, value float64, labels map[string]string) {
+	if p.metrics != nil {
+		*p.metrics = append(*p.metrics, Metric{Name: name, Help: help, Kind: MetricCounter, Value: value, Labels: labels})
+		return
+	}
+	p.header(name, help, "counter")
+	p.sample(name, labels, value)
+}
+
+// Gauge writes a gauge sample.
+func (p *PrometheusWriter) Gauge(name, help string, value float64, labels map[string]string) {
+	if p.metrics != nil {
+		*p.metrics = append(*p.metrics, Metric{Name: name, Help: help, Kind: MetricGauge, Value: value, Labels: labels})
+		return
+	}
+	p.header(name, help, "gauge")
+	p.sample(name, labels, value)
+}
+
+// Histogram writes a histogram's buckets, sum, and count.
+func (p *PrometheusWriter) Histogram(name, help string, snap HistogramSnapshot, labels map[string]string) {
+	if p.metrics != nil {
+		*p.metrics = append(*p.metrics, Metric{Name: name, Help: help, Kind: MetricHistogram, Histogram: snap, Labels: labels})
+		return
+	}
+	p.header(name, help, "histogram")
+	for i, bound := range snap.Bounds {
+		p.sample(name+"_bucket", withLabel(labels, "le", formatFloat(bound)), float64(snap.Counts[i]))
+	}
+	p.sample(name+"_bucket", withLabel(labels, "le", "+Inf"), float64(snap.Count))
+	p.sample(name+"_sum", labels, snap.Sum)
+	p.sample(name+"_count", labels, float64(snap.Count))
+}
+
+func (p *PrometheusWriter) header(name, help, kind string) {
+	if p.seen[name] {
+		return
+	}
+	p.seen[name] = true
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p *PrometheusWriter) sample(name string, labels map[string]string, value float64) {
+	p.w.WriteString(name)
+	if len(labels) > 0 {
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		p.w.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				p.w.WriteByte(',')
+			}
+			fmt.Fprintf(p.w, "%s=\"%s\"", k, labelEscaper.Replace(labels[k]))
+		}
+		p.w.WriteByte('}')
+	}
+	p.w.WriteByte(' ')
+	p.w.WriteString(formatFloat(value))
+	p.w.WriteByte('\n')
+}
+
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCollector struct {
+	h *Histogram
+}
+
+func (c testCollector) CollectPrometheus(w *PrometheusWriter) {
+	w.Counter("test_total", "Test counter.", 3, map[string]string{"kind": `a"b`})
+	w.Histogram("test_seconds", "Test latency.", c.h.Snapshot(), nil)
+}
+
+func TestPrometheusExporter(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 1})
+	h.Observe(50 * time.Millisecond)
+	h.Observe(2 * time.Second)
+
+	rec := httptest.NewRecorder()
+	NewPrometheusExporter(testCollector{h}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE test_total counter\n",
+		`test_total{kind="a\"b"} 3`,
+		"# TYPE test_seconds histogram\n",
+		`test_seconds_bucket{le="0.1"} 1`,
+		`test_seconds_bucket{le="1"} 1`,
+		`test_seconds_bucket{le="+Inf"} 2`,
+		"test_seconds_sum 2.05\n",
+		"test_seconds_count 2\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("output missing %q:\n%s", want, body)
+		}
+	}
+}
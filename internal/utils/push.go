@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMetricsPushInterval is how often a MetricsPusher exports when
+// MetricsPushConfig leaves Interval unset.
+const DefaultMetricsPushInterval = 10 * time.Second
+
+// Metrics push protocols accepted in MetricsPushConfig.
+const (
+	MetricsProtocolStatsD = "statsd"
+	MetricsProtocolOTLP   = "otlp"
+)
+
+// MetricKind is the type of a collected metric.
+type MetricKind int
+
+const (
+	MetricCounter MetricKind = iota
+	MetricGauge
+	MetricHistogram
+)
+
+// Metric is one sample written by a PrometheusCollector. Counter and gauge
+// samples carry Value; histogram samples carry Histogram.
+type Metric struct {
+	Name      string
+	Help      string
+	Kind      MetricKind
+	Value     float64
+	Histogram HistogramSnapshot
+	Labels    map[string]string
+}
+
+// MetricExporter sends collected metrics to a monitoring backend. Counters
+// and histograms are cumulative since the process started; an exporter
+// whose backend expects increments computes them itself.
+type MetricExporter interface {
+	Export(ctx context.Context, metrics []Metric) error
+}
+
+// CollectMetrics returns the samples collectors write, for exporters that
+// push instead of being scraped.
+func CollectMetrics(collectors ...PrometheusCollector) []Metric {
+	var metrics []Metric
+	w := &PrometheusWriter{metrics: &metrics}
+	for _, c := range collectors {
+		c.CollectPrometheus(w)
+	}
+	return metrics
+}
+
+// NewMetricExporter returns the exporter for cfg.Protocol.
+func NewMetricExporter(cfg *MetricsPushConfig) (MetricExporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("metrics push: endpoint is required")
+	}
+	switch cfg.Protocol {
+	case MetricsProtocolStatsD:
+		return NewStatsDExporter(cfg.Endpoint, cfg.Prefix)
+	case MetricsProtocolOTLP:
+		return NewOTLPExporter(cfg.Endpoint, cfg.ServiceName, cfg.Headers), nil
+	default:
+		return nil, fmt.Errorf("metrics push: unknown protocol %q", cfg.Protocol)
+	}
+}
+
+// MetricsPusher exports the metrics of registered collectors on an
+// interval, for pipelines that do not scrape PrometheusExporter. A failed
+// export is logged and counted; the next interval sends current values
+// again, so nothing is lost for cumulative metrics.
+type MetricsPusher struct {
+	exporter MetricExporter
+	interval time.Duration
+	logger   *Logger
+
+	mu         sync.RWMutex
+	collectors []PrometheusCollector
+
+	failures  atomic.Uint64
+	closeOnce sync.Once
+	closeErr  error
+	closing   chan struct{}
+	done      chan struct{}
+}
+
+// PusherOption configures a MetricsPusher.
+type PusherOption func(*MetricsPusher)
+
+// WithPusherLogger makes the pusher log through logger. Entries are prefixed
+// with the logger's prefix followed by "Metrics". Without it the pusher
+// derives its logger from DefaultLogger.
+func WithPusherLogger(logger *Logger) PusherOption {
+	return func(p *MetricsPusher) {
+		if logger != nil {
+			p.logger = logger.Named("Metrics")
+		}
+	}
+}
+
+// NewMetricsPusher starts exporting the metrics of collectors through
+// exporter every interval. A non-positive interval uses
+// DefaultMetricsPushInterval.
+func NewMetricsPusher(exporter MetricExporter, interval time.Duration, collectors []PrometheusCollector, opts ...PusherOption) *MetricsPusher {
+	if interval <= 0 {
+		interval = DefaultMetricsPushInterval
+	}
+	p := &MetricsPusher{
+		exporter:   exporter,
+		interval:   interval,
+		logger:     DefaultLogger().Named("Metrics"),
+		collectors: collectors,
+		closing:    make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	go p.run()
+	return p
+}
+
+// NewMetricsPusherFromConfig starts a MetricsPusher with the exporter and
+// interval of cfg. It returns nil, and no error, when cfg is not enabled;
+// the methods of a nil MetricsPusher do nothing.
+func NewMetricsPusherFromConfig(cfg *MetricsPushConfig, collectors []PrometheusCollector, opts ...PusherOption) (*MetricsPusher, error) {
+	if !cfg.IsEnabled() {
+		return nil, nil
+	}
+	exporter, err := NewMetricExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewMetricsPusher(exporter, cfg.Interval, collectors, opts...), nil
+}
+
+// Register adds a collector.
+func (p *MetricsPusher) Register(c PrometheusCollector) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.collectors = append(p.collectors, c)
+}
+
+// Flush exports the current metrics now.
+func (p *MetricsPusher) Flush(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.RLock()
+	metrics := CollectMetrics(p.collectors...)
+	p.mu.RUnlock()
+
+	if err := p.exporter.Export(ctx, metrics); err != nil {
+		p.failures.Add(1)
+		return fmt.Errorf("export metrics: %w", err)
+	}
+	return nil
+}
+
+// Failures returns how many exports failed.
+func (p *MetricsPusher) Failures() uint64 {
+	if p == nil {
+		return 0
+	}
+	return p.failures.Load()
+}
+
+// Close stops the pusher after a final export, so the last interval's
+// metrics are not lost, then closes the exporter if it is an io.Closer,
+// such as a StatsDExporter's socket. It returns the errors of both; later
+// calls return the same.
+func (p *MetricsPusher) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.closeOnce.Do(func() {
+		close(p.closing)
+		<-p.done
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+		defer cancel()
+		p.closeErr = p.Flush(ctx)
+		if closer, ok := p.exporter.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				p.closeErr = errors.Join(p.closeErr, fmt.Errorf("close exporter: %w", err))
+			}
+		}
+	})
+	return p.closeErr
+}
+
+func (p *MetricsPusher) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.closing:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+		if err := p.Flush(ctx); err != nil {
+			p.logger.Warn("Metrics export failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsDExporterSendsIncrements(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+	exporter, err := NewStatsDExporter(conn.LocalAddr().String(), "app")
+	if err != nil {
+		t.Fatalf("NewStatsDExporter: %v", err)
+	}
+	defer exporter.Close()
+
+	read := func() string {
+		buf := make([]byte, maxStatsDPacket)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return string(buf[:n])
+	}
+
+	h := NewHistogram([]float64{0.1, 1})
+	h.Observe(50 * time.Millisecond)
+	collector := testCollector{h}
+	if err := exporter.Export(context.Background(), CollectMetrics(collector)); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	got := read()
+	for _, want := range []string{
+		"app.test_total:3|c|#kind:a\"b",
+		"app.test_seconds.count:1|c",
+		"app.test_seconds.bucket:1|c|#le:0.1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("packet lacks %q:\n%s", want, got)
+		}
+	}
+
+	// Only what changed since the last export is sent.
+	h.Observe(2 * time.Second)
+	if err := exporter.Export(context.Background(), CollectMetrics(collector)); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	got = read()
+	if strings.Contains(got, "test_total") || !strings.Contains(got, "app.test_seconds.bucket:1|c|#le:+Inf") {
+		t.Fatalf("second packet:\n%s", got)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var got otlpRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	h := NewHistogram([]float64{0.1, 1})
+	h.Observe(50 * time.Millisecond)
+	h.Observe(2 * time.Second)
+	exporter := NewOTLPExporter(srv.URL, "svc", map[string]string{"Authorization": "Bearer t"})
+	if err := exporter.Export(context.Background(), CollectMetrics(testCollector{h})); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	metrics := got.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 2 || metrics[0].Sum == nil || !metrics[0].Sum.IsMonotonic || metrics[0].Sum.DataPoints[0].AsDouble != 3 {
+		t.Fatalf("counter = %+v", metrics)
+	}
+	point := metrics[1].Histogram.DataPoints[0]
+	if point.Count != "2" || strings.Join(point.BucketCounts, ",") != "1,0,1" {
+		t.Fatalf("histogram point = %+v", point)
+	}
+	if attrs := got.ResourceMetrics[0].Resource.Attributes; len(attrs) != 1 || attrs[0].Value.StringValue != "svc" {
+		t.Fatalf("resource = %+v", attrs)
+	}
+
+	if err := NewOTLPExporter(srv.URL, "", nil).Export(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Export without credentials = %v, want status 401", err)
+	}
+}
+
+type exportRecorder chan []Metric
+
+func (r exportRecorder) Export(ctx context.Context, metrics []Metric) error {
+	r <- metrics
+	return nil
+}
+
+func TestMetricsPusherFlushesOnInterval(t *testing.T) {
+	exports := make(exportRecorder, 16)
+	pusher := NewMetricsPusher(exports, 10*time.Millisecond, []PrometheusCollector{testCollector{NewHistogram(nil)}})
+
+	select {
+	case metrics := <-exports:
+		if len(metrics) != 2 {
+			t.Fatalf("exported %d metrics, want 2", len(metrics))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no export within a second")
+	}
+	if err := pusher.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var disabled *MetricsPusher
+	if p, err := NewMetricsPusherFromConfig(nil, nil); p != disabled || err != nil {
+		t.Fatalf("NewMetricsPusherFromConfig(nil, nil) = %v, %v", p, err)
+	}
+	if _, err := NewMetricsPusherFromConfig(&MetricsPushConfig{Protocol: "carrier-pigeon", Endpoint: "x"}, nil); err == nil {
+		t.Fatal("unknown protocol accepted")
+	}
+}
+
+// closingExporter records whether it was closed.
+type closingExporter struct {
+	exportRecorder
+	closed bool
+}
+
+func (e *closingExporter) Close() error {
+	e.closed = true
+	return nil
+}
+
+func TestMetricsPusherClosesExporter(t *testing.T) {
+	exporter := &closingExporter{exportRecorder: make(exportRecorder, 16)}
+	pusher := NewMetricsPusher(exporter, time.Hour, nil)
+	if err := pusher.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(exporter.exportRecorder) != 1 || !exporter.closed {
+		t.Fatalf("%d final exports, closed = %v; want one export, then close", len(exporter.exportRecorder), exporter.closed)
+	}
+	if err := pusher.Close(); err != nil || len(exporter.exportRecorder) != 1 {
+		t.Fatalf("second Close = %v after %d exports, want nothing more", err, len(exporter.exportRecorder))
+	}
+}
+
+type failingExporter struct{}
+
+func (failingExporter) Export(context.Context, []Metric) error {
+	return errors.New("collector unreachable")
+}
+
+func TestMetricsPusherLogsThroughItsLogger(t *testing.T) {
+	var logs bytes.Buffer
+	pusher := NewMetricsPusher(failingExporter{}, time.Millisecond, nil, WithPusherLogger(NewLogger(WithOutput(&logs))))
+	deadline := time.Now().Add(time.Second)
+	for pusher.Failures() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	pusher.Close()
+	if !strings.Contains(logs.String(), "Metrics export failed") {
+		t.Fatalf("logs = %q, want the failed export", logs.String())
+	}
+}
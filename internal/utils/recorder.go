@@ -0,0 +1,393 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrRecordingNotFound is returned by a replaying Recorder for a request
+// that matches no recording. The request is not sent.
+var ErrRecordingNotFound = errors.New("no recording matches request")
+
+// RecorderMode selects whether a Recorder captures or serves exchanges.
+type RecorderMode string
+
+// Recorder modes.
+const (
+	// RecordMode sends every request and stores it with its response.
+	RecordMode RecorderMode = "record"
+	// ReplayMode answers every request from the store without touching the
+	// network.
+	ReplayMode RecorderMode = "replay"
+)
+
+// DefaultRecorderIgnoredFields are the top-level JSON request fields left
+// out of the match key. JSON-RPC numbers requests with "id", which differs
+// between runs.
+var DefaultRecorderIgnoredFields = []string{"id"}
+
+// Recording is one HTTP exchange captured by a Recorder. Bodies are stored
+// decoded and with credentials redacted.
+type Recording struct {
+	// Key is the hash requests are matched by; see Recorder.
+	Key             string            `json:"key"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	StatusCode      int               `json:"status_code"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body"`
+}
+
+// RecordingStore persists recordings. Implementations must be safe for
+// concurrent use.
+type RecordingStore interface {
+	// Append stores rec after those already stored.
+	Append(rec Recording) error
+	// Load returns every stored recording in the order appended.
+	Load() ([]Recording, error)
+}
+
+// FileRecordingStore stores recordings in a file, one JSON object per line,
+// so a recording session can be inspected and edited by hand.
+type FileRecordingStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileRecordingStore returns a store backed by the file at path, which
+// is created on the first Append.
+func NewFileRecordingStore(path string) *FileRecordingStore {
+	return &FileRecordingStore{path: path}
+}
+
+// Append implements RecordingStore.
+func (s *FileRecordingStore) Append(rec Recording) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load implements RecordingStore.
+func (s *FileRecordingStore) Load() ([]Recording, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var recs []Recording
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec Recording
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs, scanner.Err()
+}
+
+// RecorderConfig configures a Recorder.
+type RecorderConfig struct {
+	Mode  RecorderMode
+	Store RecordingStore
+	// RedactFields names further JSON fields and query parameters whose
+	// values are redacted, besides DefaultRedactedKeys.
+	RedactFields []string
+	// Secrets, such as API keys, are redacted wherever they appear.
+	Secrets []string
+	// IgnoredFields are top-level JSON request fields left out of the match
+	// key. Nil uses DefaultRecorderIgnoredFields.
+	IgnoredFields []string
+}
+
+// Recorder captures HTTP exchanges to a RecordingStore and replays them
+// offline, e.g. to reproduce an incident deterministically. Wrap the
+// transport of a client with it; solana.WithRecorder and
+// openai.ClientConfig.Recorder do so.
+//
+// Requests are matched by Key, a SHA-256 hash of the method, the URL path
+// and query, and the body. The host is left out, so recordings replay
+// against any endpoint. A JSON body is compared by value: key order and
+// whitespace do not matter, IgnoredFields are dropped, and redacted values
+// compare equal. Since recordings are redacted, requests differing only in
+// a credential match the same recording.
+//
+// Repeated requests with the same key are answered with their recordings
+// in the order recorded, and the last one is served again once they run
+// out, so polling calls replay as they happened. A request matching no
+// recording fails with ErrRecordingNotFound rather than reaching the
+// network. Responses are read in full before they are returned, so a
+// streamed response is recorded and replayed as a whole; WebSocket traffic
+// is not recorded.
+type Recorder struct {
+	mode     RecorderMode
+	store    RecordingStore
+	redactor *Redactor
+	ignored  map[string]bool
+
+	mu     sync.Mutex
+	replay map[string][]Recording
+	served map[string]int
+}
+
+// NewRecorder creates a recorder. In ReplayMode the store is loaded now.
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	if config.Store == nil {
+		return nil, errors.New("recorder: store is required")
+	}
+	ignored := config.IgnoredFields
+	if ignored == nil {
+		ignored = DefaultRecorderIgnoredFields
+	}
+	r := &Recorder{
+		mode:     config.Mode,
+		store:    config.Store,
+		redactor: NewRedactor(config.RedactFields, config.Secrets...),
+		ignored:  make(map[string]bool, len(ignored)),
+	}
+	for _, field := range ignored {
+		r.ignored[field] = true
+	}
+
+	switch config.Mode {
+	case RecordMode:
+	case ReplayMode:
+		recs, err := config.Store.Load()
+		if err != nil {
+			return nil, fmt.Errorf("recorder: load recordings: %w", err)
+		}
+		r.replay = make(map[string][]Recording)
+		r.served = make(map[string]int)
+		for _, rec := range recs {
+			r.replay[rec.Key] = append(r.replay[rec.Key], rec)
+		}
+	default:
+		return nil, fmt.Errorf("recorder: unknown mode %q", config.Mode)
+	}
+	return r, nil
+}
+
+// Mode returns the recorder's mode.
+func (r *Recorder) Mode() RecorderMode {
+	return r.mode
+}
+
+// Wrap returns a RoundTripper that records the exchanges of next or, in
+// ReplayMode, answers without calling it. A nil next uses
+// http.DefaultTransport.
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{recorder: r, next: next}
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := t.recorder
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	reqBody, err := decodeBody(req.Header.Get("Content-Encoding"), body)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: decode request body: %w", err)
+	}
+	rec := Recording{
+		Method:      req.Method,
+		URL:         r.redactURL(req.URL),
+		RequestBody: string(r.redactBody(reqBody, false)),
+	}
+	rec.Key = r.key(req.Method, rec.URL, reqBody)
+
+	if r.mode == ReplayMode {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		found, ok := r.next(rec.Key)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s (key %s)", ErrRecordingNotFound, rec.Method, rec.URL, rec.Key)
+		}
+		return found.response(req), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	respBody, err := decodeBody(resp.Header.Get("Content-Encoding"), data)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: decode response body: %w", err)
+	}
+	rec.StatusCode = resp.StatusCode
+	rec.ResponseBody = string(r.redactBody(respBody, false))
+	rec.ResponseHeaders = r.redactor.Headers(resp.Header)
+	for _, name := range []string{"Content-Encoding", "Content-Length"} {
+		delete(rec.ResponseHeaders, name)
+	}
+	if err := r.store.Append(rec); err != nil {
+		return nil, fmt.Errorf("recorder: store recording: %w", err)
+	}
+	return resp, nil
+}
+
+// next returns the recording to serve for key.
+func (r *Recorder) next(key string) (Recording, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recs := r.replay[key]
+	if len(recs) == 0 {
+		return Recording{}, false
+	}
+	i := r.served[key]
+	if i >= len(recs) {
+		i = len(recs) - 1
+	}
+	r.served[key] = i + 1
+	return recs[i], true
+}
+
+// response builds the response of rec to req.
+func (rec Recording) response(req *http.Request) *http.Response {
+	header := make(http.Header, len(rec.ResponseHeaders))
+	for name, value := range rec.ResponseHeaders {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(rec.ResponseBody)),
+		ContentLength: int64(len(rec.ResponseBody)),
+		Request:       req,
+	}
+}
+
+// key hashes the parts of a request that identify it.
+func (r *Recorder) key(method, redactedURL string, body []byte) string {
+	u, _ := url.Parse(redactedURL)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", method, u.RequestURI())
+	h.Write(r.canonicalBody(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalBody returns body redacted, with a JSON body re-encoded with
+// sorted keys and without ignored fields.
+func (r *Recorder) canonicalBody(body []byte) []byte {
+	return r.redactBody(body, true)
+}
+
+// redactBody returns body with known secrets and, if it is JSON, the values
+// of redacted fields replaced. Numbers are kept exactly, unlike
+// Redactor.Body, so redacted responses still decode into uint64 fields.
+// With dropIgnored the ignored top-level fields are removed too, of the
+// body or of each request in a batch.
+func (r *Recorder) redactBody(body []byte, dropIgnored bool) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return []byte(r.redactor.String(string(body)))
+	}
+	if dropIgnored {
+		elems := []interface{}{v}
+		if batch, ok := v.([]interface{}); ok {
+			elems = batch
+		}
+		for _, elem := range elems {
+			if obj, ok := elem.(map[string]interface{}); ok {
+				for field := range r.ignored {
+					delete(obj, field)
+				}
+			}
+		}
+	}
+	out, err := json.Marshal(r.redactor.value(v))
+	if err != nil {
+		return []byte(r.redactor.String(string(body)))
+	}
+	return []byte(r.redactor.String(string(out)))
+}
+
+// redactURL renders u with the values of redacted query parameters and
+// known secrets replaced.
+func (r *Recorder) redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	for name := range query {
+		if r.redactor.redacts(name) {
+			query.Set(name, Redacted)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return r.redactor.String(redacted.String())
+}
+
+// decodeBody returns body decompressed according to its Content-Encoding.
+func decodeBody(encoding string, body []byte) ([]byte, error) {
+	if !strings.EqualFold(encoding, "gzip") || len(body) == 0 {
+		return body, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
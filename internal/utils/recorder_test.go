@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		if n == 1 {
+			io.WriteString(w, `{"id":1,"result":{"lamports":18446744073709551615}}`)
+			return
+		}
+		io.WriteString(w, `{"id":2,"result":{"lamports":5}}`)
+	}))
+	defer srv.Close()
+
+	store := NewFileRecordingStore(filepath.Join(t.TempDir(), "session.jsonl"))
+	post := func(rt http.RoundTripper, base, body string) (string, error) {
+		req, _ := http.NewRequest(http.MethodPost, base+"/rpc?api-key=s3cret", strings.NewReader(body))
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data), nil
+	}
+
+	recorder, err := NewRecorder(RecorderConfig{Mode: RecordMode, Store: store, Secrets: []string{"sk-live"}})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	rt := recorder.Wrap(nil)
+	for i, body := range []string{
+		`{"id":1,"method":"getBalance","params":["abc"],"api_key":"sk-live"}`,
+		`{"id":2,"method":"getBalance","params":["abc"],"api_key":"sk-live"}`,
+	} {
+		if _, err := post(rt, srv.URL, body); err != nil {
+			t.Fatalf("recording call %d: %v", i, err)
+		}
+	}
+
+	recs, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(recs) != 2 || recs[0].Key != recs[1].Key {
+		t.Fatalf("recordings = %+v, want two with the same key", recs)
+	}
+	for _, rec := range recs {
+		line := rec.URL + rec.RequestBody
+		if strings.Contains(line, "s3cret") || strings.Contains(line, "sk-live") || rec.ResponseHeaders["Set-Cookie"] != Redacted {
+			t.Fatalf("recording leaks a secret: %+v", rec)
+		}
+	}
+	if !strings.Contains(recs[0].ResponseBody, "18446744073709551615") {
+		t.Fatalf("response body lost precision: %s", recs[0].ResponseBody)
+	}
+
+	srv.Close()
+	replayer, err := NewRecorder(RecorderConfig{Mode: ReplayMode, Store: store})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	rt = replayer.Wrap(nil)
+	// Field order, the request id, and the credential do not affect the match.
+	body := `{"params":["abc"],"method":"getBalance","id":7,"api_key":"other"}`
+	var got []string
+	for i := 0; i < 3; i++ {
+		resp, err := post(rt, "http://replay.invalid", body)
+		if err != nil {
+			t.Fatalf("replay %d: %v", i, err)
+		}
+		got = append(got, resp)
+	}
+	if !strings.Contains(got[0], "18446744073709551615") || !strings.Contains(got[1], `"lamports":5`) || got[2] != got[1] {
+		t.Fatalf("replayed %q", got)
+	}
+
+	if _, err := post(rt, "http://replay.invalid", `{"method":"getSlot"}`); !errors.Is(err, ErrRecordingNotFound) {
+		t.Fatalf("unrecorded request = %v, want ErrRecordingNotFound", err)
+	}
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redacted replaces the value of a redacted header or field in logs.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedKeys are the header and JSON field names whose values
+// Redactor always hides. Names are compared ignoring case, "-", and "_",
+// so "api_key" also covers "API-Key" and "apiKey".
+var DefaultRedactedKeys = []string{
+	"authorization",
+	"proxy-authorization",
+	"cookie",
+	"set-cookie",
+	"api_key",
+	"x-api-key",
+	"password",
+	"secret",
+	"client_secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"private_key",
+}
+
+// Redactor hides credentials in headers and JSON bodies before they are
+// logged. A nil or zero Redactor hides DefaultRedactedKeys only.
+type Redactor struct {
+	keys    map[string]bool
+	secrets []string
+}
+
+// NewRedactor returns a Redactor that hides DefaultRedactedKeys and keys.
+// Each non-empty secret, such as an API key, is also replaced wherever it
+// appears verbatim, in case it turns up under a name no key list covers.
+func NewRedactor(keys []string, secrets ...string) *Redactor {
+	r := &Redactor{keys: make(map[string]bool, len(DefaultRedactedKeys)+len(keys))}
+	for _, list := range [][]string{DefaultRedactedKeys, keys} {
+		for _, key := range list {
+			r.keys[normalizeKey(key)] = true
+		}
+	}
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r
+}
+
+var defaultRedactor = NewRedactor(nil)
+
+func normalizeKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+}
+
+func (r *Redactor) redacts(key string) bool {
+	if r == nil || r.keys == nil {
+		r = defaultRedactor
+	}
+	return r.keys[normalizeKey(key)]
+}
+
+// Headers renders h as a map for a log entry, with the values of redacted
+// headers replaced by Redacted.
+func (r *Redactor) Headers(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if r.redacts(name) {
+			out[name] = Redacted
+			continue
+		}
+		out[name] = r.String(strings.Join(values, ", "))
+	}
+	return out
+}
+
+// Body renders a request or response body for a log entry. A JSON body has
+// the values of redacted fields replaced at any depth; any other body is
+// kept as is. Known secrets are then replaced, and the result is cut to
+// limit bytes, noting how much was dropped. A limit of zero or less keeps
+// the whole body.
+func (r *Redactor) Body(data []byte, limit int) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err == nil {
+		if redacted, err := json.Marshal(r.value(v)); err == nil {
+			data = redacted
+		}
+	}
+	s := r.String(string(data))
+	if limit > 0 && len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = fmt.Sprintf("%s... (%d bytes truncated)", s[:cut], len(s)-cut)
+	}
+	return s
+}
+
+func (r *Redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if r.redacts(k) {
+				v[k] = Redacted
+			} else {
+				v[k] = r.value(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = r.value(v[i])
+		}
+	}
+	return v
+}
+
+// String replaces every known secret in s with Redacted.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
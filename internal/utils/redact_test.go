@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactorBody(t *testing.T) {
+	r := NewRedactor([]string{"session"}, "sk-123")
+
+	got := r.Body([]byte(`{"model":"m","api_key":"k","nested":[{"Session":"s","note":"uses sk-123"}]}`), 0)
+	for _, leaked := range []string{`"k"`, `"s"`, "sk-123"} {
+		if strings.Contains(got, leaked) {
+			t.Fatalf("Body = %s, leaks %s", got, leaked)
+		}
+	}
+	if !strings.Contains(got, `"model":"m"`) {
+		t.Fatalf("Body = %s, lost an unredacted field", got)
+	}
+
+	if got := r.Body([]byte("not json sk-123"), 0); got != "not json "+Redacted {
+		t.Fatalf("Body(text) = %q", got)
+	}
+	if got := r.Body([]byte("héllo"), 2); got != "h... (5 bytes truncated)" {
+		t.Fatalf("Body(truncated) = %q", got)
+	}
+}
+
+func TestRedactorHeaders(t *testing.T) {
+	var r *Redactor
+	h := http.Header{"Authorization": {"Bearer x"}, "X-Api-Key": {"y"}, "Accept": {"*/*"}}
+	got := r.Headers(h)
+	if got["Authorization"] != Redacted || got["X-Api-Key"] != Redacted || got["Accept"] != "*/*" {
+		t.Fatalf("Headers = %v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned when a retry is refused because the
+// shared retry budget has no tokens left.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// WrapAttempts prefixes a non-nil err with the number of attempts made when
+// there was more than one. A failure on the first attempt is returned
+// unchanged, so it reads the same as a call that never retries.
+func WrapAttempts(err error, attempts int) error {
+	if err == nil || attempts <= 1 {
+		return err
+	}
+	return fmt.Errorf("after %d attempts: %w", attempts, err)
+}
+
+// RetryBudget is a token bucket of retries shared by every component that
+// retries. Each retry spends one token; tokens refill at a fixed rate up to
+// a burst capacity. During a widespread failure the bucket drains and
+// retries fail fast instead of multiplying load on a struggling dependency.
+//
+// A nil *RetryBudget allows every retry, so components can hold one
+// unconditionally.
+type RetryBudget struct {
+	mu       sync.Mutex
+	rate     float64 // tokens per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+
+	allowed uint64
+	denied  uint64
+}
+
+// NewRetryBudget creates a budget allowing perSecond retries on average with
+// bursts of up to burst retries. The bucket starts full.
+func NewRetryBudget(perSecond float64, burst int) *RetryBudget {
+	if perSecond < 0 {
+		perSecond = 0
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	b := &RetryBudget{
+		rate:     perSecond,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		now:      time.Now,
+	}
+	b.last = b.now()
+	return b
+}
+
+// Allow spends a token and reports whether the retry may proceed.
+func (b *RetryBudget) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens < 1 {
+		b.denied++
+		return false
+	}
+	b.tokens--
+	b.allowed++
+	return true
+}
+
+// Acquire is Allow returning ErrRetryBudgetExhausted when the retry is
+// refused.
+func (b *RetryBudget) Acquire() error {
+	if !b.Allow() {
+		return ErrRetryBudgetExhausted
+	}
+	return nil
+}
+
+// Utilization is the fraction of the burst capacity currently spent, from 0
+// (no recent retries) to 1 (exhausted).
+func (b *RetryBudget) Utilization() float64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	return 1 - b.tokens/b.capacity
+}
+
+func (b *RetryBudget) refill() {
+	now := b.now()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+}
+
+// GetMetrics returns retry budget metrics.
+func (b *RetryBudget) GetMetrics() map[string]interface{} {
+	if b == nil {
+		return nil
+	}
+	utilization := b.Utilization()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return map[string]interface{}{
+		"allowed_total": b.allowed,
+		"denied_total":  b.denied,
+		"capacity":      b.capacity,
+		"utilization":   utilization,
+	}
+}
+
+// CollectPrometheus implements PrometheusCollector.
+func (b *RetryBudget) CollectPrometheus(w *PrometheusWriter) {
+	if b == nil {
+		return
+	}
+	utilization := b.Utilization()
+
+	b.mu.Lock()
+	allowed, denied := b.allowed, b.denied
+	b.mu.Unlock()
+
+	w.Gauge("retry_budget_utilization", "Fraction of the shared retry budget spent.", utilization, nil)
+	w.Counter("retry_budget_retries_total", "Retries checked against the shared budget.", float64(allowed), map[string]string{"result": "allowed"})
+	w.Counter("retry_budget_retries_total", "Retries checked against the shared budget.", float64(denied), map[string]string{"result": "denied"})
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewRetryBudget(1, 2)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	for i := 0; i < 2; i++ {
+		if err := b.Acquire(); err != nil {
+			t.Fatalf("retry %d: %v", i, err)
+		}
+	}
+	if err := b.Acquire(); !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("Acquire on empty budget = %v, want ErrRetryBudgetExhausted", err)
+	}
+	if u := b.Utilization(); u != 1 {
+		t.Fatalf("Utilization = %v, want 1", u)
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("budget did not refill")
+	}
+	if u := b.Utilization(); u != 0.75 {
+		t.Fatalf("Utilization = %v, want 0.75", u)
+	}
+
+	m := b.GetMetrics()
+	if m["allowed_total"] != uint64(3) || m["denied_total"] != uint64(1) {
+		t.Fatalf("metrics = %v", m)
+	}
+
+	var nilBudget *RetryBudget
+	if !nilBudget.Allow() {
+		t.Fatal("nil budget refused a retry")
+	}
+}
+
+func TestWrapAttempts(t *testing.T) {
+	base := errors.New("boom")
+	if err := WrapAttempts(base, 1); err != base {
+		t.Fatalf("first attempt = %v, want the error unchanged", err)
+	}
+	if err := WrapAttempts(nil, 3); err != nil {
+		t.Fatalf("nil error = %v", err)
+	}
+	if err := WrapAttempts(base, 3); !errors.Is(err, base) || err.Error() != "after 3 attempts: boom" {
+		t.Fatalf("third attempt = %v", err)
+	}
+}
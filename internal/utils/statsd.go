@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxStatsDPacket keeps datagrams within a typical network MTU so they are
+// not fragmented or dropped.
+const maxStatsDPacket = 1432
+
+// StatsDExporter sends metrics over UDP in the StatsD line format, with
+// labels as DogStatsD tags. StatsD counters are increments, so counters are
+// sent as the change since the previous export; a counter that went down,
+// as after a reset, is sent in full. Gauges are sent as gauges. A histogram
+// is sent as the increments of its "<name>.count" and "<name>.sum" and of
+// "<name>.bucket" tagged with each upper bound "le", mirroring its
+// Prometheus buckets, since the individual observations are not kept.
+type StatsDExporter struct {
+	conn   net.Conn
+	prefix string
+
+	mu   sync.Mutex
+	last map[string]float64
+}
+
+// NewStatsDExporter creates an exporter sending to the StatsD server at
+// address (host:port). Metric names are prefixed with prefix and a dot,
+// unless prefix is empty.
+func NewStatsDExporter(address, prefix string) (*StatsDExporter, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: dial %s: %w", address, err)
+	}
+	if prefix != "" {
+		prefix = statsDName(prefix) + "."
+	}
+	return &StatsDExporter{conn: conn, prefix: prefix, last: make(map[string]float64)}, nil
+}
+
+// Export implements MetricExporter.
+func (e *StatsDExporter) Export(ctx context.Context, metrics []Metric) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var lines []string
+	for _, m := range metrics {
+		name := e.prefix + statsDName(m.Name)
+		switch m.Kind {
+		case MetricCounter:
+			lines = e.counter(lines, name, m.Value, m.Labels)
+		case MetricGauge:
+			lines = append(lines, statsDLine(name, m.Value, "g", m.Labels))
+		case MetricHistogram:
+			h := m.Histogram
+			lines = e.counter(lines, name+".count", float64(h.Count), m.Labels)
+			lines = e.counter(lines, name+".sum", h.Sum, m.Labels)
+			for i, bound := range h.Bounds {
+				lines = e.counter(lines, name+".bucket", float64(h.Counts[i]), withLabel(m.Labels, "le", formatFloat(bound)))
+			}
+			lines = e.counter(lines, name+".bucket", float64(h.Count), withLabel(m.Labels, "le", "+Inf"))
+		}
+	}
+	return e.send(ctx, lines)
+}
+
+// counter appends the line for the increase of a cumulative counter since
+// the last export. Unchanged counters are skipped.
+func (e *StatsDExporter) counter(lines []string, name string, value float64, labels map[string]string) []string {
+	key := name + statsDTags(labels)
+	delta := value - e.last[key]
+	if delta < 0 {
+		delta = value
+	}
+	e.last[key] = value
+	if delta == 0 {
+		return lines
+	}
+	return append(lines, statsDLine(name, delta, "c", labels))
+}
+
+// send writes lines in datagrams of at most maxStatsDPacket bytes.
+func (e *StatsDExporter) send(ctx context.Context, lines []string) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		e.conn.SetWriteDeadline(deadline)
+	}
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := e.conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsDPacket {
+			if err := flush(); err != nil {
+				return fmt.Errorf("statsd: %w", err)
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("statsd: %w", err)
+	}
+	return nil
+}
+
+// Close closes the UDP socket.
+func (e *StatsDExporter) Close() error {
+	return e.conn.Close()
+}
+
+func statsDLine(name string, value float64, kind string, labels map[string]string) string {
+	return fmt.Sprintf("%s:%s|%s%s", name, formatFloat(value), kind, statsDTags(labels))
+}
+
+// statsDTags renders labels as a DogStatsD tag suffix, sorted by name, or
+// "" without labels.
+func statsDTags(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString("|#")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(statsDTag(k))
+		b.WriteByte(':')
+		b.WriteString(statsDTag(labels[k]))
+	}
+	return b.String()
+}
+
+// statsDName and statsDTag replace the characters that delimit the StatsD
+// line format.
+var (
+	statsDNameEscaper = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", "\n", "_")
+	statsDTagEscaper  = strings.NewReplacer(":", "_", "|", "_", ",", "_", "#", "_", "\n", "_")
+)
+
+func statsDName(name string) string { return statsDNameEscaper.Replace(name) }
+
+func statsDTag(tag string) string { return statsDTagEscaper.Replace(tag) }
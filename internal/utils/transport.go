@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for TransportConfig fields left at zero.
+const (
+	DefaultDialTimeout     = 5 * time.Second
+	DefaultKeepAlive       = 30 * time.Second
+	DefaultIdleConnTimeout = 90 * time.Second
+	DefaultMaxIdleConns    = 100
+	DefaultMaxConnsPerHost = 64
+)
+
+// TransportConfig sizes a pooled HTTP transport.
+type TransportConfig struct {
+	DialTimeout     time.Duration
+	KeepAlive       time.Duration
+	IdleConnTimeout time.Duration
+	// MaxIdleConns caps idle connections across all hosts. The per-host
+	// idle cap is the smaller of this and MaxConnsPerHost, so every
+	// connection a host may open can be kept for reuse.
+	MaxIdleConns int
+	// MaxConnsPerHost caps total connections to a single host; requests
+	// beyond it wait for a connection to free up.
+	MaxConnsPerHost int
+}
+
+func (c TransportConfig) withDefaults() TransportConfig {
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = DefaultDialTimeout
+	}
+	if c.KeepAlive <= 0 {
+		c.KeepAlive = DefaultKeepAlive
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	if c.MaxIdleConns <= 0 {
+		c.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if c.MaxConnsPerHost <= 0 {
+		c.MaxConnsPerHost = DefaultMaxConnsPerHost
+	}
+	return c
+}
+
+// Transport is a keep-alive HTTP transport that counts the connections it
+// holds open.
+type Transport struct {
+	*http.Transport
+	open  atomic.Int64
+	dials atomic.Uint64
+}
+
+// NewTransport creates a transport sized by config. Zero fields use the
+// Default* values.
+func NewTransport(config TransportConfig) *Transport {
+	config = config.withDefaults()
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: config.KeepAlive,
+	}
+
+	idlePerHost := config.MaxConnsPerHost
+	if idlePerHost > config.MaxIdleConns {
+		idlePerHost = config.MaxIdleConns
+	}
+
+	t := &Transport{}
+	t.Transport = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			t.dials.Add(1)
+			t.open.Add(1)
+			return &countedConn{Conn: conn, open: &t.open}, nil
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   idlePerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   config.DialTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	return t
+}
+
+// OpenConnections returns the number of connections currently open, idle
+// or in use.
+func (t *Transport) OpenConnections() int64 {
+	return t.open.Load()
+}
+
+// Dials returns the number of connections opened so far. Under steady load
+// it stays flat while connections are reused.
+func (t *Transport) Dials() uint64 {
+	return t.dials.Load()
+}
+
+var (
+	sharedMu         sync.Mutex
+	sharedTransports = make(map[TransportConfig]*Transport)
+)
+
+// SharedTransport returns the process-wide transport for config, creating it
+// on first use. Clients built with the same settings share one connection
+// pool, so creating many clients does not multiply open sockets.
+func SharedTransport(config TransportConfig) *Transport {
+	config = config.withDefaults()
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	t, ok := sharedTransports[config]
+	if !ok {
+		t = NewTransport(config)
+		sharedTransports[config] = t
+	}
+	return t
+}
+
+// countedConn decrements the open count exactly once when closed.
+type countedConn struct {
+	net.Conn
+	open *atomic.Int64
+	once sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() { c.open.Add(-1) })
+	return c.Conn.Close()
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransportCountsConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	transport := NewTransport(TransportConfig{})
+	client := &http.Client{Transport: transport}
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if transport.Dials() != 1 || transport.OpenConnections() != 1 {
+		t.Fatalf("dials = %d, open = %d, want 1 and 1", transport.Dials(), transport.OpenConnections())
+	}
+
+	transport.CloseIdleConnections()
+	if n := transport.OpenConnections(); n != 0 {
+		t.Fatalf("open after CloseIdleConnections = %d, want 0", n)
+	}
+	if SharedTransport(TransportConfig{}) != SharedTransport(TransportConfig{MaxConnsPerHost: DefaultMaxConnsPerHost}) {
+		t.Fatal("equivalent configs returned different shared transports")
+	}
+}
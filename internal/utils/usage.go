@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RequestUsage is the use of external services attributed to one request:
+// OpenAI calls, the time spent in them, tokens, and their estimated cost,
+// and Solana RPC calls, the time spent in them, and transaction fees.
+// Fields are zero for services the request did not use. Times are summed
+// over calls, so concurrent calls can add up to more than the request
+// took; they are encoded in nanoseconds.
+type RequestUsage struct {
+	OpenAIRequests   uint64        `json:"openai_requests"`
+	OpenAITime       time.Duration `json:"openai_time_ns"`
+	PromptTokens     uint64        `json:"prompt_tokens"`
+	CompletionTokens uint64        `json:"completion_tokens"`
+	EstimatedCostUSD float64       `json:"estimated_cost_usd"`
+	RPCCalls         uint64        `json:"rpc_calls"`
+	RPCTime          time.Duration `json:"rpc_time_ns"`
+	FeeLamports      uint64        `json:"fee_lamports"`
+}
+
+// UsageRecorder accumulates the RequestUsage of the work done under one
+// context. The OpenAI and Solana clients report to the recorder of the
+// context they are called with. A recorder created under another also
+// reports to it, so nested requests count toward their parent. All methods
+// are safe for concurrent use and do nothing on a nil recorder.
+type UsageRecorder struct {
+	parent *UsageRecorder
+
+	mu    sync.Mutex
+	usage RequestUsage
+}
+
+type usageRecorderKey struct{}
+
+// WithUsageRecorder returns a context carrying a new recorder, and the
+// recorder.
+func WithUsageRecorder(ctx context.Context) (context.Context, *UsageRecorder) {
+	r := &UsageRecorder{parent: UsageRecorderFrom(ctx)}
+	return context.WithValue(ctx, usageRecorderKey{}, r), r
+}
+
+// UsageRecorderFrom returns the recorder carried by ctx, or nil.
+func UsageRecorderFrom(ctx context.Context) *UsageRecorder {
+	r, _ := ctx.Value(usageRecorderKey{}).(*UsageRecorder)
+	return r
+}
+
+// AddOpenAIRequest counts one OpenAI API call that took d.
+func (r *UsageRecorder) AddOpenAIRequest(d time.Duration) {
+	r.add(func(u *RequestUsage) {
+		u.OpenAIRequests++
+		u.OpenAITime += d
+	})
+}
+
+// AddTokens records tokens consumed and their estimated cost in USD.
+func (r *UsageRecorder) AddTokens(prompt, completion uint64, costUSD float64) {
+	r.add(func(u *RequestUsage) {
+		u.PromptTokens += prompt
+		u.CompletionTokens += completion
+		u.EstimatedCostUSD += costUSD
+	})
+}
+
+// AddRPCCall counts one Solana RPC call that took d.
+func (r *UsageRecorder) AddRPCCall(d time.Duration) {
+	r.add(func(u *RequestUsage) {
+		u.RPCCalls++
+		u.RPCTime += d
+	})
+}
+
+// AddFee records a transaction fee in lamports.
+func (r *UsageRecorder) AddFee(lamports uint64) {
+	r.add(func(u *RequestUsage) { u.FeeLamports += lamports })
+}
+
+// Usage returns the usage recorded so far.
+func (r *UsageRecorder) Usage() RequestUsage {
+	if r == nil {
+		return RequestUsage{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage
+}
+
+func (r *UsageRecorder) add(fn func(*RequestUsage)) {
+	for ; r != nil; r = r.parent {
+		r.mu.Lock()
+		fn(&r.usage)
+		r.mu.Unlock()
+	}
+}
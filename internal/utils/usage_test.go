@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUsageRecorderNesting(t *testing.T) {
+	var none *UsageRecorder
+	none.AddRPCCall(time.Millisecond)
+	if none.Usage() != (RequestUsage{}) || UsageRecorderFrom(context.Background()) != nil {
+		t.Fatal("nil recorder recorded usage")
+	}
+
+	ctx, parent := WithUsageRecorder(context.Background())
+	parent.AddFee(5000)
+	ctx, child := WithUsageRecorder(ctx)
+	UsageRecorderFrom(ctx).AddTokens(10, 5, 0.5)
+	child.AddRPCCall(time.Millisecond)
+
+	if got := child.Usage(); got != (RequestUsage{PromptTokens: 10, CompletionTokens: 5, EstimatedCostUSD: 0.5, RPCCalls: 1, RPCTime: time.Millisecond}) {
+		t.Fatalf("child usage = %+v", got)
+	}
+	if got := parent.Usage(); got.RPCCalls != 1 || got.RPCTime != time.Millisecond || got.PromptTokens != 10 || got.FeeLamports != 5000 {
+		t.Fatalf("parent usage = %+v, want the child's usage included", got)
+	}
+}
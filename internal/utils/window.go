@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Defaults for NewSlidingWindow arguments left at zero.
+const (
+	DefaultMetricsWindow     = time.Minute
+	DefaultWindowGranularity = time.Second
+)
+
+// SlidingWindow counts events and failures over a trailing time window. It
+// keeps one bucket per granularity step, so memory is fixed regardless of
+// traffic; the window advances one bucket at a time.
+type SlidingWindow struct {
+	mu          sync.Mutex
+	granularity time.Duration
+	buckets     []windowBucket
+	now         func() time.Time
+}
+
+type windowBucket struct {
+	step   int64 // time step the counts belong to
+	events uint64
+	errors uint64
+}
+
+// WindowSnapshot summarises a SlidingWindow.
+type WindowSnapshot struct {
+	Window    time.Duration `json:"window"`
+	Events    uint64        `json:"events"`
+	Errors    uint64        `json:"errors"`
+	PerSecond float64       `json:"per_second"`
+	ErrorRate float64       `json:"error_rate"`
+}
+
+// NewSlidingWindow creates a window of the given length counted in
+// granularity steps and timed by now. Zero durations use
+// DefaultMetricsWindow and DefaultWindowGranularity, and a nil now uses
+// time.Now.
+func NewSlidingWindow(window, granularity time.Duration, now func() time.Time) *SlidingWindow {
+	if window <= 0 {
+		window = DefaultMetricsWindow
+	}
+	if granularity <= 0 {
+		granularity = DefaultWindowGranularity
+	}
+	if now == nil {
+		now = time.Now
+	}
+	n := int(window / granularity)
+	if n < 1 {
+		n = 1
+	}
+	return &SlidingWindow{
+		granularity: granularity,
+		buckets:     make([]windowBucket, n),
+		now:         now,
+	}
+}
+
+// Record counts one event, and a failure when failed is true.
+func (w *SlidingWindow) Record(failed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	step := w.now().UnixNano() / int64(w.granularity)
+	b := &w.buckets[step%int64(len(w.buckets))]
+	if b.step != step {
+		*b = windowBucket{step: step}
+	}
+	b.events++
+	if failed {
+		b.errors++
+	}
+}
+
+// Snapshot returns the counts within the window.
+func (w *SlidingWindow) Snapshot() WindowSnapshot {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	step := w.now().UnixNano() / int64(w.granularity)
+	oldest := step - int64(len(w.buckets)) + 1
+	window := time.Duration(len(w.buckets)) * w.granularity
+
+	snap := WindowSnapshot{Window: window}
+	for _, b := range w.buckets {
+		if b.step >= oldest && b.step <= step {
+			snap.Events += b.events
+			snap.Errors += b.errors
+		}
+	}
+	snap.PerSecond = float64(snap.Events) / window.Seconds()
+	if snap.Events > 0 {
+		snap.ErrorRate = float64(snap.Errors) / float64(snap.Events)
+	}
+	return snap
+}
+
+// Reset clears all buckets.
+func (w *SlidingWindow) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.buckets {
+		w.buckets[i] = windowBucket{}
+	}
+}
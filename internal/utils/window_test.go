@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	w := NewSlidingWindow(10*time.Second, time.Second, func() time.Time { return now })
+
+	for i := 0; i < 4; i++ {
+		w.Record(i == 0)
+	}
+	now = now.Add(5 * time.Second)
+	w.Record(false)
+
+	snap := w.Snapshot()
+	if snap.Events != 5 || snap.Errors != 1 || snap.ErrorRate != 0.2 || snap.PerSecond != 0.5 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+
+	// The first four events age out once the window has moved past them.
+	now = now.Add(5 * time.Second)
+	if snap := w.Snapshot(); snap.Events != 1 || snap.Errors != 0 {
+		t.Fatalf("snapshot after 10s = %+v", snap)
+	}
+
+	w.Reset()
+	if snap := w.Snapshot(); snap.Events != 0 {
+		t.Fatalf("snapshot after Reset = %+v", snap)
+	}
+}